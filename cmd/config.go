@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"go-log/internal/api/logics"
+	"go-log/internal/utils"
+)
+
+const configUsage = `Usage: go-log config check [flags] [file]
+
+Validates a JSON, YAML or TOML configuration file (default: the file the server would
+load) after resolving includes and ${VAR} references, and reports every problem with its
+JSON path, e.g. $.servers[1].table_name.
+
+Flags:
+  --json     print the findings as JSON
+  --strict   treat warnings as errors
+
+Exit codes: 0 valid, 1 invalid, 2 usage error or unreadable/unparseable file.
+`
+
+// runConfigCommand implements "go-log config ..." and returns the process exit code.
+func runConfigCommand(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Print(configUsage)
+		return 2
+	}
+	if args[0] != "check" {
+		fmt.Fprintf(os.Stderr, "go-log config: unknown command %q\n\n%s", args[0], configUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config check", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	asJSON := fs.Bool("json", false, "print JSON")
+	strict := fs.Bool("strict", false, "treat warnings as errors")
+
+	// Accept flags before and after the file name
+	var files []string
+	rest := args[1:]
+	for {
+		if err := fs.Parse(rest); err != nil {
+			if !errors.Is(err, flag.ErrHelp) {
+				fmt.Fprintf(os.Stderr, "go-log config check: %v\n\n%s", err, configUsage)
+			} else {
+				fmt.Print(configUsage)
+			}
+			return 2
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		files = append(files, fs.Arg(0))
+		rest = fs.Args()[1:]
+	}
+	if len(files) > 1 {
+		fmt.Fprintf(os.Stderr, "go-log config check: expected one file, got %d\n\n%s", len(files), configUsage)
+		return 2
+	}
+
+	path := logics.ConfigFilePath()
+	if len(files) == 1 {
+		path = files[0]
+	}
+	data, _, err := utils.LoadConfigDocument(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-log config check: %v\n", err)
+		return 2
+	}
+
+	_, validation := logics.ParseMonitoringConfig(data)
+	failed := !validation.Valid() || (*strict && len(validation.Warnings) > 0)
+
+	if *asJSON {
+		out, err := json.MarshalIndent(validation, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-log config check: %v\n", err)
+			return 2
+		}
+		fmt.Println(string(out))
+	} else {
+		for _, line := range logics.FormatConfigIssues(validation.Errors, "error") {
+			fmt.Println(line)
+		}
+		for _, line := range logics.FormatConfigIssues(validation.Warnings, "warning") {
+			fmt.Println(line)
+		}
+		if failed {
+			fmt.Printf("%s: invalid (%d errors, %d warnings)\n", path, len(validation.Errors), len(validation.Warnings))
+		} else {
+			fmt.Printf("%s: ok (%d warnings)\n", path, len(validation.Warnings))
+		}
+	}
+
+	switch {
+	case len(validation.Errors) > 0 && validation.Errors[0].Code == logics.ConfigCodeInvalidJSON:
+		return 2
+	case failed:
+		return 1
+	}
+	return 0
+}
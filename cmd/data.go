@@ -0,0 +1,724 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go-log/internal/api/logics"
+	"go-log/internal/api/models"
+	"go-log/internal/utils"
+)
+
+const (
+	defaultTransferBatch = 500
+	defaultStateFile     = "go-log-data.state.json"
+	ndjsonExt            = ".ndjson"
+)
+
+const dataUsage = `Usage: go-log data <command> [flags]
+
+Commands:
+  export   --from <backend> --out <dir>        write every table to <dir>/<table>.ndjson
+  import   --in <dir> --to <backend>           load <table>.ndjson files into a backend
+  migrate  --from <backend> --to <backend>     copy every table between backends
+  drop-legacy --on <backend> [--force]        drop <table>_legacy tables left by the schema migration
+
+Backends: file, sqlite, postgres, tsdb (configured through .env and configs.json).
+
+Shared flags:
+  --tables a,b      only copy these tables (default: every table of the source)
+  --since, --until  only copy snapshots within this time range
+  --batch N         snapshots per page (default 500)
+  --state FILE      progress file used to resume (default go-log-data.state.json)
+  --restart         ignore an existing progress file and start over
+  --log-path DIR    log directory of the file backend (default: "path" in configs.json)
+`
+
+// transferOptions holds the parsed flags of one data command.
+type transferOptions struct {
+	command   string
+	from      string
+	to        string
+	dir       string
+	tables    []string
+	since     time.Time
+	until     time.Time
+	batch     int
+	statePath string
+	restart   bool
+	logPath   string
+}
+
+// runDataCommand implements "go-log data ..." and returns the process exit code.
+func runDataCommand(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Print(dataUsage)
+		return 2
+	}
+
+	if args[0] == "drop-legacy" {
+		return runDropLegacy(args[1:])
+	}
+
+	opts, err := parseTransferFlags(args[0], args[1:])
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "go-log data %s: %v\n\n%s", args[0], err, dataUsage)
+		}
+		return 2
+	}
+
+	// Keep backend init chatter out of the progress output
+	utils.SetLogLevelByName("warn")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := runTransfer(ctx, opts); err != nil {
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintf(os.Stderr, "interrupted; progress saved to %s, rerun the same command to resume\n", opts.statePath)
+		} else {
+			fmt.Fprintf(os.Stderr, "go-log data %s: %v\n", opts.command, err)
+		}
+		return 1
+	}
+	return 0
+}
+
+// runDropLegacy implements "go-log data drop-legacy". Legacy tables are kept after their
+// rows are copied into the current schema, so they are only removed on request.
+func runDropLegacy(args []string) int {
+	fs := flag.NewFlagSet("data drop-legacy", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	on := fs.String("on", "", "backend holding the legacy tables")
+	force := fs.Bool("force", false, "also drop tables with rows that could not be converted")
+	if err := fs.Parse(args); err != nil || *on == "" || fs.NArg() > 0 {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "go-log data drop-legacy: --on is required\n\n%s", dataUsage)
+		}
+		return 2
+	}
+
+	utils.SetLogLevelByName("warn")
+	logics.InitMonitoringConfigCLI()
+	cfg := *logics.GetMonitoringConfig()
+	utils.InitLogger(&cfg)
+	defer func() {
+		if err := utils.CloseStorageBackends(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to close storage: %v\n", err)
+		}
+	}()
+
+	backend, err := openStorageBackend(*on)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-log data drop-legacy: %v\n", err)
+		return 1
+	}
+	dropper, ok := backend.(utils.LegacyTableDropper)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "go-log data drop-legacy: %s has no legacy tables\n", backend.Name())
+		return 1
+	}
+	dropped, err := dropper.DropLegacyTables(*force)
+	for _, name := range dropped {
+		fmt.Printf("dropped %s\n", name)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-log data drop-legacy: %v\n", err)
+		return 1
+	}
+	fmt.Printf("dropped %d legacy tables from %s\n", len(dropped), backend.Name())
+	return 0
+}
+
+func parseTransferFlags(command string, args []string) (*transferOptions, error) {
+	opts := &transferOptions{command: command}
+	fs := flag.NewFlagSet("data "+command, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var tables, since, until string
+	fs.StringVar(&opts.from, "from", "", "source backend")
+	fs.StringVar(&opts.to, "to", "", "target backend")
+	fs.StringVar(&tables, "tables", "", "comma-separated tables to copy")
+	fs.StringVar(&since, "since", "", "only copy snapshots at or after this time")
+	fs.StringVar(&until, "until", "", "only copy snapshots at or before this time")
+	fs.IntVar(&opts.batch, "batch", defaultTransferBatch, "snapshots per page")
+	fs.StringVar(&opts.statePath, "state", defaultStateFile, "progress file")
+	fs.BoolVar(&opts.restart, "restart", false, "ignore saved progress")
+	fs.StringVar(&opts.logPath, "log-path", "", "file backend log directory")
+	switch command {
+	case "export":
+		fs.StringVar(&opts.dir, "out", "", "output directory")
+	case "import":
+		fs.StringVar(&opts.dir, "in", "", "input directory")
+	case "migrate":
+	default:
+		return nil, fmt.Errorf("unknown command %q", command)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	switch command {
+	case "export":
+		if opts.from == "" || opts.dir == "" {
+			return nil, fmt.Errorf("--from and --out are required")
+		}
+		opts.to = ""
+	case "import":
+		if opts.to == "" || opts.dir == "" {
+			return nil, fmt.Errorf("--in and --to are required")
+		}
+		opts.from = ""
+	case "migrate":
+		if opts.from == "" || opts.to == "" {
+			return nil, fmt.Errorf("--from and --to are required")
+		}
+		if utils.NormalizeStorageName(opts.from) == utils.NormalizeStorageName(opts.to) {
+			return nil, fmt.Errorf("source and target are the same backend")
+		}
+	}
+
+	if opts.batch <= 0 {
+		return nil, fmt.Errorf("--batch must be positive")
+	}
+	for _, name := range strings.Split(tables, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			opts.tables = append(opts.tables, name)
+		}
+	}
+	for _, bound := range []struct {
+		flag  string
+		value string
+		dst   *time.Time
+	}{{"since", since, &opts.since}, {"until", until, &opts.until}} {
+		if bound.value == "" {
+			continue
+		}
+		ts, err := utils.ParseTimestamp(bound.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s timestamp", bound.flag)
+		}
+		*bound.dst = ts
+	}
+	return opts, nil
+}
+
+// runTransfer copies every selected table from the source to the target, saving progress
+// after each page so an interrupted run continues where it stopped.
+func runTransfer(ctx context.Context, opts *transferOptions) error {
+	logics.InitMonitoringConfigCLI()
+	cfg := *logics.GetMonitoringConfig()
+	if opts.logPath != "" {
+		cfg.Path = opts.logPath
+	}
+	utils.InitLogger(&cfg)
+	defer func() {
+		if err := utils.CloseStorageBackends(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to close storage: %v\n", err)
+		}
+	}()
+
+	source, err := openTransferSource(opts)
+	if err != nil {
+		return err
+	}
+	sink, err := openTransferSink(opts)
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+
+	tables := opts.tables
+	if len(tables) == 0 {
+		if tables, err = source.Tables(); err != nil {
+			return fmt.Errorf("failed to list source tables: %w", err)
+		}
+	}
+
+	state, err := loadTransferState(opts)
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	var total int64
+	for _, table := range tables {
+		progress := state.table(table)
+		if progress.Done {
+			fmt.Printf("%s: already copied (%d rows), skipping\n", table, progress.Rows)
+			continue
+		}
+		if progress.Rows > 0 {
+			fmt.Printf("%s: resuming after %d rows\n", table, progress.Rows)
+		}
+
+		if err := sink.Begin(table, progress.Checkpoint); err != nil {
+			return fmt.Errorf("%s: %w", table, err)
+		}
+
+		copied := int64(0)
+		err := source.Read(table, progress.Position, opts.batch, func(batch []models.SystemMonitoring, position string, last bool) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			batch = filterTransferRange(batch, opts.since, opts.until)
+			if len(batch) > 0 {
+				checkpoint, err := sink.Write(table, batch)
+				if err != nil {
+					return err
+				}
+				progress.Checkpoint = checkpoint
+			}
+			progress.Position = position
+			progress.Rows += int64(len(batch))
+			progress.Done = last
+			copied += int64(len(batch))
+			if err := state.save(); err != nil {
+				return err
+			}
+			if len(batch) > 0 {
+				fmt.Printf("%s: %d rows (reached %s)\n", table, progress.Rows, utils.FormatTimestampUTC(batch[len(batch)-1].Timestamp))
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", table, err)
+		}
+
+		progress.Done = true
+		if err := state.save(); err != nil {
+			return err
+		}
+		total += copied
+		fmt.Printf("%s: done, %d rows\n", table, progress.Rows)
+	}
+
+	// A finished transfer leaves nothing to resume
+	if err := os.Remove(opts.statePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove progress file: %w", err)
+	}
+	fmt.Printf("copied %d rows from %d tables in %s\n", total, len(tables), time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+func filterTransferRange(batch []models.SystemMonitoring, since, until time.Time) []models.SystemMonitoring {
+	if since.IsZero() && until.IsZero() {
+		return batch
+	}
+	kept := batch[:0]
+	for _, s := range batch {
+		if !since.IsZero() && s.Timestamp.Before(since) {
+			continue
+		}
+		if !until.IsZero() && s.Timestamp.After(until) {
+			continue
+		}
+		kept = append(kept, s)
+	}
+	return kept
+}
+
+// openStorageBackend resolves and opens one backend by name for the CLI.
+func openStorageBackend(name string) (utils.StorageBackend, error) {
+	backend, ok := utils.GetStorageBackend(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown backend %q (want one of %s)", name, strings.Join(utils.RegisteredStorageBackends(), ", "))
+	}
+	if err := backend.Init(); err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", backend.Name(), err)
+	}
+	return backend, nil
+}
+
+// storageTable maps a clean table name to the name the backends expect.
+func storageTable(table string) string {
+	if utils.IsEmptyOrWhitespace(table) || table == "default" {
+		return utils.DefaultTableName
+	}
+	return table
+}
+
+// transferState is the progress file written after every page.
+type transferState struct {
+	path    string
+	Command string                    `json:"command"`
+	Source  string                    `json:"source"`
+	Target  string                    `json:"target"`
+	Tables  map[string]*tableProgress `json:"tables"`
+}
+
+// tableProgress records how far one table got. Position is the source's resume token
+// (backend cursor, log day or input byte offset); Checkpoint is the output size for exports.
+type tableProgress struct {
+	Position   string `json:"position,omitempty"`
+	Checkpoint int64  `json:"checkpoint,omitempty"`
+	Rows       int64  `json:"rows"`
+	Done       bool   `json:"done"`
+}
+
+func transferEndpoint(backend, dir string) string {
+	if backend != "" {
+		return utils.NormalizeStorageName(backend)
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
+}
+
+// loadTransferState reads the progress file, refusing one that belongs to a different transfer.
+func loadTransferState(opts *transferOptions) (*transferState, error) {
+	state := &transferState{
+		path:    opts.statePath,
+		Command: opts.command,
+		Source:  transferEndpoint(opts.from, opts.dir),
+		Target:  transferEndpoint(opts.to, opts.dir),
+		Tables:  map[string]*tableProgress{},
+	}
+	if opts.restart {
+		return state, nil
+	}
+
+	data, err := os.ReadFile(opts.statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read progress file: %w", err)
+	}
+	var saved transferState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse progress file %s: %w", opts.statePath, err)
+	}
+	if saved.Command != state.Command || saved.Source != state.Source || saved.Target != state.Target {
+		return nil, fmt.Errorf("progress file %s belongs to %s %s -> %s; pass --restart or another --state",
+			opts.statePath, saved.Command, saved.Source, saved.Target)
+	}
+	if saved.Tables != nil {
+		state.Tables = saved.Tables
+	}
+	return state, nil
+}
+
+func (s *transferState) table(name string) *tableProgress {
+	progress, ok := s.Tables[name]
+	if !ok {
+		progress = &tableProgress{}
+		s.Tables[name] = progress
+	}
+	return progress
+}
+
+// save replaces the progress file atomically.
+func (s *transferState) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0640); err != nil {
+		return fmt.Errorf("failed to write progress file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write progress file: %w", err)
+	}
+	return nil
+}
+
+// transferSource yields snapshots page by page, starting after a saved position.
+type transferSource interface {
+	Tables() ([]string, error)
+	// Read calls page for each batch with the position to resume after it; last is set
+	// when the source knows the batch is the final one.
+	Read(table, position string, size int, page func(batch []models.SystemMonitoring, position string, last bool) error) error
+}
+
+// transferSink stores pages for one table at a time.
+type transferSink interface {
+	// Begin prepares a table, discarding output written after checkpoint by an interrupted run.
+	Begin(table string, checkpoint int64) error
+	// Write stores a page and returns the checkpoint to resume from.
+	Write(table string, batch []models.SystemMonitoring) (int64, error)
+	Close() error
+}
+
+func openTransferSource(opts *transferOptions) (transferSource, error) {
+	if opts.from == "" {
+		return &ndjsonSource{dir: opts.dir}, nil
+	}
+	backend, err := openStorageBackend(opts.from)
+	if err != nil {
+		return nil, err
+	}
+	if backend.Name() == utils.StorageFile {
+		return fileLogSource{}, nil
+	}
+	src := &backendSource{backend: backend}
+	if !opts.since.IsZero() {
+		src.from = utils.FormatTimestampUTC(opts.since)
+	}
+	if !opts.until.IsZero() {
+		src.to = utils.FormatTimestampUTC(opts.until)
+	}
+	return src, nil
+}
+
+func openTransferSink(opts *transferOptions) (transferSink, error) {
+	if opts.to == "" {
+		if err := os.MkdirAll(opts.dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+		return &ndjsonSink{dir: opts.dir}, nil
+	}
+	backend, err := openStorageBackend(opts.to)
+	if err != nil {
+		return nil, err
+	}
+	return backendSink{backend: backend}, nil
+}
+
+// backendSource pages through a queryable backend using its keyset cursor.
+type backendSource struct {
+	backend  utils.StorageBackend
+	from, to string
+}
+
+func (b *backendSource) Tables() ([]string, error) { return b.backend.ListTables() }
+
+func (b *backendSource) Read(table, position string, size int, page func([]models.SystemMonitoring, string, bool) error) error {
+	req := utils.PageRequest{Limit: size, Cursor: position}
+	for {
+		batch, next, err := b.backend.Query(storageTable(table), b.from, b.to, req)
+		if err != nil {
+			return err
+		}
+		last := next == "" || len(batch) == 0
+		if err := page(batch, next, last); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		req.Cursor = next
+	}
+}
+
+// fileLogSource replays the daily JSON log files. The position is the last finished day,
+// or "<day>:<n>" once the first n snapshots of a day are stored, so a resumed copy
+// continues inside the day instead of replaying it.
+type fileLogSource struct{}
+
+func (fileLogSource) Tables() ([]string, error) { return utils.GetFileTables() }
+
+func (fileLogSource) Read(table, position string, size int, page func([]models.SystemMonitoring, string, bool) error) error {
+	afterDay, resumeDay, done, err := parseFileLogPosition(position)
+	if err != nil {
+		return err
+	}
+	return utils.ReadLogSnapshots(table, afterDay, func(day string, batch []models.SystemMonitoring) error {
+		stored := 0
+		if day == resumeDay {
+			stored = min(done, len(batch))
+			batch = batch[stored:]
+		}
+		// A day is only recorded as finished once its last chunk is stored
+		for len(batch) > size {
+			stored += size
+			if err := page(batch[:size], fmt.Sprintf("%s:%d", day, stored), false); err != nil {
+				return err
+			}
+			batch = batch[size:]
+		}
+		return page(batch, day, false)
+	})
+}
+
+// parseFileLogPosition splits a fileLogSource position into the last finished day to read
+// after and, for a day stopped part-way, that day and how many of its snapshots are stored.
+func parseFileLogPosition(position string) (afterDay, resumeDay string, done int, err error) {
+	day, count, partial := strings.Cut(position, ":")
+	if !partial {
+		return position, "", 0, nil
+	}
+	start, perr := time.Parse("2006-01-02", day)
+	done, cerr := strconv.Atoi(count)
+	if perr != nil || cerr != nil || done < 0 {
+		return "", "", 0, fmt.Errorf("invalid saved position %q", position)
+	}
+	// Read from the interrupted day itself; the day before it was finished
+	return start.AddDate(0, 0, -1).Format("2006-01-02"), day, done, nil
+}
+
+// ndjsonSource reads <dir>/<table>.ndjson files; the position is a byte offset.
+type ndjsonSource struct {
+	dir string
+}
+
+func (n *ndjsonSource) Tables() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(n.dir, "*"+ndjsonExt))
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]string, 0, len(matches))
+	for _, match := range matches {
+		tables = append(tables, strings.TrimSuffix(filepath.Base(match), ndjsonExt))
+	}
+	sort.Strings(tables)
+	return tables, nil
+}
+
+func (n *ndjsonSource) Read(table, position string, size int, page func([]models.SystemMonitoring, string, bool) error) error {
+	path, err := ndjsonPath(n.dir, table)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var offset int64
+	if position != "" {
+		if offset, err = strconv.ParseInt(position, 10, 64); err != nil {
+			return fmt.Errorf("invalid saved position %q", position)
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	reader := bufio.NewReaderSize(f, 1<<20)
+	batch := make([]models.SystemMonitoring, 0, size)
+	for {
+		raw, readErr := reader.ReadBytes('\n')
+		offset += int64(len(raw))
+		if trimmed := strings.TrimSpace(string(raw)); trimmed != "" {
+			var snapshot models.SystemMonitoring
+			if err := json.Unmarshal([]byte(trimmed), &snapshot); err != nil {
+				return fmt.Errorf("%s: invalid snapshot near byte %d: %w", filepath.Base(path), offset, err)
+			}
+			batch = append(batch, snapshot)
+		}
+
+		eof := errors.Is(readErr, io.EOF)
+		if readErr != nil && !eof {
+			return readErr
+		}
+		if len(batch) >= size || eof {
+			if err := page(batch, strconv.FormatInt(offset, 10), eof); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+		if eof {
+			return nil
+		}
+	}
+}
+
+// backendSink writes pages through the storage registry, keeping source timestamps.
+// SQLite and Postgres skip snapshots whose timestamp is already stored, so rewriting the
+// page an interrupted run was on is harmless there; file and tsdb targets store it twice.
+type backendSink struct {
+	backend utils.StorageBackend
+}
+
+func (backendSink) Begin(string, int64) error { return nil }
+
+func (b backendSink) Write(table string, batch []models.SystemMonitoring) (int64, error) {
+	return 0, utils.WriteSnapshots(b.backend, storageTable(table), batch)
+}
+
+func (backendSink) Close() error { return nil }
+
+// ndjsonSink appends full snapshots, one JSON object per line, to <dir>/<table>.ndjson.
+type ndjsonSink struct {
+	dir    string
+	file   *os.File
+	offset int64
+}
+
+func (n *ndjsonSink) Begin(table string, checkpoint int64) error {
+	if err := n.Close(); err != nil {
+		return err
+	}
+	path, err := ndjsonPath(n.dir, table)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0640)
+	if err != nil {
+		return err
+	}
+	// Drop anything written after the last saved page
+	if err := f.Truncate(checkpoint); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Seek(checkpoint, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+	n.file, n.offset = f, checkpoint
+	return nil
+}
+
+func (n *ndjsonSink) Write(_ string, batch []models.SystemMonitoring) (int64, error) {
+	w := bufio.NewWriter(n.file)
+	for i := range batch {
+		line, err := json.Marshal(&batch[i])
+		if err != nil {
+			return n.offset, err
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		return n.offset, err
+	}
+	if err := n.file.Sync(); err != nil {
+		return n.offset, err
+	}
+	pos, err := n.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return n.offset, err
+	}
+	n.offset = pos
+	return pos, nil
+}
+
+func (n *ndjsonSink) Close() error {
+	if n.file == nil {
+		return nil
+	}
+	err := n.file.Close()
+	n.file = nil
+	return err
+}
+
+// ndjsonPath returns <dir>/<table>.ndjson, rejecting names that would escape dir.
+func ndjsonPath(dir, table string) (string, error) {
+	if table == "" || table != filepath.Base(table) || strings.HasPrefix(table, ".") {
+		return "", fmt.Errorf("invalid table name %q", table)
+	}
+	return filepath.Join(dir, table+ndjsonExt), nil
+}
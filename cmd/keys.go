@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go-log/internal/utils"
+)
+
+const keysUsage = `Usage: go-log keys <command> [flags]
+
+Manages API keys. Keys are stored as SHA-256 hashes in the key file (API_KEYS_PATH,
+default ./api-keys.json); the key itself is printed once, when it is created. The server
+picks up changes to the file without a restart.
+
+Commands:
+  create --name NAME [--role ROLE] [--scopes LIST] [--expires WHEN]
+             create a key; ROLE is viewer (default), operator or admin, LIST a
+             comma-separated subset of the role's scopes, WHEN a duration (720h) or
+             an RFC 3339 time
+  list [--json]
+             list keys and their status
+  revoke ID|NAME
+             revoke a key by ID, or the active key with that name
+  roles      list the scopes granted by each role
+
+Flags:
+  --file PATH   key file to use instead of API_KEYS_PATH
+
+Exit codes: 0 success, 1 failure, 2 usage error.
+`
+
+// runKeysCommand implements "go-log keys ..." and returns the process exit code.
+func runKeysCommand(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Print(keysUsage)
+		return 2
+	}
+	command := args[0]
+
+	fs := flag.NewFlagSet("keys "+command, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	file := fs.String("file", utils.APIKeysPath(), "key file")
+	name := fs.String("name", "", "key name")
+	role := fs.String("role", utils.RoleViewer, "role")
+	scopes := fs.String("scopes", "", "comma-separated scopes")
+	expires := fs.String("expires", "", "expiry")
+	asJSON := fs.Bool("json", false, "print JSON")
+
+	// Accept flags before and after positional arguments
+	var positional []string
+	rest := args[1:]
+	for {
+		if err := fs.Parse(rest); err != nil {
+			if !errors.Is(err, flag.ErrHelp) {
+				fmt.Fprintf(os.Stderr, "go-log keys %s: %v\n\n%s", command, err, keysUsage)
+			} else {
+				fmt.Print(keysUsage)
+			}
+			return 2
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		rest = fs.Args()[1:]
+	}
+
+	switch command {
+	case "create":
+		if len(positional) > 0 {
+			return keysUsageError(command, "unexpected argument %q", positional[0])
+		}
+		var expiresAt *time.Time
+		if *expires != "" {
+			at, err := parseKeyExpiry(*expires)
+			if err != nil {
+				return keysUsageError(command, "%v", err)
+			}
+			expiresAt = &at
+		}
+		var scopeList []string
+		if *scopes != "" {
+			scopeList = strings.Split(*scopes, ",")
+		}
+		key, plaintext, err := utils.CreateAPIKey(*file, *name, *role, scopeList, expiresAt)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-log keys create: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Created API key %s (%s, role %s, scopes %s) in %s\n", key.ID, key.Name, key.Role, strings.Join(key.GrantedScopes(), ","), *file)
+		if key.ExpiresAt != nil {
+			fmt.Printf("Expires: %s\n", key.ExpiresAt.Format(time.RFC3339))
+		}
+		fmt.Printf("\n%s\n\nStore it now: it is not saved and cannot be shown again.\n", plaintext)
+		return 0
+
+	case "list":
+		if len(positional) > 0 {
+			return keysUsageError(command, "unexpected argument %q", positional[0])
+		}
+		keys, err := utils.LoadAPIKeys(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-log keys list: %v\n", err)
+			return 1
+		}
+		now := utils.NowUTC()
+		if *asJSON {
+			type listedKey struct {
+				utils.APIKey
+				Hash   string   `json:"hash,omitempty"`
+				Status string   `json:"status"`
+				Grants []string `json:"granted_scopes"`
+			}
+			listed := make([]listedKey, 0, len(keys))
+			for _, key := range keys {
+				listed = append(listed, listedKey{APIKey: key, Status: key.Status(now), Grants: key.GrantedScopes()})
+			}
+			out, err := json.MarshalIndent(listed, "", "  ")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "go-log keys list: %v\n", err)
+				return 1
+			}
+			fmt.Println(string(out))
+			return 0
+		}
+		if len(keys) == 0 {
+			fmt.Printf("No API keys in %s\n", *file)
+			return 0
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tROLE\tSCOPES\tSTATUS\tCREATED\tEXPIRES")
+		for _, key := range keys {
+			expiry := "never"
+			if key.ExpiresAt != nil {
+				expiry = key.ExpiresAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Name, key.Role, strings.Join(key.GrantedScopes(), ","),
+				key.Status(now), key.CreatedAt.Format(time.RFC3339), expiry)
+		}
+		tw.Flush()
+		return 0
+
+	case "revoke":
+		if len(positional) != 1 {
+			return keysUsageError(command, "expected one key ID or name, got %d", len(positional))
+		}
+		key, err := utils.RevokeAPIKey(*file, positional[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-log keys revoke: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Revoked API key %s (%s)\n", key.ID, key.Name)
+		return 0
+
+	case "roles":
+		for _, role := range utils.Roles {
+			fmt.Printf("%-9s %s\n", role, strings.Join(utils.RoleScopes(role), ","))
+		}
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "go-log keys: unknown command %q\n\n%s", command, keysUsage)
+	return 2
+}
+
+func keysUsageError(command, format string, args ...any) int {
+	fmt.Fprintf(os.Stderr, "go-log keys %s: %s\n\n%s", command, fmt.Sprintf(format, args...), keysUsage)
+	return 2
+}
+
+// parseKeyExpiry accepts a duration from now or an absolute RFC 3339 time.
+func parseKeyExpiry(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("--expires must be in the future")
+		}
+		return utils.NowUTC().Add(d).Truncate(time.Second), nil
+	}
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--expires must be a duration such as 720h or an RFC 3339 time")
+	}
+	if !at.After(utils.NowUTC()) {
+		return time.Time{}, fmt.Errorf("--expires must be in the future")
+	}
+	return at.UTC(), nil
+}
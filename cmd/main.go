@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,8 +12,9 @@ import (
 	"strings"
 	"syscall"
 
-	"go-log/internal/api/router"
+	"go-log/internal/api/handlers"
 	"go-log/internal/api/logics"
+	"go-log/internal/api/router"
 	"go-log/internal/config"
 	"go-log/internal/utils"
 )
@@ -75,32 +77,147 @@ func main() {
 	// Initialize HTTP client configuration
 	utils.InitHTTPConfig()
 
-	// Setup graceful shutdown
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	// "go-log data ..." runs a backup/restore command instead of the server
+	if len(os.Args) > 1 && os.Args[1] == "data" {
+		os.Exit(runDataCommand(os.Args[2:]))
+	}
+	// "go-log config check" validates a configuration file without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfigCommand(os.Args[2:]))
+	}
+	// "go-log keys ..." manages API keys without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		os.Exit(runKeysCommand(os.Args[2:]))
+	}
+	// "go-log users ..." manages dashboard accounts without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "users" {
+		os.Exit(runUsersCommand(os.Args[2:]))
+	}
+	// "go-log oidc check" tests the single sign-on settings against the identity provider
+	if len(os.Args) > 1 && os.Args[1] == "oidc" {
+		os.Exit(runOIDCCommand(os.Args[2:]))
+	}
 
-	// Setup cleanup on exit
-	go func() {
-		<-c
-		log.Println("Shutting down server...")
-		
-		// Clean up all monitoring goroutines
-		logics.CleanupAllGoroutines()
-		
-		// Close HTTP client connections
-		utils.CloseHTTPClient()
-		
-		// Close database connection if open
-		utils.CloseDatabase()
-		
-		log.Println("Server shutdown completed")
-		os.Exit(0)
-	}()
+	// Catch signals before the collectors start, so none is missed during startup
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	// Validate the rate limit policies and connect a shared backend before serving
+	if err := handlers.InitRateLimiting(); err != nil {
+		log.Fatalf("Failed to configure rate limiting: %v", err)
+	}
 
 	// Create and configure the Chi router
 	r := router.NewRouter()
 
 	addr := fmt.Sprintf(":%s", envConfig.Port)
-	log.Println("Server running on", addr)
-	log.Fatal(http.ListenAndServe(addr, r))
+	server := newHTTPServer(addr, r)
+	servers := []*http.Server{server}
+	serveErr := make(chan error, 2)
+
+	if !envConfig.IsTLSEnabled() {
+		log.Println("Server running on", addr)
+		go func() { serveErr <- server.ListenAndServe() }()
+	} else {
+		tlsConfig, err := utils.NewServerTLSConfig()
+		if err != nil {
+			log.Fatalf("Failed to configure TLS: %v", err)
+		}
+		server.TLSConfig = tlsConfig
+
+		// Plain HTTP only redirects, so links and bookmarks without https:// keep working
+		if envConfig.TLSHTTPRedirectPort != "" {
+			redirectAddr := fmt.Sprintf(":%s", envConfig.TLSHTTPRedirectPort)
+			redirect := newHTTPServer(redirectAddr, handlers.HTTPSRedirectHandler(envConfig.Port))
+			servers = append(servers, redirect)
+			log.Println("Redirecting HTTP on", redirectAddr, "to HTTPS")
+			go func() { serveErr <- redirect.ListenAndServe() }()
+		}
+
+		if envConfig.TLSClientCAFile != "" {
+			log.Printf("Verifying client certificates against %s (%s for API routes)", envConfig.TLSClientCAFile, envConfig.TLSClientAuth)
+		}
+		log.Println("Server running on", addr, "(HTTPS)")
+		// The certificates come from server.TLSConfig, which reloads them when they change
+		go func() { serveErr <- server.ListenAndServeTLS("", "") }()
+	}
+
+	exitCode := 0
+	select {
+	case err := <-serveErr:
+		// A listener failed to start (port in use, permissions); still close storage cleanly
+		log.Printf("Server failed: %v", err)
+		exitCode = 1
+	case sig := <-stop:
+		log.Printf("Received %s, shutting down...", sig)
+	}
+
+	// A second signal skips the remaining cleanup
+	go func() {
+		<-stop
+		log.Println("Second signal received, exiting without waiting")
+		os.Exit(1)
+	}()
+
+	shutdown(servers)
+	os.Exit(exitCode)
+}
+
+// shutdown stops the server in order: HTTP listeners stop accepting connections while
+// in-flight requests and the current collection round finish, then exporters are flushed
+// and storage is closed. Draining shares the SHUTDOWN_TIMEOUT deadline; past it, open
+// connections are closed and outstanding fetches cancelled.
+func shutdown(servers []*http.Server) {
+	envConfig := config.GetEnvConfig()
+	ctx, cancel := context.WithTimeout(context.Background(), envConfig.ShutdownTimeout)
+	defer cancel()
+
+	// Collectors are stopped alongside the HTTP drain, so neither waits for the other
+	collectorsDone := make(chan struct{})
+	go func() {
+		defer close(collectorsDone)
+		if err := logics.Shutdown(ctx); err != nil {
+			log.Printf("Collectors did not finish in time: %v", err)
+		}
+	}()
+
+	log.Printf("Draining in-flight HTTP requests (timeout %s)...", envConfig.ShutdownTimeout)
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("HTTP server on %s did not drain in time, closing open connections: %v", srv.Addr, err)
+			srv.Close()
+		}
+	}
+	log.Println("HTTP server stopped")
+	<-collectorsDone
+
+	// Flush pending exporter batches
+	log.Println("Flushing exporters...")
+	utils.StopExporters()
+
+	// Close storage backends (database connections, buffered TSDB samples)
+	log.Println("Closing storage...")
+	if err := utils.CloseStorageBackends(); err != nil {
+		log.Printf("Failed to close storage: %v", err)
+	}
+
+	// Close HTTP client connections
+	utils.CloseHTTPClient()
+
+	log.Println("Server shutdown completed")
+}
+
+// newHTTPServer returns a server for handler with the SERVER_* timeouts, so slow or idle
+// clients cannot hold connections open indefinitely.
+func newHTTPServer(addr string, handler http.Handler) *http.Server {
+	envConfig := config.GetEnvConfig()
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: envConfig.ServerReadHeaderTimeout,
+		ReadTimeout:       envConfig.ServerReadTimeout,
+		WriteTimeout:      envConfig.ServerWriteTimeout,
+		IdleTimeout:       envConfig.ServerIdleTimeout,
+		MaxHeaderBytes:    1 << 20,
+	}
 }
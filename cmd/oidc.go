@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"go-log/internal/config"
+	"go-log/internal/utils"
+)
+
+const oidcUsage = `Usage: go-log oidc check [--token TOKEN|-] [--audience AUD]
+
+Checks the single sign-on settings (OIDC_*) against the identity provider: fetches its
+discovery document and signing keys and validates OIDC_ROLE_MAPPING. With --token it
+also verifies a token the way API requests are verified and prints the principal it
+maps to; "-" reads the token from standard input.
+
+Flags:
+  --token TOKEN   token to verify
+  --audience AUD  expected audience (default: OIDC_AUDIENCE, else OIDC_CLIENT_ID)
+
+Exit codes: 0 success, 1 failure, 2 usage error.
+`
+
+// runOIDCCommand implements "go-log oidc check" and returns the process exit code.
+func runOIDCCommand(args []string) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Print(oidcUsage)
+		return 2
+	}
+	fs := flag.NewFlagSet("oidc check", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	token := fs.String("token", "", "token to verify")
+	audience := fs.String("audience", config.GetEnvConfig().GetOIDCAudience(), "expected audience")
+	if err := fs.Parse(args[1:]); err != nil || fs.NArg() > 0 {
+		if err != nil && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "go-log oidc check: %v\n\n", err)
+		}
+		fmt.Print(oidcUsage)
+		return 2
+	}
+
+	envConfig := config.GetEnvConfig()
+	if !envConfig.IsOIDCEnabled() {
+		fmt.Fprintln(os.Stderr, "go-log oidc check: OIDC_ISSUER is not set")
+		return 1
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	failed := false
+	fail := func(format string, args ...any) {
+		fmt.Printf("FAIL  "+format+"\n", args...)
+		failed = true
+	}
+
+	metadata, err := utils.OIDCDiscover(ctx)
+	if err != nil {
+		fail("discovery: %v", err)
+		return 1
+	}
+	fmt.Printf("OK    issuer %s\n", metadata.Issuer)
+	fmt.Printf("      authorization endpoint %s\n", metadata.AuthorizationEndpoint)
+	fmt.Printf("      token endpoint %s\n", metadata.TokenEndpoint)
+	if len(metadata.CodeChallengeMethodsSupported) > 0 && !slices.Contains(metadata.CodeChallengeMethodsSupported, "S256") {
+		fmt.Println("WARN  the provider does not advertise PKCE with S256")
+	}
+
+	if ids, err := utils.OIDCSigningKeyIDs(ctx); err != nil {
+		fail("signing keys: %v", err)
+	} else {
+		fmt.Printf("OK    %d signing key(s) at %s: %s\n", len(ids), metadata.JWKSURI, strings.Join(ids, ", "))
+	}
+
+	if mapping, err := utils.ParseOIDCRoleMapping(envConfig.OIDCRoleMapping); err != nil {
+		fail("role mapping: %v", err)
+	} else if len(mapping) == 0 && envConfig.OIDCDefaultRole == "" {
+		fmt.Println("WARN  OIDC_ROLE_MAPPING and OIDC_DEFAULT_ROLE are empty; every user will be denied")
+	} else {
+		fmt.Printf("OK    %d group mapping(s), default role %q\n", len(mapping), envConfig.OIDCDefaultRole)
+	}
+
+	if envConfig.IsOIDCLoginEnabled() {
+		fmt.Printf("OK    dashboard single sign-on for client %s, redirect %s\n", envConfig.OIDCClientID, envConfig.OIDCRedirectURL)
+		if !strings.HasSuffix(envConfig.OIDCRedirectURL, "/login/oidc/callback") {
+			fmt.Println("WARN  OIDC_REDIRECT_URL should end in /login/oidc/callback")
+		}
+	} else {
+		fmt.Println("INFO  dashboard single sign-on is off; set OIDC_CLIENT_ID and OIDC_REDIRECT_URL to enable it")
+	}
+
+	if *token != "" {
+		raw := *token
+		if raw == "-" {
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				fail("reading the token: %v", err)
+				return 1
+			}
+			raw = strings.TrimSpace(line)
+		}
+		claims, err := utils.VerifyOIDCToken(ctx, raw, *audience)
+		if err != nil {
+			fail("token: %v", err)
+			return 1
+		}
+		subject, _ := claims["sub"].(string)
+		fmt.Printf("OK    token for %s (subject %s), groups %v\n", utils.OIDCUsername(claims), subject, utils.OIDCGroups(claims))
+		if role, err := utils.OIDCRole(claims); err != nil {
+			fail("token role: %v", err)
+		} else {
+			fmt.Printf("      role %s, scopes %s\n", role, strings.Join(utils.GrantedScopes(role, utils.OIDCTokenScopes(claims)), ", "))
+		}
+	}
+
+	if failed {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go-log/internal/utils"
+)
+
+const usersUsage = `Usage: go-log users <command> [flags]
+
+Manages the local accounts that can sign in to the dashboard. Accounts are stored with
+argon2id or bcrypt password hashes in the user file (USERS_PATH, default ./users.json).
+The server picks up changes to the file without a restart.
+
+Commands:
+  add NAME [--role ROLE] [--algorithm ALG] [--password-stdin]
+             create an account; ROLE is viewer (default), operator or admin, ALG
+             argon2id (default) or bcrypt
+  passwd NAME [--algorithm ALG] [--password-stdin]
+             set a new password
+  update NAME [--role ROLE] [--disabled=true|false]
+             change the role or disable the account
+  remove NAME
+             delete the account
+  list [--json]
+             list accounts
+
+The password is prompted for on the terminal, or read from the first line of standard
+input with --password-stdin.
+
+Flags:
+  --file PATH   user file to use instead of USERS_PATH
+
+Exit codes: 0 success, 1 failure, 2 usage error.
+`
+
+// runUsersCommand implements "go-log users ..." and returns the process exit code.
+func runUsersCommand(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Print(usersUsage)
+		return 2
+	}
+	command := args[0]
+
+	fs := flag.NewFlagSet("users "+command, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	file := fs.String("file", utils.UsersPath(), "user file")
+	role := fs.String("role", "", "role")
+	algorithm := fs.String("algorithm", utils.PasswordArgon2id, "password hash")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from stdin")
+	disabled := fs.String("disabled", "", "true or false")
+	asJSON := fs.Bool("json", false, "print JSON")
+
+	// Accept flags before and after positional arguments
+	var positional []string
+	rest := args[1:]
+	for {
+		if err := fs.Parse(rest); err != nil {
+			if !errors.Is(err, flag.ErrHelp) {
+				fmt.Fprintf(os.Stderr, "go-log users %s: %v\n\n%s", command, err, usersUsage)
+			} else {
+				fmt.Print(usersUsage)
+			}
+			return 2
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		rest = fs.Args()[1:]
+	}
+
+	if command == "list" {
+		if len(positional) > 0 {
+			return usersUsageError(command, "unexpected argument %q", positional[0])
+		}
+		return listUsers(*file, *asJSON)
+	}
+	switch command {
+	case "add", "passwd", "update", "remove":
+	default:
+		fmt.Fprintf(os.Stderr, "go-log users: unknown command %q\n\n%s", command, usersUsage)
+		return 2
+	}
+	if len(positional) != 1 {
+		return usersUsageError(command, "expected one user name, got %d", len(positional))
+	}
+	name := positional[0]
+
+	switch command {
+	case "remove":
+		if err := utils.RemoveUser(*file, name); err != nil {
+			fmt.Fprintf(os.Stderr, "go-log users remove: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Removed user %s\n", name)
+		return 0
+
+	case "update":
+		update := utils.UserUpdate{Role: *role}
+		if *disabled != "" {
+			value := *disabled == "true"
+			if !value && *disabled != "false" {
+				return usersUsageError(command, "--disabled must be true or false")
+			}
+			update.Disabled = &value
+		}
+		if update.Role == "" && update.Disabled == nil {
+			return usersUsageError(command, "nothing to change; pass --role or --disabled")
+		}
+		user, err := utils.UpsertUser(*file, name, false, update)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-log users update: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Updated user %s (role %s, %s)\n", user.Username, user.Role, userStatus(user))
+		return 0
+	}
+
+	// add and passwd need a password
+	password, err := readPassword(*passwordStdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-log users %s: %v\n", command, err)
+		return 1
+	}
+	update := utils.UserUpdate{Password: password, Algorithm: *algorithm}
+	if command == "add" {
+		update.Role = *role
+	} else if *role != "" {
+		return usersUsageError(command, "use update to change the role")
+	}
+	user, err := utils.UpsertUser(*file, name, command == "add", update)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-log users %s: %v\n", command, err)
+		return 1
+	}
+	if command == "add" {
+		fmt.Printf("Created user %s (role %s) in %s\n", user.Username, user.Role, *file)
+	} else {
+		fmt.Printf("Changed the password of %s\n", user.Username)
+	}
+	return 0
+}
+
+func listUsers(file string, asJSON bool) int {
+	users, err := utils.LoadUsers(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-log users list: %v\n", err)
+		return 1
+	}
+	if asJSON {
+		type listedUser struct {
+			Username  string    `json:"username"`
+			Role      string    `json:"role"`
+			Status    string    `json:"status"`
+			CreatedAt time.Time `json:"created_at"`
+			UpdatedAt time.Time `json:"updated_at"`
+		}
+		listed := make([]listedUser, 0, len(users))
+		for _, user := range users {
+			listed = append(listed, listedUser{user.Username, user.Role, userStatus(user), user.CreatedAt, user.UpdatedAt})
+		}
+		out, err := json.MarshalIndent(listed, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "go-log users list: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(out))
+		return 0
+	}
+	if len(users) == 0 {
+		fmt.Printf("No users in %s\n", file)
+		return 0
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "USERNAME\tROLE\tSTATUS\tHASH\tUPDATED")
+	for _, user := range users {
+		algorithm := utils.PasswordBcrypt
+		if strings.HasPrefix(user.PasswordHash, "$argon2id$") {
+			algorithm = utils.PasswordArgon2id
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", user.Username, user.Role, userStatus(user), algorithm, user.UpdatedAt.Format(time.RFC3339))
+	}
+	tw.Flush()
+	return 0
+}
+
+func userStatus(user utils.DashboardUser) string {
+	if user.Disabled {
+		return "disabled"
+	}
+	return "active"
+}
+
+func usersUsageError(command, format string, args ...any) int {
+	fmt.Fprintf(os.Stderr, "go-log users %s: %s\n\n%s", command, fmt.Sprintf(format, args...), usersUsage)
+	return 2
+}
+
+// readPassword reads the password from the first line of stdin, or prompts for it twice
+// with terminal echo turned off.
+func readPassword(fromStdin bool) (string, error) {
+	reader := bufio.NewReader(os.Stdin)
+	if fromStdin {
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("failed to read the password: %w", err)
+		}
+		password := strings.TrimRight(line, "\r\n")
+		if password == "" {
+			return "", fmt.Errorf("no password on standard input")
+		}
+		return password, nil
+	}
+
+	setTerminalEcho(false)
+	defer setTerminalEcho(true)
+	var entered [2]string
+	for i, prompt := range []string{"Password: ", "Repeat password: "} {
+		fmt.Fprint(os.Stderr, prompt)
+		line, err := reader.ReadString('\n')
+		fmt.Fprintln(os.Stderr)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("failed to read the password: %w", err)
+		}
+		entered[i] = strings.TrimRight(line, "\r\n")
+	}
+	if entered[0] == "" {
+		return "", fmt.Errorf("the password is empty")
+	}
+	if entered[0] != entered[1] {
+		return "", fmt.Errorf("the passwords do not match")
+	}
+	return entered[0], nil
+}
+
+// setTerminalEcho switches echo with stty; where stty is unavailable the password is
+// echoed, and --password-stdin should be used instead.
+func setTerminalEcho(on bool) {
+	mode := "-echo"
+	if on {
+		mode = "echo"
+	}
+	cmd := exec.Command("stty", mode)
+	cmd.Stdin = os.Stdin
+	_ = cmd.Run()
+}
@@ -8,10 +8,7 @@ import (
     "os"
     "path/filepath"
 	"slices"
-	"strconv"
 	"strings"
-	"sync"
-	"time"
 )
 
 func getAESSecret() string {
@@ -75,7 +72,8 @@ func CORSMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		}
 
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-GoLog-Key-Id, X-GoLog-Timestamp, X-GoLog-Nonce, X-GoLog-Signature")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Next-Cursor, Content-Disposition")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		if r.Method == "OPTIONS" {
@@ -164,111 +162,6 @@ func EnsureDatabaseDirectoryExists() error {
 	return ensureDirectoryExists(GetDatabaseFolder())
 }
 
-// Rate limiting structures
-type clientEntry struct {
-	tokens     float64
-	lastRefill time.Time
-	mutex      sync.Mutex
-}
-
-var (
-	rateLimitClients = make(map[string]*clientEntry)
-	clientMutex      sync.RWMutex
-)
-
-// getRateLimitConfig returns rate limiting configuration from environment
-func getRateLimitConfig() (requestsPerSecond float64, burstSize int) {
-	envConfig := config.GetEnvConfig()
-	return envConfig.RateLimitRPS, envConfig.RateLimitBurst
-}
-
-// getClientKey extracts client identifier for rate limiting
-func getClientKey(r *http.Request) string {
-	// Try X-Forwarded-For first (for proxied requests)
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		ips := strings.Split(xff, ",")
-		return strings.TrimSpace(ips[0])
-	}
-	
-	// Try X-Real-IP
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return xri
-	}
-	
-	// Fall back to RemoteAddr
-	ip := r.RemoteAddr
-	if idx := strings.LastIndex(ip, ":"); idx != -1 {
-		ip = ip[:idx]
-	}
-	return ip
-}
-
-// isRateLimitEnabled checks if rate limiting is enabled
-func isRateLimitEnabled() bool {
-	envConfig := config.GetEnvConfig()
-	return envConfig.IsRateLimitEnabled()
-}
-
-// TokenBucket implements rate limiting using token bucket algorithm
-func RateLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Check if rate limiting is enabled
-		if !isRateLimitEnabled() {
-			next(w, r)
-			return
-		}
-		
-		clientKey := getClientKey(r)
-		rps, burst := getRateLimitConfig()
-		
-		clientMutex.RLock()
-		client, exists := rateLimitClients[clientKey]
-		clientMutex.RUnlock()
-		
-		if !exists {
-			client = &clientEntry{
-				tokens:     float64(burst),
-				lastRefill: utils.NowUTC(),
-			}
-			clientMutex.Lock()
-			rateLimitClients[clientKey] = client
-			clientMutex.Unlock()
-		}
-		
-		client.mutex.Lock()
-		defer client.mutex.Unlock()
-		
-		now := utils.NowUTC()
-		elapsed := now.Sub(client.lastRefill).Seconds()
-		
-		// Refill tokens based on elapsed time
-		client.tokens += elapsed * rps
-		if client.tokens > float64(burst) {
-			client.tokens = float64(burst)
-		}
-		client.lastRefill = now
-		
-		// Check if we have tokens available
-		if client.tokens < 1 {
-			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(burst))
-			w.Header().Set("X-RateLimit-Remaining", "0")
-			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(time.Second).Unix(), 10))
-			setHeader(w, http.StatusTooManyRequests, `{"status":false, "error": "Rate limit exceeded"}`)
-			return
-		}
-		
-		// Consume a token
-		client.tokens--
-		
-		// Set rate limit headers
-		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(burst))
-		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(client.tokens)))
-		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(time.Second).Unix(), 10))
-		
-		next(w, r)
-	}
-}
-
 func MethodMiddleware(allowedMethods ...string) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-log/internal/api/logics"
+	"go-log/internal/api/models"
+	"go-log/internal/utils"
+)
+
+const maxAdminBodyBytes = 1 << 20
+
+// adminActor names the caller of an admin endpoint for the audit log.
+func adminActor(r *http.Request) string {
+	return PrincipalFromContext(r.Context()).String()
+}
+
+// ConfigReloadHandler reports recent configuration loads (GET) or reloads configs.json now (POST).
+// GET|POST /api/v1/admin/config/reload
+func ConfigReloadHandler(w http.ResponseWriter, r *http.Request) {
+	status := http.StatusOK
+	payload := map[string]any{"status": true}
+	if r.Method == http.MethodPost {
+		result := logics.ReloadMonitoringConfig(logics.ReloadTriggerAPI)
+		if !result.Success {
+			// The running configuration is kept; the result says why the file was rejected
+			status = http.StatusUnprocessableEntity
+			payload["status"] = false
+			payload["error"] = result.Error
+		}
+		payload["result"] = result
+	} else {
+		history := logics.ConfigReloadHistory()
+		if len(history) > 0 {
+			payload["last"] = history[0]
+		}
+		payload["history"] = history
+	}
+
+	resp, err := json.Marshal(payload)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Failed to marshal response")
+		return
+	}
+	setHeader(w, status, string(resp))
+}
+
+// HeartbeatsAdminHandler lists and edits heartbeat checks in the configuration file.
+// GET|POST /api/v1/admin/heartbeats, GET|PUT|DELETE /api/v1/admin/heartbeats/{name}
+func HeartbeatsAdminHandler(w http.ResponseWriter, r *http.Request) {
+	configEntryHandler(w, r, logics.ConfigResourceHeartbeat, "/admin/heartbeats")
+}
+
+// ServersAdminHandler lists and edits monitored servers in the configuration file.
+// GET|POST /api/v1/admin/servers, GET|PUT|DELETE /api/v1/admin/servers/{name}
+func ServersAdminHandler(w http.ResponseWriter, r *http.Request) {
+	configEntryHandler(w, r, logics.ConfigResourceServer, "/admin/servers")
+}
+
+func configEntryHandler(w http.ResponseWriter, r *http.Request, resource, collection string) {
+	name := ""
+	if idx := strings.Index(r.URL.Path, collection); idx >= 0 {
+		name = strings.TrimSpace(strings.Trim(r.URL.Path[idx+len(collection):], "/"))
+	}
+
+	change := logics.ConfigChange{Resource: resource, Name: name, Actor: adminActor(r), Remote: r.RemoteAddr}
+	switch {
+	case r.Method == http.MethodGet:
+		listConfigEntries(w, resource, name)
+		return
+	case r.Method == http.MethodPost && name == "":
+		change.Action = logics.ConfigActionCreate
+	case r.Method == http.MethodPut && name != "":
+		change.Action = logics.ConfigActionUpdate
+	case r.Method == http.MethodDelete && name != "":
+		change.Action = logics.ConfigActionDelete
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	if change.Action != logics.ConfigActionDelete {
+		body := http.MaxBytesReader(w, r.Body, maxAdminBodyBytes)
+		defer body.Close()
+		dec := json.NewDecoder(body)
+		dec.DisallowUnknownFields()
+		var err error
+		if resource == logics.ConfigResourceServer {
+			change.Server = &models.ServerEndpoint{}
+			err = dec.Decode(change.Server)
+			if err == nil && change.Server.Name == "" {
+				change.Server.Name = name
+			}
+		} else {
+			change.Heartbeat = &models.ServerConfig{}
+			err = dec.Decode(change.Heartbeat)
+			if err == nil && change.Heartbeat.Name == "" {
+				change.Heartbeat.Name = name
+			}
+		}
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+			return
+		}
+	}
+
+	result, err := logics.ApplyConfigChange(change)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, utils.ErrConfigEntryNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, utils.ErrConfigEntryExists), errors.Is(err, utils.ErrConfigNotWritable):
+			status = http.StatusConflict
+		case errors.Is(err, utils.ErrValidationFailed):
+			status = http.StatusBadRequest
+		case errors.Is(err, utils.ErrInvalidConfig):
+			status = http.StatusUnprocessableEntity
+		}
+		payload := map[string]any{"status": false, "error": err.Error()}
+		var validation *logics.ConfigValidation
+		if errors.As(err, &validation) {
+			payload["validation"] = validation
+		}
+		resp, _ := json.Marshal(payload)
+		setHeader(w, status, string(resp))
+		return
+	}
+
+	status := http.StatusOK
+	if change.Action == logics.ConfigActionCreate {
+		status = http.StatusCreated
+	}
+	resp, err := json.Marshal(map[string]any{"status": true, "action": change.Action, "result": result})
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Failed to marshal response")
+		return
+	}
+	setHeader(w, status, string(resp))
+}
+
+// listConfigEntries writes every configured entry, or the one called name, with secrets redacted.
+func listConfigEntries(w http.ResponseWriter, resource, name string) {
+	var entries []any
+	if resource == logics.ConfigResourceServer {
+		for _, srv := range logics.AdminServers() {
+			if name == "" || strings.TrimSpace(srv.Name) == name {
+				entries = append(entries, srv)
+			}
+		}
+	} else {
+		for _, hb := range logics.AdminHeartbeats() {
+			if name == "" || strings.TrimSpace(hb.Name) == name {
+				entries = append(entries, hb)
+			}
+		}
+	}
+
+	payload := map[string]any{"status": true}
+	if name == "" {
+		if entries == nil {
+			entries = []any{}
+		}
+		payload["items"] = entries
+	} else {
+		if len(entries) == 0 {
+			writeJSONError(w, http.StatusNotFound, fmt.Sprintf("%s %q not found", resource, name))
+			return
+		}
+		payload["item"] = entries[0]
+	}
+	resp, err := json.Marshal(payload)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Failed to marshal response")
+		return
+	}
+	setHeader(w, http.StatusOK, string(resp))
+}
@@ -0,0 +1,429 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"go-log/internal/api/logics"
+	"go-log/internal/config"
+	"go-log/internal/utils"
+)
+
+const (
+	// APIKeyHeader carries an API key for clients that cannot set a Bearer token.
+	APIKeyHeader = "X-API-Key"
+	// CSRFHeader carries the session's CSRF token on POST, PUT and DELETE requests made
+	// with a session cookie; forms send it as the csrf_token field instead.
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string   `json:"subject"` // "key:<id>", "business:<id>", "user:<name>", "oidc:<sub>", "federation:<key id>" or "anonymous"
+	Name    string   `json:"name,omitempty"`
+	Method  string   `json:"method"` // "api_key", "jwt", "oidc", "session", "proxy", "signature" or "none"
+	Role    string   `json:"role"`
+	Scopes  []string `json:"scopes"`
+
+	session *logics.Session // set for Method "session"
+}
+
+// HasScope reports whether the principal was granted scope.
+func (p *Principal) HasScope(scope string) bool {
+	return p != nil && slices.Contains(p.Scopes, scope)
+}
+
+// SessionUsername returns the signed-in user of a session principal, or "".
+func (p *Principal) SessionUsername() string {
+	if p == nil || p.session == nil {
+		return ""
+	}
+	return p.session.Username
+}
+
+// SessionCSRFToken returns the CSRF token of a session principal, or "".
+func (p *Principal) SessionCSRFToken() string {
+	if p == nil || p.session == nil {
+		return ""
+	}
+	return p.session.CSRFToken
+}
+
+// String names the principal for logs and the audit trail.
+func (p *Principal) String() string {
+	if p == nil {
+		return "anonymous"
+	}
+	if p.Name != "" {
+		return fmt.Sprintf("%s (%s)", p.Subject, p.Name)
+	}
+	return p.Subject
+}
+
+type principalContextKey struct{}
+
+// PrincipalFromContext returns the caller stored by the scope middleware, or nil.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalContextKey{}).(*Principal)
+	return principal
+}
+
+// IsAuthRequired reports whether routes check credentials and scopes: when AUTH_ENABLED or
+// CHECK_TOKEN asks for it, and otherwise as soon as any credential is configured, unless
+// AUTH_ENABLED=false opts out.
+func IsAuthRequired() bool {
+	envConfig := config.GetEnvConfig()
+	if envConfig.IsAuthRequired() {
+		return true
+	}
+	return !envConfig.AuthDisabled && credentialsConfigured()
+}
+
+// credentialsConfigured reports whether any credential source exists: API keys, dashboard
+// users, an OIDC issuer, a trusted proxy user header, or ingest agent tokens. Issuing a
+// credential only protects anything if it is also checked.
+func credentialsConfigured() bool {
+	envConfig := config.GetEnvConfig()
+	if envConfig.IsOIDCEnabled() || envConfig.AuthProxyUserHeader != "" {
+		return true
+	}
+	return utils.HasAPIKeys() || utils.HasDashboardUsers() || logics.HasAgentTokens()
+}
+
+// anonymousPrincipal is the caller when access control is off. It may read metrics and
+// nothing else.
+func anonymousPrincipal() *Principal {
+	return &Principal{Subject: "anonymous", Method: "none", Role: utils.RoleViewer, Scopes: utils.RoleScopes(utils.RoleViewer)}
+}
+
+// requestCredential returns the API key from X-API-Key, or the Bearer token.
+func requestCredential(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get(APIKeyHeader)); key != "" {
+		return key
+	}
+	return strings.TrimSpace(getTokenFromHeader(r))
+}
+
+// authenticateRequest resolves the request's credential to a principal. A credential in
+// the Authorization or X-API-Key header wins; otherwise the trusted proxy's user header
+// and then the session cookie are used. A request signed by a collector must carry a
+// valid signature, which on its own authenticates it as a viewer.
+func authenticateRequest(r *http.Request) (*Principal, error) {
+	credential := requestCredential(r)
+	if r.Header.Get(utils.FederationSignatureHeader) != "" {
+		keyID, err := utils.VerifyFederationRequest(r)
+		if err != nil {
+			return nil, err
+		}
+		if credential == "" {
+			return &Principal{
+				Subject: "federation:" + keyID,
+				Method:  "signature",
+				Role:    utils.RoleViewer,
+				Scopes:  utils.RoleScopes(utils.RoleViewer),
+			}, nil
+		}
+	}
+	if credential == "" {
+		if principal, err := authenticateProxyUser(r); principal != nil || err != nil {
+			return principal, err
+		}
+		return authenticateSession(r)
+	}
+
+	if utils.IsAPIKey(credential) {
+		key, err := utils.AuthenticateAPIKey(credential)
+		if err != nil {
+			return nil, err
+		}
+		return &Principal{
+			Subject: "key:" + key.ID,
+			Name:    key.Name,
+			Method:  "api_key",
+			Role:    key.Role,
+			Scopes:  key.GrantedScopes(),
+		}, nil
+	}
+
+	envConfig := config.GetEnvConfig()
+	// Provider tokens are signed JWTs (three dot-separated parts); the AES-encrypted tokens
+	// below never contain a dot
+	if envConfig.IsOIDCEnabled() && strings.Count(credential, ".") == 2 {
+		return authenticateOIDCToken(r.Context(), credential)
+	}
+	if envConfig.AESSecret == "" || envConfig.JWTSecret == "" {
+		return nil, fmt.Errorf("%w: JWT authentication is not configured", utils.ErrInvalidCredentials)
+	}
+	claims, err := utils.DecryptAndParseToken[TokenClaims](credential, envConfig.AESSecret, envConfig.JWTSecret)
+	if err != nil {
+		return nil, err
+	}
+	roleName := claims.Role
+	if roleName == "" {
+		roleName = envConfig.JWTDefaultRole
+	}
+	role, err := utils.ParseRole(roleName)
+	if err != nil {
+		return nil, utils.NewAuthError("INVALID_ROLE", "token role is not recognized", err)
+	}
+	return &Principal{
+		Subject: fmt.Sprintf("business:%d", claims.BusinessID),
+		Method:  "jwt",
+		Role:    role,
+		Scopes:  utils.GrantedScopes(role, claims.Scopes),
+	}, nil
+}
+
+// authenticateOIDCToken verifies a bearer token issued by the OIDC provider. The role comes
+// from the token's groups; go-log scopes in its "scope" claim narrow it further.
+func authenticateOIDCToken(ctx context.Context, token string) (*Principal, error) {
+	claims, err := utils.VerifyOIDCToken(ctx, token, config.GetEnvConfig().GetOIDCAudience())
+	if err != nil {
+		return nil, err
+	}
+	role, err := utils.OIDCRole(claims)
+	if err != nil {
+		return nil, err
+	}
+	subject, _ := claims["sub"].(string)
+	return &Principal{
+		Subject: "oidc:" + subject,
+		Name:    utils.OIDCUsername(claims),
+		Method:  "oidc",
+		Role:    role,
+		Scopes:  utils.GrantedScopes(role, utils.OIDCTokenScopes(claims)),
+	}, nil
+}
+
+// authenticateSession resolves the dashboard session cookie.
+func authenticateSession(r *http.Request) (*Principal, error) {
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil {
+		return nil, utils.ErrMissingToken
+	}
+	session, user, err := logics.LookupSession(cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+	principal := &Principal{
+		Subject: "user:" + user.Username,
+		Method:  "session",
+		Role:    user.Role,
+		Scopes:  utils.RoleScopes(user.Role),
+		session: session,
+	}
+	if session.Source == logics.SessionSourceOIDC {
+		principal.Subject, principal.Name = "oidc:"+session.Subject, session.Username
+	}
+	return principal, nil
+}
+
+// authenticateProxyUser trusts the user named in AUTH_PROXY_USER_HEADER when the request
+// comes from an address in AUTH_PROXY_TRUSTED_IPS. It returns nil, nil when the mode is
+// off or the header is absent.
+func authenticateProxyUser(r *http.Request) (*Principal, error) {
+	envConfig := config.GetEnvConfig()
+	if envConfig.AuthProxyUserHeader == "" {
+		return nil, nil
+	}
+	username := strings.TrimSpace(r.Header.Get(envConfig.AuthProxyUserHeader))
+	if username == "" {
+		return nil, nil
+	}
+	if !isTrustedProxy(r.RemoteAddr, envConfig.AuthProxyTrustedIPs) {
+		return nil, fmt.Errorf("%w: %s header sent by untrusted address %s", utils.ErrInvalidCredentials, envConfig.AuthProxyUserHeader, r.RemoteAddr)
+	}
+	roleName := envConfig.AuthProxyDefaultRole
+	if envConfig.AuthProxyRoleHeader != "" {
+		if value := strings.TrimSpace(r.Header.Get(envConfig.AuthProxyRoleHeader)); value != "" {
+			roleName = value
+		}
+	}
+	role, err := utils.ParseRole(roleName)
+	if err != nil {
+		return nil, utils.NewAuthError("INVALID_ROLE", "proxy role is not recognized", err)
+	}
+	return &Principal{
+		Subject: "user:" + username,
+		Method:  "proxy",
+		Role:    role,
+		Scopes:  utils.RoleScopes(role),
+	}, nil
+}
+
+// isTrustedProxy reports whether remoteAddr matches one of the comma-separated IPs or
+// CIDRs in trusted.
+func isTrustedProxy(remoteAddr, trusted string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for entry := range strings.SplitSeq(trusted, ",") {
+		entry = strings.TrimSpace(entry)
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if trustedIP := net.ParseIP(entry); trustedIP != nil && trustedIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCSRF protects state-changing requests authenticated by the browser on its own.
+// Session requests must echo the session's CSRF token; proxy-authenticated requests must
+// come from the same origin.
+func checkCSRF(r *http.Request, principal *Principal) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+	switch principal.Method {
+	case "session":
+		token := r.Header.Get(CSRFHeader)
+		if token == "" {
+			token = r.PostFormValue("csrf_token")
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(principal.session.CSRFToken)) != 1 {
+			return fmt.Errorf("%w: missing or invalid CSRF token", utils.ErrForbidden)
+		}
+	case "proxy":
+		if !isSameOrigin(r) {
+			return fmt.Errorf("%w: cross-origin request rejected", utils.ErrForbidden)
+		}
+	}
+	return nil
+}
+
+// isSameOrigin reports whether the request's Origin (or, without one, its Referer) names
+// the host the request was sent to.
+func isSameOrigin(r *http.Request) bool {
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+	if source == "" {
+		return false
+	}
+	parsed, err := url.Parse(source)
+	return err == nil && parsed.Host != "" && strings.EqualFold(parsed.Host, r.Host)
+}
+
+// wantsLoginRedirect reports whether an unauthenticated request comes from a browser
+// that should be sent to the login page instead of getting a JSON error.
+func wantsLoginRedirect(r *http.Request) bool {
+	if r.Method != http.MethodGet || !IsDashboardEnabled() {
+		return false
+	}
+	if !utils.HasDashboardUsers() && !config.GetEnvConfig().IsOIDCLoginEnabled() {
+		return false
+	}
+	return r.Header.Get("HX-Request") != "" || strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// changesConfig reports whether scope lets the caller rewrite or reload the monitoring
+// configuration. Such routes always need a credential, even when access control is off.
+func changesConfig(scope string) bool {
+	return scope == utils.ScopeConfigWrite || scope == utils.ScopeConfigReload
+}
+
+// authorizeRequest checks that the caller holds scope and returns the request with the
+// principal in its context. When it returns false the error response has been written.
+func authorizeRequest(w http.ResponseWriter, r *http.Request, scope string) (*http.Request, bool) {
+	principal, err := authenticateRequest(r)
+	if !IsAuthRequired() && err != nil {
+		if changesConfig(scope) {
+			// Without access control nobody could be told apart from anybody else
+			utils.LogWarn("refused unauthenticated %s %s from %s: access control is off", r.Method, r.URL.Path, getClientKey(r))
+			writeJSONError(w, http.StatusForbidden, fmt.Sprintf("%v: %s requires a credential; set AUTH_ENABLED=true and configure API keys, users or OIDC", utils.ErrForbidden, scope))
+			return r, false
+		}
+		// Credentials are optional; a valid one still names the caller in the audit log
+		principal, err = anonymousPrincipal(), nil
+	}
+
+	if err != nil {
+		if !errors.Is(err, utils.ErrMissingToken) {
+			utils.LogWarn("authentication failed for %s %s from %s: %v", r.Method, r.URL.Path, getClientKey(r), err)
+		}
+		if errors.Is(err, utils.ErrForbidden) {
+			// A valid credential whose identity maps to no role
+			writeJSONError(w, http.StatusForbidden, err.Error())
+			return r, false
+		}
+		if wantsLoginRedirect(r) {
+			target := loginURL(r.URL.RequestURI())
+			if r.Header.Get("HX-Request") != "" {
+				// htmx swaps the response into the page; ask it to navigate instead
+				w.Header().Set("HX-Redirect", target)
+				w.WriteHeader(http.StatusUnauthorized)
+				return r, false
+			}
+			http.Redirect(w, r, target, http.StatusSeeOther)
+			return r, false
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="go-log"`)
+		writeJSONError(w, http.StatusUnauthorized, err.Error())
+		return r, false
+	}
+	if err := checkCSRF(r, principal); err != nil {
+		writeJSONError(w, http.StatusForbidden, err.Error())
+		return r, false
+	}
+	if !principal.HasScope(scope) {
+		writeJSONError(w, http.StatusForbidden, fmt.Sprintf("%v: %s requires scope %s", utils.ErrForbidden, principal.Role, scope))
+		return r, false
+	}
+	return r.WithContext(context.WithValue(r.Context(), principalContextKey{}, principal)), true
+}
+
+// RequireScope is chi middleware that rejects callers without scope.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r, ok := authorizeRequest(w, r, scope); ok {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// RequireScopeFunc is RequireScope for http.HandlerFunc middleware chains.
+func RequireScopeFunc(scope string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r, ok := authorizeRequest(w, r, scope); ok {
+				next(w, r)
+			}
+		}
+	}
+}
+
+// RequireMethodScopeFunc picks the required scope by HTTP method, for handlers that serve
+// both reads and writes on one path. Methods missing from scopes are rejected.
+func RequireMethodScopeFunc(scopes map[string]string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			scope, ok := scopes[r.Method]
+			if !ok {
+				setHeader(w, http.StatusMethodNotAllowed, `{"status":false, "error": "Method not allowed"}`)
+				return
+			}
+			if r, ok := authorizeRequest(w, r, scope); ok {
+				next(w, r)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"go-log/internal/config"
+	"go-log/internal/utils"
+)
+
+// useTestAuthEnv clears every credential source and reloads the environment config.
+func useTestAuthEnv(t *testing.T, authEnabled string) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("GO_ENV", "development")
+	t.Setenv("AUTH_ENABLED", authEnabled)
+	t.Setenv("CHECK_TOKEN", "false")
+	t.Setenv("API_KEYS_PATH", filepath.Join(dir, "api-keys.json"))
+	t.Setenv("USERS_PATH", filepath.Join(dir, "users.json"))
+	t.Setenv("OIDC_ISSUER", "")
+	t.Setenv("AUTH_PROXY_USER_HEADER", "")
+	t.Setenv("MONITOR_CONFIG_PATH", filepath.Join(dir, "configs.json"))
+	config.InitEnvConfig()
+	t.Cleanup(config.InitEnvConfig)
+	return filepath.Join(dir, "api-keys.json")
+}
+
+func authorizeStatus(scope, method, apiKey string) int {
+	req := httptest.NewRequest(method, "/api/v1/admin/servers", nil)
+	if apiKey != "" {
+		req.Header.Set(APIKeyHeader, apiKey)
+	}
+	rec := httptest.NewRecorder()
+	if _, ok := authorizeRequest(rec, req, scope); ok {
+		return http.StatusOK
+	}
+	return rec.Code
+}
+
+func TestAuthorizeRequestWithoutCredentials(t *testing.T) {
+	useTestAuthEnv(t, "")
+	if IsAuthRequired() {
+		t.Fatal("auth required with no credential configured")
+	}
+
+	tests := []struct {
+		scope, method string
+		want          int
+	}{
+		{utils.ScopeMetricsRead, http.MethodGet, http.StatusOK},
+		{utils.ScopeConfigRead, http.MethodGet, http.StatusForbidden},
+		{utils.ScopeConfigReload, http.MethodPost, http.StatusForbidden},
+		{utils.ScopeConfigWrite, http.MethodPost, http.StatusForbidden},
+		{utils.ScopeConfigWrite, http.MethodDelete, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if got := authorizeStatus(tt.scope, tt.method, ""); got != tt.want {
+			t.Errorf("anonymous %s %s: status %d, want %d", tt.method, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorizeRequestEnforcedOnceKeysExist(t *testing.T) {
+	path := useTestAuthEnv(t, "")
+	_, admin, err := utils.CreateAPIKey(path, "deploy", utils.RoleAdmin, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, viewer, err := utils.CreateAPIKey(path, "grafana", utils.RoleViewer, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsAuthRequired() {
+		t.Fatal("auth not required although API keys exist")
+	}
+
+	tests := []struct {
+		name, scope, key string
+		want             int
+	}{
+		{"anonymous read", utils.ScopeMetricsRead, "", http.StatusUnauthorized},
+		{"viewer read", utils.ScopeMetricsRead, viewer, http.StatusOK},
+		{"viewer write", utils.ScopeConfigWrite, viewer, http.StatusForbidden},
+		{"admin write", utils.ScopeConfigWrite, admin, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if got := authorizeStatus(tt.scope, http.MethodPost, tt.key); got != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorizeRequestOptOut(t *testing.T) {
+	path := useTestAuthEnv(t, "false")
+	_, admin, err := utils.CreateAPIKey(path, "deploy", utils.RoleAdmin, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsAuthRequired() {
+		t.Fatal("AUTH_ENABLED=false did not opt out")
+	}
+	if got := authorizeStatus(utils.ScopeMetricsRead, http.MethodGet, ""); got != http.StatusOK {
+		t.Errorf("anonymous read: status %d, want 200", got)
+	}
+	if got := authorizeStatus(utils.ScopeConfigWrite, http.MethodPost, ""); got != http.StatusForbidden {
+		t.Errorf("anonymous write: status %d, want 403", got)
+	}
+	if got := authorizeStatus(utils.ScopeConfigWrite, http.MethodPost, admin); got != http.StatusOK {
+		t.Errorf("admin write: status %d, want 200", got)
+	}
+}
@@ -22,7 +22,8 @@ func DashboardHandler(w http.ResponseWriter, r *http.Request) {
 
 	cfg := logics.GetMonitoringConfig()
 	defaultRange := config.GetEnvConfig().GetDashboardDefaultRange()
-	dashboard := views.DashboardPage(views.DashboardProps{Config: cfg, DefaultRangePreset: defaultRange})
+	user, csrfToken := dashboardSession(r)
+	dashboard := views.DashboardPage(views.DashboardProps{Config: cfg, DefaultRangePreset: defaultRange, User: user, CSRFToken: csrfToken})
 	templ.Handler(dashboard).ServeHTTP(w, r)
 }
 
@@ -54,7 +55,8 @@ func ChromeComponentHandler(w http.ResponseWriter, r *http.Request) {
 func HeroSectionHandler(w http.ResponseWriter, r *http.Request) {
 	cfg := logics.GetMonitoringConfig()
 	defaultRange := config.GetEnvConfig().GetDashboardDefaultRange()
-	templ.Handler(views.HeroSection(views.HeroProps{RefreshLabel: refreshLabelFromConfigForRoutes(cfg), DefaultRangePreset: defaultRange})).ServeHTTP(w, r)
+	user, csrfToken := dashboardSession(r)
+	templ.Handler(views.HeroSection(views.HeroProps{RefreshLabel: refreshLabelFromConfigForRoutes(cfg), DefaultRangePreset: defaultRange, User: user, CSRFToken: csrfToken})).ServeHTTP(w, r)
 }
 
 
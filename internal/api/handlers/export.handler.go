@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-log/internal/api/logics"
+	"go-log/internal/api/models"
+	"go-log/internal/utils"
+)
+
+// Trailers sent after a complete export. A download without them was cut short.
+const (
+	exportStatusTrailer = "X-Export-Status"
+	exportRowsTrailer   = "X-Export-Rows"
+)
+
+// ExportHandler streams raw snapshots of a table for a time range as a file download.
+// GET /api/v1/export?table=&from=&to=&format=csv|ndjson|parquet
+func ExportHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	table := strings.TrimSpace(query.Get("table"))
+	from := strings.TrimSpace(query.Get("from"))
+	to := strings.TrimSpace(query.Get("to"))
+	format := strings.ToLower(strings.TrimSpace(query.Get("format")))
+	for name, bound := range map[string]string{"from": from, "to": to} {
+		if bound == "" {
+			continue
+		}
+		if _, err := utils.ParseTimestamp(bound); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s timestamp", name))
+			return
+		}
+	}
+
+	// The SERVER_WRITE_TIMEOUT deadline would cut a long export off mid-stream
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		utils.LogWarnWithContext("export", "failed to clear write deadline; long exports may be cut off", err)
+	}
+
+	// Pin an open range to now, so rows arriving during the export cannot bring columns
+	// the first pass did not see
+	rangeTo := to
+	if rangeTo == "" {
+		rangeTo = utils.FormatTimestampUTC(utils.NowUTC())
+	}
+
+	var columns *utils.ExportColumns
+	if utils.ExportNeedsColumns(format) {
+		var err error
+		if columns, err = logics.CollectExportColumns(r.Context(), table, from, rangeTo); err != nil {
+			writeJSONError(w, exportErrorStatus(err), err.Error())
+			return
+		}
+	}
+
+	writer, err := utils.NewExportWriter(format, w, columns)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Nothing is written until the first page arrives, so backend and cursor errors
+	// can still be reported with a proper status code.
+	started := false
+	start := func() {
+		started = true
+		w.Header().Set("Content-Type", writer.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, exportFilename(table, from, to, writer.Extension())))
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Trailer", exportStatusTrailer+", "+exportRowsTrailer)
+		w.WriteHeader(http.StatusOK)
+	}
+	rows := 0
+	flusher, _ := w.(http.Flusher)
+	err = logics.StreamMonitoringExport(r.Context(), table, from, rangeTo, func(batch []models.SystemMonitoring) error {
+		if !started {
+			start()
+		}
+		if err := writer.WriteBatch(batch); err != nil {
+			return err
+		}
+		rows += len(batch)
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		if !started {
+			// Empty range: return an empty file rather than an error
+			start()
+		}
+		err = writer.Close()
+	}
+
+	if err != nil {
+		if !started {
+			writeJSONError(w, exportErrorStatus(err), err.Error())
+			return
+		}
+		// Headers are gone. Aborting the connection instead of ending the body normally
+		// lets the client see that the file is truncated.
+		utils.LogWarnWithContext("export", "export stream aborted", err)
+		panic(http.ErrAbortHandler)
+	}
+	w.Header().Set(exportStatusTrailer, "complete")
+	w.Header().Set(exportRowsTrailer, strconv.Itoa(rows))
+}
+
+// exportErrorStatus maps an error raised before the export started to a status code.
+func exportErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrQueryNotSupported):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, utils.ErrInvalidCursor):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// exportFilename builds a download name such as "default_2024-01-01_2024-01-31.csv".
+func exportFilename(table, from, to, ext string) string {
+	parts := []string{utils.SanitizeFilesystemName(table)}
+	if parts[0] == "" {
+		parts[0] = "default"
+	}
+	for _, bound := range []string{from, to} {
+		if bound != "" {
+			if ts, err := utils.ParseTimestamp(bound); err == nil {
+				parts = append(parts, ts.Format("2006-01-02"))
+			}
+		}
+	}
+	return strings.Join(parts, "_") + "." + ext
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"go-log/internal/api/logics"
+	"go-log/internal/api/models"
+	"go-log/internal/utils"
+)
+
+// maxIngestBodyBytes caps one push; agents send at most a few hundred snapshots per request.
+const maxIngestBodyBytes = 8 << 20
+
+// IngestHandler accepts snapshots pushed by agents and stores them in the agent's server table.
+// POST /api/v1/ingest with "Authorization: Bearer <agent token>", "X-Agent-Name: <name>"
+// and a JSON array of snapshots (the same shape /api/v1/monitoring returns).
+func IngestHandler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.Header.Get(logics.AgentNameHeader))
+	token := getTokenFromHeader(r)
+	registration := strings.TrimSpace(r.Header.Get(logics.RegistrationTokenHeader))
+
+	var snapshots []models.SystemMonitoring
+	body := http.MaxBytesReader(w, r.Body, maxIngestBodyBytes)
+	defer body.Close()
+	if err := json.NewDecoder(body).Decode(&snapshots); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		writeJSONError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	result, err := logics.IngestAgentSnapshots(name, token, registration, snapshots)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, utils.ErrIngestDisabled):
+			status = http.StatusNotFound
+		case errors.Is(err, utils.ErrMissingToken), errors.Is(err, utils.ErrInvalidCredentials), errors.Is(err, utils.ErrUnknownAgent):
+			status = http.StatusUnauthorized
+		case errors.Is(err, utils.ErrValidationFailed):
+			status = http.StatusBadRequest
+		case errors.Is(err, utils.ErrStorageUnavailable):
+			status = http.StatusServiceUnavailable
+		}
+		if status == http.StatusUnauthorized {
+			utils.LogWarn("ingest rejected for agent %q: %v", name, err)
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+
+	resp, err := json.Marshal(map[string]any{
+		"status":     true,
+		"agent":      result.Agent,
+		"table":      result.Table,
+		"accepted":   result.Accepted,
+		"registered": result.Registered,
+	})
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Failed to marshal response")
+		return
+	}
+	setHeader(w, http.StatusOK, string(resp))
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+
+	"go-log/internal/api/logics"
+	"go-log/internal/config"
+	"go-log/internal/utils"
+	"go-log/web/views"
+)
+
+const (
+	// SessionCookieName holds the dashboard session ID.
+	SessionCookieName = "go_log_session"
+	// loginCSRFCookieName holds the token the login form must echo (double-submit), since
+	// there is no session to keep it in yet.
+	loginCSRFCookieName = "go_log_login_csrf"
+
+	maxLoginBodyBytes = 64 << 10
+)
+
+// LoginPageHandler renders the login form.
+// GET /login
+func LoginPageHandler(w http.ResponseWriter, r *http.Request) {
+	renderLoginPage(w, r, http.StatusOK, views.LoginProps{Next: safeRedirectTarget(r.URL.Query().Get("next"))})
+}
+
+// LoginHandler checks the submitted credentials and starts a session.
+// POST /login (form: username, password, csrf_token, next)
+func LoginHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxLoginBodyBytes)
+	if err := r.ParseForm(); err != nil {
+		renderLoginPage(w, r, http.StatusBadRequest, views.LoginProps{Error: "The form could not be read. Please try again."})
+		return
+	}
+	username := strings.TrimSpace(r.PostForm.Get("username"))
+	props := views.LoginProps{Username: username, Next: safeRedirectTarget(r.PostForm.Get("next"))}
+
+	cookie, err := r.Cookie(loginCSRFCookieName)
+	token := r.PostForm.Get("csrf_token")
+	if err != nil || token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(cookie.Value)) != 1 {
+		props.Error = "Your sign-in form expired. Please try again."
+		renderLoginPage(w, r, http.StatusForbidden, props)
+		return
+	}
+
+	session, user, err := logics.LoginUser(username, r.PostForm.Get("password"))
+	if err != nil {
+		utils.LogWarn("login failed for %q from %s: %v", username, getClientKey(r), err)
+		props.Error = "Invalid username or password."
+		renderLoginPage(w, r, http.StatusUnauthorized, props)
+		return
+	}
+	utils.LogInfo("login: %s (%s) from %s", user.Username, user.Role, getClientKey(r))
+
+	clearCookie(w, loginCSRFCookieName)
+	startBrowserSession(w, r, session, props.Next)
+}
+
+// startBrowserSession sets the session cookie and sends the browser on to next.
+func startBrowserSession(w http.ResponseWriter, r *http.Request, session *logics.Session, next string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    session.ID,
+		Path:     "/",
+		Expires:  session.ExpiresAt,
+		MaxAge:   int(time.Until(session.ExpiresAt).Seconds()),
+		HttpOnly: true,
+		Secure:   config.GetEnvConfig().SessionCookieSecure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	if next == "" {
+		next = "/"
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// LogoutHandler ends the session and returns to the login page.
+// POST /logout (form: csrf_token)
+func LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(SessionCookieName); err == nil {
+		session, user, err := logics.LookupSession(cookie.Value)
+		if err == nil {
+			principal := &Principal{Method: "session", session: session}
+			if err := checkCSRF(r, principal); err != nil {
+				writeJSONError(w, http.StatusForbidden, err.Error())
+				return
+			}
+			logics.EndSession(session.ID)
+			utils.LogInfo("logout: %s", user.Username)
+		}
+	}
+	clearCookie(w, SessionCookieName)
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func renderLoginPage(w http.ResponseWriter, r *http.Request, status int, props views.LoginProps) {
+	token, err := logics.RandomToken()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Failed to start login")
+		return
+	}
+	props.CSRFToken = token
+	// Offer the password form when there are local users, or when there is nothing else
+	envConfig := config.GetEnvConfig()
+	props.PasswordLogin = utils.HasDashboardUsers() || !envConfig.IsOIDCLoginEnabled()
+	if envConfig.IsOIDCLoginEnabled() {
+		props.SSOURL = "/login/oidc"
+		if props.Next != "" {
+			props.SSOURL += "?next=" + url.QueryEscape(props.Next)
+		}
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     loginCSRFCookieName,
+		Value:    token,
+		Path:     "/login",
+		MaxAge:   int(time.Hour.Seconds()),
+		HttpOnly: true,
+		Secure:   config.GetEnvConfig().SessionCookieSecure,
+		SameSite: http.SameSiteStrictMode,
+	})
+	w.Header().Set("Cache-Control", "no-store")
+	templ.Handler(views.LoginPage(props), templ.WithStatus(status)).ServeHTTP(w, r)
+}
+
+func clearCookie(w http.ResponseWriter, name string) {
+	path := "/"
+	switch name {
+	case loginCSRFCookieName:
+		path = "/login"
+	case oidcStateCookieName:
+		path = "/login/oidc"
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		Path:     path,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   config.GetEnvConfig().SessionCookieSecure,
+	})
+}
+
+// loginURL returns the login page address that comes back to next after signing in.
+func loginURL(next string) string {
+	if next = safeRedirectTarget(next); next == "" || next == "/" {
+		return "/login"
+	}
+	return "/login?next=" + url.QueryEscape(next)
+}
+
+// safeRedirectTarget keeps next only if it is a path on this server, so the login form
+// cannot be used to redirect to another site.
+func safeRedirectTarget(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.ContainsAny(next, "\\\r\n") {
+		return ""
+	}
+	return next
+}
+
+// dashboardSession returns the signed-in user and CSRF token for the dashboard page, or
+// empty strings when the caller did not sign in with a session.
+func dashboardSession(r *http.Request) (user, csrfToken string) {
+	principal := PrincipalFromContext(r.Context())
+	if principal == nil || principal.Method != "session" {
+		return "", ""
+	}
+	return principal.SessionUsername(), principal.SessionCSRFToken()
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -21,16 +22,23 @@ import (
 )
 
 type TokenClaims struct {
-	BusinessID int `json:"business_id"`
+	BusinessID int      `json:"business_id"`
+	Role       string   `json:"role,omitempty"`   // viewer, operator or admin; JWT_DEFAULT_ROLE when empty
+	Scopes     []string `json:"scopes,omitempty"` // narrows the role's scopes when set
 }
 
 type FilterRequest struct {
 	From      string `json:"from,omitempty"`
 	To        string `json:"to,omitempty"`
 	TableName string `json:"table_name,omitempty"`
+	// Limit/Cursor switch to raw, keyset-paginated results; the next cursor is sent in X-Next-Cursor
+	Limit  int    `json:"limit,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
 }
 
-var remoteConfigHTTPClient = &http.Client{Timeout: 10 * time.Second}
+func (f FilterRequest) page() utils.PageRequest {
+	return utils.PageRequest{Limit: f.Limit, Cursor: f.Cursor}
+}
 
 func MonitoringRoutes() {
 	// Initialize monitoring configuration at startup
@@ -64,8 +72,8 @@ func MonitoringRoutes() {
 
         payload := map[string]any{
             "refresh_interval_seconds": refresh,
-            "heartbeat":                cfg.Heartbeat,
-            "servers":                  cfg.Servers,
+            "heartbeat":                logics.PublicHeartbeats(cfg),
+            "servers":                  logics.PublicServers(cfg),
             "storage":                  cfg.Storage,
             "path":                     cfg.Path,
             "persist_server_logs":      cfg.PersistServerLogs,
@@ -81,7 +89,7 @@ func MonitoringRoutes() {
 	}
 
 	// Serve dashboard UI via templ
-	http.HandleFunc("/", RateLimitMiddleware(CORSMiddleware(MethodMiddleware(http.MethodGet)(func(w http.ResponseWriter, r *http.Request) {
+	http.HandleFunc("/", RateLimitMiddleware(CORSMiddleware(MethodMiddleware(http.MethodGet)(RequireScopeFunc(utils.ScopeMetricsRead)(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/" {
 			http.NotFound(w, r)
 			return
@@ -94,19 +102,48 @@ func MonitoringRoutes() {
 
 		cfg := logics.GetMonitoringConfig()
 		defaultRange := config.GetEnvConfig().GetDashboardDefaultRange()
-		dashboard := views.DashboardPage(views.DashboardProps{Config: cfg, DefaultRangePreset: defaultRange})
+		user, csrfToken := dashboardSession(r)
+		dashboard := views.DashboardPage(views.DashboardProps{Config: cfg, DefaultRangePreset: defaultRange, User: user, CSRFToken: csrfToken})
 		templ.Handler(dashboard).ServeHTTP(w, r)
-	}))))
+	})))))
+
+	// Login form and logout; reachable without credentials
+	http.HandleFunc("/login", RateLimitMiddleware(MethodMiddleware(http.MethodGet, http.MethodPost)(func(w http.ResponseWriter, r *http.Request) {
+		if !IsDashboardEnabled() {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == http.MethodPost {
+			LoginHandler(w, r)
+			return
+		}
+		LoginPageHandler(w, r)
+	})))
+	http.HandleFunc("/logout", RateLimitMiddleware(MethodMiddleware(http.MethodPost)(LogoutHandler)))
+	http.HandleFunc("/login/oidc", RateLimitMiddleware(MethodMiddleware(http.MethodGet)(func(w http.ResponseWriter, r *http.Request) {
+		if !IsDashboardEnabled() {
+			http.NotFound(w, r)
+			return
+		}
+		OIDCLoginHandler(w, r)
+	})))
+	http.HandleFunc("/login/oidc/callback", RateLimitMiddleware(MethodMiddleware(http.MethodGet)(func(w http.ResponseWriter, r *http.Request) {
+		if !IsDashboardEnabled() {
+			http.NotFound(w, r)
+			return
+		}
+		OIDCCallbackHandler(w, r)
+	})))
 
 	// Serve HTMX component fragments using templ
 	registerComponent := func(path string, builder func() templ.Component) {
-		http.HandleFunc(path, RateLimitMiddleware(CORSMiddleware(MethodMiddleware(http.MethodGet)(func(w http.ResponseWriter, r *http.Request) {
+		http.HandleFunc(path, RateLimitMiddleware(CORSMiddleware(MethodMiddleware(http.MethodGet)(RequireScopeFunc(utils.ScopeMetricsRead)(func(w http.ResponseWriter, r *http.Request) {
 			if !IsDashboardEnabled() {
 				http.NotFound(w, r)
 				return
 			}
 			templ.Handler(builder()).ServeHTTP(w, r)
-		}))))
+		})))))
 	}
 
 	registerComponent("/components/background.html", views.BackgroundComponent)
@@ -146,7 +183,7 @@ func MonitoringRoutes() {
 	}))))
 
 	// Serve monitoring configuration for UI
-	http.HandleFunc("/api/v1/server-config", RateLimitMiddleware(CORSMiddleware(MethodMiddleware(http.MethodGet, http.MethodOptions)(configHandler))))
+	http.HandleFunc("/api/v1/server-config", RateLimitMiddleware(ClientCertMiddleware(CORSMiddleware(MethodMiddleware(http.MethodGet, http.MethodOptions)(RequireScopeFunc(utils.ScopeMetricsRead)(configHandler))))))
 
 	// Serve available tables endpoint
 	tablesHandler := func(w http.ResponseWriter, r *http.Request) {
@@ -166,18 +203,9 @@ func MonitoringRoutes() {
 		setHeader(w, http.StatusOK, string(jsonData))
 	}
 
-	http.HandleFunc("/api/v1/tables", RateLimitMiddleware(CORSMiddleware(MethodMiddleware(http.MethodGet, http.MethodOptions)(tablesHandler))))
+	http.HandleFunc("/api/v1/tables", RateLimitMiddleware(ClientCertMiddleware(CORSMiddleware(MethodMiddleware(http.MethodGet, http.MethodOptions)(RequireScopeFunc(utils.ScopeMetricsRead)(tablesHandler))))))
 
     monitoringHandler := func(w http.ResponseWriter, r *http.Request) {
-		// Check token only in production if CHECK_TOKEN_IN_PRODUCTION is enabled
-		if IsProduction() && ShouldCheckTokenInProduction() {
-			_, err := ValidateTokenAndParseGeneric[TokenClaims](r)
-			if err != nil {
-				setHeader(w, http.StatusUnauthorized, fmt.Sprintf(`{"status":false, "error": "%s"}`, err.Error()))
-				return
-			}
-		}
-
         // Parse optional filter from request body (support chunked/unknown content length)
         var filter FilterRequest
         body, err := io.ReadAll(r.Body)
@@ -197,13 +225,20 @@ func MonitoringRoutes() {
         // Generate monitoring data based on filter
         var responseArray []any
 
-		if filter.From != "" || filter.To != "" || filter.TableName != "" {
+		if filter.From != "" || filter.To != "" || filter.TableName != "" || filter.page().Active() {
 			// Use filtered data from database (with optional table specification)
-			filteredData, err := logics.MonitoringDataGeneratorWithTableFilter(filter.TableName, filter.From, filter.To)
+			filteredData, nextCursor, err := logics.MonitoringDataPageWithTableFilter(filter.TableName, filter.From, filter.To, filter.page())
 			if err != nil {
-				setHeader(w, http.StatusInternalServerError, fmt.Sprintf(`{"status":false, "error": "%s"}`, err.Error()))
+				status := http.StatusInternalServerError
+				if errors.Is(err, utils.ErrInvalidCursor) {
+					status = http.StatusBadRequest
+				}
+				setHeader(w, status, fmt.Sprintf(`{"status":false, "error": "%s"}`, err.Error()))
 				return
 			}
+			if nextCursor != "" {
+				w.Header().Set("X-Next-Cursor", nextCursor)
+			}
 			responseArray = filteredData
 		} else {
 			// Use current metrics and wrap in array
@@ -226,7 +261,14 @@ func MonitoringRoutes() {
 	}
 
 	// Apply middleware to restrict to POST method only
-	http.HandleFunc("/monitoring", RateLimitMiddleware(CORSMiddleware(MethodMiddleware(http.MethodPost, http.MethodOptions)(monitoringHandler))))
+	http.HandleFunc("/monitoring", RateLimitMiddleware(ClientCertMiddleware(CORSMiddleware(MethodMiddleware(http.MethodPost, http.MethodOptions)(RequireScopeFunc(utils.ScopeMetricsRead)(monitoringHandler))))))
+	http.HandleFunc("/api/v1/export", RateLimitMiddleware(ClientCertMiddleware(CORSMiddleware(MethodMiddleware(http.MethodGet, http.MethodOptions)(RequireScopeFunc(utils.ScopeMetricsRead)(ExportHandler))))))
+	http.HandleFunc("/api/v1/ingest", RateLimitMiddleware(ClientCertMiddleware(MethodMiddleware(http.MethodPost)(IngestHandler))))
+	http.HandleFunc("/api/v1/admin/config/reload", RateLimitMiddleware(ClientCertMiddleware(RequireMethodScopeFunc(map[string]string{http.MethodGet: utils.ScopeConfigRead, http.MethodPost: utils.ScopeConfigReload})(ConfigReloadHandler))))
+	http.HandleFunc("/api/v1/admin/heartbeats", RateLimitMiddleware(ClientCertMiddleware(RequireMethodScopeFunc(map[string]string{http.MethodGet: utils.ScopeConfigRead, http.MethodPost: utils.ScopeConfigWrite})(HeartbeatsAdminHandler))))
+	http.HandleFunc("/api/v1/admin/heartbeats/", RateLimitMiddleware(ClientCertMiddleware(RequireMethodScopeFunc(map[string]string{http.MethodGet: utils.ScopeConfigRead, http.MethodPut: utils.ScopeConfigWrite, http.MethodDelete: utils.ScopeConfigWrite})(HeartbeatsAdminHandler))))
+	http.HandleFunc("/api/v1/admin/servers", RateLimitMiddleware(ClientCertMiddleware(RequireMethodScopeFunc(map[string]string{http.MethodGet: utils.ScopeConfigRead, http.MethodPost: utils.ScopeConfigWrite})(ServersAdminHandler))))
+	http.HandleFunc("/api/v1/admin/servers/", RateLimitMiddleware(ClientCertMiddleware(RequireMethodScopeFunc(map[string]string{http.MethodGet: utils.ScopeConfigRead, http.MethodPut: utils.ScopeConfigWrite, http.MethodDelete: utils.ScopeConfigWrite})(ServersAdminHandler))))
 }
 
 func proxyRemoteServerConfig(w http.ResponseWriter, target string, cfg *models.MonitoringConfig) {
@@ -236,35 +278,27 @@ func proxyRemoteServerConfig(w http.ResponseWriter, target string, cfg *models.M
 		return
 	}
 
-	if cfg == nil || !isRemoteServerAllowed(normalized, cfg.Servers) {
+	if cfg == nil {
 		writeJSONError(w, http.StatusForbidden, "remote server is not allowed")
 		return
 	}
-
-	// Try to fetch config from remote server first
-	remoteURL := fmt.Sprintf("%s/api/v1/server-config", strings.TrimRight(normalized, "/"))
-	req, err := http.NewRequest(http.MethodGet, remoteURL, nil)
-	if err != nil {
-		writeJSONError(w, http.StatusBadGateway, fmt.Sprintf("failed to create remote request: %v", err))
-		return
-	}
-
-	resp, err := remoteConfigHTTPClient.Do(req)
-	if err != nil {
-		writeJSONError(w, http.StatusBadGateway, fmt.Sprintf("remote config request failed: %v", err))
+	server, ok := findRemoteServer(normalized, logics.MonitoredServers(cfg))
+	if !ok {
+		writeJSONError(w, http.StatusForbidden, "remote server is not allowed")
 		return
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	// Try to fetch config from remote server first; the poller coalesces and caches these
+	remote, err := logics.FetchRemoteServerConfig(server, normalized)
 	if err != nil {
-		writeJSONError(w, http.StatusBadGateway, fmt.Sprintf("failed to read remote response: %v", err))
+		writeJSONError(w, http.StatusBadGateway, err.Error())
 		return
 	}
+	body := remote.Body
 
 	// If remote server returns 404, it's likely an older version without the endpoint
 	// Fall back to generating a compatible config based on local configuration
-	if resp.StatusCode == http.StatusNotFound {
+	if remote.StatusCode == http.StatusNotFound {
 		fallbackConfig := generateFallbackRemoteConfig(normalized, cfg)
 		jsonData, err := json.Marshal(fallbackConfig)
 		if err != nil {
@@ -275,12 +309,12 @@ func proxyRemoteServerConfig(w http.ResponseWriter, target string, cfg *models.M
 		return
 	}
 
-	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+	if remote.StatusCode < http.StatusOK || remote.StatusCode >= http.StatusMultipleChoices {
 		if len(body) == 0 {
-			writeJSONError(w, resp.StatusCode, fmt.Sprintf("remote server returned status %d", resp.StatusCode))
+			writeJSONError(w, remote.StatusCode, fmt.Sprintf("remote server returned status %d", remote.StatusCode))
 			return
 		}
-		setHeader(w, resp.StatusCode, string(body))
+		setHeader(w, remote.StatusCode, string(body))
 		return
 	}
 
@@ -343,17 +377,18 @@ func normalizeRemoteAddress(raw string) (string, error) {
 	return base, nil
 }
 
-func isRemoteServerAllowed(target string, servers []models.ServerEndpoint) bool {
+// findRemoteServer returns the monitored server at target; only those may be proxied.
+func findRemoteServer(target string, servers []models.ServerEndpoint) (models.ServerEndpoint, bool) {
 	for _, server := range servers {
 		normalized, err := normalizeRemoteAddress(server.Address)
 		if err != nil {
 			continue
 		}
 		if normalized == target {
-			return true
+			return server, true
 		}
 	}
-	return false
+	return models.ServerEndpoint{}, false
 }
 
 func writeJSONError(w http.ResponseWriter, status int, message string) {
@@ -400,8 +435,8 @@ func ServerConfigHandler(w http.ResponseWriter, r *http.Request) {
 
     payload := map[string]any{
         "refresh_interval_seconds": refresh,
-        "heartbeat":                cfg.Heartbeat,
-        "servers":                  cfg.Servers,
+        "heartbeat":                logics.PublicHeartbeats(cfg),
+        "servers":                  logics.PublicServers(cfg),
         "storage":                  cfg.Storage,
         "path":                     cfg.Path,
         "persist_server_logs":      cfg.PersistServerLogs,
@@ -436,15 +471,6 @@ func TablesHandler(w http.ResponseWriter, r *http.Request) {
 
 // MonitoringHandler handles monitoring data requests
 func MonitoringHandler(w http.ResponseWriter, r *http.Request) {
-	// Check token only in production if CHECK_TOKEN_IN_PRODUCTION is enabled
-	if IsProduction() && ShouldCheckTokenInProduction() {
-		_, err := ValidateTokenAndParseGeneric[TokenClaims](r)
-		if err != nil {
-			setHeader(w, http.StatusUnauthorized, fmt.Sprintf(`{"status":false, "error": "%s"}`, err.Error()))
-			return
-		}
-	}
-
     // Parse optional filter from request body (support chunked/unknown content length)
     var filter FilterRequest
     body, err := io.ReadAll(r.Body)
@@ -464,13 +490,20 @@ func MonitoringHandler(w http.ResponseWriter, r *http.Request) {
     // Generate monitoring data based on filter
     var responseArray []any
 
-	if filter.From != "" || filter.To != "" || filter.TableName != "" {
+	if filter.From != "" || filter.To != "" || filter.TableName != "" || filter.page().Active() {
 		// Use filtered data from database (with optional table specification)
-		filteredData, err := logics.MonitoringDataGeneratorWithTableFilter(filter.TableName, filter.From, filter.To)
+		filteredData, nextCursor, err := logics.MonitoringDataPageWithTableFilter(filter.TableName, filter.From, filter.To, filter.page())
 		if err != nil {
-			setHeader(w, http.StatusInternalServerError, fmt.Sprintf(`{"status":false, "error": "%s"}`, err.Error()))
+			status := http.StatusInternalServerError
+			if errors.Is(err, utils.ErrInvalidCursor) {
+				status = http.StatusBadRequest
+			}
+			setHeader(w, status, fmt.Sprintf(`{"status":false, "error": "%s"}`, err.Error()))
 			return
 		}
+		if nextCursor != "" {
+			w.Header().Set("X-Next-Cursor", nextCursor)
+		}
 		responseArray = filteredData
 	} else {
 		// Use current metrics and wrap in array
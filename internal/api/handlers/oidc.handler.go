@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"go-log/internal/api/logics"
+	"go-log/internal/config"
+	"go-log/internal/utils"
+	"go-log/web/views"
+)
+
+// oidcStateCookieName ties the provider's callback to the browser that started the login,
+// so a callback URL from someone else's login cannot sign this browser in.
+const oidcStateCookieName = "go_log_oidc_state"
+
+// OIDCLoginHandler sends the browser to the identity provider to sign in.
+// GET /login/oidc?next=PATH
+func OIDCLoginHandler(w http.ResponseWriter, r *http.Request) {
+	envConfig := config.GetEnvConfig()
+	if !envConfig.IsOIDCLoginEnabled() {
+		http.NotFound(w, r)
+		return
+	}
+	next := safeRedirectTarget(r.URL.Query().Get("next"))
+	authURL, state, err := logics.StartOIDCLogin(r.Context(), next)
+	if err != nil {
+		utils.LogWarnWithContext("oidc", "failed to start single sign-on", err)
+		renderLoginPage(w, r, http.StatusBadGateway, views.LoginProps{Next: next, Error: "Single sign-on is unavailable. Please try again later."})
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    state,
+		Path:     "/login/oidc",
+		MaxAge:   int(logics.OIDCLoginTimeout.Seconds()),
+		HttpOnly: true,
+		Secure:   envConfig.SessionCookieSecure,
+		SameSite: http.SameSiteLaxMode, // sent on the provider's top-level redirect back
+	})
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallbackHandler finishes the login when the provider redirects back, and starts a
+// dashboard session.
+// GET /login/oidc/callback?code=CODE&state=STATE
+func OIDCCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	if !config.GetEnvConfig().IsOIDCLoginEnabled() {
+		http.NotFound(w, r)
+		return
+	}
+	query := r.URL.Query()
+	state := query.Get("state")
+	cookie, err := r.Cookie(oidcStateCookieName)
+	clearCookie(w, oidcStateCookieName)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookie.Value)) != 1 {
+		renderLoginPage(w, r, http.StatusForbidden, views.LoginProps{Error: "Your sign-in attempt expired. Please try again."})
+		return
+	}
+	if providerError := query.Get("error"); providerError != "" {
+		logics.CancelOIDCLogin(state)
+		utils.LogWarn("single sign-on failed from %s: provider returned %s: %s", getClientKey(r), providerError, query.Get("error_description"))
+		renderLoginPage(w, r, http.StatusUnauthorized, views.LoginProps{Error: "Single sign-on was cancelled or denied."})
+		return
+	}
+
+	session, next, err := logics.FinishOIDCLogin(r.Context(), state, query.Get("code"))
+	if err != nil {
+		utils.LogWarn("single sign-on failed from %s: %v", getClientKey(r), err)
+		status, message := http.StatusUnauthorized, "Single sign-on failed. Please try again."
+		if errors.Is(err, utils.ErrForbidden) {
+			status, message = http.StatusForbidden, "Your account is not allowed to use the dashboard."
+		}
+		renderLoginPage(w, r, status, views.LoginProps{Error: message})
+		return
+	}
+	utils.LogInfo("login: %s (%s, single sign-on) from %s", session.Username, session.Role, getClientKey(r))
+	startBrowserSession(w, r, session, next)
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"go-log/internal/api/logics"
+	"go-log/internal/config"
+	"go-log/internal/utils"
+)
+
+// oidcGrant is an authorization code the mock provider has issued.
+type oidcGrant struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+// mockOIDCProvider is an identity provider with discovery, a JWKS and a token endpoint that
+// enforces PKCE.
+type mockOIDCProvider struct {
+	server *httptest.Server
+	key    *ecdsa.PrivateKey
+
+	mu     sync.Mutex
+	grants map[string]oidcGrant
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &mockOIDCProvider{key: key, grants: map[string]oidcGrant{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(utils.OIDCProviderMetadata{
+			Issuer:                p.server.URL,
+			AuthorizationEndpoint: p.server.URL + "/authorize",
+			TokenEndpoint:         p.server.URL + "/token",
+			JWKSURI:               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "EC", "crv": "P-256", "kid": "key-1", "use": "sig",
+			"x": base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			"y": base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		}}})
+	})
+	mux.HandleFunc("/token", p.token)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *mockOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	grant, ok := p.grants[r.PostFormValue("code")]
+	delete(p.grants, r.PostFormValue("code"))
+	p.mu.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(verifier[:]) != grant.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, grant.claims)
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+}
+
+// authorize plays the user signing in at the provider: it issues a code for the login
+// request authURL, with the ID token claims edit returns.
+func (p *mockOIDCProvider) authorize(t *testing.T, authURL string, edit func(jwt.MapClaims)) string {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	if query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("login request without an S256 challenge: %s", authURL)
+	}
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": p.server.URL, "aud": "go-log", "sub": "user-1", "preferred_username": "alice",
+		"nonce": query.Get("nonce"), "groups": []any{"ops"},
+		"iat": now.Unix(), "exp": now.Add(5 * time.Minute).Unix(),
+	}
+	if edit != nil {
+		edit(claims)
+	}
+	code := "code-" + query.Get("state")
+	p.mu.Lock()
+	p.grants[code] = oidcGrant{challenge: query.Get("code_challenge"), claims: claims}
+	p.mu.Unlock()
+	return code
+}
+
+// startOIDCLogin runs the login handler and returns its redirect to the provider and the
+// state cookie.
+func startOIDCLogin(t *testing.T) (string, *http.Cookie) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	OIDCLoginHandler(rec, httptest.NewRequest(http.MethodGet, "/login/oidc?next=/servers", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("login: status %d, want %d", rec.Code, http.StatusFound)
+	}
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == oidcStateCookieName {
+			return rec.Header().Get("Location"), cookie
+		}
+	}
+	t.Fatal("login set no state cookie")
+	return "", nil
+}
+
+func finishOIDCLogin(code, state string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/login/oidc/callback?"+url.Values{"code": {code}, "state": {state}}.Encode(), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	OIDCCallbackHandler(rec, req)
+	return rec
+}
+
+func TestOIDCLoginFlow(t *testing.T) {
+	useTestAuthEnv(t, "true")
+	p := newMockOIDCProvider(t)
+	t.Setenv("OIDC_ISSUER", p.server.URL)
+	t.Setenv("OIDC_CLIENT_ID", "go-log")
+	t.Setenv("OIDC_REDIRECT_URL", "http://localhost:8080/login/oidc/callback")
+	t.Setenv("OIDC_GROUPS_CLAIM", "groups")
+	t.Setenv("OIDC_ROLE_MAPPING", "ops=operator,admins=admin")
+	t.Setenv("OIDC_DEFAULT_ROLE", "")
+	config.InitEnvConfig()
+
+	stateOf := func(authURL string) string {
+		u, _ := url.Parse(authURL)
+		return u.Query().Get("state")
+	}
+
+	tests := []struct {
+		name     string
+		run      func(t *testing.T) *httptest.ResponseRecorder
+		want     int
+		wantRole string
+	}{
+		{
+			name: "signed in",
+			run: func(t *testing.T) *httptest.ResponseRecorder {
+				authURL, cookie := startOIDCLogin(t)
+				return finishOIDCLogin(p.authorize(t, authURL, nil), stateOf(authURL), cookie)
+			},
+			want:     http.StatusSeeOther,
+			wantRole: utils.RoleOperator,
+		},
+		{
+			name: "most privileged group",
+			run: func(t *testing.T) *httptest.ResponseRecorder {
+				authURL, cookie := startOIDCLogin(t)
+				code := p.authorize(t, authURL, func(c jwt.MapClaims) { c["groups"] = []any{"ops", "admins"} })
+				return finishOIDCLogin(code, stateOf(authURL), cookie)
+			},
+			want:     http.StatusSeeOther,
+			wantRole: utils.RoleAdmin,
+		},
+		{
+			name: "unmapped group",
+			run: func(t *testing.T) *httptest.ResponseRecorder {
+				authURL, cookie := startOIDCLogin(t)
+				code := p.authorize(t, authURL, func(c jwt.MapClaims) { c["groups"] = []any{"sales"} })
+				return finishOIDCLogin(code, stateOf(authURL), cookie)
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name: "state mismatch",
+			run: func(t *testing.T) *httptest.ResponseRecorder {
+				authURL, _ := startOIDCLogin(t)
+				_, otherCookie := startOIDCLogin(t)
+				return finishOIDCLogin(p.authorize(t, authURL, nil), stateOf(authURL), otherCookie)
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name: "no state cookie",
+			run: func(t *testing.T) *httptest.ResponseRecorder {
+				authURL, _ := startOIDCLogin(t)
+				return finishOIDCLogin(p.authorize(t, authURL, nil), stateOf(authURL), nil)
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name: "nonce mismatch",
+			run: func(t *testing.T) *httptest.ResponseRecorder {
+				authURL, cookie := startOIDCLogin(t)
+				code := p.authorize(t, authURL, func(c jwt.MapClaims) { c["nonce"] = "replayed" })
+				return finishOIDCLogin(code, stateOf(authURL), cookie)
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			// A code issued to another login is injected into this one; its PKCE challenge
+			// does not match this login's verifier
+			name: "pkce verifier mismatch",
+			run: func(t *testing.T) *httptest.ResponseRecorder {
+				authURL, cookie := startOIDCLogin(t)
+				otherURL, _ := startOIDCLogin(t)
+				return finishOIDCLogin(p.authorize(t, otherURL, nil), stateOf(authURL), cookie)
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "wrong audience",
+			run: func(t *testing.T) *httptest.ResponseRecorder {
+				authURL, cookie := startOIDCLogin(t)
+				code := p.authorize(t, authURL, func(c jwt.MapClaims) { c["aud"] = "other-client" })
+				return finishOIDCLogin(code, stateOf(authURL), cookie)
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "code redeemed twice",
+			run: func(t *testing.T) *httptest.ResponseRecorder {
+				authURL, cookie := startOIDCLogin(t)
+				code := p.authorize(t, authURL, nil)
+				finishOIDCLogin(code, stateOf(authURL), cookie)
+				return finishOIDCLogin(code, stateOf(authURL), cookie)
+			},
+			want: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := tt.run(t)
+			if rec.Code != tt.want {
+				t.Fatalf("callback: status %d, want %d: %s", rec.Code, tt.want, strings.TrimSpace(rec.Body.String()))
+			}
+			var sessionID string
+			for _, cookie := range rec.Result().Cookies() {
+				if cookie.Name == SessionCookieName {
+					sessionID = cookie.Value
+				}
+			}
+			if tt.wantRole == "" {
+				if sessionID != "" {
+					t.Fatal("failed sign-in set a session cookie")
+				}
+				return
+			}
+			if got := rec.Header().Get("Location"); got != "/servers" {
+				t.Errorf("redirect to %q, want /servers", got)
+			}
+			session, user, err := logics.LookupSession(sessionID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { logics.EndSession(session.ID) })
+			if user.Username != "alice" || user.Role != tt.wantRole {
+				t.Errorf("signed in as %s (%s), want alice (%s)", user.Username, user.Role, tt.wantRole)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-log/internal/config"
+	"go-log/internal/utils"
+)
+
+const (
+	// rateLimitBackendTimeout bounds a shared-backend lookup, so a slow Redis delays
+	// requests by at most this much before the in-memory fallback answers.
+	rateLimitBackendTimeout = 200 * time.Millisecond
+	// rateLimitWarnInterval spaces out warnings about an unreachable shared backend.
+	rateLimitWarnInterval = time.Minute
+)
+
+var (
+	rateLimitOnce     sync.Once
+	rateLimitErr      error
+	rateLimitStore    utils.RateLimitStore
+	rateLimitFallback *utils.MemoryRateLimitStore
+	rateLimitPolicies utils.RateLimitPolicies
+
+	rateLimitWarnMu sync.Mutex
+	rateLimitWarned time.Time
+)
+
+// InitRateLimiting validates the RATE_LIMIT_* settings and opens the configured backend.
+// Without a call, the first rate-limited request initializes it.
+func InitRateLimiting() error {
+	rateLimitOnce.Do(func() {
+		envConfig := config.GetEnvConfig()
+		rateLimitFallback = utils.NewMemoryRateLimitStore(envConfig.RateLimitMaxClients, envConfig.RateLimitClientTTL)
+		rateLimitStore = rateLimitFallback
+		rateLimitPolicies, _ = utils.ParseRateLimitPolicies("", utils.RateLimitPolicy{RPS: envConfig.RateLimitRPS, Burst: envConfig.RateLimitBurst})
+		if !envConfig.IsRateLimitEnabled() {
+			return
+		}
+
+		if envConfig.RateLimitRPS <= 0 || envConfig.RateLimitBurst < 1 {
+			rateLimitErr = fmt.Errorf("%w: RATE_LIMIT_RPS and RATE_LIMIT_BURST must be above zero", utils.ErrInvalidConfig)
+			return
+		}
+		policies, err := utils.ParseRateLimitPolicies(envConfig.RateLimitPolicies, rateLimitPolicies.Default)
+		if err != nil {
+			rateLimitErr = err
+			return
+		}
+		rateLimitPolicies = policies
+
+		store, err := utils.NewRateLimitStore()
+		if err != nil {
+			rateLimitErr = err
+			return
+		}
+		rateLimitStore = store
+		utils.LogInfo("rate limiting with the %s backend: %g requests/s, burst %d by default", store.Name(), envConfig.RateLimitRPS, envConfig.RateLimitBurst)
+	})
+	return rateLimitErr
+}
+
+// isRateLimitEnabled checks if rate limiting is enabled
+func isRateLimitEnabled() bool {
+	envConfig := config.GetEnvConfig()
+	return envConfig.IsRateLimitEnabled()
+}
+
+// RateLimitMiddleware admits requests within their policy's budget and answers 429
+// otherwise. Requests with a valid API key are counted per key, all others per client IP;
+// each policy has its own budget.
+func RateLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Check if rate limiting is enabled
+		if !isRateLimitEnabled() {
+			next(w, r)
+			return
+		}
+		if err := InitRateLimiting(); err != nil {
+			utils.LogWarnWithContext("rate-limit", "invalid rate limit settings; using the defaults", err)
+		}
+
+		key := rateLimitAPIKey(r)
+		policy := rateLimitPolicies.Match(r.URL.Path, key)
+		if policy.Unlimited {
+			next(w, r)
+			return
+		}
+		identity := "ip:" + getClientKey(r)
+		if key != nil {
+			identity = "key:" + key.ID
+		}
+		bucket := policy.Name + "|" + identity
+
+		ctx, cancel := context.WithTimeout(r.Context(), rateLimitBackendTimeout)
+		result, err := rateLimitStore.Allow(ctx, bucket, policy)
+		cancel()
+		if err != nil {
+			warnRateLimitBackend(err)
+			result, _ = rateLimitFallback.Allow(r.Context(), bucket, policy)
+		}
+
+		reset := utils.NowUTC().Add(max(result.RetryAfter, time.Second))
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(max(result.RetryAfter, time.Second).Seconds()))))
+			setHeader(w, http.StatusTooManyRequests, `{"status":false, "error": "Rate limit exceeded"}`)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// rateLimitAPIKey returns the request's API key when it is valid. Invalid keys are counted
+// by IP, so made-up keys cannot open fresh budgets.
+func rateLimitAPIKey(r *http.Request) *utils.APIKey {
+	credential := requestCredential(r)
+	if !utils.IsAPIKey(credential) {
+		return nil
+	}
+	key, err := utils.AuthenticateAPIKey(credential)
+	if err != nil {
+		return nil
+	}
+	return key
+}
+
+// warnRateLimitBackend logs a failing shared backend at most once per rateLimitWarnInterval.
+func warnRateLimitBackend(err error) {
+	rateLimitWarnMu.Lock()
+	defer rateLimitWarnMu.Unlock()
+	if time.Since(rateLimitWarned) < rateLimitWarnInterval {
+		return
+	}
+	rateLimitWarned = time.Now()
+	utils.LogWarnWithContext("rate-limit", fmt.Sprintf("%s backend unavailable, limiting per instance until it recovers", rateLimitStore.Name()), err)
+}
+
+// getClientKey returns the client's IP address. X-Forwarded-For and X-Real-IP are only
+// believed when the connection comes from TRUSTED_PROXIES. The forwarded chain is read
+// from the right, skipping trusted proxies, so a client cannot pick its address by sending
+// the header itself.
+func getClientKey(r *http.Request) string {
+	peer := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+	trusted := config.GetEnvConfig().TrustedProxies
+	if !isTrustedProxy(r.RemoteAddr, trusted) {
+		return peer
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			if i == 0 || !isTrustedProxy(hop, trusted) {
+				return hop
+			}
+		}
+		return peer
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return peer
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-log/internal/config"
+)
+
+func TestGetClientKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		trusted   string
+		peer      string
+		forwarded []string
+		realIP    string
+		want      string
+	}{
+		{name: "direct client", trusted: "127.0.0.1", peer: "203.0.113.7:5000", want: "203.0.113.7"},
+		{name: "forged header from untrusted peer", trusted: "127.0.0.1", peer: "203.0.113.7:5000",
+			forwarded: []string{"198.51.100.1"}, realIP: "198.51.100.2", want: "203.0.113.7"},
+		{name: "trusted proxy", trusted: "127.0.0.1", peer: "127.0.0.1:5000",
+			forwarded: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "client-supplied hops ignored", trusted: "127.0.0.1", peer: "127.0.0.1:5000",
+			forwarded: []string{"10.9.9.9, 198.51.100.1"}, want: "198.51.100.1"},
+		{name: "proxy chain in trusted cidr", trusted: "10.0.0.0/8", peer: "10.0.0.2:5000",
+			forwarded: []string{"198.51.100.1, 10.1.2.3"}, want: "198.51.100.1"},
+		{name: "hops split across headers", trusted: "10.0.0.0/8", peer: "10.0.0.2:5000",
+			forwarded: []string{"198.51.100.1", "10.1.2.3"}, want: "198.51.100.1"},
+		{name: "every hop trusted", trusted: "10.0.0.0/8", peer: "10.0.0.2:5000",
+			forwarded: []string{"10.0.0.9, 10.1.2.3"}, want: "10.0.0.9"},
+		{name: "garbage hop stops the walk", trusted: "10.0.0.0/8", peer: "10.0.0.2:5000",
+			forwarded: []string{"198.51.100.1, not-an-ip, 10.1.2.3"}, want: "10.0.0.2"},
+		{name: "ipv6 proxy", trusted: "::1, fd00::/8", peer: "[fd00::2]:5000",
+			forwarded: []string{"2001:db8::1"}, want: "2001:db8::1"},
+		{name: "x-real-ip from trusted proxy", trusted: "127.0.0.1", peer: "127.0.0.1:5000",
+			realIP: "198.51.100.3", want: "198.51.100.3"},
+		{name: "invalid x-real-ip", trusted: "127.0.0.1", peer: "127.0.0.1:5000",
+			realIP: "unknown", want: "127.0.0.1"},
+		{name: "peer is not the trusted proxy", trusted: "192.0.2.1", peer: "127.0.0.1:5000",
+			forwarded: []string{"198.51.100.1"}, want: "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRUSTED_PROXIES", tt.trusted)
+			config.InitEnvConfig()
+			t.Cleanup(config.InitEnvConfig)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/metrics", nil)
+			req.RemoteAddr = tt.peer
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := getClientKey(req); got != tt.want {
+				t.Errorf("client key %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"go-log/internal/config"
+	"go-log/internal/utils"
+)
+
+// ClientCertMiddleware rejects API requests that did not present a client certificate
+// signed by TLS_CLIENT_CA_FILE, when TLS_CLIENT_AUTH is "require". The certificate is
+// checked in addition to, not instead of, the request's credentials.
+func ClientCertMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !config.GetEnvConfig().RequiresClientCertificate() || r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+		if utils.VerifiedClientCertificate(r.TLS) == nil {
+			utils.LogWarn("client certificate required for %s %s from %s", r.Method, r.URL.Path, getClientKey(r))
+			writeJSONError(w, http.StatusUnauthorized, "a client certificate signed by the trusted CA is required")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// HTTPSRedirectHandler sends plain HTTP requests to the same URL over HTTPS on httpsPort.
+func HTTPSRedirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if host == "" {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		} else if strings.Contains(host, ":") && !strings.HasPrefix(host, "[") {
+			host = "[" + host + "]" // IPv6 literal
+		}
+		// 308 keeps the method and body of API calls
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
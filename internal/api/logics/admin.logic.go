@@ -0,0 +1,462 @@
+package logics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go-log/internal/api/models"
+	"go-log/internal/utils"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Configuration entries managed through the admin API.
+const (
+	ConfigResourceHeartbeat = "heartbeat"
+	ConfigResourceServer    = "server"
+)
+
+// Admin actions recorded in the audit log.
+const (
+	ConfigActionCreate = "create"
+	ConfigActionUpdate = "update"
+	ConfigActionDelete = "delete"
+)
+
+const redactedValue = "[redacted]"
+
+// ConfigChange is one edit of a heartbeat check or a monitored server.
+type ConfigChange struct {
+	Resource  string                 // ConfigResourceHeartbeat or ConfigResourceServer
+	Action    string                 // ConfigActionCreate, ConfigActionUpdate or ConfigActionDelete
+	Name      string                 // entry to update or delete
+	Heartbeat *models.ServerConfig   // new heartbeat for create/update
+	Server    *models.ServerEndpoint // new server for create/update
+	Actor     string                 // who made the change, for the audit log
+	Remote    string                 // client address, for the audit log
+}
+
+func configListKey(resource string) string {
+	if resource == ConfigResourceServer {
+		return "servers"
+	}
+	return "heartbeat"
+}
+
+// AdminHeartbeats returns the configured heartbeat checks with header values redacted.
+func AdminHeartbeats() []models.ServerConfig {
+	cfg := GetMonitoringConfig()
+	heartbeats := make([]models.ServerConfig, 0, len(cfg.Heartbeat))
+	for _, hb := range cfg.Heartbeat {
+		heartbeats = append(heartbeats, redactHeartbeat(hb))
+	}
+	return heartbeats
+}
+
+// AdminServers returns the configured (not discovered) servers with tokens redacted.
+func AdminServers() []models.ServerEndpoint {
+	cfg := GetMonitoringConfig()
+	servers := make([]models.ServerEndpoint, 0, len(cfg.Servers))
+	for _, srv := range cfg.Servers {
+		servers = append(servers, redactServer(srv))
+	}
+	return servers
+}
+
+func redactHeartbeat(hb models.ServerConfig) models.ServerConfig {
+	if len(hb.Headers) > 0 {
+		headers := make(map[string]string, len(hb.Headers))
+		for key := range hb.Headers {
+			headers[key] = redactedValue
+		}
+		hb.Headers = headers
+	}
+	return hb
+}
+
+func redactServer(srv models.ServerEndpoint) models.ServerEndpoint {
+	if srv.Token != "" {
+		srv.Token = redactedValue
+	}
+	if srv.Auth != nil {
+		auth := *srv.Auth
+		for _, secret := range []*string{&auth.Token, &auth.Password, &auth.HMACSecret} {
+			if *secret != "" {
+				*secret = redactedValue
+			}
+		}
+		srv.Auth = &auth
+	}
+	return srv
+}
+
+// restoreServerSecrets puts back the secrets an admin client sent as redactedValue, as it
+// received them from the listing.
+func restoreServerSecrets(srv *models.ServerEndpoint, old models.ServerEndpoint) {
+	if srv.Token == redactedValue {
+		srv.Token = old.Token
+	}
+	if srv.Auth == nil {
+		return
+	}
+	auth := *srv.Auth
+	oldAuth := models.ServerAuth{}
+	if old.Auth != nil {
+		oldAuth = *old.Auth
+	}
+	if auth.Token == redactedValue {
+		auth.Token = oldAuth.Token
+	}
+	if auth.Password == redactedValue {
+		auth.Password = oldAuth.Password
+	}
+	if auth.HMACSecret == redactedValue {
+		auth.HMACSecret = oldAuth.HMACSecret
+	}
+	srv.Auth = &auth
+}
+
+// ApplyConfigChange writes a heartbeat or server change to the configuration file and
+// applies it. The new file is validated before it replaces the old one, so a rejected
+// change leaves both the file and the running configuration untouched. Every attempt is
+// written to the audit log.
+func ApplyConfigChange(change ConfigChange) (result ConfigReloadResult, err error) {
+	InitMonitoringConfig()
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	result = ConfigReloadResult{Trigger: ReloadTriggerAdmin, Time: utils.NowUTC()}
+	audit := utils.AuditEntry{
+		Time:     result.Time,
+		Actor:    change.Actor,
+		Remote:   change.Remote,
+		Action:   change.Action,
+		Resource: change.Resource,
+		Name:     change.Name,
+	}
+	if audit.Name == "" && change.Heartbeat != nil {
+		audit.Name = strings.TrimSpace(change.Heartbeat.Name)
+	}
+	if audit.Name == "" && change.Server != nil {
+		audit.Name = strings.TrimSpace(change.Server.Name)
+	}
+	defer func() {
+		audit.Success = err == nil
+		if err != nil {
+			audit.Error = err.Error()
+		}
+		if auditErr := utils.WriteAuditEntry(audit); auditErr != nil {
+			utils.LogErrorWithContext("audit", "failed to record configuration change", auditErr)
+		}
+	}()
+
+	configPath := getConfigPath()
+	if ext := strings.ToLower(filepath.Ext(configPath)); ext != ".json" && ext != "" {
+		return result, fmt.Errorf("%w: %s is not a JSON file; edit it directly", utils.ErrConfigNotWritable, filepath.Base(configPath))
+	}
+	raw, err := os.ReadFile(configPath)
+	if err != nil {
+		return result, fmt.Errorf("failed to read configuration file %s: %w", configPath, err)
+	}
+	doc, err := decodeOrderedObject(raw)
+	if err != nil {
+		return result, fmt.Errorf("%w: %s: %v", utils.ErrConfigNotWritable, filepath.Base(configPath), err)
+	}
+
+	key := configListKey(change.Resource)
+	var items []json.RawMessage
+	if value := doc.get(key); value != nil && string(value) != "null" {
+		if err := json.Unmarshal(value, &items); err != nil {
+			return result, fmt.Errorf("%w: %q is not a list", utils.ErrConfigNotWritable, key)
+		}
+	}
+
+	items, before, after, err := editConfigEntries(items, change)
+	audit.Before, audit.After = before, after
+	if err != nil {
+		return result, err
+	}
+
+	list, err := json.Marshal(items)
+	if err != nil {
+		return result, fmt.Errorf("%w: %v", utils.ErrDataMarshalFailed, err)
+	}
+	doc.set(key, list)
+	next, sources, err := writeConfigFile(configPath, doc.encode())
+	if err != nil {
+		result.Error = err.Error()
+		recordReload(result)
+		return result, err
+	}
+
+	monitoringConfigMu.Lock()
+	applyMonitoringConfig(next, &result)
+	configSources = sources
+	monitoringConfigMu.Unlock()
+	applyExporters(next)
+
+	result.Success = true
+	recordReload(result)
+	return result, nil
+}
+
+// editConfigEntries applies change to the raw entries of the configuration file and returns
+// the redacted entry before and after the change.
+func editConfigEntries(items []json.RawMessage, change ConfigChange) (_ []json.RawMessage, before, after any, err error) {
+	index := -1
+	for i := 0; i < len(items) && change.Name != ""; i++ {
+		var entry struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(items[i], &entry) == nil && strings.TrimSpace(entry.Name) == change.Name {
+			index = i
+			break
+		}
+	}
+	if index >= 0 {
+		before = redactRawEntry(change.Resource, items[index])
+	}
+
+	switch change.Action {
+	case ConfigActionCreate, ConfigActionUpdate:
+		var previous json.RawMessage
+		if index >= 0 && change.Action == ConfigActionUpdate {
+			previous = items[index]
+		}
+		entry, name, err := configEntry(change, previous)
+		if err != nil {
+			return nil, before, nil, err
+		}
+		after = redactRawEntry(change.Resource, entry)
+
+		if change.Action == ConfigActionCreate || name != change.Name {
+			if configEntryExists(change.Resource, name) {
+				return nil, before, after, fmt.Errorf("%w: %s %q", utils.ErrConfigEntryExists, change.Resource, name)
+			}
+		}
+		if change.Action == ConfigActionCreate {
+			return append(items, entry), before, after, nil
+		}
+		if index < 0 {
+			return nil, before, after, missingConfigEntry(change)
+		}
+		items[index] = entry
+		return items, before, after, nil
+
+	case ConfigActionDelete:
+		if index < 0 {
+			return nil, nil, nil, missingConfigEntry(change)
+		}
+		return append(items[:index], items[index+1:]...), before, nil, nil
+	}
+	return nil, before, nil, fmt.Errorf("%w: unknown action %q", utils.ErrValidationFailed, change.Action)
+}
+
+// configEntry normalizes the new entry of a create or update and returns it with its name.
+// Secrets sent back as the redacted placeholder keep their value from previous, the entry
+// as written in the file, so a listed entry can be edited and sent back unchanged.
+func configEntry(change ConfigChange, previous json.RawMessage) (json.RawMessage, string, error) {
+	var entry any
+	var name string
+	switch {
+	case change.Resource == ConfigResourceHeartbeat && change.Heartbeat != nil:
+		hb := *change.Heartbeat
+		hb.Name = strings.TrimSpace(hb.Name)
+		hb.URL = strings.TrimSpace(hb.URL)
+		var old models.ServerConfig
+		if previous != nil && json.Unmarshal(previous, &old) == nil {
+			for key, value := range hb.Headers {
+				if value == redactedValue {
+					hb.Headers[key] = old.Headers[key]
+				}
+			}
+		}
+		entry, name = hb, hb.Name
+	case change.Resource == ConfigResourceServer && change.Server != nil:
+		srv := *change.Server
+		var old models.ServerEndpoint
+		if previous != nil && json.Unmarshal(previous, &old) == nil {
+			restoreServerSecrets(&srv, old)
+		}
+		srv.Name = strings.TrimSpace(srv.Name)
+		srv.Address = strings.TrimSpace(srv.Address)
+		if utils.IsEmptyOrWhitespace(srv.TableName) {
+			srv.TableName = srv.Name
+		}
+		srv.TableName = utils.SanitizeTableName(srv.TableName)
+		entry, name = srv, srv.Name
+	default:
+		return nil, "", fmt.Errorf("%w: missing %s entry", utils.ErrValidationFailed, change.Resource)
+	}
+	if name == "" {
+		return nil, "", fmt.Errorf("%w: name is required", utils.ErrValidationFailed)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", utils.ErrDataMarshalFailed, err)
+	}
+	return data, name, nil
+}
+
+// configEntryExists reports whether the running configuration, including included files,
+// already has an entry with this name.
+func configEntryExists(resource, name string) bool {
+	cfg := GetMonitoringConfig()
+	if resource == ConfigResourceServer {
+		for _, srv := range cfg.Servers {
+			if strings.TrimSpace(srv.Name) == name {
+				return true
+			}
+		}
+		return false
+	}
+	for _, hb := range cfg.Heartbeat {
+		if strings.TrimSpace(hb.Name) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// missingConfigEntry explains why an entry is not in the configuration file: it either
+// does not exist or comes from an included file, which the API does not edit.
+func missingConfigEntry(change ConfigChange) error {
+	if configEntryExists(change.Resource, change.Name) {
+		return fmt.Errorf("%w: %s %q is defined in an included file", utils.ErrConfigNotWritable, change.Resource, change.Name)
+	}
+	return fmt.Errorf("%w: %s %q", utils.ErrConfigEntryNotFound, change.Resource, change.Name)
+}
+
+func redactRawEntry(resource string, raw json.RawMessage) any {
+	if resource == ConfigResourceServer {
+		var srv models.ServerEndpoint
+		if json.Unmarshal(raw, &srv) != nil {
+			return nil
+		}
+		return redactServer(srv)
+	}
+	var hb models.ServerConfig
+	if json.Unmarshal(raw, &hb) != nil {
+		return nil
+	}
+	return redactHeartbeat(hb)
+}
+
+// writeConfigFile validates data as the new configuration file and atomically replaces
+// configPath with it. It returns the loaded configuration and its source files.
+func writeConfigFile(configPath string, data []byte) (*models.MonitoringConfig, map[string]time.Time, error) {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(configPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	// The temporary file lives next to the original so includes resolve the same way and
+	// the rename is atomic
+	tmp, err := os.CreateTemp(filepath.Dir(configPath), "."+filepath.Base(configPath)+".*.json")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to write configuration file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, mode)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to write configuration file: %w", err)
+	}
+
+	next, sources, err := readConfigFile(tmpPath, configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.Rename(tmpPath, configPath); err != nil {
+		return nil, nil, fmt.Errorf("failed to replace configuration file: %w", err)
+	}
+
+	// Record the file under its real name so the watcher does not reload it again
+	if abs, err := filepath.Abs(tmpPath); err == nil {
+		delete(sources, abs)
+	}
+	if abs, err := filepath.Abs(configPath); err == nil {
+		if info, err := os.Stat(abs); err == nil {
+			sources[abs] = info.ModTime()
+		}
+	}
+	return next, sources, nil
+}
+
+// orderedObject is a JSON object that keeps its key order, so rewriting the configuration
+// file only changes the edited key.
+type orderedObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+func decodeOrderedObject(data []byte) (*orderedObject, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("the top level must be a JSON object")
+	}
+	obj := &orderedObject{values: map[string]json.RawMessage{}}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if _, dup := obj.values[key]; !dup {
+			obj.keys = append(obj.keys, key)
+		}
+		obj.values[key] = value
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (o *orderedObject) get(key string) json.RawMessage { return o.values[key] }
+
+func (o *orderedObject) set(key string, value json.RawMessage) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// encode renders the object indented by two spaces, with a trailing newline.
+func (o *orderedObject) encode() []byte {
+	var compact bytes.Buffer
+	compact.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			compact.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		compact.Write(name)
+		compact.WriteByte(':')
+		compact.Write(o.values[key])
+	}
+	compact.WriteByte('}')
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact.Bytes(), "", "  "); err != nil {
+		return append(compact.Bytes(), '\n')
+	}
+	out.WriteByte('\n')
+	return out.Bytes()
+}
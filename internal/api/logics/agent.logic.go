@@ -0,0 +1,262 @@
+package logics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-log/internal/api/models"
+	"go-log/internal/utils"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers identifying a pushing agent on POST /api/v1/ingest.
+const (
+	AgentNameHeader         = "X-Agent-Name"
+	RegistrationTokenHeader = "X-Registration-Token"
+)
+
+const (
+	defaultAgentTimeout     = 10 * time.Second
+	defaultAgentMaxBuffered = 50000 // snapshots kept on disk while the collector is unreachable
+	agentPushBatch          = utils.OutboxSegmentSize
+	agentMaxPending         = 300 // snapshots kept in memory when there is no log path to spool to
+)
+
+var (
+	agentMu         sync.Mutex
+	agentPending    []models.SystemMonitoring
+	agentBusy       bool
+	agentFailing    bool
+	agentOutbox     *utils.AgentOutbox
+	agentOutboxPath string
+)
+
+// ingestURL validates the agent settings and returns the collector's ingest endpoint.
+func ingestURL(agent models.AgentConfig) (string, error) {
+	if utils.IsEmptyOrWhitespace(agent.Name) || utils.IsEmptyOrWhitespace(agent.Token) {
+		return "", fmt.Errorf("%w: agent name and token are required", utils.ErrInvalidConfig)
+	}
+	parsed, err := url.Parse(strings.TrimSpace(agent.CollectorURL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("%w: agent collector_url must be an absolute http(s) URL", utils.ErrInvalidConfig)
+	}
+	return strings.TrimRight(parsed.String(), "/") + "/api/v1/ingest", nil
+}
+
+// agentOutboxFor returns the on-disk outbox under cfg.Path, reopening it when the path
+// changes. It returns nil when there is no usable log path. Callers hold agentMu.
+func agentOutboxFor(cfg *models.MonitoringConfig) *utils.AgentOutbox {
+	if cfg.Path == agentOutboxPath && agentOutbox != nil {
+		return agentOutbox
+	}
+	agentOutbox, agentOutboxPath = nil, cfg.Path
+	if utils.IsEmptyOrWhitespace(cfg.Path) {
+		return nil
+	}
+	limit := cfg.Agent.MaxBuffered
+	if limit <= 0 {
+		limit = defaultAgentMaxBuffered
+	}
+	outbox, err := utils.OpenAgentOutbox(cfg.Path, limit)
+	if err != nil {
+		utils.LogWarnWithContext("agent", "offline buffer unavailable; undelivered snapshots are kept in memory only", err)
+		return nil
+	}
+	agentOutbox = outbox
+	return outbox
+}
+
+// spoolPending moves the in-memory queue to the outbox. Callers hold agentMu.
+func spoolPending(outbox *utils.AgentOutbox) {
+	if outbox == nil || len(agentPending) == 0 {
+		return
+	}
+	dropped, err := outbox.Append(agentPending)
+	if err != nil {
+		utils.LogWarnWithContext("agent", "failed to buffer snapshots on disk", err)
+		return
+	}
+	if dropped > 0 {
+		utils.LogWarn("agent: offline buffer full, dropped %d oldest snapshots", dropped)
+	}
+	agentPending = nil
+}
+
+// queueAgentPush adds a snapshot to the push queue and starts a send unless one is in flight.
+// While the collector is unreachable snapshots are spooled to <path>/agent-outbox and later
+// backfilled oldest first with their original timestamps; without a log path they stay in
+// memory (oldest dropped past agentMaxPending).
+func queueAgentPush(cfg *models.MonitoringConfig, snapshot *models.SystemMonitoring) {
+	if cfg == nil || cfg.Agent == nil || !cfg.Agent.Enabled || snapshot == nil {
+		return
+	}
+	agent := *cfg.Agent
+
+	agentMu.Lock()
+	outbox := agentOutboxFor(cfg)
+	agentPending = append(agentPending, *snapshot)
+	if agentFailing || len(agentPending) >= agentMaxPending {
+		spoolPending(outbox)
+	}
+	if over := len(agentPending) - agentMaxPending; over > 0 {
+		agentPending = agentPending[over:]
+	}
+	if agentBusy || !beginBackgroundWork() {
+		agentMu.Unlock()
+		return
+	}
+	agentBusy = true
+	agentMu.Unlock()
+
+	go func() {
+		defer endBackgroundWork()
+		defer func() {
+			if r := recover(); r != nil {
+				utils.LogErrorWithContext("agent", "push goroutine panic recovered", fmt.Errorf("%v", r))
+			}
+			agentMu.Lock()
+			agentBusy = false
+			agentMu.Unlock()
+		}()
+
+		if outbox != nil && !drainAgentOutbox(agent, outbox) {
+			return
+		}
+
+		agentMu.Lock()
+		batch := append([]models.SystemMonitoring(nil), agentPending[:min(len(agentPending), agentPushBatch)]...)
+		agentMu.Unlock()
+		if len(batch) == 0 {
+			return
+		}
+
+		err := pushToCollector(agent, batch)
+
+		agentMu.Lock()
+		defer agentMu.Unlock()
+		if err != nil {
+			agentFailed(err)
+			spoolPending(outbox)
+			return
+		}
+		agentRecovered()
+
+		// Snapshots are queued in time order; drop everything the collector has accepted
+		sentUntil := batch[len(batch)-1].Timestamp
+		keep := 0
+		for keep < len(agentPending) && !agentPending[keep].Timestamp.After(sentUntil) {
+			keep++
+		}
+		agentPending = agentPending[keep:]
+	}()
+}
+
+// drainAgentOutbox sends buffered segments oldest first, deleting each once the collector
+// accepts it. It reports whether the outbox is empty.
+func drainAgentOutbox(agent models.AgentConfig, outbox *utils.AgentOutbox) bool {
+	sent := 0
+	for {
+		// The backlog is left for the next start rather than holding up shutdown
+		if isShuttingDown() {
+			return false
+		}
+		name, batch, err := outbox.Oldest()
+		if err != nil {
+			utils.LogWarnWithContext("agent", "failed to read offline buffer", err)
+			return false
+		}
+		if name == "" {
+			if sent > 0 {
+				utils.LogInfo("agent: backfill complete, %d buffered snapshots delivered", sent)
+			}
+			return true
+		}
+
+		err = pushToCollector(agent, batch)
+		if rerr := outbox.Release(name, err == nil); rerr != nil {
+			utils.LogWarnWithContext("agent", "failed to remove delivered snapshots from offline buffer", rerr)
+		}
+
+		agentMu.Lock()
+		if err != nil {
+			agentFailed(err)
+			agentMu.Unlock()
+			return false
+		}
+		agentRecovered()
+		agentMu.Unlock()
+		sent += len(batch)
+	}
+}
+
+// spoolAgentPending writes snapshots that were queued but not delivered to the offline
+// buffer, so they are sent after the next start. Without a log path they are lost.
+func spoolAgentPending() {
+	agentMu.Lock()
+	defer agentMu.Unlock()
+	if len(agentPending) == 0 {
+		return
+	}
+	if agentOutbox == nil {
+		utils.LogWarn("agent: %d undelivered snapshots dropped on shutdown (no log path to buffer them)", len(agentPending))
+		return
+	}
+	count := len(agentPending)
+	spoolPending(agentOutbox)
+	if len(agentPending) == 0 {
+		utils.LogInfo("agent: %d undelivered snapshots buffered for the next start", count)
+	}
+}
+
+// agentFailed logs the first failed push of an outage. Callers hold agentMu.
+func agentFailed(err error) {
+	if !agentFailing {
+		utils.LogWarnWithContext("agent", "push to collector failed; snapshots are buffered until it is reachable", err)
+	}
+	agentFailing = true
+}
+
+// agentRecovered logs the end of an outage. Callers hold agentMu.
+func agentRecovered() {
+	if agentFailing {
+		utils.LogInfo("agent: collector reachable again")
+	}
+	agentFailing = false
+}
+
+// pushToCollector posts one batch to the collector's ingest endpoint.
+func pushToCollector(agent models.AgentConfig, batch []models.SystemMonitoring) error {
+	endpoint, err := ingestURL(agent)
+	if err != nil {
+		return err
+	}
+
+	timeout := defaultAgentTimeout
+	if d, err := time.ParseDuration(strings.TrimSpace(agent.Timeout)); err == nil && d > 0 {
+		timeout = d
+	}
+
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("%w: %v", utils.ErrDataMarshalFailed, err)
+	}
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + agent.Token,
+		AgentNameHeader: strings.TrimSpace(agent.Name),
+	}
+	if agent.RegistrationToken != "" {
+		headers[RegistrationTokenHeader] = agent.RegistrationToken
+	}
+
+	ctx, cancel := context.WithTimeout(backgroundContext(), timeout)
+	defer cancel()
+	_, err = utils.MakeHTTPRequestWithLimits(ctx, http.MethodPost, endpoint, bytes.NewReader(body), headers)
+	return err
+}
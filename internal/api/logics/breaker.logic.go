@@ -0,0 +1,183 @@
+package logics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"go-log/internal/api/models"
+	"go-log/internal/config"
+	"go-log/internal/utils"
+)
+
+// breakerProbeTimeout frees a half-open circuit whose probe never reported back, so a lost
+// probe cannot keep the server from being polled again.
+const breakerProbeTimeout = time.Minute
+
+// errCircuitOpen is returned instead of polling a server whose circuit is open, and wraps the
+// failure that opens it.
+var errCircuitOpen = errors.New("circuit open")
+
+// serverBreaker tracks the polls to one server. After FailureThreshold consecutive failures
+// the circuit opens and polls are skipped for a back-off that doubles every time the circuit
+// reopens, up to the maximum. When it ends, one probe is let through: success closes the
+// circuit, failure opens it again.
+type serverBreaker struct {
+	state               models.CircuitState
+	consecutiveFailures int
+	trips               int // times the circuit opened since it was last closed
+	nextRetry           time.Time
+	probeStarted        time.Time
+}
+
+var (
+	serverBreakersMu sync.Mutex
+	serverBreakers   = make(map[string]*serverBreaker) // by normalized server address
+)
+
+// allowServerPoll reports whether the server at address may be polled now. It returns an
+// error wrapping errCircuitOpen while its circuit is open or another probe is in flight.
+// Every nil result must be followed by recordServerPoll.
+func allowServerPoll(address string) error {
+	if !config.GetEnvConfig().ServerBreakerEnabled {
+		return nil
+	}
+	now := time.Now()
+
+	serverBreakersMu.Lock()
+	defer serverBreakersMu.Unlock()
+	breaker, ok := serverBreakers[address]
+	if !ok {
+		return nil
+	}
+	switch breaker.state {
+	case models.CircuitOpen:
+		if now.Before(breaker.nextRetry) {
+			return fmt.Errorf("%w after %d consecutive failures; next retry at %s", errCircuitOpen,
+				breaker.consecutiveFailures, utils.FormatTimestampUTC(breaker.nextRetry))
+		}
+		breaker.state = models.CircuitHalfOpen
+		breaker.probeStarted = now
+	case models.CircuitHalfOpen:
+		if now.Sub(breaker.probeStarted) < breakerProbeTimeout {
+			return fmt.Errorf("%w: waiting for a probe to finish", errCircuitOpen)
+		}
+		breaker.probeStarted = now
+	}
+	return nil
+}
+
+// serverCircuitError returns an error wrapping errCircuitOpen while the circuit of the server
+// at address is open. Unlike allowServerPoll it never lets a probe through, for requests
+// that do not count towards the circuit.
+func serverCircuitError(address string) error {
+	if !config.GetEnvConfig().ServerBreakerEnabled {
+		return nil
+	}
+
+	serverBreakersMu.Lock()
+	defer serverBreakersMu.Unlock()
+	breaker, ok := serverBreakers[address]
+	if !ok || breaker.state == models.CircuitClosed || !time.Now().Before(breaker.nextRetry) {
+		return nil
+	}
+	return fmt.Errorf("%w after %d consecutive failures; next retry at %s", errCircuitOpen,
+		breaker.consecutiveFailures, utils.FormatTimestampUTC(breaker.nextRetry))
+}
+
+// recordServerPoll updates the circuit of the server at address with a poll's outcome and
+// reports whether the failure opened it; the opening is logged here, so callers need not log
+// the failure again. Polls cancelled by shutdown are not held against the server.
+func recordServerPoll(server models.ServerEndpoint, address string, err error) bool {
+	envConfig := config.GetEnvConfig()
+	if !envConfig.ServerBreakerEnabled {
+		return false
+	}
+
+	serverBreakersMu.Lock()
+	defer serverBreakersMu.Unlock()
+	breaker, ok := serverBreakers[address]
+
+	if err == nil {
+		if ok && breaker.state != models.CircuitClosed {
+			utils.LogInfo("server '%s' (%s) is reachable again after %d failed polls; circuit closed",
+				server.Name, address, breaker.consecutiveFailures)
+		}
+		delete(serverBreakers, address)
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		if ok && breaker.state == models.CircuitHalfOpen {
+			breaker.state = models.CircuitOpen
+		}
+		return false
+	}
+
+	if !ok {
+		breaker = &serverBreaker{state: models.CircuitClosed}
+		serverBreakers[address] = breaker
+	}
+	breaker.consecutiveFailures++
+	if breaker.state == models.CircuitClosed && breaker.consecutiveFailures < envConfig.ServerBreakerFailureThreshold {
+		return false
+	}
+
+	breaker.trips++
+	backoff := breakerBackoff(breaker.trips, envConfig.ServerBreakerBaseBackoff, envConfig.ServerBreakerMaxBackoff)
+	breaker.state = models.CircuitOpen
+	breaker.nextRetry = time.Now().Add(backoff)
+	utils.LogWarnWithContext("server-monitoring",
+		fmt.Sprintf("Server '%s' (%s) failed %d consecutive polls; circuit open, retrying in %s",
+			server.Name, address, breaker.consecutiveFailures, backoff.Round(time.Second)), err)
+	return true
+}
+
+// breakerBackoff returns base doubled for every trip after the first, capped at limit, with
+// up to 10% jitter so servers that failed together are not retried together.
+func breakerBackoff(trips int, base, limit time.Duration) time.Duration {
+	backoff := base
+	for i := 1; i < trips && backoff < limit; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, limit)
+	return backoff + time.Duration(rand.Int64N(int64(backoff)/10+1))
+}
+
+// serverBreakerStatus fills in the circuit fields of a server's metrics.
+func serverBreakerStatus(metric *models.ServerMetrics, address string) {
+	if !config.GetEnvConfig().ServerBreakerEnabled {
+		return
+	}
+	metric.CircuitState = models.CircuitClosed
+
+	serverBreakersMu.Lock()
+	defer serverBreakersMu.Unlock()
+	breaker, ok := serverBreakers[address]
+	if !ok {
+		return
+	}
+	metric.CircuitState = breaker.state
+	metric.ConsecutiveFailures = breaker.consecutiveFailures
+	if breaker.state == models.CircuitOpen {
+		metric.NextRetry = utils.FormatTimestampUTC(breaker.nextRetry)
+	}
+}
+
+// pruneServerBreakers forgets the circuits of servers that are no longer monitored.
+func pruneServerBreakers(servers []models.ServerEndpoint) {
+	monitored := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		monitored[normalizeServerAddress(server.Address)] = true
+	}
+
+	serverBreakersMu.Lock()
+	defer serverBreakersMu.Unlock()
+	for address := range serverBreakers {
+		if !monitored[address] {
+			delete(serverBreakers, address)
+		}
+	}
+}
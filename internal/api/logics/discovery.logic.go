@@ -0,0 +1,393 @@
+package logics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-log/internal/api/models"
+	"go-log/internal/utils"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDiscoveryInterval = 30 * time.Second
+	minDiscoveryInterval     = time.Second
+	discoveryTimeout         = 10 * time.Second
+)
+
+// Supported discovery provider types.
+const (
+	DiscoveryFile = "file"
+	DiscoveryDNS  = "dns"
+	DiscoveryHTTP = "http"
+)
+
+var (
+	discoveryMu       sync.RWMutex
+	discoveredServers = map[int][]models.ServerEndpoint{} // keyed by provider index in cfg.Discovery
+	discoveryStopChan chan struct{}
+	discoveryConfig   string // fingerprint of the running providers
+)
+
+// discoveryLabel identifies a provider in logs.
+func discoveryLabel(dc models.DiscoveryConfig) string {
+	switch strings.ToLower(dc.Type) {
+	case DiscoveryFile:
+		return "file:" + dc.Path
+	case DiscoveryDNS:
+		return "dns:" + dc.Name
+	case DiscoveryHTTP:
+		return "http:" + dc.URL
+	}
+	return dc.Type
+}
+
+// startDiscovery (re)starts the discovery providers when their configuration changed.
+func startDiscovery(cfg *models.MonitoringConfig) {
+	var providers []models.DiscoveryConfig
+	if cfg != nil {
+		providers = cfg.Discovery
+	}
+	fingerprint, _ := json.Marshal(providers)
+	if isShuttingDown() {
+		return
+	}
+
+	discoveryMu.Lock()
+	defer discoveryMu.Unlock()
+	if string(fingerprint) == discoveryConfig && discoveryStopChan != nil {
+		return
+	}
+
+	if discoveryStopChan != nil {
+		close(discoveryStopChan)
+		discoveryStopChan = nil
+	}
+	for _, servers := range discoveredServers {
+		forgetServers(servers)
+	}
+	discoveredServers = map[int][]models.ServerEndpoint{}
+	discoveryConfig = string(fingerprint)
+
+	if len(providers) == 0 {
+		return
+	}
+	stop := make(chan struct{})
+	discoveryStopChan = stop
+	for idx, dc := range providers {
+		interval := defaultDiscoveryInterval
+		if d, err := time.ParseDuration(strings.TrimSpace(dc.RefreshInterval)); err == nil && d > 0 {
+			interval = max(d, minDiscoveryInterval)
+		}
+		go runDiscoveryProvider(idx, dc, interval, stop)
+	}
+}
+
+// stopDiscovery stops the discovery providers. Discovered servers are kept, so the
+// remaining shutdown work still sees them.
+func stopDiscovery() {
+	discoveryMu.Lock()
+	defer discoveryMu.Unlock()
+	if discoveryStopChan != nil {
+		close(discoveryStopChan)
+		discoveryStopChan = nil
+	}
+}
+
+func runDiscoveryProvider(idx int, dc models.DiscoveryConfig, interval time.Duration, stop chan struct{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			utils.LogErrorWithContext("discovery", "provider goroutine panic recovered", fmt.Errorf("%v", r))
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(backgroundContext(), discoveryTimeout)
+		servers, err := discover(ctx, dc)
+		cancel()
+		if err != nil {
+			// Keep the last known servers; a flapping provider should not drop them
+			utils.LogWarnWithContext("discovery", fmt.Sprintf("%s failed", discoveryLabel(dc)), err)
+		} else {
+			applyDiscoveredServers(idx, dc, servers, stop)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+func discover(ctx context.Context, dc models.DiscoveryConfig) ([]models.ServerEndpoint, error) {
+	switch strings.ToLower(strings.TrimSpace(dc.Type)) {
+	case DiscoveryFile:
+		return discoverFromFiles(dc.Path)
+	case DiscoveryDNS:
+		return discoverFromDNS(ctx, dc.Name, dc.Scheme)
+	case DiscoveryHTTP:
+		return discoverFromHTTP(ctx, dc.URL, dc.Token)
+	}
+	return nil, fmt.Errorf("%w: unknown discovery type %q", utils.ErrInvalidConfig, dc.Type)
+}
+
+// decodeServerList accepts a JSON/YAML array of servers or an object with a "servers" array.
+func decodeServerList(data []byte, yaml bool) ([]models.ServerEndpoint, error) {
+	decode := json.Unmarshal
+	if yaml {
+		decode = utils.DecodeYAML
+	}
+	var servers []models.ServerEndpoint
+	if err := decode(data, &servers); err == nil {
+		return servers, nil
+	}
+	var wrapper struct {
+		Servers []models.ServerEndpoint `json:"servers"`
+	}
+	if err := decode(data, &wrapper); err != nil {
+		return nil, err
+	}
+	return wrapper.Servers, nil
+}
+
+// discoverFromFiles reads every *.json, *.yaml and *.yml file in dir. A file that fails to
+// parse fails the whole round so its servers are not dropped while it is being edited.
+func discoverFromFiles(dir string) ([]models.ServerEndpoint, error) {
+	if utils.IsEmptyOrWhitespace(dir) {
+		return nil, fmt.Errorf("%w: file discovery requires a path", utils.ErrInvalidConfig)
+	}
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read discovery directory: %w", err)
+	}
+
+	var servers []models.ServerEndpoint
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+		found, err := decodeServerList(data, ext != ".json")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", entry.Name(), err)
+		}
+		servers = append(servers, found...)
+	}
+	return servers, nil
+}
+
+// discoverFromDNS resolves an SRV record; every target becomes a server named after its host.
+func discoverFromDNS(ctx context.Context, record, scheme string) ([]models.ServerEndpoint, error) {
+	if utils.IsEmptyOrWhitespace(record) {
+		return nil, fmt.Errorf("%w: dns discovery requires a name", utils.ErrInvalidConfig)
+	}
+	scheme = strings.ToLower(strings.TrimSpace(scheme))
+	if scheme == "" {
+		scheme = "http"
+	}
+	if scheme != "http" && scheme != "https" {
+		return nil, fmt.Errorf("%w: dns discovery scheme must be http or https", utils.ErrInvalidConfig)
+	}
+
+	_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", strings.TrimSpace(record))
+	if err != nil {
+		return nil, fmt.Errorf("SRV lookup failed: %w", err)
+	}
+
+	hosts := make(map[string]int, len(records))
+	for _, srv := range records {
+		hosts[strings.TrimSuffix(srv.Target, ".")]++
+	}
+	servers := make([]models.ServerEndpoint, 0, len(records))
+	for _, srv := range records {
+		host := strings.TrimSuffix(srv.Target, ".")
+		port := strconv.Itoa(int(srv.Port))
+		name := host
+		if hosts[host] > 1 {
+			name = host + ":" + port
+		}
+		servers = append(servers, models.ServerEndpoint{
+			Name:    name,
+			Address: scheme + "://" + net.JoinHostPort(host, port),
+		})
+	}
+	return servers, nil
+}
+
+// discoverFromHTTP fetches a JSON server list from a discovery endpoint.
+func discoverFromHTTP(ctx context.Context, endpoint, token string) ([]models.ServerEndpoint, error) {
+	if utils.IsEmptyOrWhitespace(endpoint) {
+		return nil, fmt.Errorf("%w: http discovery requires a url", utils.ErrInvalidConfig)
+	}
+	headers := map[string]string{"Accept": "application/json"}
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	body, err := utils.MakeHTTPRequestWithLimits(ctx, http.MethodGet, strings.TrimSpace(endpoint), nil, headers)
+	if err != nil {
+		return nil, err
+	}
+	servers, err := decodeServerList(body, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid discovery response: %w", err)
+	}
+	return servers, nil
+}
+
+// applyDiscoveredServers replaces a provider's servers, preparing tables for new ones and
+// dropping cached metrics of removed ones.
+func applyDiscoveredServers(idx int, dc models.DiscoveryConfig, found []models.ServerEndpoint, stop chan struct{}) {
+	cfg := GetMonitoringConfig()
+	servers := make([]models.ServerEndpoint, 0, len(found))
+	seen := make(map[string]struct{}, len(found)+len(cfg.Servers))
+	for _, srv := range cfg.Servers {
+		seen[normalizeServerAddress(srv.Address)] = struct{}{}
+	}
+	for _, srv := range found {
+		srv.Name = strings.TrimSpace(srv.Name)
+		srv.Address = normalizeServerAddress(srv.Address)
+		if srv.Address == "" {
+			continue
+		}
+		if srv.Name == "" {
+			srv.Name = srv.Address
+		}
+		if _, dup := seen[srv.Address]; dup {
+			continue
+		}
+		seen[srv.Address] = struct{}{}
+		if utils.IsEmptyOrWhitespace(srv.TableName) {
+			srv.TableName = srv.Name
+		}
+		srv.TableName = utils.SanitizeTableName(srv.TableName)
+		if srv.Auth == nil && srv.Token == "" && dc.ServerAuth != nil {
+			auth := *dc.ServerAuth
+			srv.Auth = &auth
+		}
+		servers = append(servers, srv)
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Address < servers[j].Address })
+
+	discoveryMu.Lock()
+	if stop != discoveryStopChan {
+		// Providers were restarted while this round was running
+		discoveryMu.Unlock()
+		return
+	}
+	previous := discoveredServers[idx]
+	discoveredServers[idx] = servers
+	discoveryMu.Unlock()
+
+	before := make(map[string]struct{}, len(previous))
+	for _, srv := range previous {
+		before[srv.Address] = struct{}{}
+	}
+	var added []models.ServerEndpoint
+	for _, srv := range servers {
+		if _, ok := before[srv.Address]; !ok {
+			added = append(added, srv)
+		}
+		delete(before, srv.Address)
+	}
+	var removed []models.ServerEndpoint
+	for _, srv := range previous {
+		if _, ok := before[srv.Address]; ok {
+			removed = append(removed, srv)
+		}
+	}
+
+	if len(added) > 0 {
+		ensureServerTables(&models.MonitoringConfig{Storage: cfg.Storage, Servers: added})
+	}
+	forgetServers(removed)
+	for _, srv := range added {
+		utils.LogInfo("discovery: %s added server %s (%s)", discoveryLabel(dc), srv.Name, srv.Address)
+	}
+	for _, srv := range removed {
+		utils.LogInfo("discovery: %s removed server %s (%s)", discoveryLabel(dc), srv.Name, srv.Address)
+	}
+}
+
+// forgetServers drops cached metrics so removed servers vanish from the dashboard.
+func forgetServers(servers []models.ServerEndpoint) {
+	if len(servers) == 0 {
+		return
+	}
+	serverMetricsCacheMu.Lock()
+	for _, srv := range servers {
+		delete(serverMetricsCache, normalizeServerAddress(srv.Address))
+	}
+	serverMetricsCacheMu.Unlock()
+}
+
+// MonitoredServers returns the configured servers followed by discovered ones. Discovered
+// servers never override a configured server with the same address or table.
+func MonitoredServers(cfg *models.MonitoringConfig) []models.ServerEndpoint {
+	if cfg == nil {
+		return nil
+	}
+
+	discoveryMu.RLock()
+	defer discoveryMu.RUnlock()
+	if len(discoveredServers) == 0 {
+		return cfg.Servers
+	}
+
+	servers := append([]models.ServerEndpoint(nil), cfg.Servers...)
+	addresses := make(map[string]struct{}, len(servers))
+	tables := make(map[string]struct{}, len(servers))
+	for _, srv := range servers {
+		addresses[normalizeServerAddress(srv.Address)] = struct{}{}
+		tables[utils.SanitizeTableName(srv.TableName)] = struct{}{}
+	}
+
+	providers := make([]int, 0, len(discoveredServers))
+	for idx := range discoveredServers {
+		providers = append(providers, idx)
+	}
+	sort.Ints(providers)
+	for _, idx := range providers {
+		for _, srv := range discoveredServers[idx] {
+			if _, ok := addresses[srv.Address]; ok {
+				continue
+			}
+			if _, ok := tables[srv.TableName]; ok {
+				continue
+			}
+			addresses[srv.Address] = struct{}{}
+			tables[srv.TableName] = struct{}{}
+			servers = append(servers, srv)
+		}
+	}
+	return servers
+}
+
+// PublicServers returns MonitoredServers without their tokens, for API responses.
+func PublicServers(cfg *models.MonitoringConfig) []models.ServerEndpoint {
+	monitored := MonitoredServers(cfg)
+	if monitored == nil {
+		return nil
+	}
+	servers := make([]models.ServerEndpoint, len(monitored))
+	copy(servers, monitored)
+	for i := range servers {
+		servers[i].Token = ""
+		servers[i].Auth = nil
+	}
+	return servers
+}
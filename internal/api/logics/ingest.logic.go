@@ -0,0 +1,355 @@
+package logics
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-log/internal/api/models"
+	"go-log/internal/utils"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// agentAddressScheme prefixes the pseudo address of pushing agents. It keys the server
+// metrics cache and is what the dashboard shows instead of a reachable URL.
+const agentAddressScheme = "agent://"
+
+// maxIngestClockSkew is how far in the future a pushed timestamp may be before it is
+// replaced with the ingest time.
+const maxIngestClockSkew = 5 * time.Minute
+
+// registeredAgent is an agent accepted through auto-registration. Only a digest of its
+// token is kept; the first token an agent registers with is the one it must keep using.
+type registeredAgent struct {
+	endpoint     models.ServerEndpoint
+	tokenDigest  [sha256.Size]byte
+	registeredAt time.Time
+}
+
+var (
+	registeredAgents   = map[string]registeredAgent{}
+	registeredAgentsMu sync.RWMutex
+	// registeredAgentsPath is the registry file registeredAgents was loaded from; empty
+	// until the first successful load
+	registeredAgentsPath string
+)
+
+// IngestResult describes what was stored for one push.
+type IngestResult struct {
+	Agent      string `json:"agent"`
+	Table      string `json:"table"`
+	Accepted   int    `json:"accepted"`
+	Registered bool   `json:"registered,omitempty"`
+}
+
+func tokenDigest(token string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(token))
+}
+
+// tokensEqual compares secrets in constant time regardless of their length.
+func tokensEqual(a, b string) bool {
+	da, db := tokenDigest(a), tokenDigest(b)
+	return subtle.ConstantTimeCompare(da[:], db[:]) == 1
+}
+
+func agentKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// agentEndpoint builds the server entry an agent's snapshots are persisted under.
+func agentEndpoint(name, table string) models.ServerEndpoint {
+	if utils.IsEmptyOrWhitespace(table) {
+		table = name
+	}
+	return models.ServerEndpoint{
+		Name:      name,
+		Address:   agentAddressScheme + agentKey(name),
+		TableName: utils.SanitizeTableName(table),
+	}
+}
+
+// loadRegisteredAgentsLocked reads the agent registry the first time it is needed, and again
+// when AGENT_REGISTRY_PATH changes. registeredAgentsMu must be held for writing.
+func loadRegisteredAgentsLocked() error {
+	path := utils.AgentRegistryPath()
+	if registeredAgentsPath == path {
+		return nil
+	}
+	list, err := utils.LoadRegisteredAgents(path)
+	if err != nil {
+		return err
+	}
+	agents := make(map[string]registeredAgent, len(list))
+	for _, a := range list {
+		digest, err := hex.DecodeString(a.TokenSHA256)
+		if err != nil || len(digest) != sha256.Size {
+			return fmt.Errorf("%w: agent registry %s: invalid token digest for %q", utils.ErrInvalidConfig, path, a.Name)
+		}
+		entry := registeredAgent{endpoint: agentEndpoint(a.Name, a.TableName), registeredAt: a.RegisteredAt}
+		copy(entry.tokenDigest[:], digest)
+		agents[agentKey(a.Name)] = entry
+	}
+	registeredAgents, registeredAgentsPath = agents, path
+	return nil
+}
+
+// saveRegisteredAgentsLocked writes registeredAgents to the registry file.
+// registeredAgentsMu must be held for writing.
+func saveRegisteredAgentsLocked() error {
+	list := make([]utils.RegisteredAgent, 0, len(registeredAgents))
+	for _, a := range registeredAgents {
+		list = append(list, utils.RegisteredAgent{
+			Name:         a.endpoint.Name,
+			TableName:    a.endpoint.TableName,
+			TokenSHA256:  hex.EncodeToString(a.tokenDigest[:]),
+			RegisteredAt: a.registeredAt,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return utils.SaveRegisteredAgents(registeredAgentsPath, list)
+}
+
+// tableOnBackend reports whether an enabled storage backend already holds table, e.g. the
+// data of a server or agent that was removed from the configuration.
+func tableOnBackend(cfg *models.MonitoringConfig, table string) (bool, error) {
+	for _, backend := range utils.EnabledStorageBackends(cfg.Storage) {
+		tables, err := backend.ListTables()
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", backend.Name(), err)
+		}
+		for _, existing := range tables {
+			if existing == table {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// tableInUse reports whether a table already belongs to a pulled server or another agent.
+func tableInUse(cfg *models.MonitoringConfig, table, agent string) bool {
+	if table == "" || table == "default" {
+		return true
+	}
+	for _, srv := range MonitoredServers(cfg) {
+		if utils.SanitizeTableName(srv.TableName) == table {
+			return true
+		}
+	}
+	for _, a := range cfg.Ingest.Agents {
+		if agentKey(a.Name) != agent && agentEndpoint(a.Name, a.TableName).TableName == table {
+			return true
+		}
+	}
+	for key, a := range registeredAgents {
+		if key != agent && a.endpoint.TableName == table {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticateAgent resolves the pushing agent, auto-registering unknown agents when the
+// ingest policy allows it.
+func authenticateAgent(cfg *models.MonitoringConfig, name, token, registration string) (models.ServerEndpoint, bool, error) {
+	key := agentKey(name)
+	if key == "" || token == "" {
+		return models.ServerEndpoint{}, false, utils.ErrMissingToken
+	}
+
+	for _, a := range cfg.Ingest.Agents {
+		if agentKey(a.Name) != key {
+			continue
+		}
+		if !tokensEqual(token, a.Token) {
+			return models.ServerEndpoint{}, false, utils.ErrInvalidCredentials
+		}
+		return agentEndpoint(a.Name, a.TableName), false, nil
+	}
+
+	registeredAgentsMu.Lock()
+	defer registeredAgentsMu.Unlock()
+	// Without the registry a registered name could be claimed again with a new token
+	if err := loadRegisteredAgentsLocked(); err != nil {
+		utils.LogWarnWithContext("ingest", "failed to load agent registry", err)
+		return models.ServerEndpoint{}, false, fmt.Errorf("%w: %v", utils.ErrStorageUnavailable, err)
+	}
+
+	if a, ok := registeredAgents[key]; ok {
+		digest := tokenDigest(token)
+		if subtle.ConstantTimeCompare(digest[:], a.tokenDigest[:]) != 1 {
+			return models.ServerEndpoint{}, false, utils.ErrInvalidCredentials
+		}
+		return a.endpoint, false, nil
+	}
+
+	if !cfg.Ingest.AutoRegister || cfg.Ingest.RegistrationToken == "" {
+		return models.ServerEndpoint{}, false, utils.ErrUnknownAgent
+	}
+	if registration == "" || !tokensEqual(registration, cfg.Ingest.RegistrationToken) {
+		return models.ServerEndpoint{}, false, utils.ErrInvalidCredentials
+	}
+
+	endpoint := agentEndpoint(strings.TrimSpace(name), "")
+	if tableInUse(cfg, endpoint.TableName, key) {
+		return models.ServerEndpoint{}, false, fmt.Errorf("%w: table %q is already in use", utils.ErrValidationFailed, endpoint.TableName)
+	}
+	// A table left behind by a removed server or agent is not handed to a newcomer
+	exists, err := tableOnBackend(cfg, endpoint.TableName)
+	if err != nil {
+		return models.ServerEndpoint{}, false, fmt.Errorf("%w: %v", utils.ErrStorageUnavailable, err)
+	}
+	if exists {
+		return models.ServerEndpoint{}, false, fmt.Errorf("%w: table %q already exists; list the agent under ingest.agents to reuse it", utils.ErrValidationFailed, endpoint.TableName)
+	}
+	registeredAgents[key] = registeredAgent{endpoint: endpoint, tokenDigest: tokenDigest(token), registeredAt: utils.NowUTC()}
+	if err := saveRegisteredAgentsLocked(); err != nil {
+		delete(registeredAgents, key)
+		utils.LogWarnWithContext("ingest", "failed to save agent registry", err)
+		return models.ServerEndpoint{}, false, fmt.Errorf("%w: %v", utils.ErrStorageUnavailable, err)
+	}
+	utils.LogInfo("auto-registered agent %s (table %s)", endpoint.Name, endpoint.TableName)
+	return endpoint, true, nil
+}
+
+// IngestAgentSnapshots authenticates a pushing agent and persists its snapshots to the agent's
+// server table on every enabled backend under their original timestamps, so backfilled
+// snapshots land where they were taken rather than when they arrived.
+func IngestAgentSnapshots(name, token, registration string, snapshots []models.SystemMonitoring) (*IngestResult, error) {
+	cfg := GetMonitoringConfig()
+	if cfg == nil || cfg.Ingest == nil || !cfg.Ingest.Enabled {
+		return nil, utils.ErrIngestDisabled
+	}
+
+	srv, registered, err := authenticateAgent(cfg, name, token, registration)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("%w: no snapshots in request", utils.ErrValidationFailed)
+	}
+
+	backends := utils.EnabledStorageBackends(cfg.Storage)
+	if registered {
+		for _, backend := range backends {
+			if err := backend.PrepareTable(srv.TableName); err != nil {
+				utils.LogWarnWithContext("ingest", fmt.Sprintf("failed to prepare %s table for %s", backend.Name(), srv.TableName), err)
+			}
+		}
+	}
+
+	// Backfilled snapshots keep their original timestamps; only missing or implausibly
+	// future ones (agent clock far ahead) are stamped with the ingest time.
+	now := utils.NowUTC()
+	for i := range snapshots {
+		if snapshots[i].Timestamp.IsZero() || snapshots[i].Timestamp.After(now.Add(maxIngestClockSkew)) {
+			snapshots[i].Timestamp = now
+		}
+		snapshots[i].Timestamp = snapshots[i].Timestamp.UTC()
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].Timestamp.Before(snapshots[j].Timestamp) })
+	latest := snapshots[len(snapshots)-1:]
+
+	persisted := 0
+	var lastErr error
+	for _, backend := range backends {
+		if err := utils.WriteSnapshots(backend, srv.TableName, snapshots); err != nil {
+			utils.LogWarnWithContext("ingest", fmt.Sprintf("failed to write %s snapshots to %s", srv.Name, backend.Name()), err)
+			lastErr = err
+			continue
+		}
+		persisted++
+	}
+	// Refusing the batch keeps it in the agent's offline buffer for a later retry
+	if len(backends) > 0 && persisted == 0 {
+		return nil, fmt.Errorf("%w: %v", utils.ErrStorageUnavailable, lastErr)
+	}
+
+	// A backfill of old snapshots must not make an agent look live on the dashboard
+	if now.Sub(latest[0].Timestamp) <= 2*defaultRefreshDuration(cfg.RefreshTime) {
+		payload, err := json.Marshal(latest)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", utils.ErrDataMarshalFailed, err)
+		}
+		// Trim payload to only fields used by the dashboard
+		if trimmed, terr := utils.FilterMonitoringPayload(payload); terr == nil && len(trimmed) > 0 {
+			payload = trimmed
+		}
+		if _, err := updateServerMetricsCache(srv, payload); err != nil {
+			utils.LogWarnWithContext("ingest", fmt.Sprintf("failed to parse metrics pushed by %s", srv.Name), err)
+		}
+	}
+
+	return &IngestResult{Agent: srv.Name, Table: srv.TableName, Accepted: len(snapshots), Registered: registered}, nil
+}
+
+// HasAgentTokens reports whether ingest is on with at least one agent token or a
+// registration token, so that some credential exists on this node.
+func HasAgentTokens() bool {
+	cfg := GetMonitoringConfig()
+	if cfg == nil || cfg.Ingest == nil || !cfg.Ingest.Enabled {
+		return false
+	}
+	return len(cfg.Ingest.Agents) > 0 || cfg.Ingest.RegistrationToken != ""
+}
+
+// knownAgents lists configured and auto-registered agents, sorted by name.
+func knownAgents(cfg *models.MonitoringConfig) []models.ServerEndpoint {
+	if cfg == nil || cfg.Ingest == nil || !cfg.Ingest.Enabled {
+		return nil
+	}
+	agents := make([]models.ServerEndpoint, 0, len(cfg.Ingest.Agents))
+	seen := make(map[string]struct{}, len(cfg.Ingest.Agents))
+	for _, a := range cfg.Ingest.Agents {
+		if agentKey(a.Name) == "" {
+			continue
+		}
+		seen[agentKey(a.Name)] = struct{}{}
+		agents = append(agents, agentEndpoint(a.Name, a.TableName))
+	}
+
+	registeredAgentsMu.Lock()
+	if err := loadRegisteredAgentsLocked(); err != nil {
+		utils.LogWarnWithContext("ingest", "failed to load agent registry", err)
+	}
+	for key, a := range registeredAgents {
+		if _, ok := seen[key]; !ok {
+			agents = append(agents, a.endpoint)
+		}
+	}
+	registeredAgentsMu.Unlock()
+
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Name < agents[j].Name })
+	return agents
+}
+
+// agentServerMetrics reports the last snapshot pushed by every known agent. Agents that have
+// not pushed within two refresh intervals are stale; agents that never pushed are offline.
+func agentServerMetrics(cfg *models.MonitoringConfig, refresh time.Duration) []models.ServerMetrics {
+	agents := knownAgents(cfg)
+	metrics := make([]models.ServerMetrics, 0, len(agents))
+	for _, srv := range agents {
+		cached, ok := getCachedServerMetric(srv.Address)
+		if !ok {
+			metrics = append(metrics, models.ServerMetrics{
+				Name:      srv.Name,
+				Address:   srv.Address,
+				Status:    "offline",
+				Message:   "no snapshot pushed yet",
+				Timestamp: utils.FormatTimestampUTC(utils.NowUTC()),
+			})
+			continue
+		}
+		metric := cached.metric
+		if isCacheStale(cached, refresh) {
+			metric.Status = "stale"
+			metric.Message = fmt.Sprintf("no snapshot pushed since %s", utils.FormatTimestampUTC(cached.fetchedAt))
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics
+}
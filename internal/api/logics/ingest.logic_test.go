@@ -0,0 +1,89 @@
+package logics
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-log/internal/api/models"
+	"go-log/internal/config"
+	"go-log/internal/utils"
+)
+
+// useTestIngest points the agent registry and the file backend at a temporary directory
+// and returns a config that accepts auto-registration.
+func useTestIngest(t *testing.T) *models.MonitoringConfig {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("AGENT_REGISTRY_PATH", filepath.Join(dir, "agents.json"))
+	config.InitEnvConfig()
+	t.Cleanup(config.InitEnvConfig)
+
+	cfg := &models.MonitoringConfig{
+		Path:    dir,
+		Storage: []string{"file"},
+		Ingest:  &models.IngestConfig{Enabled: true, AutoRegister: true, RegistrationToken: "enrol"},
+	}
+	utils.InitLogger(cfg)
+	t.Cleanup(func() { utils.InitLogger(nil) })
+	forgetRegisteredAgents()
+	t.Cleanup(forgetRegisteredAgents)
+	return cfg
+}
+
+// forgetRegisteredAgents drops the in-memory registrations, as a restart does.
+func forgetRegisteredAgents() {
+	registeredAgentsMu.Lock()
+	registeredAgents, registeredAgentsPath = map[string]registeredAgent{}, ""
+	registeredAgentsMu.Unlock()
+}
+
+func TestAutoRegisteredAgentSurvivesRestart(t *testing.T) {
+	cfg := useTestIngest(t)
+
+	srv, registered, err := authenticateAgent(cfg, "Edge-1", "first-token", "enrol")
+	if err != nil || !registered {
+		t.Fatalf("registration: registered=%v err=%v", registered, err)
+	}
+	// The agent's table now exists on the file backend
+	if err := os.MkdirAll(filepath.Join(cfg.Path, "servers", srv.TableName), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	forgetRegisteredAgents()
+
+	tests := []struct {
+		name, agent, token string
+		wantErr            error
+	}{
+		{"same token", "edge-1", "first-token", nil},
+		{"other token", "edge-1", "stolen-token", utils.ErrInvalidCredentials},
+		{"other spelling", " EDGE-1 ", "stolen-token", utils.ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		got, registered, err := authenticateAgent(cfg, tt.agent, tt.token, "enrol")
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (registered || got.TableName != srv.TableName) {
+			t.Errorf("%s: registered=%v table=%s, want the existing %s", tt.name, registered, got.TableName, srv.TableName)
+		}
+	}
+}
+
+func TestAutoRegisterRejectsExistingTable(t *testing.T) {
+	cfg := useTestIngest(t)
+	// Data left behind by a server that was removed from the configuration
+	if err := os.MkdirAll(filepath.Join(cfg.Path, "servers", "old_db"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := authenticateAgent(cfg, "old_db", "token", "enrol"); !errors.Is(err, utils.ErrValidationFailed) {
+		t.Fatalf("error %v, want %v", err, utils.ErrValidationFailed)
+	}
+	if _, registered, err := authenticateAgent(cfg, "new_db", "token", "enrol"); err != nil || !registered {
+		t.Fatalf("fresh name: registered=%v err=%v", registered, err)
+	}
+}
@@ -31,7 +31,7 @@ var (
 	monitoringConfig     *models.MonitoringConfig
 	monitoringConfigOnce sync.Once
 	monitoringConfigMu   sync.RWMutex
-	lastConfigModTime    time.Time
+	configSources        map[string]time.Time // files of the loaded configuration and their modification times
 	loggingTicker        *time.Ticker
 	loggingStopChan      chan struct{}
 	loggingMu            sync.Mutex
@@ -44,55 +44,32 @@ var (
 
 type cachedServerMetric struct {
 	metric    models.ServerMetrics
-	fetchedAt time.Time
+	fetchedAt time.Time // last successful poll or push
+	polledAt  time.Time // last poll, successful or not
+	err       error     // why the last poll failed; nil after a success
 }
 
-// InitMonitoringConfig loads the monitoring configuration once at startup
+// InitMonitoringConfig loads the monitoring configuration once at startup and starts
+// watching configs.json for changes (see reload.logic.go).
 func InitMonitoringConfig() {
 	monitoringConfigOnce.Do(func() {
-		newConfig, err := readConfigFromFile()
-
-		monitoringConfigMu.Lock()
-		defer monitoringConfigMu.Unlock()
-
-		if err != nil {
-			// Use default config on error
+		result := loadMonitoringConfig(ReloadTriggerStartup)
+		if !result.Success {
+			monitoringConfigMu.Lock()
 			if monitoringConfig == nil {
+				// Use default config on error
 				monitoringConfig = getDefaultConfig()
 			}
-		} else {
-			// Stop existing logging if refresh time changed
-			if monitoringConfig != nil && monitoringConfig.RefreshTime != newConfig.RefreshTime {
-				stopAutoLogging()
-			}
-
-			monitoringConfig = newConfig
-
-			// Initialize logger and database for API server mode
-			utils.InitLogger(monitoringConfig)
-
-            if utils.HasStorage(monitoringConfig.Storage, "sqlite") {
-                if err := utils.InitDatabase(); err != nil {
-                    log.Printf("Failed to initialize SQLite database: %v", err)
-                }
-            }
-            if utils.HasStorage(monitoringConfig.Storage, "postgres") {
-                if err := utils.InitPostgres(); err != nil {
-                    log.Printf("Failed to initialize PostgreSQL: %v", err)
-                }
-            }
-				// Pre-create per-server tables
-				ensureServerTables(monitoringConfig)
-				startAutoLogging()
+			monitoringConfigMu.Unlock()
 		}
-		lastConfigModTime = utils.NowUTC()
+		startConfigWatcher()
 	})
 }
 
 // InitMonitoringConfigCLI loads configuration for CLI mode without auto-logging
 func InitMonitoringConfigCLI() {
 	monitoringConfigOnce.Do(func() {
-		newConfig, err := readConfigFromFile()
+		newConfig, _, err := readConfigFromFile()
 
 		monitoringConfigMu.Lock()
 		defer monitoringConfigMu.Unlock()
@@ -106,10 +83,23 @@ func InitMonitoringConfigCLI() {
 			monitoringConfig = newConfig
 			// CLI mode: NO auto-logging, NO database initialization
 		}
-		lastConfigModTime = utils.NowUTC()
 	})
 }
 
+// PublicHeartbeats returns the heartbeat checks without their request headers, which may
+// carry credentials, for API responses.
+func PublicHeartbeats(cfg *models.MonitoringConfig) []models.ServerConfig {
+	if cfg.Heartbeat == nil {
+		return nil
+	}
+	heartbeats := make([]models.ServerConfig, len(cfg.Heartbeat))
+	copy(heartbeats, cfg.Heartbeat)
+	for i := range heartbeats {
+		heartbeats[i].Headers = nil
+	}
+	return heartbeats
+}
+
 // GetHeartbeatConfig returns the cached heartbeat configuration
 func GetHeartbeatConfig() []models.ServerConfig {
 	ensureConfigLoaded()
@@ -134,60 +124,10 @@ func GetMonitoringConfig() *models.MonitoringConfig {
 	return getDefaultConfig()
 }
 
-// ensureConfigLoaded checks if config needs reloading and handles it
+// ensureConfigLoaded makes sure the configuration has been loaded; changes are picked up
+// by the config watcher.
 func ensureConfigLoaded() {
 	InitMonitoringConfig()
-
-	// Check if we should reload (every 30 seconds max)
-	monitoringConfigMu.RLock()
-	shouldCheck := time.Since(lastConfigModTime) > 30*time.Second
-	monitoringConfigMu.RUnlock()
-
-	if shouldCheck {
-		// Check if config file was modified and reload if needed
-		configPath := getConfigPath()
-		if configPath != "" {
-			if fileInfo, err := os.Stat(configPath); err == nil {
-				monitoringConfigMu.RLock()
-				lastCheck := lastConfigModTime
-				monitoringConfigMu.RUnlock()
-
-				if fileInfo.ModTime().After(lastCheck) {
-					// Reload config with auto-logging enabled (for API server)
-					newConfig, err := readConfigFromFile()
-
-					monitoringConfigMu.Lock()
-					if err == nil {
-						if monitoringConfig != nil && monitoringConfig.RefreshTime != newConfig.RefreshTime {
-							stopAutoLogging()
-						}
-						monitoringConfig = newConfig
-						utils.InitLogger(monitoringConfig)
-
-                        if utils.HasStorage(monitoringConfig.Storage, "sqlite") {
-                            if err := utils.InitDatabase(); err != nil {
-                                log.Printf("Failed to initialize database: %v", err)
-                            }
-                        }
-                        if utils.HasStorage(monitoringConfig.Storage, "postgres") {
-                            if err := utils.InitPostgres(); err != nil {
-                                log.Printf("Failed to initialize PostgreSQL: %v", err)
-                            }
-                        }
-						// Pre-create per-server tables
-						ensureServerTables(monitoringConfig)
-						startAutoLogging()
-					}
-					lastConfigModTime = utils.NowUTC()
-					monitoringConfigMu.Unlock()
-				} else {
-					monitoringConfigMu.Lock()
-					lastConfigModTime = utils.NowUTC()
-					monitoringConfigMu.Unlock()
-				}
-			}
-		}
-	}
 }
 
 // getDefaultLogPath returns the default log path from environment
@@ -218,26 +158,13 @@ func ensureServerTables(cfg *models.MonitoringConfig) {
         return
     }
 
-    // SQLite
-    if utils.HasStorage(cfg.Storage, "sqlite") && utils.IsDatabaseInitialized() {
+    for _, backend := range utils.EnabledStorageBackends(cfg.Storage) {
         for _, srv := range cfg.Servers {
             if utils.IsEmptyOrWhitespace(srv.TableName) {
                 continue
             }
-            if err := utils.PrepareSQLiteServerTable(srv.TableName); err != nil {
-                utils.LogWarnWithContext("init", fmt.Sprintf("failed to prepare sqlite table for %s", srv.TableName), err)
-            }
-        }
-    }
-
-    // PostgreSQL
-    if utils.HasStorage(cfg.Storage, "postgres") && utils.IsPostgresInitialized() {
-        for _, srv := range cfg.Servers {
-            if utils.IsEmptyOrWhitespace(srv.TableName) {
-                continue
-            }
-            if err := utils.PreparePostgresServerTable(srv.TableName); err != nil {
-                utils.LogWarnWithContext("init", fmt.Sprintf("failed to prepare postgres table for %s", srv.TableName), err)
+            if err := backend.PrepareTable(srv.TableName); err != nil {
+                utils.LogWarnWithContext("init", fmt.Sprintf("failed to prepare %s table for %s", backend.Name(), srv.TableName), err)
             }
         }
     }
@@ -328,16 +255,28 @@ func MonitoringDataGenerator() (*models.SystemMonitoring, error) {
 }
 
 func collectServerMetrics(cfg *models.MonitoringConfig) []models.ServerMetrics {
-	if cfg == nil || len(cfg.Servers) == 0 {
+	if cfg == nil {
 		return nil
 	}
 
 	refreshDuration := defaultRefreshDuration(cfg.RefreshTime)
 
-	results := make([]models.ServerMetrics, len(cfg.Servers))
+	// Pushing agents are served from the cache filled by ingest; they cannot be polled
+	agentMetrics := agentServerMetrics(cfg, refreshDuration)
+	servers := MonitoredServers(cfg)
+	if len(servers) == 0 {
+		if len(agentMetrics) == 0 {
+			return nil
+		}
+		return agentMetrics
+	}
+
+	pruneServerBreakers(servers)
+
+	results := make([]models.ServerMetrics, len(servers))
 	var wg sync.WaitGroup
 
-	for idx, server := range cfg.Servers {
+	for idx, server := range servers {
 		wg.Add(1)
 		go func(i int, srv models.ServerEndpoint) {
 			defer wg.Done()
@@ -373,7 +312,7 @@ func collectServerMetrics(cfg *models.MonitoringConfig) []models.ServerMetrics {
 		filtered = append(filtered, metric)
 	}
 
-	return filtered
+	return append(filtered, agentMetrics...)
 }
 
 func buildServerMetricSnapshot(server models.ServerEndpoint, refresh time.Duration) models.ServerMetrics {
@@ -401,23 +340,28 @@ func buildServerMetricSnapshot(server models.ServerEndpoint, refresh time.Durati
 		if existing.Status == "" {
 			existing.Status = "ok"
 		}
+		serverBreakerStatus(&existing, normalized)
 		return existing
 	}
 
-	fetched, err := fetchAndCacheServerMetric(server)
+	// A poll that failed during this interval is reported as is rather than repeated; the
+	// poller already logged it
+	var polled *serverPollResult
+	var err error
+	if cached, ok := getCachedServerMetric(normalized); ok && cached.err != nil && time.Since(cached.polledAt) < refresh {
+		err = cached.err
+	} else {
+		polled, err = pollServer(server)
+	}
 	if err != nil {
 		metric.Status = "error"
 		metric.Message = err.Error()
 		metric.Timestamp = utils.FormatTimestampUTC(utils.NowUTC())
-
-		// Log the server connection failure for monitoring purposes
-		utils.LogWarnWithContext("server-monitoring",
-			fmt.Sprintf("Server '%s' (%s) is unavailable", server.Name, server.Address), err)
-
+		serverBreakerStatus(&metric, normalized)
 		return metric
 	}
 
-	result := *fetched
+	result := *polled.metric
 	if result.Name == "" {
 		result.Name = metric.Name
 	}
@@ -427,6 +371,7 @@ func buildServerMetricSnapshot(server models.ServerEndpoint, refresh time.Durati
 	if result.Status == "" {
 		result.Status = "ok"
 	}
+	serverBreakerStatus(&result, normalized)
 
 	return result
 }
@@ -456,21 +401,6 @@ func isCacheStale(entry cachedServerMetric, refresh time.Duration) bool {
 	return time.Since(entry.fetchedAt) > staleness
 }
 
-func fetchAndCacheServerMetric(server models.ServerEndpoint) (*models.ServerMetrics, error) {
-	normalized := normalizeServerAddress(server.Address)
-	if normalized == "" {
-		return nil, fmt.Errorf("server address is empty")
-	}
-
-	// Use the shared HTTP client for resource efficiency
-	payload, err := fetchServerMonitoring(normalized)
-	if err != nil {
-		return nil, err
-	}
-
-	return updateServerMetricsCache(server, payload)
-}
-
 func updateServerMetricsCache(server models.ServerEndpoint, payload []byte) (*models.ServerMetrics, error) {
 	metric, err := processServerMetricsPayload(server, payload)
 	if err != nil {
@@ -491,10 +421,12 @@ func updateServerMetricsCache(server models.ServerEndpoint, payload []byte) (*mo
 		metric.Timestamp = utils.FormatTimestampUTC(utils.NowUTC())
 	}
 
+	now := utils.NowUTC()
 	serverMetricsCacheMu.Lock()
 	serverMetricsCache[normalized] = cachedServerMetric{
 		metric:    *metric,
-		fetchedAt: utils.NowUTC(),
+		fetchedAt: now,
+		polledAt:  now,
 	}
 	serverMetricsCacheMu.Unlock()
 
@@ -685,36 +617,6 @@ func convertDiskMaps(items []map[string]any) []models.DiskSpace {
 	return disks
 }
 
-func convertDiskValueToModels(value any) []models.DiskSpace {
-	switch typed := value.(type) {
-	case []models.DiskSpace:
-		return typed
-	case []map[string]any:
-		return convertDiskMaps(typed)
-	case []any:
-		maps := make([]map[string]any, 0, len(typed))
-		for _, item := range typed {
-			if entry, ok := item.(map[string]any); ok {
-				maps = append(maps, entry)
-			}
-		}
-		return convertDiskMaps(maps)
-	default:
-		if value == nil {
-			return nil
-		}
-		data, err := json.Marshal(value)
-		if err != nil {
-			return nil
-		}
-		var disks []models.DiskSpace
-		if err := json.Unmarshal(data, &disks); err != nil {
-			return nil
-		}
-		return disks
-	}
-}
-
 func toFloat64(value any) float64 {
 	switch v := value.(type) {
 	case float64:
@@ -756,388 +658,133 @@ func normalizeServerAddress(address string) string {
 }
 
 func MonitoringDataGeneratorWithTableFilter(tableName, from, to string) ([]any, error) {
+    result, _, err := MonitoringDataPageWithTableFilter(tableName, from, to, utils.PageRequest{})
+    return result, err
+}
+
+// MonitoringDataPageWithTableFilter behaves like MonitoringDataGeneratorWithTableFilter; when page is
+// active it returns raw (not downsampled) snapshots one page at a time along with the next cursor.
+func MonitoringDataPageWithTableFilter(tableName, from, to string, page utils.PageRequest) ([]any, string, error) {
     cfg := GetMonitoringConfig()
-    hasSQLite := utils.IsDatabaseInitialized()
-    hasPG := utils.IsPostgresInitialized()
 
     // Determine intent: historical query if any date bound provided
     isHistoricalQuery := !utils.IsEmptyOrWhitespace(from) || !utils.IsEmptyOrWhitespace(to)
 
-    // If no database backend is available:
+    // If no queryable backend is available:
     // - For historical (date-range) queries: return empty result (no history to query)
     // - For non-historical (latest) requests: return a live snapshot
-    if !hasSQLite && !hasPG {
+    backend := selectQueryBackend(cfg.Storage, isHistoricalQuery)
+    if backend == nil {
         if isHistoricalQuery {
-            return []any{}, nil
+            return []any{}, "", nil
         }
         currentData, err := MonitoringDataGenerator()
         if err != nil {
-            return []any{}, err
+            return []any{}, "", err
         }
         if currentData != nil {
-            return []any{currentData}, nil
+            return []any{currentData}, "", nil
         }
-        return []any{}, nil
+        return []any{}, "", nil
     }
 
-	// Determine which table to query
-	var filteredData []models.MonitoringLogEntry
-	var err error
-
-    // Check if this is a historical query (any date range provided) or database query (no date range but database available)
-    isDatabaseQuery := (hasSQLite || hasPG) && (isHistoricalQuery || (utils.IsEmptyOrWhitespace(from) && utils.IsEmptyOrWhitespace(to)))
-    
-    var useSQLite, usePostgres bool
-    
-    if isDatabaseQuery {
-        if isHistoricalQuery {
-            // For historical queries, use the HISTORICAL_QUERY_STORAGE environment variable
-            historicalStorage := config.GetEnvConfig().GetHistoricalQueryStorage()
-            if historicalStorage == "sqlite" && utils.HasStorage(cfg.Storage, "sqlite") && hasSQLite {
-                useSQLite = true
-                usePostgres = false
-            } else if historicalStorage == "postgres" && utils.HasStorage(cfg.Storage, "postgres") && hasPG {
-                useSQLite = false
-                usePostgres = true
-            } else {
-                // Fallback to original logic if preferred storage is not available
-                useSQLite = utils.HasStorage(cfg.Storage, "sqlite") && hasSQLite
-                usePostgres = utils.HasStorage(cfg.Storage, "postgres") && hasPG && !useSQLite
-            }
-        } else {
-            // For non-historical queries (dashboard initial load), prefer PostgreSQL for better performance
-            usePostgres = utils.HasStorage(cfg.Storage, "postgres") && hasPG
-            useSQLite = utils.HasStorage(cfg.Storage, "sqlite") && hasSQLite && !usePostgres
-        }
-    } else {
-        // No database available, will fall back to current data
-        useSQLite = false
-        usePostgres = false
-    }
-
-    if useSQLite {
-        if utils.IsEmptyOrWhitespace(tableName) || tableName == "default" {
-            filteredData, err = utils.QueryFilteredTableData(utils.DefaultTableName, from, to)
-        } else {
-            filteredData, err = utils.QueryFilteredTableData(tableName, from, to)
-        }
-    } else if usePostgres {
-        if utils.IsEmptyOrWhitespace(tableName) || tableName == "default" {
-            filteredData, err = utils.QueryFilteredPostgresData(utils.DefaultTableName, from, to)
-        } else {
-            filteredData, err = utils.QueryFilteredPostgresData(tableName, from, to)
-        }
-    } else {
-        // Neither backend is usable - if this is a historical query, return empty
-        if isHistoricalQuery {
-            return []any{}, nil
-        }
-        // For non-historical queries, fall back to current data
-        currentData, err := MonitoringDataGenerator()
-        if err != nil {
-            return []any{}, err
-        }
-        if currentData != nil {
-            return []any{currentData}, nil
-        }
-        return []any{}, nil
+    if utils.IsEmptyOrWhitespace(tableName) || tableName == "default" {
+        tableName = utils.DefaultTableName
     }
 
+	filteredData, nextCursor, err := backend.Query(tableName, from, to, page)
 	if err != nil {
-		return []any{}, fmt.Errorf("failed to query filtered monitoring data: %w", err)
+		return []any{}, "", fmt.Errorf("failed to query filtered monitoring data: %w", err)
 	}
 
 	if len(filteredData) == 0 {
 		// When no historical data is found, return empty array to allow frontend to handle gracefully
-		return []any{}, nil
+		return []any{}, "", nil
 	}
 
 	result := make([]any, 0, len(filteredData))
-	for _, entry := range filteredData {
-		snapshot, convErr := convertLogEntryToSystemMonitoring(entry)
-		if convErr == nil {
-			result = append(result, snapshot)
-		} else {
-			result = append(result, entry.Body)
-		}
-	}
-
-	return result, nil
-}
-
-func convertLogEntryToSystemMonitoring(entry models.MonitoringLogEntry) (*models.SystemMonitoring, error) {
-	if entry.Body == nil {
-		return nil, fmt.Errorf("empty log entry body")
-	}
-
-	// Check if this is a remote server entry with nested payload structure
-	if payload, hasPayload := entry.Body["payload"]; hasPayload {
-		return convertServerLogEntryToSystemMonitoring(entry, payload)
+	for i := range filteredData {
+		result = append(result, &filteredData[i])
 	}
 
-	// Handle local monitoring data with flat structure
-	return convertFlatLogEntryToSystemMonitoring(entry)
+	return result, nextCursor, nil
 }
 
-func convertServerLogEntryToSystemMonitoring(entry models.MonitoringLogEntry, payload any) (*models.SystemMonitoring, error) {
-	// Remote server data is stored as: {"time": "...", "payload": [{"timestamp": "...", "cpu": {...}, ...}]}
-	payloadArray, ok := payload.([]any)
-	if !ok || len(payloadArray) == 0 {
-		return nil, fmt.Errorf("invalid payload format")
-	}
+// exportPageSize is the number of raw snapshots read per backend round trip while exporting.
+const exportPageSize = 1000
 
-	// Take the first (and typically only) SystemMonitoring entry from the payload
-	firstEntry, ok := payloadArray[0].(map[string]any)
-	if !ok {
-		return nil, fmt.Errorf("invalid payload entry format")
-	}
-
-	// Convert the nested structure to SystemMonitoring
-	data, err := json.Marshal(firstEntry)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload entry: %w", err)
-	}
-
-	var snapshot models.SystemMonitoring
-	if err := json.Unmarshal(data, &snapshot); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal to SystemMonitoring: %w", err)
-	}
+// StreamMonitoringExport reads raw snapshots of a table within [from, to] newest first and
+// hands them to emit page by page, so the full range is never held in memory.
+func StreamMonitoringExport(ctx context.Context, tableName, from, to string, emit func([]models.SystemMonitoring) error) error {
+    cfg := GetMonitoringConfig()
+    backend := selectQueryBackend(cfg.Storage, true)
+    if backend == nil {
+        return fmt.Errorf("%w: no queryable storage backend configured", utils.ErrQueryNotSupported)
+    }
 
-	// Use the entry time if timestamp is not set
-	if snapshot.Timestamp.IsZero() && entry.Time != "" {
-		if ts, err := utils.ParseTimestampUTC(entry.Time); err == nil {
-			snapshot.Timestamp = ts
-		}
-	}
+    if utils.IsEmptyOrWhitespace(tableName) || tableName == "default" {
+        tableName = utils.DefaultTableName
+    }
 
-	return &snapshot, nil
+    page := utils.PageRequest{Limit: exportPageSize}
+    for {
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+        batch, next, err := backend.Query(tableName, from, to, page)
+        if err != nil {
+            return fmt.Errorf("failed to read export page: %w", err)
+        }
+        if len(batch) > 0 {
+            if err := emit(batch); err != nil {
+                return err
+            }
+        }
+        if next == "" || len(batch) == 0 {
+            return nil
+        }
+        page.Cursor = next
+    }
 }
 
-func convertFlatLogEntryToSystemMonitoring(entry models.MonitoringLogEntry) (*models.SystemMonitoring, error) {
-	snapshot := &models.SystemMonitoring{}
-
-	// Parse timestamp
-	if entry.Time != "" {
-		if ts, err := utils.ParseTimestampUTC(entry.Time); err == nil {
-			snapshot.Timestamp = ts
-		}
-	}
-
-	// Helper function to safely convert to float64
-	toFloat64 := func(v any) float64 {
-		switch val := v.(type) {
-		case float64:
-			return val
-		case float32:
-			return float64(val)
-		case int:
-			return float64(val)
-		case int64:
-			return float64(val)
-		case uint64:
-			return float64(val)
-		default:
-			return 0
-		}
-	}
-
-	// Helper function to safely convert to uint64
-	toUint64 := func(v any) uint64 {
-		switch val := v.(type) {
-		case uint64:
-			return val
-		case int64:
-			if val >= 0 {
-				return uint64(val)
-			}
-			return 0
-		case int:
-			if val >= 0 {
-				return uint64(val)
-			}
-			return 0
-		case float64:
-			if val >= 0 {
-				return uint64(val)
-			}
-			return 0
-		default:
-			return 0
-		}
-	}
-
-	// Helper function to safely convert to int
-	toInt := func(v any) int {
-		switch val := v.(type) {
-		case int:
-			return val
-		case int64:
-			return int(val)
-		case float64:
-			return int(val)
-		default:
-			return 0
-		}
-	}
-
-	// Helper function to safely convert to string
-	toString := func(v any) string {
-		if v == nil {
-			return ""
-		}
-		if s, ok := v.(string); ok {
-			return s
-		}
-		return fmt.Sprintf("%v", v)
-	}
-
-	// Map CPU fields
-	snapshot.CPU = models.CPU{
-		UsagePercent: toFloat64(entry.Body["cpu_usage_percent"]),
-		CoreCount:    toInt(entry.Body["cpu_cores"]),
-		Goroutines:   toInt(entry.Body["cpu_goroutines"]),
-		LoadAverage:  toString(entry.Body["cpu_load_average"]),
-		Architecture: toString(entry.Body["cpu_architecture"]),
-	}
-
-	// Map RAM fields
-	snapshot.RAM = models.RAM{
-		TotalBytes:     toUint64(entry.Body["ram_total_bytes"]),
-		UsedBytes:      toUint64(entry.Body["ram_used_bytes"]),
-		AvailableBytes: toUint64(entry.Body["ram_available_bytes"]),
-		UsedPct:        toFloat64(entry.Body["ram_used_percent"]),
-		BufferBytes:    0, // Not stored in flat format
-	}
-
-	// Map NetworkIO fields
-	snapshot.NetworkIO = models.NetworkIO{
-		BytesSent:   toUint64(entry.Body["network_bytes_sent"]),
-		BytesRecv:   toUint64(entry.Body["network_bytes_recv"]),
-		PacketsSent: toUint64(entry.Body["network_packets_sent"]),
-		PacketsRecv: toUint64(entry.Body["network_packets_recv"]),
-		ErrorsIn:    toUint64(entry.Body["network_errors_in"]),
-		ErrorsOut:   toUint64(entry.Body["network_errors_out"]),
-		DropsIn:     toUint64(entry.Body["network_drops_in"]),
-		DropsOut:    toUint64(entry.Body["network_drops_out"]),
-	}
-
-	// Map DiskIO fields
-	snapshot.DiskIO = models.DiskIO{
-		ReadBytes:  toUint64(entry.Body["diskio_read_bytes"]),
-		WriteBytes: toUint64(entry.Body["diskio_write_bytes"]),
-		ReadCount:  toUint64(entry.Body["diskio_read_count"]),
-		WriteCount: toUint64(entry.Body["diskio_write_count"]),
-		ReadTime:   toUint64(entry.Body["diskio_read_time"]),
-		WriteTime:  toUint64(entry.Body["diskio_write_time"]),
-		IOTime:     toUint64(entry.Body["diskio_io_time"]),
-	}
-
-	// Map Process fields
-	snapshot.Process = models.Process{
-		TotalProcesses: toInt(entry.Body["process_total"]),
-		RunningProcs:   toInt(entry.Body["process_running"]),
-		SleepingProcs:  toInt(entry.Body["process_sleeping"]),
-		ZombieProcs:    toInt(entry.Body["process_zombie"]),
-		StoppedProcs:   toInt(entry.Body["process_stopped"]),
-		LoadAvg1:       toFloat64(entry.Body["process_load_avg_1"]),
-		LoadAvg5:       toFloat64(entry.Body["process_load_avg_5"]),
-		LoadAvg15:      toFloat64(entry.Body["process_load_avg_15"]),
-	}
-
-	// Map DiskSpace fields - try to get from disk_spaces array first, fallback to flat fields
-	if diskSpaces, ok := entry.Body["disk_spaces"]; ok {
-		if diskArray, ok := diskSpaces.([]any); ok {
-			for _, diskItem := range diskArray {
-				if diskMap, ok := diskItem.(map[string]any); ok {
-					disk := models.DiskSpace{
-						Path:           toString(diskMap["path"]),
-						Device:         toString(diskMap["device"]),
-						FileSystem:     toString(diskMap["filesystem"]),
-						TotalBytes:     toUint64(diskMap["total_bytes"]),
-						UsedBytes:      toUint64(diskMap["used_bytes"]),
-						AvailableBytes: toUint64(diskMap["available_bytes"]),
-						UsedPct:        toFloat64(diskMap["used_pct"]),
-					}
-					snapshot.DiskSpace = append(snapshot.DiskSpace, disk)
-				}
-			}
-		}
-	}
-
-	// If no disk_spaces array, create one from flat fields for backward compatibility
-	if len(snapshot.DiskSpace) == 0 {
-		snapshot.DiskSpace = []models.DiskSpace{
-			{
-				Path:           "/", // Assume root
-				Device:         "unknown",
-				FileSystem:     "unknown",
-				TotalBytes:     toUint64(entry.Body["disk_total_bytes"]),
-				UsedBytes:      toUint64(entry.Body["disk_used_bytes"]),
-				AvailableBytes: toUint64(entry.Body["disk_available_bytes"]),
-				UsedPct:        toFloat64(entry.Body["disk_used_percent"]),
-			},
-		}
-	}
+// CollectExportColumns reads the range once and returns every column its snapshots flatten
+// into, so a fixed-schema export can write a header that no later row outgrows.
+func CollectExportColumns(ctx context.Context, tableName, from, to string) (*utils.ExportColumns, error) {
+    columns := utils.NewExportColumns()
+    err := StreamMonitoringExport(ctx, tableName, from, to, func(batch []models.SystemMonitoring) error {
+        columns.Add(batch)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    return columns, nil
+}
 
-	// Map Heartbeat and ServerMetrics if present
-	if heartbeat, ok := entry.Body["heartbeat"]; ok && heartbeat != nil {
-		// Convert heartbeat data from database storage format
-		if heartbeatArray, ok := heartbeat.([]any); ok {
-			for _, rawCheck := range heartbeatArray {
-				checkMap, ok := rawCheck.(map[string]any)
-				if !ok {
-					continue
-				}
-				
-				// Convert timestamps to proper time.Time format
-				var lastChecked time.Time
-				if lastCheckedStr, ok := checkMap["last_checked"].(string); ok {
-					if parsed, err := time.Parse(time.RFC3339, lastCheckedStr); err == nil {
-						lastChecked = parsed
-					}
-				}
-				
-				check := models.ServerCheck{
-					Name:         toString(checkMap["name"]),
-					URL:          toString(checkMap["url"]),
-					Status:       models.ServerStatus(toString(checkMap["status"])),
-					ResponseTime: toString(checkMap["response_time"]),
-					ResponseMs:   int64(toFloat64(checkMap["response_ms"])),
-					LastChecked:  lastChecked,
-					Error:        toString(checkMap["error"]),
-				}
-				snapshot.Heartbeat = append(snapshot.Heartbeat, check)
-			}
-		}
-	}
+// historicalQueryPreference and latestQueryPreference list queryable backends in the order they are tried.
+// Historical queries favour the local SQLite file; the dashboard's latest view favours PostgreSQL.
+var (
+    historicalQueryPreference = []string{utils.StorageSQLite, utils.StoragePostgres, utils.StorageTSDB}
+    latestQueryPreference     = []string{utils.StoragePostgres, utils.StorageSQLite, utils.StorageTSDB}
+)
 
-	if serverMetrics, ok := entry.Body["server_metrics"]; ok && serverMetrics != nil {
-		if metricArray, ok := serverMetrics.([]any); ok {
-			for _, rawMetric := range metricArray {
-				metricMap, ok := rawMetric.(map[string]any)
-				if !ok {
-					continue
-				}
-				metric := models.ServerMetrics{
-					Name:              toString(metricMap["name"]),
-					Address:           normalizeServerAddress(toString(metricMap["address"])),
-					CPUUsage:          toFloat64(metricMap["cpu_usage"]),
-					MemoryUsedPercent: toFloat64(metricMap["memory_used_percent"]),
-					DiskUsedPercent:   toFloat64(metricMap["disk_used_percent"]),
-					NetworkInBytes:    toUint64(metricMap["network_in_bytes"]),
-					NetworkOutBytes:   toUint64(metricMap["network_out_bytes"]),
-					LoadAverage:       toString(metricMap["cpu_load_average"]),
-					Timestamp:         toString(metricMap["timestamp"]),
-					Status:            toString(metricMap["status"]),
-					Message:           toString(metricMap["message"]),
-					DiskSpace:         convertDiskValueToModels(metricMap["disk_space"]),
-				}
-				snapshot.ServerMetrics = append(snapshot.ServerMetrics, metric)
-			}
-		}
-	}
+// selectQueryBackend picks the backend that serves monitoring queries, or nil when none is ready.
+// For historical queries HISTORICAL_QUERY_STORAGE wins when that backend is configured and ready.
+func selectQueryBackend(storage []string, historical bool) utils.StorageBackend {
+    preference := latestQueryPreference
+    if historical {
+        preference = append([]string{config.GetEnvConfig().GetHistoricalQueryStorage()}, historicalQueryPreference...)
+    }
 
-	return snapshot, nil
+    for _, name := range preference {
+        if !utils.HasStorage(storage, name) {
+            continue
+        }
+        if backend, ok := utils.GetStorageBackend(name); ok && backend.Ready() {
+            return backend
+        }
+    }
+    return nil
 }
 
 func getCPUInfo() (models.CPU, error) {
@@ -1516,7 +1163,7 @@ func checkSingleServer(server models.ServerConfig) models.ServerCheck {
 		timeout = 5 * time.Second // Default timeout
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(backgroundContext(), timeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
@@ -1533,6 +1180,10 @@ func checkSingleServer(server models.ServerConfig) models.ServerCheck {
 		}
 	}
 
+	for key, value := range server.Headers {
+		req.Header.Set(key, value)
+	}
+
 	// Use shared HTTP client with timeout for server checks
 	client := utils.GetHTTPClientWithTimeout(timeout)
 	resp, err := client.Do(req)
@@ -1570,20 +1221,25 @@ func checkSingleServer(server models.ServerConfig) models.ServerCheck {
 	}
 }
 
-func readConfigFromFile() (*models.MonitoringConfig, error) {
+func readConfigFromFile() (*models.MonitoringConfig, map[string]time.Time, error) {
 	configPath := getConfigPath()
 	if configPath == "" {
-		return nil, fmt.Errorf("could not locate configuration file")
+		return nil, nil, fmt.Errorf("could not locate configuration file")
 	}
+	return readConfigFile(configPath, configPath)
+}
 
-	data, err := os.ReadFile(configPath)
+// readConfigFile loads and validates the configuration at path; configPath names it in messages.
+func readConfigFile(path, configPath string) (*models.MonitoringConfig, map[string]time.Time, error) {
+	// JSON, YAML or TOML, with includes resolved and ${VAR} references substituted
+	data, sources, err := utils.LoadConfigDocument(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read configuration file %s: %w", configPath, err)
+		return nil, sources, err
 	}
 
-	var monitoringConfig models.MonitoringConfig
-	if err = json.Unmarshal(data, &monitoringConfig); err != nil {
-		return nil, fmt.Errorf("failed to parse configuration file %s: %w", configPath, err)
+	monitoringConfig, validation := decodeMonitoringConfig(data)
+	if monitoringConfig == nil {
+		return nil, sources, fmt.Errorf("failed to parse configuration file %s: %w", configPath, validation)
 	}
 
 	// Override path with environment variable if set
@@ -1592,10 +1248,23 @@ func readConfigFromFile() (*models.MonitoringConfig, error) {
 		monitoringConfig.Path = envConfig.BaseLogFolder
 	}
 
-	return &monitoringConfig, nil
+	validateMonitoringConfig(validation, monitoringConfig)
+	for _, w := range validation.Warnings {
+		utils.LogWarn("%s: %s: %s (%s)", configPath, ConfigIssuePath(w), w.Message, w.Code)
+	}
+	if err := validation.Err(); err != nil {
+		return nil, sources, fmt.Errorf("configuration file %s: %w", configPath, err)
+	}
+	return monitoringConfig, sources, nil
+}
+
+// ConfigFilePath returns the configuration file used by the server.
+func ConfigFilePath() string {
+	return getConfigPath()
 }
 
-// getConfigPath returns the path to configs.json
+// getConfigPath returns the path to the configuration file: MONITOR_CONFIG_PATH, or the
+// first of configs.json, configs.yaml, configs.yml and configs.toml in the project root.
 func getConfigPath() string {
 	envConfig := config.GetEnvConfig()
 	if override := strings.TrimSpace(envConfig.MonitorConfigPath); override != "" {
@@ -1608,7 +1277,13 @@ func getConfigPath() string {
 	}
 
 	projectRoot := findProjectRoot(cwd)
-	return filepath.Join(projectRoot, "configs.json")
+	for _, name := range utils.ConfigFileNames {
+		candidate := filepath.Join(projectRoot, name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return filepath.Join(projectRoot, utils.ConfigFileNames[0])
 }
 
 func findProjectRoot(startPath string) string {
@@ -1646,7 +1321,7 @@ func formatDuration(d time.Duration) string {
 
 // startAutoLogging starts the automatic logging based on refresh_time
 func startAutoLogging() {
-	if monitoringConfig == nil {
+	if monitoringConfig == nil || isShuttingDown() {
 		return
 	}
 
@@ -1681,8 +1356,18 @@ func startAutoLogging() {
 		for {
 			select {
 			case <-ticker.C:
+				// A tick racing with shutdown is skipped; one already running is waited for
+				if !beginBackgroundWork() {
+					return
+				}
+
+				// Start this tick's server polls first, so the snapshot below joins them
+				// instead of fetching the same servers again
+				polls := startFederatedPolls()
+
 				// Generate monitoring data and log it - local monitoring should never fail the entire system
 				func() {
+					defer endBackgroundWork()
 					defer func() {
 						if r := recover(); r != nil {
 							utils.LogErrorWithContext("auto-logging", "local monitoring panic recovered", fmt.Errorf("%v", r))
@@ -1693,19 +1378,25 @@ func startAutoLogging() {
 						if logErr := utils.LogMonitoringData(data); logErr != nil {
 							utils.LogWarnWithContext("auto-logging", "failed to log monitoring data", logErr)
 						}
+						utils.ExportSnapshot(data)
+						queueAgentPush(GetMonitoringConfig(), data)
 					} else {
 						utils.LogWarnWithContext("auto-logging", "failed to generate monitoring data", err)
 					}
 				}()
 
 				// Persist remote server logs - this should never block or crash local monitoring
+				if !beginBackgroundWork() {
+					return
+				}
 				go func() {
+					defer endBackgroundWork()
 					defer func() {
 						if r := recover(); r != nil {
 							utils.LogErrorWithContext("server-persistence", "server logging panic recovered", fmt.Errorf("%v", r))
 						}
 					}()
-					persistServerLogs()
+					persistFederatedPolls(polls)
 				}()
 			case <-stopChan:
 				return
@@ -1749,6 +1440,14 @@ func configureLogRotation() {
     }
 
 	rotateCfg := monitoringConfig.LogRotate
+
+    // TimescaleDB expires and compresses hypertables natively; this also removes policies when rotation is off
+    if utils.HasStorage(monitoringConfig.Storage, utils.StoragePostgres) && utils.IsPostgresInitialized() && utils.IsTimescaleDBAvailable() {
+        if err := utils.ConfigureTimescalePolicies(rotateCfg); err != nil {
+            utils.LogWarnWithContext("log-rotation", "failed to configure TimescaleDB policies", err)
+        }
+    }
+
 	if rotateCfg == nil || !rotateCfg.Enabled {
 		return
 	}
@@ -1759,21 +1458,10 @@ func configureLogRotation() {
 	}
 
     performCleanup := func(retention int) {
-        if utils.HasStorage(monitoringConfig.Storage, "file") {
-            if err := utils.CleanOldLogs(retention); err != nil {
-                utils.LogWarnWithContext("log-rotation", "log cleanup failed", err)
-            }
-        }
-
         cutoff := time.Now().AddDate(0, 0, -retention)
-        if utils.HasStorage(monitoringConfig.Storage, "sqlite") && utils.IsDatabaseInitialized() {
-            if err := utils.CleanOldDatabaseEntries(cutoff); err != nil {
-                utils.LogWarnWithContext("log-rotation", "sqlite cleanup failed", err)
-            }
-        }
-        if utils.HasStorage(monitoringConfig.Storage, "postgres") && utils.IsPostgresInitialized() {
-            if err := utils.CleanOldPostgresEntries(cutoff); err != nil {
-                utils.LogWarnWithContext("log-rotation", "postgres cleanup failed", err)
+        for _, backend := range utils.EnabledStorageBackends(monitoringConfig.Storage) {
+            if err := backend.Cleanup(cutoff); err != nil {
+                utils.LogWarnWithContext("log-rotation", fmt.Sprintf("%s cleanup failed", backend.Name()), err)
             }
         }
     }
@@ -1835,6 +1523,7 @@ func CleanupAllGoroutines() {
 
 	// Stop auto-logging goroutines
 	stopAutoLogging()
+	stopConfigWatcher()
 
 	utils.LogInfo("all monitoring goroutines cleaned up successfully")
 }
@@ -1853,126 +1542,31 @@ func IsLogRotationActive() bool {
 	return logRotateTicker != nil && logRotateStopChan != nil
 }
 
-func persistServerLogs() {
-	monitoringConfigMu.RLock()
-	cfg := monitoringConfig
-	monitoringConfigMu.RUnlock()
-
-    // Persist remote server logs whenever servers are configured
-    if cfg == nil || len(cfg.Servers) == 0 {
-        return
-    }
-
-    writeFile := utils.HasStorage(cfg.Storage, "file")
-    writeSqlite := utils.HasStorage(cfg.Storage, "sqlite") && utils.IsDatabaseInitialized()
-    writePG := utils.HasStorage(cfg.Storage, "postgres") && utils.IsPostgresInitialized()
-
-	if writeFile && utils.IsEmptyOrWhitespace(cfg.Path) {
-		utils.LogWarn("persist_server_logs enabled but log path is empty; skipping file persistence")
-		writeFile = false
-	}
-
-    if !writeFile && !writeSqlite && !writePG {
-        return
-    }
-
-	// Process each server concurrently with individual timeout handling
-	// This prevents one slow/failed server from blocking others
-	var wg sync.WaitGroup
-	
-	for _, server := range cfg.Servers {
-		if utils.IsEmptyOrWhitespace(server.TableName) || utils.IsEmptyOrWhitespace(server.Address) {
-			continue
-		}
-
-		wg.Add(1)
-		go func(srv models.ServerEndpoint) {
-			defer wg.Done()
-			defer func() {
-				if r := recover(); r != nil {
-					utils.LogErrorWithContext("server-persistence", 
-						fmt.Sprintf("Server persistence panic for '%s' (%s)", srv.Name, srv.Address),
-						fmt.Errorf("panic: %v", r))
-				}
-			}()
-
-			// Add timeout context for each server individually
-			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			defer cancel()
-
-			// Use context-aware fetch with individual server timeout
-            payload, err := fetchServerMonitoringWithContext(ctx, srv.Address)
-            if err != nil {
-                utils.LogWarnWithContext("server-monitoring", fmt.Sprintf("failed to fetch monitoring data from %s", srv.Address), err)
-                return
-            }
-
-            // Trim payload to only fields used by the dashboard
-            if trimmed, terr := utils.FilterMonitoringPayload(payload); terr == nil && len(trimmed) > 0 {
-                payload = trimmed
-            }
-
-            // Update cache - this should be fast and not block
-            if _, err := updateServerMetricsCache(srv, payload); err != nil {
-                utils.LogWarnWithContext("server-monitoring", fmt.Sprintf("failed to parse server metrics from %s", srv.Address), err)
-            }
-
-			// File and database operations with error isolation
-            if writeFile {
-                if err := utils.WriteServerLogToFile(cfg.Path, srv, payload); err != nil {
-                    utils.LogWarnWithContext("server-monitoring", fmt.Sprintf("failed to write server log file for %s", srv.Address), err)
-                }
-            }
-
-            if writeSqlite {
-                if err := utils.WriteServerLogToDatabase(srv.TableName, payload); err != nil {
-                    utils.LogWarnWithContext("server-monitoring", fmt.Sprintf("failed to write server log to sqlite for %s", srv.Address), err)
-                }
-            }
-            if writePG {
-                if err := utils.WriteServerLogToPostgres(srv.TableName, payload); err != nil {
-                    utils.LogWarnWithContext("server-monitoring", fmt.Sprintf("failed to write server log to postgres for %s", srv.Address), err)
-                }
-            }
-		}(server)
+// fetchServerMonitoringWithContext polls a server's monitoring endpoint, presenting the
+// server's credentials (bearer or basic auth, client certificate, request signature). While
+// the server's circuit is open it fails with errCircuitOpen without sending a request; a
+// failure that opens the circuit wraps errCircuitOpen too.
+func fetchServerMonitoringWithContext(ctx context.Context, server models.ServerEndpoint) ([]byte, error) {
+	address := normalizeServerAddress(server.Address)
+	if err := allowServerPoll(address); err != nil {
+		return nil, err
 	}
-
-	// Wait for all servers to complete with overall timeout
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
-
-	select {
-	case <-done:
-		// All servers completed successfully
-	case <-time.After(60 * time.Second):
-		utils.LogWarn("server persistence timed out after 60 seconds; some servers may still be processing")
+	payload, err := pollServerMonitoring(ctx, server)
+	if recordServerPoll(server, address, err) {
+		return nil, fmt.Errorf("%w: %w", errCircuitOpen, err)
 	}
+	return payload, err
 }
 
-func fetchServerMonitoring(baseAddress string) ([]byte, error) {
-	// Get timeout from environment configuration
-	envConfig := config.GetEnvConfig()
-	timeout := envConfig.ServerMonitoringTimeout
+func pollServerMonitoring(ctx context.Context, server models.ServerEndpoint) ([]byte, error) {
+	endpoint := strings.TrimRight(server.Address, "/") + "/api/v1/monitoring"
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-	
-	return fetchServerMonitoringWithContext(ctx, baseAddress)
-}
-
-func fetchServerMonitoringWithContext(ctx context.Context, baseAddress string) ([]byte, error) {
-	endpoint := strings.TrimRight(baseAddress, "/") + "/api/v1/monitoring"
-
-	// Use the centralized HTTP utility with resource limits
-	headers := map[string]string{
-		"Content-Type": "application/json",
+	req, client, err := utils.NewServerRequest(ctx, server, http.MethodPost, endpoint, []byte("{}"))
+	if err != nil {
+		return nil, fmt.Errorf("server request failed: %w", err)
 	}
-
-	body := strings.NewReader("{}")
-	payload, err := utils.MakeHTTPRequestWithLimits(ctx, http.MethodPost, endpoint, body, headers)
+	// Use the centralized HTTP utility with resource limits
+	payload, err := utils.DoHTTPRequestWithLimits(client, req)
 
 	if err != nil {
 		// Provide more specific error messages for different failure types
@@ -0,0 +1,130 @@
+package logics
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"go-log/internal/config"
+	"go-log/internal/utils"
+)
+
+const (
+	// OIDCLoginTimeout is how long a user has to finish signing in at the provider.
+	OIDCLoginTimeout = 10 * time.Minute
+	// maxPendingOIDCLogins caps the logins started but not finished, so unauthenticated
+	// requests cannot grow the map without bound.
+	maxPendingOIDCLogins = 10000
+)
+
+// oidcLogin is a single sign-on started by StartOIDCLogin, keyed by its state parameter.
+type oidcLogin struct {
+	codeVerifier string
+	nonce        string
+	next         string
+	expiresAt    time.Time
+}
+
+var (
+	pendingOIDCLogins   = map[string]oidcLogin{}
+	pendingOIDCLoginsMu sync.Mutex
+)
+
+// StartOIDCLogin begins an authorization-code login with PKCE. It returns the provider URL
+// to send the browser to and the state the callback must bring back; next is where the
+// browser goes once signed in.
+func StartOIDCLogin(ctx context.Context, next string) (authURL, state string, err error) {
+	login := oidcLogin{next: next, expiresAt: utils.NowUTC().Add(OIDCLoginTimeout)}
+	if state, err = RandomToken(); err != nil {
+		return "", "", err
+	}
+	if login.codeVerifier, err = RandomToken(); err != nil {
+		return "", "", err
+	}
+	if login.nonce, err = RandomToken(); err != nil {
+		return "", "", err
+	}
+	if authURL, err = utils.OIDCAuthorizationURL(ctx, state, login.nonce, login.codeVerifier); err != nil {
+		return "", "", err
+	}
+
+	pendingOIDCLoginsMu.Lock()
+	defer pendingOIDCLoginsMu.Unlock()
+	now := utils.NowUTC()
+	for key, pending := range pendingOIDCLogins {
+		if now.After(pending.expiresAt) {
+			delete(pendingOIDCLogins, key)
+		}
+	}
+	if len(pendingOIDCLogins) >= maxPendingOIDCLogins {
+		return "", "", fmt.Errorf("too many sign-ins in progress, try again later")
+	}
+	pendingOIDCLogins[state] = login
+	return authURL, state, nil
+}
+
+// CancelOIDCLogin forgets a login the provider reported as failed.
+func CancelOIDCLogin(state string) {
+	pendingOIDCLoginsMu.Lock()
+	defer pendingOIDCLoginsMu.Unlock()
+	delete(pendingOIDCLogins, state)
+}
+
+// FinishOIDCLogin redeems the code the provider sent back for state, verifies the ID token,
+// maps the user's groups to a role and starts a session. It returns the session and where
+// to send the browser.
+func FinishOIDCLogin(ctx context.Context, state, code string) (*Session, string, error) {
+	pendingOIDCLoginsMu.Lock()
+	login, ok := pendingOIDCLogins[state]
+	delete(pendingOIDCLogins, state)
+	pendingOIDCLoginsMu.Unlock()
+	if !ok || utils.NowUTC().After(login.expiresAt) {
+		return nil, "", fmt.Errorf("%w: the sign-in attempt is unknown or has expired", utils.ErrInvalidToken)
+	}
+
+	idToken, err := utils.ExchangeOIDCCode(ctx, code, login.codeVerifier)
+	if err != nil {
+		return nil, "", err
+	}
+	clientID := config.GetEnvConfig().OIDCClientID
+	claims, err := utils.VerifyOIDCToken(ctx, idToken, clientID)
+	if err != nil {
+		return nil, "", err
+	}
+	if nonce, _ := claims["nonce"].(string); subtle.ConstantTimeCompare([]byte(nonce), []byte(login.nonce)) != 1 {
+		return nil, "", fmt.Errorf("%w: ID token nonce does not match", utils.ErrInvalidClaims)
+	}
+	if err := checkAuthorizedParty(claims, clientID); err != nil {
+		return nil, "", err
+	}
+
+	username := utils.OIDCUsername(claims)
+	role, err := utils.OIDCRole(claims)
+	if err != nil {
+		return nil, "", fmt.Errorf("user %q: %w", username, err)
+	}
+	subject, _ := claims["sub"].(string)
+	session, err := startSession(Session{Username: username, Subject: subject, Role: role, Source: SessionSourceOIDC})
+	if err != nil {
+		return nil, "", err
+	}
+	return session, login.next, nil
+}
+
+// checkAuthorizedParty rejects ID tokens issued to several audiences unless this client is
+// the authorized party.
+func checkAuthorizedParty(claims jwt.MapClaims, clientID string) error {
+	audience, err := claims.GetAudience()
+	if err != nil {
+		return fmt.Errorf("%w: %v", utils.ErrInvalidClaims, err)
+	}
+	azp, _ := claims["azp"].(string)
+	if (len(audience) > 1 || azp != "") && azp != clientID {
+		return fmt.Errorf("%w: ID token was issued to %q", utils.ErrInvalidClaims, azp)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package logics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"go-log/internal/api/models"
+	"go-log/internal/config"
+	"go-log/internal/utils"
+)
+
+// Remote servers are fetched by a single poller. Every tick, startFederatedPolls starts one
+// poll per server: it fetches the server's snapshot, parses it into the metrics cache and
+// hands the payload to persistFederatedPolls. Everything else reads the cache. When it is
+// stale, a request joins the poll already in flight for that server instead of starting
+// another, so a server is fetched at most once at a time however many requests arrive.
+// A server slower than the refresh interval is joined by later ticks too; each poll result
+// is persisted by whichever tick claims it first, so it is stored once.
+
+const (
+	// federatedPersistTimeout bounds how long a tick waits for its polls before persisting.
+	federatedPersistTimeout = 60 * time.Second
+	// remoteConfigTimeout bounds fetching a remote server's configuration.
+	remoteConfigTimeout = 10 * time.Second
+	// remoteConfigCacheTTL is how long a remote server's configuration is reused; it only
+	// changes when that server is reconfigured.
+	remoteConfigCacheTTL = 30 * time.Second
+)
+
+var (
+	serverPolls       singleflight.Group // by normalized server address
+	remoteConfigPolls singleflight.Group // by remote base URL
+
+	remoteConfigCache   = map[string]cachedRemoteConfig{}
+	remoteConfigCacheMu sync.Mutex
+)
+
+// serverPollResult is what one poll of a server produced. Every caller that joined the
+// poll receives the same result.
+type serverPollResult struct {
+	metric    *models.ServerMetrics
+	payload   []byte      // trimmed to the fields the dashboard uses, for persistence
+	persisted atomic.Bool // set by the tick that writes payload to storage
+}
+
+// claimPersist reports whether the caller is the first to persist this result.
+func (p *serverPollResult) claimPersist() bool {
+	return p.persisted.CompareAndSwap(false, true)
+}
+
+// federatedPoll is a poll started by startFederatedPolls.
+type federatedPoll struct {
+	server models.ServerEndpoint
+	result <-chan singleflight.Result
+}
+
+// RemoteServerConfig is a monitored server's answer to /api/v1/server-config.
+type RemoteServerConfig struct {
+	StatusCode int
+	Body       []byte
+}
+
+type cachedRemoteConfig struct {
+	config    RemoteServerConfig
+	fetchedAt time.Time
+}
+
+// pollServer polls the server, or waits for the poll already in flight for it.
+func pollServer(server models.ServerEndpoint) (*serverPollResult, error) {
+	result := <-startServerPoll(server)
+	polled, _ := result.Val.(*serverPollResult)
+	return polled, result.Err
+}
+
+// startServerPoll starts polling the server unless a poll is already in flight, and returns
+// the channel that receives the result. The poll is registered before it returns.
+func startServerPoll(server models.ServerEndpoint) <-chan singleflight.Result {
+	address := normalizeServerAddress(server.Address)
+	return serverPolls.DoChan(address, func() (any, error) {
+		return fetchServerSnapshot(server, address)
+	})
+}
+
+// fetchServerSnapshot fetches the server's snapshot, caches its metrics and logs failures.
+// A failure is remembered too, so requests during the rest of the interval report it
+// instead of polling again.
+func fetchServerSnapshot(server models.ServerEndpoint, address string) (polled *serverPollResult, err error) {
+	defer func() {
+		// Recover here: a panic inside singleflight would take the whole process down
+		if r := recover(); r != nil {
+			polled, err = nil, fmt.Errorf("monitoring panic: %v", r)
+			utils.LogErrorWithContext("server-monitoring",
+				fmt.Sprintf("Server monitoring panic for '%s' (%s)", server.Name, address), err)
+		}
+		if err != nil {
+			recordServerPollError(address, err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(backgroundContext(), config.GetEnvConfig().ServerMonitoringTimeout)
+	defer cancel()
+
+	target := server
+	target.Address = address
+	payload, err := fetchServerMonitoringWithContext(ctx, target)
+	if err != nil {
+		// The breaker logs the failures that open the circuit
+		if !errors.Is(err, errCircuitOpen) {
+			utils.LogWarnWithContext("server-monitoring",
+				fmt.Sprintf("Server '%s' (%s) is unavailable", server.Name, address), err)
+		}
+		return nil, err
+	}
+
+	// Parse the full payload; a payload that does not parse is neither cached nor persisted
+	metric, err := updateServerMetricsCache(server, payload)
+	if err != nil {
+		utils.LogWarnWithContext("server-monitoring", fmt.Sprintf("failed to parse server metrics from %s", address), err)
+		return nil, err
+	}
+	// Only the persisted copy is trimmed
+	polled = &serverPollResult{metric: metric, payload: payload}
+	if trimmed, terr := utils.FilterMonitoringPayload(payload); terr == nil && len(trimmed) > 0 {
+		polled.payload = trimmed
+	}
+	return polled, nil
+}
+
+// recordServerPollError marks the server's cached metrics with a failed poll.
+func recordServerPollError(address string, err error) {
+	serverMetricsCacheMu.Lock()
+	defer serverMetricsCacheMu.Unlock()
+	entry := serverMetricsCache[address]
+	entry.polledAt = utils.NowUTC()
+	entry.err = err
+	serverMetricsCache[address] = entry
+}
+
+// startFederatedPolls starts this tick's poll of every monitored server without waiting for
+// them.
+func startFederatedPolls() []federatedPoll {
+	monitoringConfigMu.RLock()
+	cfg := monitoringConfig
+	monitoringConfigMu.RUnlock()
+
+	servers := MonitoredServers(cfg)
+	polls := make([]federatedPoll, 0, len(servers))
+	for _, server := range servers {
+		if utils.IsEmptyOrWhitespace(server.Address) {
+			continue
+		}
+		polls = append(polls, federatedPoll{server: server, result: startServerPoll(server)})
+	}
+	return polls
+}
+
+// persistFederatedPolls waits for the polls and writes each payload to the storage backends,
+// unless another tick already wrote it. One slow or failing server does not hold up the others.
+func persistFederatedPolls(polls []federatedPoll) {
+	if len(polls) == 0 {
+		return
+	}
+
+	monitoringConfigMu.RLock()
+	cfg := monitoringConfig
+	monitoringConfigMu.RUnlock()
+
+	var backends []utils.StorageBackend
+	if cfg != nil {
+		if utils.HasStorage(cfg.Storage, utils.StorageFile) && utils.IsEmptyOrWhitespace(cfg.Path) {
+			utils.LogWarn("persist_server_logs enabled but log path is empty; skipping file persistence")
+		}
+		backends = utils.EnabledStorageBackends(cfg.Storage)
+	}
+
+	var wg sync.WaitGroup
+	for _, poll := range polls {
+		wg.Add(1)
+		go func(poll federatedPoll) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					utils.LogErrorWithContext("server-persistence",
+						fmt.Sprintf("Server persistence panic for '%s' (%s)", poll.server.Name, poll.server.Address),
+						fmt.Errorf("panic: %v", r))
+				}
+			}()
+
+			result := <-poll.result
+			polled, _ := result.Val.(*serverPollResult)
+			if result.Err != nil || polled == nil || utils.IsEmptyOrWhitespace(poll.server.TableName) {
+				return
+			}
+			// Ticks that joined the same slow poll would otherwise store it again
+			if !polled.claimPersist() {
+				return
+			}
+			for _, backend := range backends {
+				if err := backend.WriteServer(poll.server, polled.payload); err != nil {
+					utils.LogWarnWithContext("server-monitoring", fmt.Sprintf("failed to write server log to %s for %s", backend.Name(), poll.server.Address), err)
+				}
+			}
+		}(poll)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(federatedPersistTimeout):
+		utils.LogWarn("server persistence timed out after %s; some servers may still be processing", federatedPersistTimeout)
+	}
+}
+
+// FetchRemoteServerConfig returns the configuration published by the monitored server at
+// baseURL, for the dashboard's view of that server. Answers are reused for a short while,
+// and concurrent requests for the same server share one fetch. Servers whose circuit is
+// open are not contacted.
+func FetchRemoteServerConfig(server models.ServerEndpoint, baseURL string) (RemoteServerConfig, error) {
+	remoteConfigCacheMu.Lock()
+	cached, ok := remoteConfigCache[baseURL]
+	remoteConfigCacheMu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < remoteConfigCacheTTL {
+		return cached.config, nil
+	}
+
+	result, err, _ := remoteConfigPolls.Do(baseURL, func() (any, error) {
+		return fetchRemoteServerConfig(server, baseURL)
+	})
+	if err != nil {
+		return RemoteServerConfig{}, err
+	}
+	return result.(RemoteServerConfig), nil
+}
+
+func fetchRemoteServerConfig(server models.ServerEndpoint, baseURL string) (RemoteServerConfig, error) {
+	if err := serverCircuitError(normalizeServerAddress(server.Address)); err != nil {
+		return RemoteServerConfig{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(backgroundContext(), remoteConfigTimeout)
+	defer cancel()
+	remoteURL := strings.TrimRight(baseURL, "/") + "/api/v1/server-config"
+	req, client, err := utils.NewServerRequest(ctx, server, http.MethodGet, remoteURL, nil)
+	if err != nil {
+		return RemoteServerConfig{}, fmt.Errorf("failed to create remote request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return RemoteServerConfig{}, fmt.Errorf("remote config request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return RemoteServerConfig{}, fmt.Errorf("failed to read remote response: %w", err)
+	}
+
+	remote := RemoteServerConfig{StatusCode: resp.StatusCode, Body: body}
+	// Cache answers, including the 404 of older servers; errors are retried on the next request
+	if (resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices) || resp.StatusCode == http.StatusNotFound {
+		remoteConfigCacheMu.Lock()
+		remoteConfigCache[baseURL] = cachedRemoteConfig{config: remote, fetchedAt: time.Now()}
+		remoteConfigCacheMu.Unlock()
+	}
+	return remote, nil
+}
@@ -0,0 +1,80 @@
+package logics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"go-log/internal/api/models"
+	"go-log/internal/config"
+	"go-log/internal/utils"
+)
+
+func TestPersistFederatedPollsSkipsUnparsedPayload(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("BASE_LOG_FOLDER", dir)
+	config.InitEnvConfig()
+	t.Cleanup(config.InitEnvConfig)
+	cfg := &models.MonitoringConfig{Path: dir, Storage: []string{utils.StorageFile}}
+	utils.InitLogger(cfg)
+	t.Cleanup(func() { utils.InitLogger(nil) })
+
+	monitoringConfigMu.Lock()
+	previous := monitoringConfig
+	monitoringConfig = cfg
+	monitoringConfigMu.Unlock()
+	t.Cleanup(func() {
+		monitoringConfigMu.Lock()
+		monitoringConfig = previous
+		monitoringConfigMu.Unlock()
+	})
+
+	var valid atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !valid.Load() {
+			// Valid JSON, so a storage backend would accept it, but no metrics
+			w.Write([]byte(`{"status":"maintenance"}`))
+			return
+		}
+		w.Write([]byte(`[{"timestamp":"2024-03-01T10:00:00Z","cpu":{"usage_percent":5}}]`))
+	}))
+	t.Cleanup(srv.Close)
+	server := models.ServerEndpoint{Name: "edge", Address: srv.URL, TableName: "edge_db"}
+
+	// Count the raw entries: a payload without metrics yields no snapshot when read back
+	stored := func() int {
+		t.Helper()
+		files, err := filepath.Glob(filepath.Join(dir, "servers", server.TableName, "*.log"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		count := 0
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var entries []models.ServerLogEntry
+			if err := json.Unmarshal(data, &entries); err != nil {
+				t.Fatal(err)
+			}
+			count += len(entries)
+		}
+		return count
+	}
+
+	persistFederatedPolls([]federatedPoll{{server: server, result: startServerPoll(server)}})
+	if n := stored(); n != 0 {
+		t.Fatalf("%d entries stored from a payload that did not parse", n)
+	}
+
+	valid.Store(true)
+	persistFederatedPolls([]federatedPoll{{server: server, result: startServerPoll(server)}})
+	if n := stored(); n != 1 {
+		t.Fatalf("%d entries stored from a valid payload, want 1", n)
+	}
+}
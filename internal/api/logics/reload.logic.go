@@ -0,0 +1,319 @@
+package logics
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-log/internal/api/models"
+	"go-log/internal/utils"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// What triggered a configuration (re)load.
+const (
+	ReloadTriggerStartup = "startup"
+	ReloadTriggerFile    = "file"
+	ReloadTriggerSignal  = "signal"
+	ReloadTriggerAPI     = "api"
+	ReloadTriggerAdmin   = "admin" // a heartbeat or server edited through the admin API
+)
+
+const (
+	configWatchInterval = 2 * time.Second
+	configWatchDebounce = 250 * time.Millisecond // lets editors finish writing before the file is read
+	reloadHistorySize   = 20
+)
+
+// ConfigReloadResult describes the outcome of one configuration load.
+type ConfigReloadResult struct {
+	Trigger         string    `json:"trigger"`
+	Time            time.Time `json:"time"`
+	Success         bool      `json:"success"`
+	Error           string    `json:"error,omitempty"`
+	Changed         []string  `json:"changed,omitempty"`       // top-level config keys that changed
+	ServersAdded    []string  `json:"servers_added,omitempty"` // servers whose tables were created
+	TickerRestarted bool      `json:"ticker_restarted"`
+}
+
+var (
+	reloadMu          sync.Mutex // serializes loads so two triggers never interleave
+	reloadHistory     []ConfigReloadResult
+	reloadHistoryMu   sync.RWMutex
+	configWatcherStop chan struct{}
+	configWatcherMu   sync.Mutex
+)
+
+// ReloadMonitoringConfig re-reads configs.json and applies it if it is valid. An invalid
+// file leaves the running configuration untouched.
+func ReloadMonitoringConfig(trigger string) ConfigReloadResult {
+	InitMonitoringConfig()
+	return loadMonitoringConfig(trigger)
+}
+
+// ConfigReloadHistory returns the most recent loads, newest first.
+func ConfigReloadHistory() []ConfigReloadResult {
+	reloadHistoryMu.RLock()
+	defer reloadHistoryMu.RUnlock()
+	history := make([]ConfigReloadResult, len(reloadHistory))
+	for i, result := range reloadHistory {
+		history[len(reloadHistory)-1-i] = result
+	}
+	return history
+}
+
+func recordReload(result ConfigReloadResult) {
+	reloadHistoryMu.Lock()
+	reloadHistory = append(reloadHistory, result)
+	if over := len(reloadHistory) - reloadHistorySize; over > 0 {
+		reloadHistory = reloadHistory[over:]
+	}
+	reloadHistoryMu.Unlock()
+
+	if result.Success {
+		if result.Trigger != ReloadTriggerStartup {
+			utils.LogInfo("configuration reloaded (%s): changed=%v servers_added=%v ticker_restarted=%t",
+				result.Trigger, result.Changed, result.ServersAdded, result.TickerRestarted)
+		}
+		return
+	}
+	if result.Trigger == ReloadTriggerStartup {
+		utils.LogWarn("configuration load failed, using defaults: %s", result.Error)
+		return
+	}
+	utils.LogWarn("configuration reload (%s) failed, keeping the running configuration: %s", result.Trigger, result.Error)
+}
+
+// loadMonitoringConfig reads, validates and applies configs.json. It must not call
+// GetMonitoringConfig, since it also runs inside InitMonitoringConfig's sync.Once.
+func loadMonitoringConfig(trigger string) ConfigReloadResult {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	result := ConfigReloadResult{Trigger: trigger, Time: utils.NowUTC()}
+
+	// readConfigFromFile validates the file, so a rejected file never reaches applyMonitoringConfig
+	next, sources, err := readConfigFromFile()
+	if err != nil {
+		result.Error = err.Error()
+		monitoringConfigMu.Lock()
+		if len(sources) > 0 {
+			// Do not retry the same broken files on every poll
+			configSources = sources
+		}
+		monitoringConfigMu.Unlock()
+		recordReload(result)
+		return result
+	}
+
+	monitoringConfigMu.Lock()
+	applyMonitoringConfig(next, &result)
+	configSources = sources
+	monitoringConfigMu.Unlock()
+	applyExporters(next)
+
+	result.Success = true
+	recordReload(result)
+	return result
+}
+
+// applyMonitoringConfig swaps in a validated configuration and reconfigures the parts that
+// depend on it, except exporters (see applyExporters). The ticker is only restarted when
+// refresh_time changed. Callers hold monitoringConfigMu.
+func applyMonitoringConfig(next *models.MonitoringConfig, result *ConfigReloadResult) {
+	prev := monitoringConfig
+	result.Changed = changedConfigKeys(prev, next)
+	result.ServersAdded = addedServers(prev, next)
+	forgetServers(removedServers(prev, next))
+
+	monitoringConfig = next
+
+	// Initialize logger and database for API server mode
+	utils.InitLogger(next)
+	utils.InitStorageBackends(next.Storage)
+	// Pre-create per-server tables
+	ensureServerTables(next)
+	startDiscovery(next)
+
+	if prev == nil || prev.RefreshTime != next.RefreshTime || !IsAutoLoggingActive() {
+		startAutoLogging()
+		result.TickerRestarted = true
+		return
+	}
+	if configKeyChanged(result.Changed, "logrotate", "storage") {
+		configureLogRotation()
+	}
+}
+
+// applyExporters reconfigures the exporters for a configuration that was just applied.
+// Stopping an exporter waits for its final flush, so this runs after monitoringConfigMu
+// is released; callers hold reloadMu, which keeps exporter swaps in reload order.
+func applyExporters(next *models.MonitoringConfig) {
+	if err := utils.ConfigureExporters(next.Exporters); err != nil {
+		utils.LogWarnWithContext("config", "failed to configure exporters", err)
+	}
+}
+
+// changedConfigKeys lists the top-level keys whose JSON differs between two configurations.
+func changedConfigKeys(prev, next *models.MonitoringConfig) []string {
+	flatten := func(cfg *models.MonitoringConfig) map[string]json.RawMessage {
+		fields := map[string]json.RawMessage{}
+		if cfg == nil {
+			return fields
+		}
+		if data, err := json.Marshal(cfg); err == nil {
+			json.Unmarshal(data, &fields)
+		}
+		return fields
+	}
+	before, after := flatten(prev), flatten(next)
+
+	var changed []string
+	for key, value := range after {
+		if string(before[key]) != string(value) {
+			changed = append(changed, key)
+		}
+		delete(before, key)
+	}
+	for key := range before {
+		changed = append(changed, key)
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func configKeyChanged(changed []string, keys ...string) bool {
+	for _, c := range changed {
+		for _, key := range keys {
+			if c == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addedServers names the configured servers whose table did not exist in prev.
+func addedServers(prev, next *models.MonitoringConfig) []string {
+	known := map[string]struct{}{}
+	if prev != nil {
+		for _, srv := range prev.Servers {
+			known[utils.SanitizeTableName(srv.TableName)] = struct{}{}
+		}
+	}
+	var added []string
+	for _, srv := range next.Servers {
+		table := utils.SanitizeTableName(srv.TableName)
+		if table == "" {
+			continue
+		}
+		if _, ok := known[table]; !ok {
+			added = append(added, srv.Name)
+		}
+	}
+	return added
+}
+
+// removedServers returns the configured servers of prev whose address is gone from next.
+func removedServers(prev, next *models.MonitoringConfig) []models.ServerEndpoint {
+	if prev == nil {
+		return nil
+	}
+	kept := make(map[string]struct{}, len(next.Servers))
+	for _, srv := range next.Servers {
+		kept[normalizeServerAddress(srv.Address)] = struct{}{}
+	}
+	var removed []models.ServerEndpoint
+	for _, srv := range prev.Servers {
+		if _, ok := kept[normalizeServerAddress(srv.Address)]; !ok {
+			removed = append(removed, srv)
+		}
+	}
+	return removed
+}
+
+// startConfigWatcher reloads the configuration when a file's modification time changes (polled, so
+// it also works on filesystems without change notifications) or on SIGHUP.
+func startConfigWatcher() {
+	configWatcherMu.Lock()
+	defer configWatcherMu.Unlock()
+	if configWatcherStop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	configWatcherStop = stop
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				utils.LogErrorWithContext("config-watcher", "goroutine panic recovered", fmt.Errorf("%v", r))
+			}
+		}()
+		defer signal.Stop(hup)
+
+		ticker := time.NewTicker(configWatchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if configFileChanged() {
+					time.Sleep(configWatchDebounce)
+					loadMonitoringConfig(ReloadTriggerFile)
+				}
+			case <-hup:
+				utils.LogInfo("SIGHUP received, reloading configuration")
+				loadMonitoringConfig(ReloadTriggerSignal)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopConfigWatcher stops watching configs.json.
+func stopConfigWatcher() {
+	configWatcherMu.Lock()
+	defer configWatcherMu.Unlock()
+	if configWatcherStop != nil {
+		close(configWatcherStop)
+		configWatcherStop = nil
+	}
+}
+
+// configFileChanged reports whether the configuration file or one of its includes was
+// modified, created or removed since it was last loaded.
+func configFileChanged() bool {
+	path := getConfigPath()
+	if _, err := os.Stat(path); err != nil {
+		return false
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	monitoringConfigMu.RLock()
+	defer monitoringConfigMu.RUnlock()
+	if _, ok := configSources[path]; !ok {
+		return true
+	}
+	for file, modTime := range configSources {
+		info, err := os.Stat(file)
+		if err != nil {
+			if !modTime.IsZero() {
+				return true
+			}
+			continue
+		}
+		if !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package logics
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-log/internal/config"
+	"go-log/internal/utils"
+)
+
+// Session sources: local users from the user file, or single sign-on through the OIDC
+// provider.
+const (
+	SessionSourceLocal = "local"
+	SessionSourceOIDC  = "oidc"
+)
+
+// Session is a signed-in dashboard user. Sessions live in memory, so a restart signs
+// everyone out.
+type Session struct {
+	ID        string
+	Username  string
+	Subject   string // provider subject of OIDC users
+	Role      string // role of OIDC users; local users take theirs from the user file
+	Source    string
+	CSRFToken string // sent back by the dashboard on every POST, PUT and DELETE
+	CreatedAt time.Time
+	LastSeen  time.Time
+	ExpiresAt time.Time
+}
+
+var (
+	sessions   = map[string]*Session{}
+	sessionsMu sync.Mutex
+)
+
+// LoginUser checks username and password against the user file and starts a session.
+func LoginUser(username, password string) (*Session, *utils.DashboardUser, error) {
+	user, err := utils.AuthenticateUser(username, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	session, err := startSession(Session{Username: user.Username, Role: user.Role, Source: SessionSourceLocal})
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, user, nil
+}
+
+// startSession stores a new session for template with fresh ID and CSRF token.
+func startSession(template Session) (*Session, error) {
+	id, err := RandomToken()
+	if err != nil {
+		return nil, err
+	}
+	csrf, err := RandomToken()
+	if err != nil {
+		return nil, err
+	}
+	now := utils.NowUTC()
+	session := &template
+	session.ID = id
+	session.CSRFToken = csrf
+	session.CreatedAt = now
+	session.LastSeen = now
+	session.ExpiresAt = now.Add(config.GetEnvConfig().SessionTTL)
+
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	pruneSessionsLocked(now)
+	sessions[id] = session
+	copied := *session
+	return &copied, nil
+}
+
+// LookupSession returns the session with id and the user it belongs to. Expired and idle
+// sessions, and sessions of local users that were removed or disabled since, are ended.
+// OIDC users keep the role they signed in with until the session ends.
+func LookupSession(id string) (*Session, *utils.DashboardUser, error) {
+	if id == "" {
+		return nil, nil, utils.ErrMissingToken
+	}
+	now := utils.NowUTC()
+
+	sessionsMu.Lock()
+	session, ok := sessions[id]
+	if ok && !sessionActive(session, now) {
+		delete(sessions, id)
+		ok = false
+	}
+	var copied Session
+	if ok {
+		session.LastSeen = now
+		copied = *session
+	}
+	sessionsMu.Unlock()
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: the session has expired", utils.ErrTokenExpired)
+	}
+
+	if copied.Source == SessionSourceOIDC {
+		return &copied, &utils.DashboardUser{Username: copied.Username, Role: copied.Role}, nil
+	}
+	user, err := utils.LookupUser(copied.Username)
+	if err != nil {
+		EndSession(id)
+		return nil, nil, err
+	}
+	return &copied, user, nil
+}
+
+// EndSession signs a session out.
+func EndSession(id string) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	delete(sessions, id)
+}
+
+func sessionActive(session *Session, now time.Time) bool {
+	if !now.Before(session.ExpiresAt) {
+		return false
+	}
+	idle := config.GetEnvConfig().SessionIdleTimeout
+	return idle <= 0 || now.Sub(session.LastSeen) < idle
+}
+
+func pruneSessionsLocked(now time.Time) {
+	for id, session := range sessions {
+		if !sessionActive(session, now) {
+			delete(sessions, id)
+		}
+	}
+}
+
+// RandomToken returns 32 random bytes, base64url encoded, for session IDs and CSRF tokens.
+func RandomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
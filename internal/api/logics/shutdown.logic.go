@@ -0,0 +1,100 @@
+package logics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-log/internal/utils"
+)
+
+// abortGracePeriod is how long cancelled background work gets to return once the
+// shutdown deadline has passed.
+const abortGracePeriod = 5 * time.Second
+
+var (
+	// backgroundCtx is the parent of every collector and federation fetch. It is cancelled
+	// when shutdown stops waiting, so outstanding requests fail fast instead of running
+	// into their own timeouts.
+	backgroundCtx, cancelBackground = context.WithCancel(context.Background())
+
+	backgroundMu   sync.Mutex
+	backgroundWork sync.WaitGroup
+	shuttingDown   bool
+)
+
+// backgroundContext returns the context collectors derive their request contexts from.
+func backgroundContext() context.Context {
+	return backgroundCtx
+}
+
+// beginBackgroundWork registers a unit of collection or delivery work that shutdown must
+// wait for. It returns false once shutdown has started; the caller then skips the work.
+// Every true result must be paired with endBackgroundWork.
+func beginBackgroundWork() bool {
+	backgroundMu.Lock()
+	defer backgroundMu.Unlock()
+	if shuttingDown {
+		return false
+	}
+	backgroundWork.Add(1)
+	return true
+}
+
+func endBackgroundWork() {
+	backgroundWork.Done()
+}
+
+// isShuttingDown reports whether Shutdown has been called.
+func isShuttingDown() bool {
+	backgroundMu.Lock()
+	defer backgroundMu.Unlock()
+	return shuttingDown
+}
+
+// Shutdown stops the collectors and waits for in-flight work (a collection round, server
+// log persistence, an agent push) to finish, so its results are written before storage is
+// closed. When ctx expires first, outstanding fetches are cancelled. Snapshots an agent has
+// not delivered yet are spooled to its offline buffer.
+func Shutdown(ctx context.Context) error {
+	backgroundMu.Lock()
+	shuttingDown = true
+	backgroundMu.Unlock()
+
+	CleanupAllGoroutines()
+	stopDiscovery()
+
+	utils.LogInfo("shutdown: waiting for in-flight collection to finish...")
+	var err error
+	if !waitForBackgroundWork(ctx) {
+		err = fmt.Errorf("background work still running at the shutdown deadline: %w", ctx.Err())
+		utils.LogWarn("shutdown: deadline reached, cancelling outstanding fetches")
+		cancelBackground()
+		abortCtx, cancel := context.WithTimeout(context.Background(), abortGracePeriod)
+		defer cancel()
+		if !waitForBackgroundWork(abortCtx) {
+			utils.LogWarn("shutdown: background work did not stop within %s", abortGracePeriod)
+		}
+	}
+	cancelBackground()
+
+	spoolAgentPending()
+	utils.LogInfo("shutdown: collectors stopped")
+	return err
+}
+
+// waitForBackgroundWork reports whether all registered work finished before ctx expired.
+func waitForBackgroundWork(ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		backgroundWork.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,520 @@
+package logics
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-log/internal/api/models"
+	"go-log/internal/utils"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validation codes reported for configs.json.
+const (
+	ConfigCodeInvalidJSON      = "INVALID_JSON"
+	ConfigCodeInvalidType      = "INVALID_TYPE"
+	ConfigCodeUnknownKey       = "UNKNOWN_KEY"
+	ConfigCodeRequired         = "REQUIRED"
+	ConfigCodeInvalidValue     = "INVALID_VALUE"
+	ConfigCodeInvalidDuration  = "INVALID_DURATION"
+	ConfigCodeInvalidURL       = "INVALID_URL"
+	ConfigCodeUnknownStorage   = "UNKNOWN_STORAGE"
+	ConfigCodeStorageAlias     = "STORAGE_ALIAS"
+	ConfigCodeDuplicateStorage = "DUPLICATE_STORAGE"
+	ConfigCodeDuplicateName    = "DUPLICATE_NAME"
+	ConfigCodeDuplicateAddress = "DUPLICATE_ADDRESS"
+	ConfigCodeDuplicateTable   = "DUPLICATE_TABLE"
+	ConfigCodeReservedTable    = "RESERVED_TABLE"
+	ConfigCodeNoEffect         = "NO_EFFECT"
+)
+
+// ConfigValidation collects the findings for one configuration document. Every finding is
+// a validation CategorizedError whose "path" context is a JSON path such as
+// $.servers[1].table_name. Errors make the configuration unusable; warnings do not.
+type ConfigValidation struct {
+	Errors   []*utils.CategorizedError
+	Warnings []*utils.CategorizedError
+}
+
+// Valid reports whether no errors were found.
+func (v *ConfigValidation) Valid() bool { return len(v.Errors) == 0 }
+
+// Err returns v as an error wrapping ErrInvalidConfig, or nil when the configuration is valid.
+func (v *ConfigValidation) Err() error {
+	if v.Valid() {
+		return nil
+	}
+	return v
+}
+
+func (v *ConfigValidation) Error() string {
+	parts := make([]string, 0, len(v.Errors))
+	for _, e := range v.Errors {
+		parts = append(parts, fmt.Sprintf("%s: %s", ConfigIssuePath(e), e.Message))
+	}
+	return fmt.Sprintf("%v: %s", utils.ErrInvalidConfig, strings.Join(parts, "; "))
+}
+
+func (v *ConfigValidation) Unwrap() error { return utils.ErrInvalidConfig }
+
+// ConfigIssuePath returns the JSON path a validation finding refers to.
+func ConfigIssuePath(e *utils.CategorizedError) string {
+	if path, ok := e.Context["path"].(string); ok {
+		return path
+	}
+	return "$"
+}
+
+func (v *ConfigValidation) errorf(path, code, format string, args ...any) {
+	v.Errors = append(v.Errors, utils.NewValidationError(code, fmt.Sprintf(format, args...), nil).WithContext("path", path))
+}
+
+func (v *ConfigValidation) warnf(path, code, format string, args ...any) {
+	v.Warnings = append(v.Warnings, utils.NewValidationError(code, fmt.Sprintf(format, args...), nil).WithContext("path", path))
+}
+
+// ParseMonitoringConfig decodes a configs.json document and validates it. The returned
+// configuration is nil when the document cannot be decoded.
+func ParseMonitoringConfig(data []byte) (*models.MonitoringConfig, *ConfigValidation) {
+	cfg, v := decodeMonitoringConfig(data)
+	if cfg != nil {
+		validateMonitoringConfig(v, cfg)
+	}
+	return cfg, v
+}
+
+// decodeMonitoringConfig decodes a document, reporting syntax errors, type errors and
+// unknown keys. Semantic checks are left to validateMonitoringConfig.
+func decodeMonitoringConfig(data []byte) (*models.MonitoringConfig, *ConfigValidation) {
+	v := &ConfigValidation{}
+
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		v.errorf("$", ConfigCodeInvalidJSON, "%s", describeJSONError(data, err))
+		return nil, v
+	}
+	if _, ok := raw.(map[string]any); !ok {
+		v.errorf("$", ConfigCodeInvalidType, "configuration must be a JSON object")
+		return nil, v
+	}
+	checkUnknownKeys(v, raw, reflect.TypeOf(models.MonitoringConfig{}), "$")
+
+	var cfg models.MonitoringConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			v.errorf(jsonFieldPath(typeErr.Field), ConfigCodeInvalidType, "expected %s, got %s", typeErr.Type, typeErr.Value)
+		} else {
+			v.errorf("$", ConfigCodeInvalidJSON, "%v", err)
+		}
+		return nil, v
+	}
+	return &cfg, v
+}
+
+// jsonFieldPath turns encoding/json's "servers.0.name" into "$.servers[0].name".
+func jsonFieldPath(field string) string {
+	path := "$"
+	for _, part := range strings.Split(field, ".") {
+		if _, err := strconv.Atoi(part); err == nil {
+			path += "[" + part + "]"
+		} else if part != "" {
+			path += "." + part
+		}
+	}
+	return path
+}
+
+// describeJSONError adds the line and column to a JSON syntax error.
+func describeJSONError(data []byte, err error) string {
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return err.Error()
+	}
+	before := data[:min(int(syntaxErr.Offset), len(data))]
+	line := strings.Count(string(before), "\n") + 1
+	column := len(before) - strings.LastIndex(string(before), "\n")
+	return fmt.Sprintf("%v (line %d, column %d)", err, line, column)
+}
+
+// checkUnknownKeys reports object keys that do not map to a field of t. Keys are matched
+// case-insensitively, like encoding/json does.
+func checkUnknownKeys(v *ConfigValidation, raw any, t reflect.Type, path string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			return
+		}
+		fields := map[string]reflect.Type{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			fields[strings.ToLower(name)] = field.Type
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fieldType, known := fields[strings.ToLower(key)]
+			if !known {
+				v.errorf(path+"."+key, ConfigCodeUnknownKey, "unknown key %q", key)
+				continue
+			}
+			checkUnknownKeys(v, obj[key], fieldType, path+"."+key)
+		}
+	case reflect.Slice:
+		if items, ok := raw.([]any); ok {
+			for i, item := range items {
+				checkUnknownKeys(v, item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case reflect.Map:
+		if obj, ok := raw.(map[string]any); ok {
+			for key, item := range obj {
+				checkUnknownKeys(v, item, t.Elem(), path+"."+key)
+			}
+		}
+	}
+}
+
+func validHTTPURL(raw string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// checkDuration validates an optional Go duration; empty means the documented default.
+func checkDuration(v *ConfigValidation, path, value string) {
+	if strings.TrimSpace(value) == "" {
+		return
+	}
+	if d, err := time.ParseDuration(strings.TrimSpace(value)); err != nil || d <= 0 {
+		v.errorf(path, ConfigCodeInvalidDuration, "%q is not a positive duration such as \"2s\" or \"1m\"", value)
+	}
+}
+
+// validateMonitoringConfig checks the semantics of a decoded configuration.
+func validateMonitoringConfig(v *ConfigValidation, cfg *models.MonitoringConfig) {
+	if strings.TrimSpace(cfg.RefreshTime) == "" {
+		v.warnf("$.refresh_time", ConfigCodeRequired, "refresh_time is not set; defaulting to 2s")
+	} else {
+		checkDuration(v, "$.refresh_time", cfg.RefreshTime)
+	}
+
+	seenStorage := map[string]int{}
+	for i, name := range cfg.Storage {
+		path := fmt.Sprintf("$.storage[%d]", i)
+		backend, ok := utils.GetStorageBackend(name)
+		if !ok {
+			v.errorf(path, ConfigCodeUnknownStorage, "unknown storage backend %q (available: %s)", name, strings.Join(utils.RegisteredStorageBackends(), ", "))
+			continue
+		}
+		if canonical := backend.Name(); name != canonical {
+			v.warnf(path, ConfigCodeStorageAlias, "%q is not the canonical name; use %q", name, canonical)
+		}
+		if first, dup := seenStorage[backend.Name()]; dup {
+			v.errorf(path, ConfigCodeDuplicateStorage, "storage backend %q is already listed at $.storage[%d]", backend.Name(), first)
+			continue
+		}
+		seenStorage[backend.Name()] = i
+	}
+	if utils.HasStorage(cfg.Storage, utils.StorageFile) && utils.IsEmptyOrWhitespace(cfg.Path) {
+		v.errorf("$.path", ConfigCodeRequired, "path is required when storage includes %q", utils.StorageFile)
+	}
+
+	if cfg.LogRotate != nil {
+		if cfg.LogRotate.MaxAgeDays < 0 {
+			v.errorf("$.logrotate.max_age_days", ConfigCodeInvalidValue, "must not be negative")
+		}
+		if cfg.LogRotate.CompressAfterDays < 0 {
+			v.errorf("$.logrotate.compress_after_days", ConfigCodeInvalidValue, "must not be negative")
+		}
+	}
+
+	validateHeartbeats(v, cfg.Heartbeat)
+	tables := validateServers(v, cfg.Servers)
+	validateExporters(v, cfg.Exporters)
+	validateAgent(v, cfg.Agent)
+	validateIngest(v, cfg.Ingest, tables)
+	validateChildTableCollisions(v, tables)
+	validateDiscovery(v, cfg.Discovery)
+}
+
+// validateChildTableCollisions rejects a table that is also the disks, heartbeats or legacy
+// table of another configured table (or of the local host's), since the two would share
+// storage and the listing could not tell them apart.
+func validateChildTableCollisions(v *ConfigValidation, tables map[string]string) {
+	owners := map[string]string{}
+	for _, child := range utils.MetricsChildTableNames("default") {
+		owners[child] = "the local host"
+	}
+	for table, path := range tables {
+		for _, child := range utils.MetricsChildTableNames(table) {
+			owners[child] = path
+		}
+	}
+
+	names := make([]string, 0, len(tables))
+	for table := range tables {
+		names = append(names, table)
+	}
+	sort.Strings(names)
+	for _, table := range names {
+		if owner, taken := owners[table]; taken {
+			v.errorf(tables[table]+".table_name", ConfigCodeReservedTable, "table %q is used for the child tables of %s", table, owner)
+		}
+	}
+}
+
+func validateHeartbeats(v *ConfigValidation, heartbeats []models.ServerConfig) {
+	names := map[string]int{}
+	for i, hb := range heartbeats {
+		path := fmt.Sprintf("$.heartbeat[%d]", i)
+		if utils.IsEmptyOrWhitespace(hb.Name) {
+			v.errorf(path+".name", ConfigCodeRequired, "name is required")
+		} else if first, dup := names[strings.TrimSpace(hb.Name)]; dup {
+			v.warnf(path+".name", ConfigCodeDuplicateName, "name %q is already used by $.heartbeat[%d]", hb.Name, first)
+		} else {
+			names[strings.TrimSpace(hb.Name)] = i
+		}
+		if !validHTTPURL(hb.URL) {
+			v.errorf(path+".url", ConfigCodeInvalidURL, "url must be an absolute http(s) URL")
+		}
+		if hb.Timeout < 0 {
+			v.errorf(path+".timeout", ConfigCodeInvalidValue, "timeout must not be negative")
+		}
+	}
+}
+
+// validateServers checks pulled servers and returns their tables, keyed by sanitized name.
+func validateServers(v *ConfigValidation, servers []models.ServerEndpoint) map[string]string {
+	tables := map[string]string{}
+	addresses := map[string]int{}
+	for i, srv := range servers {
+		path := fmt.Sprintf("$.servers[%d]", i)
+		if utils.IsEmptyOrWhitespace(srv.Name) {
+			v.errorf(path+".name", ConfigCodeRequired, "name is required")
+		}
+		if !validHTTPURL(srv.Address) {
+			v.errorf(path+".address", ConfigCodeInvalidURL, "address must be an absolute http(s) URL")
+		} else if first, dup := addresses[normalizeServerAddress(srv.Address)]; dup {
+			v.errorf(path+".address", ConfigCodeDuplicateAddress, "address %q is already used by $.servers[%d]", srv.Address, first)
+		} else {
+			addresses[normalizeServerAddress(srv.Address)] = i
+		}
+
+		validateServerAuth(v, path+".auth", srv.Token, srv.Auth, srv.Address)
+
+		table := utils.SanitizeTableName(srv.TableName)
+		switch {
+		case table == "":
+			v.warnf(path+".table_name", ConfigCodeRequired, "table_name is empty; this server's logs are not persisted")
+		case table == "default":
+			v.errorf(path+".table_name", ConfigCodeReservedTable, "table %q is reserved for the local host", table)
+		default:
+			if other, dup := tables[table]; dup {
+				v.errorf(path+".table_name", ConfigCodeDuplicateTable, "table_name %q collides with %s (both map to table %q)", srv.TableName, other, table)
+				continue
+			}
+			tables[table] = path
+		}
+	}
+	return tables
+}
+
+func validateExporters(v *ConfigValidation, exporters []models.ExporterConfig) {
+	for i, exp := range exporters {
+		path := fmt.Sprintf("$.exporters[%d]", i)
+		if err := utils.ValidateExporterConfig(exp); err != nil {
+			v.errorf(path, ConfigCodeInvalidValue, "%v", err)
+		}
+		if exp.BatchSize < 0 {
+			v.errorf(path+".batch_size", ConfigCodeInvalidValue, "batch_size must not be negative")
+		}
+		if exp.MaxRetries < 0 {
+			v.errorf(path+".max_retries", ConfigCodeInvalidValue, "max_retries must not be negative")
+		}
+	}
+}
+
+func validateAgent(v *ConfigValidation, agent *models.AgentConfig) {
+	if agent == nil || !agent.Enabled {
+		return
+	}
+	if utils.IsEmptyOrWhitespace(agent.Name) {
+		v.errorf("$.agent.name", ConfigCodeRequired, "name is required when the agent is enabled")
+	}
+	if utils.IsEmptyOrWhitespace(agent.Token) {
+		v.errorf("$.agent.token", ConfigCodeRequired, "token is required when the agent is enabled")
+	}
+	if !validHTTPURL(agent.CollectorURL) {
+		v.errorf("$.agent.collector_url", ConfigCodeInvalidURL, "collector_url must be an absolute http(s) URL")
+	}
+	checkDuration(v, "$.agent.timeout", agent.Timeout)
+	if agent.MaxBuffered < 0 {
+		v.errorf("$.agent.max_buffered", ConfigCodeInvalidValue, "max_buffered must not be negative")
+	}
+}
+
+func validateIngest(v *ConfigValidation, ingest *models.IngestConfig, serverTables map[string]string) {
+	if ingest == nil || !ingest.Enabled {
+		return
+	}
+	names := map[string]int{}
+	for i, a := range ingest.Agents {
+		path := fmt.Sprintf("$.ingest.agents[%d]", i)
+		key := agentKey(a.Name)
+		if key == "" {
+			v.errorf(path+".name", ConfigCodeRequired, "name is required")
+			continue
+		}
+		if first, dup := names[key]; dup {
+			v.errorf(path+".name", ConfigCodeDuplicateName, "agent %q is already listed at $.ingest.agents[%d]", a.Name, first)
+			continue
+		}
+		names[key] = i
+		if utils.IsEmptyOrWhitespace(a.Token) {
+			v.errorf(path+".token", ConfigCodeRequired, "token is required")
+		}
+		table := agentEndpoint(a.Name, a.TableName).TableName
+		if table == "default" {
+			v.errorf(path+".table_name", ConfigCodeReservedTable, "table %q is reserved for the local host", table)
+		} else if other, dup := serverTables[table]; dup {
+			v.errorf(path+".table_name", ConfigCodeDuplicateTable, "agent table %q collides with %s", table, other)
+		} else {
+			serverTables[table] = path
+		}
+	}
+	if ingest.AutoRegister && utils.IsEmptyOrWhitespace(ingest.RegistrationToken) {
+		v.warnf("$.ingest.auto_register", ConfigCodeNoEffect, "auto_register has no effect without registration_token")
+	}
+}
+
+func validateDiscovery(v *ConfigValidation, providers []models.DiscoveryConfig) {
+	for i, dc := range providers {
+		path := fmt.Sprintf("$.discovery[%d]", i)
+		switch strings.ToLower(strings.TrimSpace(dc.Type)) {
+		case DiscoveryFile:
+			if utils.IsEmptyOrWhitespace(dc.Path) {
+				v.errorf(path+".path", ConfigCodeRequired, "path is required for file discovery")
+			}
+		case DiscoveryDNS:
+			if utils.IsEmptyOrWhitespace(dc.Name) {
+				v.errorf(path+".name", ConfigCodeRequired, "name is required for dns discovery")
+			}
+			if scheme := strings.ToLower(strings.TrimSpace(dc.Scheme)); scheme != "" && scheme != "http" && scheme != "https" {
+				v.errorf(path+".scheme", ConfigCodeInvalidValue, "scheme must be http or https")
+			}
+		case DiscoveryHTTP:
+			if !validHTTPURL(dc.URL) {
+				v.errorf(path+".url", ConfigCodeInvalidURL, "url must be an absolute http(s) URL")
+			}
+		default:
+			v.errorf(path+".type", ConfigCodeInvalidValue, "type must be one of %s, %s, %s", DiscoveryFile, DiscoveryDNS, DiscoveryHTTP)
+		}
+		checkDuration(v, path+".refresh_interval", dc.RefreshInterval)
+		validateServerAuth(v, path+".server_auth", "", dc.ServerAuth, "")
+	}
+}
+
+// validateServerAuth checks the credentials presented to a polled server. token is the
+// server's top-level token; address is empty for discovery defaults, whose targets are
+// not known yet.
+func validateServerAuth(v *ConfigValidation, path, token string, auth *models.ServerAuth, address string) {
+	if auth == nil {
+		return
+	}
+	if auth.Token != "" && token != "" && auth.Token != token {
+		v.errorf(path+".token", ConfigCodeInvalidValue, "token conflicts with the server's top-level token")
+	}
+	if auth.Token != "" || token != "" {
+		if auth.Username != "" {
+			v.errorf(path+".username", ConfigCodeInvalidValue, "use either a bearer token or basic auth, not both")
+		}
+	}
+	if auth.Password != "" && auth.Username == "" {
+		v.errorf(path+".username", ConfigCodeRequired, "username is required with a password")
+	}
+
+	isHTTPS := strings.HasPrefix(strings.ToLower(strings.TrimSpace(address)), "https://")
+	switch {
+	case auth.ClientCert == "" && auth.ClientKey == "":
+	case auth.ClientCert == "" || auth.ClientKey == "":
+		v.errorf(path+".client_cert", ConfigCodeRequired, "client_cert and client_key must be set together")
+	default:
+		if _, err := tls.LoadX509KeyPair(auth.ClientCert, auth.ClientKey); err != nil {
+			v.errorf(path+".client_cert", ConfigCodeInvalidValue, "cannot load the client certificate: %v", err)
+		}
+		if address != "" && !isHTTPS {
+			v.errorf(path+".client_cert", ConfigCodeInvalidValue, "a client certificate needs an https address")
+		}
+	}
+	if auth.CACert != "" {
+		if _, err := utils.LoadCertPool(auth.CACert); err != nil {
+			v.errorf(path+".ca_cert", ConfigCodeInvalidValue, "%v", err)
+		}
+	}
+	if (auth.CACert != "" || auth.ServerName != "") && address != "" && !isHTTPS {
+		v.warnf(path, ConfigCodeNoEffect, "ca_cert and server_name only apply to https addresses")
+	}
+
+	if strings.ContainsAny(auth.HMACKeyID, ":, ") {
+		v.errorf(path+".hmac_key_id", ConfigCodeInvalidValue, "hmac_key_id must not contain colons, commas or spaces")
+	}
+	switch {
+	case auth.HMACSecret != "" && len(auth.HMACSecret) < 16:
+		v.errorf(path+".hmac_secret", ConfigCodeInvalidValue, "hmac_secret must be at least 16 characters")
+	case auth.HMACSecret == "" && auth.HMACKeyID != "":
+		v.warnf(path+".hmac_key_id", ConfigCodeNoEffect, "hmac_key_id has no effect without hmac_secret")
+	}
+}
+
+// configIssueJSON is the machine-readable form of a finding, used by "go-log config check --json".
+type configIssueJSON struct {
+	Severity string `json:"severity"`
+	Path     string `json:"path"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
+
+// MarshalJSON renders the findings as {"valid": bool, "errors": [...], "warnings": [...]}.
+func (v *ConfigValidation) MarshalJSON() ([]byte, error) {
+	convert := func(severity string, issues []*utils.CategorizedError) []configIssueJSON {
+		out := make([]configIssueJSON, 0, len(issues))
+		for _, e := range issues {
+			out = append(out, configIssueJSON{Severity: severity, Path: ConfigIssuePath(e), Code: e.Code, Message: e.Message})
+		}
+		return out
+	}
+	return json.Marshal(map[string]any{
+		"valid":    v.Valid(),
+		"errors":   convert("error", v.Errors),
+		"warnings": convert("warning", v.Warnings),
+	})
+}
+
+// FormatConfigIssues renders findings one per line for logs and the CLI.
+func FormatConfigIssues(issues []*utils.CategorizedError, severity string) []string {
+	lines := make([]string, 0, len(issues))
+	for _, e := range issues {
+		lines = append(lines, fmt.Sprintf("%-7s %s: %s (%s)", strings.ToUpper(severity), ConfigIssuePath(e), e.Message, e.Code))
+	}
+	return lines
+}
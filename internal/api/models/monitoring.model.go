@@ -15,6 +15,18 @@ type SystemMonitoring struct {
 	Process       Process         `json:"process"`
 	ServerMetrics []ServerMetrics `json:"server_metrics,omitempty"`
 	Heartbeat     []ServerCheck   `json:"heartbeat"`
+	// Set only on downsampled points: the number of raw snapshots folded into the bucket
+	// and per-metric statistics keyed by storage column name (e.g. "cpu_usage_percent").
+	Samples    int                    `json:"samples,omitempty"`
+	Aggregates map[string]MetricStats `json:"aggregates,omitempty"`
+}
+
+// MetricStats summarizes one metric over a downsampling bucket. The snapshot fields carry Avg.
+type MetricStats struct {
+	Avg float64  `json:"avg"`
+	Min float64  `json:"min"`
+	Max float64  `json:"max"`
+	P95 *float64 `json:"p95,omitempty"` // nil when the source cannot compute percentiles (TimescaleDB rollups)
 }
 
 type CPU struct {
@@ -63,22 +75,81 @@ const (
 type MonitoringConfig struct {
     Path              string           `json:"path"`         // Log file destination path
     RefreshTime       string           `json:"refresh_time"` // Refresh interval (e.g., "2s", "30s")
-    Storage           []string         `json:"storage"`      // Storage backends: any of ["file", "sqlite", "postgresql"]. Empty = none.
+    Storage           []string         `json:"storage"`      // Storage backends: any of ["file", "sqlite", "postgresql", "tsdb"]. Empty = none.
     PersistServerLogs bool             `json:"persist_server_logs"`
     Heartbeat         []ServerConfig   `json:"heartbeat"`
     Servers           []ServerEndpoint `json:"servers"`
     LogRotate         *LogRotateConfig `json:"logrotate,omitempty"`
+    Exporters         []ExporterConfig `json:"exporters,omitempty"` // Remote-write targets fed on every tick
+    Agent             *AgentConfig     `json:"agent,omitempty"`     // Push this node's snapshots to a central collector
+    Ingest            *IngestConfig    `json:"ingest,omitempty"`    // Accept snapshots pushed by agents (collector side)
+    Discovery         []DiscoveryConfig `json:"discovery,omitempty"` // Providers that add and remove servers at runtime
+}
+
+// DiscoveryConfig describes one source of monitored servers. Discovered servers are polled
+// like entries in "servers" and disappear when the provider stops returning them.
+type DiscoveryConfig struct {
+	Type            string      `json:"type"`                       // "file", "dns" or "http"
+	Path            string      `json:"path,omitempty"`             // file: directory of *.json / *.yaml / *.yml server lists
+	Name            string      `json:"name,omitempty"`             // dns: SRV record, e.g. _golog._tcp.example.com
+	Scheme          string      `json:"scheme,omitempty"`           // dns: scheme of discovered targets (default http)
+	URL             string      `json:"url,omitempty"`              // http: endpoint returning a server list
+	Token           string      `json:"token,omitempty"`            // http: sent as "Authorization: Bearer <token>"
+	RefreshInterval string      `json:"refresh_interval,omitempty"` // How often to re-discover (default 30s)
+	ServerAuth      *ServerAuth `json:"server_auth,omitempty"`      // Credentials for discovered servers that bring none
+}
+
+// AgentConfig turns this node into a push agent: every tick its snapshot is sent to
+// POST <collector_url>/api/v1/ingest, so the collector never has to reach the agent.
+type AgentConfig struct {
+	Enabled           bool   `json:"enabled"`
+	CollectorURL      string `json:"collector_url"`                // Base URL of the central node, e.g. https://central:3500
+	Name              string `json:"name"`                         // Agent identity on the collector
+	Token             string `json:"token"`                        // Per-agent secret, sent as "Authorization: Bearer <token>"
+	RegistrationToken string `json:"registration_token,omitempty"` // Lets the collector auto-register this agent on first push
+	Timeout           string `json:"timeout,omitempty"`            // Per-push timeout (default 10s)
+	MaxBuffered       int    `json:"max_buffered,omitempty"`       // Snapshots kept on disk while the collector is unreachable (default 50000)
+}
+
+// IngestConfig controls which agents may push to this node.
+type IngestConfig struct {
+	Enabled           bool          `json:"enabled"`
+	Agents            []AgentAccess `json:"agents,omitempty"`             // Known agents and their tokens
+	AutoRegister      bool          `json:"auto_register,omitempty"`      // Accept unknown agents that present registration_token
+	RegistrationToken string        `json:"registration_token,omitempty"` // Shared enrolment secret; auto_register is off without it
+}
+
+// AgentAccess is one agent allowed to push snapshots.
+type AgentAccess struct {
+	Name      string `json:"name"`
+	Token     string `json:"token"`
+	TableName string `json:"table_name,omitempty"` // Defaults to the sanitized agent name
+}
+
+// ExporterConfig describes one remote-write target. Durations use Go syntax (e.g. "10s").
+type ExporterConfig struct {
+	Type          string            `json:"type"`                     // "influxdb" (line protocol) or "otlp" (OTLP/HTTP JSON)
+	Name          string            `json:"name,omitempty"`           // Label used in logs; defaults to the type
+	URL           string            `json:"url"`                      // Full write endpoint, e.g. http://influx:8086/api/v2/write?org=o&bucket=b
+	Token         string            `json:"token,omitempty"`          // Sent as "Token <t>" (InfluxDB) or "Bearer <t>" (OTLP)
+	Headers       map[string]string `json:"headers,omitempty"`        // Extra request headers
+	BatchSize     int               `json:"batch_size,omitempty"`     // Snapshots per request (default 50)
+	FlushInterval string            `json:"flush_interval,omitempty"` // Max time a snapshot waits in the batch (default 10s)
+	MaxRetries    int               `json:"max_retries,omitempty"`    // Retries for 429/5xx/network errors (default 3)
+	Timeout       string            `json:"timeout,omitempty"`        // Per-request timeout (default 10s)
 }
 
 type LogRotateConfig struct {
-	Enabled    bool `json:"enabled"`
-	MaxAgeDays int  `json:"max_age_days"`
+	Enabled           bool `json:"enabled"`
+	MaxAgeDays        int  `json:"max_age_days"`
+	CompressAfterDays int  `json:"compress_after_days,omitempty"` // TimescaleDB only; 0 disables compression
 }
 
 type ServerConfig struct {
-	Name    string `json:"name"`
-	URL     string `json:"url"`
-	Timeout int    `json:"timeout"` // Timeout in seconds
+	Name    string            `json:"name"`
+	URL     string            `json:"url"`
+	Timeout int               `json:"timeout"`           // Timeout in seconds
+	Headers map[string]string `json:"headers,omitempty"` // Extra request headers, e.g. Authorization
 }
 
 type NetworkIO struct {
@@ -105,12 +176,46 @@ type ServerMetrics struct {
 	Timestamp         string      `json:"timestamp"`
 	Status            string      `json:"status"`
 	Message           string      `json:"message,omitempty"`
+
+	// Circuit breaker guarding polls to the server; empty for pushing agents
+	CircuitState        CircuitState `json:"circuit_state,omitempty"`
+	ConsecutiveFailures int          `json:"consecutive_failures,omitempty"`
+	NextRetry           string       `json:"next_retry,omitempty"` // When an open circuit lets the next poll through
 }
 
+// CircuitState is the state of a server's circuit breaker. A closed circuit polls the server
+// on every tick; an open one skips it until the back-off ends; half-open lets a single
+// probe through to decide between the two.
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
 type ServerEndpoint struct {
-	Name      string `json:"name"`
-	Address   string `json:"address"`
-	TableName string `json:"table_name"`
+	Name      string      `json:"name"`
+	Address   string      `json:"address"`
+	TableName string      `json:"table_name"`
+	Token     string      `json:"token,omitempty"` // Sent as "Authorization: Bearer <token>" when polling the server
+	Auth      *ServerAuth `json:"auth,omitempty"`  // Credentials presented when polling the server
+}
+
+// ServerAuth holds the credentials the collector presents to a server it polls. A bearer
+// token or basic auth goes in the Authorization header, a client certificate is presented
+// over TLS (mTLS), and an HMAC secret signs each request so the server can tell it comes
+// from this collector. They can be combined, except bearer with basic.
+type ServerAuth struct {
+	Token      string `json:"token,omitempty"`       // Sent as "Authorization: Bearer <token>"
+	Username   string `json:"username,omitempty"`    // Basic auth
+	Password   string `json:"password,omitempty"`
+	ClientCert string `json:"client_cert,omitempty"` // PEM certificate file for mTLS
+	ClientKey  string `json:"client_key,omitempty"`  // PEM private key file for mTLS
+	CACert     string `json:"ca_cert,omitempty"`     // PEM bundle that signs the server's certificate (default: system roots)
+	ServerName string `json:"server_name,omitempty"` // Name expected in the server's certificate (default: the address host)
+	HMACKeyID  string `json:"hmac_key_id,omitempty"` // Key ID sent with signatures (default "default")
+	HMACSecret string `json:"hmac_secret,omitempty"` // Shared secret for request signing; must match the server's FEDERATION_HMAC_KEYS
 }
 
 type DiskIO struct {
@@ -9,6 +9,7 @@ import (
 
 	"go-log/internal/api/handlers"
 	"go-log/internal/api/logics"
+	"go-log/internal/utils"
 	webstatic "go-log/web"
 )
 
@@ -19,15 +20,20 @@ func NewRouter() http.Handler {
 	// Global middleware
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
 
 	// Initialize monitoring configuration at startup
 	logics.InitMonitoringConfig()
 
+	// Exports stream for as long as their range takes, so they are mounted outside the timeout
+	setupExportRoutes(r)
+
 	// Setup route groups
-	setupDashboardRoutes(r)
-	setupAPIRoutes(r)
-	setupStaticRoutes(r)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(60 * time.Second))
+		setupDashboardRoutes(r)
+		setupAPIRoutes(r)
+		setupStaticRoutes(r)
+	})
 
 	return r
 }
@@ -40,6 +46,7 @@ func setupDashboardRoutes(r chi.Router) {
 		r.Use(dashboardMiddleware)
 		r.Use(wrapHandlerFuncMiddleware(handlers.RateLimitMiddleware))
 		r.Use(wrapHandlerFuncMiddleware(handlers.CORSMiddleware))
+		r.Use(handlers.RequireScope(utils.ScopeMetricsRead))
 
 		// Main dashboard page
 		r.Get("/", handlers.DashboardHandler)
@@ -53,6 +60,18 @@ func setupDashboardRoutes(r chi.Router) {
 		r.Get("/components/chrome.html", handlers.ChromeComponentHandler)
 		r.Get("/components/hero.html", handlers.HeroSectionHandler)
 	})
+
+	// Login routes - reachable without credentials, only when dashboard is enabled
+	r.Group(func(r chi.Router) {
+		r.Use(dashboardMiddleware)
+		r.Use(wrapHandlerFuncMiddleware(handlers.RateLimitMiddleware))
+
+		r.Get("/login", handlers.LoginPageHandler)
+		r.Post("/login", handlers.LoginHandler)
+		r.Post("/logout", handlers.LogoutHandler)
+		r.Get("/login/oidc", handlers.OIDCLoginHandler)
+		r.Get("/login/oidc/callback", handlers.OIDCCallbackHandler)
+	})
 }
 
 // setupAPIRoutes configures all API endpoints
@@ -61,24 +80,55 @@ func setupAPIRoutes(r chi.Router) {
 		// API middleware
 		r.Use(wrapHandlerFuncMiddleware(handlers.RateLimitMiddleware))
 		r.Use(wrapHandlerFuncMiddleware(handlers.CORSMiddleware))
+		r.Use(wrapHandlerFuncMiddleware(handlers.ClientCertMiddleware))
 
 		// Server configuration endpoint - available regardless of dashboard status
-		r.With(methodMiddleware("GET", "OPTIONS")).Get("/server-config", handlers.ServerConfigHandler)
+		r.With(methodMiddleware("GET", "OPTIONS"), handlers.RequireScope(utils.ScopeMetricsRead)).Get("/server-config", handlers.ServerConfigHandler)
 		
 		// Tables endpoint - requires dashboard enabled
 		r.Group(func(r chi.Router) {
 			r.Use(dashboardMiddleware)
-			r.With(methodMiddleware("GET", "OPTIONS")).Get("/tables", handlers.TablesHandler)
+			r.With(methodMiddleware("GET", "OPTIONS"), handlers.RequireScope(utils.ScopeMetricsRead)).Get("/tables", handlers.TablesHandler)
 		})
 
 		// Monitoring endpoint - core functionality, always available
-		r.With(methodMiddleware("POST", "OPTIONS")).Post("/monitoring", handlers.MonitoringHandler)
+		r.With(methodMiddleware("POST", "OPTIONS"), handlers.RequireScope(utils.ScopeMetricsRead)).Post("/monitoring", handlers.MonitoringHandler)
+
+		// Ingest endpoint - receives snapshots pushed by agents (authenticated by agent token)
+		r.With(methodMiddleware("POST")).Post("/ingest", handlers.IngestHandler)
+
+		// Admin endpoints - reads need config:read, changes config:reload or config:write
+		r.With(handlers.RequireScope(utils.ScopeConfigRead)).Get("/admin/config/reload", handlers.ConfigReloadHandler)
+		r.With(handlers.RequireScope(utils.ScopeConfigReload)).Post("/admin/config/reload", handlers.ConfigReloadHandler)
+		r.With(handlers.RequireScope(utils.ScopeConfigRead)).Get("/admin/heartbeats", handlers.HeartbeatsAdminHandler)
+		r.With(handlers.RequireScope(utils.ScopeConfigWrite)).Post("/admin/heartbeats", handlers.HeartbeatsAdminHandler)
+		r.With(handlers.RequireScope(utils.ScopeConfigRead)).Get("/admin/heartbeats/{name}", handlers.HeartbeatsAdminHandler)
+		r.With(handlers.RequireScope(utils.ScopeConfigWrite)).Put("/admin/heartbeats/{name}", handlers.HeartbeatsAdminHandler)
+		r.With(handlers.RequireScope(utils.ScopeConfigWrite)).Delete("/admin/heartbeats/{name}", handlers.HeartbeatsAdminHandler)
+		r.With(handlers.RequireScope(utils.ScopeConfigRead)).Get("/admin/servers", handlers.ServersAdminHandler)
+		r.With(handlers.RequireScope(utils.ScopeConfigWrite)).Post("/admin/servers", handlers.ServersAdminHandler)
+		r.With(handlers.RequireScope(utils.ScopeConfigRead)).Get("/admin/servers/{name}", handlers.ServersAdminHandler)
+		r.With(handlers.RequireScope(utils.ScopeConfigWrite)).Put("/admin/servers/{name}", handlers.ServersAdminHandler)
+		r.With(handlers.RequireScope(utils.ScopeConfigWrite)).Delete("/admin/servers/{name}", handlers.ServersAdminHandler)
+	})
+}
+
+// setupExportRoutes configures the export endpoint with the API middleware but without a timeout
+func setupExportRoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(wrapHandlerFuncMiddleware(handlers.RateLimitMiddleware))
+		r.Use(wrapHandlerFuncMiddleware(handlers.CORSMiddleware))
+		r.Use(wrapHandlerFuncMiddleware(handlers.ClientCertMiddleware))
+
+		// Export endpoint - streams a time range as CSV/NDJSON/Parquet
+		r.With(methodMiddleware("GET", "OPTIONS"), handlers.RequireScope(utils.ScopeMetricsRead)).Get("/api/v1/export", handlers.ExportHandler)
 	})
 }
 
 // setupStaticRoutes configures static file serving
 func setupStaticRoutes(r chi.Router) {
-	// Static files group - only active when dashboard is enabled
+	// Static files group - only active when dashboard is enabled. The bundles hold no
+	// monitoring data, so they are served without credentials.
 	r.Group(func(r chi.Router) {
 		r.Use(dashboardMiddleware)
 		r.Use(wrapHandlerFuncMiddleware(handlers.RateLimitMiddleware))
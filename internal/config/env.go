@@ -11,7 +11,19 @@ import (
 // EnvConfig holds all environment variable configurations
 type EnvConfig struct {
 	// Server Configuration
-	Port string
+	Port                    string
+	ServerReadHeaderTimeout time.Duration // time allowed to send the request headers
+	ServerReadTimeout       time.Duration // time allowed to send the whole request
+	ServerWriteTimeout      time.Duration // time allowed to write the response; 0 disables
+	ServerIdleTimeout       time.Duration // keep-alive connections idle this long are closed
+	ShutdownTimeout         time.Duration // time to drain requests and collection rounds on shutdown
+
+	// TLS
+	TLSCertFile         string // certificate chain served over HTTPS; enables TLS with TLSKeyFile
+	TLSKeyFile          string
+	TLSClientCAFile     string // CA bundle that client certificates are verified against
+	TLSClientAuth       string // "require" (API routes need a verified client certificate) or "optional"
+	TLSHTTPRedirectPort string // plain HTTP port that redirects to HTTPS; empty disables
 
 	// Security
 	AESSecret string
@@ -24,9 +36,15 @@ type EnvConfig struct {
 	CORSAllowedOrigins string
 
 	// Rate Limiting
-	RateLimitEnabled bool
-	RateLimitRPS     float64
-	RateLimitBurst   int
+	RateLimitEnabled    bool
+	RateLimitRPS        float64
+	RateLimitBurst      int
+	RateLimitPolicies   string        // comma-separated /route=rps:burst and key:<name>=rps:burst overrides
+	RateLimitMaxClients int           // buckets kept in memory; the least recently used are evicted
+	RateLimitClientTTL  time.Duration // buckets idle this long are dropped
+	RateLimitBackend    string        // "memory" or "redis" (shared between instances)
+	RateLimitRedisURL   string        // redis://[:password@]host:port[/db] for the redis backend
+	TrustedProxies      string        // comma-separated IPs/CIDRs whose X-Forwarded-For is believed
 
 	// Logging
 	LogLevel string
@@ -34,6 +52,38 @@ type EnvConfig struct {
 	// Token Validation
 	CheckToken bool
 
+	// Access Control
+	AuthEnabled    bool   // require credentials on every route, in any environment
+	AuthDisabled   bool   // AUTH_ENABLED=false: do not require them just because credentials exist
+	APIKeysPath    string // hashed API keys managed with "go-log keys" (default: ./api-keys.json)
+	JWTDefaultRole string // role of JWTs without a "role" claim
+
+	// Dashboard Login
+	UsersPath            string        // local dashboard users managed with "go-log users" (default: ./users.json)
+	SessionTTL           time.Duration // lifetime of a login session
+	SessionIdleTimeout   time.Duration // a session unused for this long expires early
+	SessionCookieSecure  bool          // send the session cookie over HTTPS only
+	AuthProxyUserHeader  string        // header set by a trusted SSO proxy with the signed-in user
+	AuthProxyRoleHeader  string        // optional header with the user's role
+	AuthProxyDefaultRole string        // role when the proxy sends no role header
+	AuthProxyTrustedIPs  string        // comma-separated IPs/CIDRs allowed to set the proxy headers
+
+	// Single Sign-On (OIDC)
+	OIDCIssuer        string // provider issuer URL; enables OIDC bearer tokens and, with a client ID, SSO login
+	OIDCClientID      string
+	OIDCClientSecret  string // empty for public clients, which rely on PKCE alone
+	OIDCRedirectURL   string // must end in /login/oidc/callback
+	OIDCScopes        string // space-separated scopes requested at login
+	OIDCAudience      string // expected "aud" of API bearer tokens (default: the client ID)
+	OIDCUsernameClaim string // claim naming the user (falls back to email, then sub)
+	OIDCGroupsClaim   string // claim holding the user's groups; dots select nested claims
+	OIDCRoleMapping   string // comma-separated group=role pairs
+	OIDCDefaultRole   string // role of users in no mapped group; empty denies them
+
+	// Federation
+	FederationHMACKeys     string        // comma-separated keyID:secret pairs that may sign requests to this node
+	FederationMaxClockSkew time.Duration // signed requests older or newer than this are rejected
+
 	// Dashboard
 	HasDashboard          bool
 	DashboardDefaultRange string
@@ -41,6 +91,9 @@ type EnvConfig struct {
     // Paths
     BaseLogFolder string
     SQLiteDSN     string
+    TSDBPath      string
+    AuditLogPath  string // JSON lines file recording admin changes (default: <BASE_LOG_FOLDER>/audit.log)
+    AgentRegistryPath string // auto-registered push agents (default: <BASE_LOG_FOLDER>/agents.json)
 
 	// Database
 	DBMaxConnections    int
@@ -58,6 +111,12 @@ type EnvConfig struct {
     MonitorConfigPath       string
     ServerMonitoringTimeout time.Duration
 
+    // Circuit breaker for polled servers
+    ServerBreakerEnabled          bool
+    ServerBreakerFailureThreshold int           // consecutive failed polls that open the circuit
+    ServerBreakerBaseBackoff      time.Duration // first back-off; doubles each time the circuit reopens
+    ServerBreakerMaxBackoff       time.Duration
+
     // Downsampling
     // If false (default): disable server-side downsampling for Postgres historical queries
     // If true: enable downsampling and use DownsampleMaxPoints for bucketing
@@ -68,7 +127,7 @@ type EnvConfig struct {
 
     // Historical Query Storage
     // Which database to use for historical queries (date range queries)
-    // Valid values: "sqlite", "postgresql"
+    // Valid values: "sqlite", "postgresql", "tsdb"
     HistoricalQueryStorage string
 
 	// HTTP Client
@@ -101,7 +160,19 @@ var envConfig *EnvConfig
 func InitEnvConfig() {
     envConfig = &EnvConfig{
 		// Server Configuration
-		Port: getEnvString("PORT", "3500"),
+		Port:                    getEnvString("PORT", "3500"),
+		ServerReadHeaderTimeout: getEnvDuration("SERVER_READ_HEADER_TIMEOUT", 10*time.Second),
+		ServerReadTimeout:       getEnvDuration("SERVER_READ_TIMEOUT", 30*time.Second),
+		ServerWriteTimeout:      getEnvDuration("SERVER_WRITE_TIMEOUT", 90*time.Second),
+		ServerIdleTimeout:       getEnvDuration("SERVER_IDLE_TIMEOUT", 120*time.Second),
+		ShutdownTimeout:         getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+
+		// TLS
+		TLSCertFile:         strings.TrimSpace(getEnvString("TLS_CERT_FILE", "")),
+		TLSKeyFile:          strings.TrimSpace(getEnvString("TLS_KEY_FILE", "")),
+		TLSClientCAFile:     strings.TrimSpace(getEnvString("TLS_CLIENT_CA_FILE", "")),
+		TLSClientAuth:       strings.ToLower(strings.TrimSpace(getEnvString("TLS_CLIENT_AUTH", "require"))),
+		TLSHTTPRedirectPort: strings.TrimSpace(getEnvString("TLS_HTTP_REDIRECT_PORT", "")),
 
 		// Security
 		AESSecret: getEnvString("AES_SECRET", ""),
@@ -114,9 +185,15 @@ func InitEnvConfig() {
 		CORSAllowedOrigins: getEnvString("CORS_ALLOWED_ORIGINS", "http://localhost:3500,http://127.0.0.1:3500"),
 
 		// Rate Limiting
-		RateLimitEnabled: getEnvBool("RATE_LIMIT_ENABLED", true),
-		RateLimitRPS:     getEnvFloat("RATE_LIMIT_RPS", 10.0),
-		RateLimitBurst:   getEnvInt("RATE_LIMIT_BURST", 20),
+		RateLimitEnabled:    getEnvBool("RATE_LIMIT_ENABLED", true),
+		RateLimitRPS:        getEnvFloat("RATE_LIMIT_RPS", 10.0),
+		RateLimitBurst:      getEnvInt("RATE_LIMIT_BURST", 20),
+		RateLimitPolicies:   getEnvString("RATE_LIMIT_POLICIES", ""),
+		RateLimitMaxClients: getEnvInt("RATE_LIMIT_MAX_CLIENTS", 10000),
+		RateLimitClientTTL:  getEnvDuration("RATE_LIMIT_CLIENT_TTL", 10*time.Minute),
+		RateLimitBackend:    strings.ToLower(strings.TrimSpace(getEnvString("RATE_LIMIT_BACKEND", "memory"))),
+		RateLimitRedisURL:   strings.TrimSpace(getEnvString("RATE_LIMIT_REDIS_URL", "")),
+		TrustedProxies:      getEnvString("TRUSTED_PROXIES", "127.0.0.1,::1"),
 
 		// Logging
 		LogLevel: getEnvString("LOG_LEVEL", "INFO"),
@@ -124,6 +201,38 @@ func InitEnvConfig() {
 		// Token Validation
 		CheckToken: getEnvBool("CHECK_TOKEN", false),
 
+		// Access Control
+		AuthEnabled:    getEnvBool("AUTH_ENABLED", false),
+		AuthDisabled:   os.Getenv("AUTH_ENABLED") != "" && !getEnvBool("AUTH_ENABLED", false),
+		APIKeysPath:    getEnvString("API_KEYS_PATH", ""),
+		JWTDefaultRole: strings.ToLower(strings.TrimSpace(getEnvString("JWT_DEFAULT_ROLE", "viewer"))),
+
+		// Dashboard Login
+		UsersPath:            getEnvString("USERS_PATH", ""),
+		SessionTTL:           getEnvDuration("SESSION_TTL", 12*time.Hour),
+		SessionIdleTimeout:   getEnvDuration("SESSION_IDLE_TIMEOUT", time.Hour),
+		SessionCookieSecure:  getEnvBool("SESSION_COOKIE_SECURE", getEnvironment() == "production" || getEnvironment() == "prod" || getEnvString("TLS_CERT_FILE", "") != ""),
+		AuthProxyUserHeader:  strings.TrimSpace(getEnvString("AUTH_PROXY_USER_HEADER", "")),
+		AuthProxyRoleHeader:  strings.TrimSpace(getEnvString("AUTH_PROXY_ROLE_HEADER", "")),
+		AuthProxyDefaultRole: strings.ToLower(strings.TrimSpace(getEnvString("AUTH_PROXY_DEFAULT_ROLE", "viewer"))),
+		AuthProxyTrustedIPs:  getEnvString("AUTH_PROXY_TRUSTED_IPS", "127.0.0.1,::1"),
+
+		// Single Sign-On (OIDC)
+		OIDCIssuer:        strings.TrimSuffix(strings.TrimSpace(getEnvString("OIDC_ISSUER", "")), "/"),
+		OIDCClientID:      strings.TrimSpace(getEnvString("OIDC_CLIENT_ID", "")),
+		OIDCClientSecret:  getEnvString("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:   strings.TrimSpace(getEnvString("OIDC_REDIRECT_URL", "")),
+		OIDCScopes:        getEnvString("OIDC_SCOPES", "openid profile email"),
+		OIDCAudience:      strings.TrimSpace(getEnvString("OIDC_AUDIENCE", "")),
+		OIDCUsernameClaim: strings.TrimSpace(getEnvString("OIDC_USERNAME_CLAIM", "preferred_username")),
+		OIDCGroupsClaim:   strings.TrimSpace(getEnvString("OIDC_GROUPS_CLAIM", "groups")),
+		OIDCRoleMapping:   getEnvString("OIDC_ROLE_MAPPING", ""),
+		OIDCDefaultRole:   strings.ToLower(strings.TrimSpace(getEnvString("OIDC_DEFAULT_ROLE", ""))),
+
+		// Federation
+		FederationHMACKeys:     getEnvString("FEDERATION_HMAC_KEYS", ""),
+		FederationMaxClockSkew: getEnvDuration("FEDERATION_MAX_CLOCK_SKEW", 5*time.Minute),
+
 		// Dashboard
 		HasDashboard:          getEnvBool("HAS_DASHBOARD", true),
 		DashboardDefaultRange: sanitizeDashboardRange(getEnvString("DASHBOARD_DEFAULT_RANGE", "")),
@@ -131,6 +240,9 @@ func InitEnvConfig() {
 		// Paths
         BaseLogFolder: getEnvString("BASE_LOG_FOLDER", "./logs"),
         SQLiteDSN:     getEnvString("SQLITE_DNS", "./monitoring.db"),
+        TSDBPath:      getEnvString("TSDB_PATH", "./tsdb"),
+        AuditLogPath:  getEnvString("AUDIT_LOG_PATH", ""),
+        AgentRegistryPath: getEnvString("AGENT_REGISTRY_PATH", ""),
 
 		// Database
 		DBMaxConnections:    getEnvInt("DB_MAX_CONNECTIONS", 10),
@@ -148,6 +260,12 @@ func InitEnvConfig() {
         MonitorConfigPath:       getEnvString("MONITOR_CONFIG_PATH", ""),
         ServerMonitoringTimeout: getEnvDuration("SERVER_MONITORING_TIMEOUT", 15*time.Second),
 
+        // Circuit breaker for polled servers
+        ServerBreakerEnabled:          getEnvBool("SERVER_BREAKER_ENABLED", true),
+        ServerBreakerFailureThreshold: getEnvInt("SERVER_BREAKER_FAILURE_THRESHOLD", 3),
+        ServerBreakerBaseBackoff:      getEnvDuration("SERVER_BREAKER_BASE_BACKOFF", 10*time.Second),
+        ServerBreakerMaxBackoff:       getEnvDuration("SERVER_BREAKER_MAX_BACKOFF", 5*time.Minute),
+
         // Downsampling
         EnableDownsampling:  getEnvBool("ENABLE_DOWNSAMPLING", false),
         DownsampleMaxPoints: getEnvInt("MONITORING_DOWNSAMPLE_MAX_POINTS", 150),
@@ -277,6 +395,41 @@ func (c *EnvConfig) ShouldCheckTokenInProduction() bool {
 	return c.CheckToken
 }
 
+// IsAuthRequired returns true if routes must check credentials and scopes: AUTH_ENABLED,
+// or CHECK_TOKEN in production
+func (c *EnvConfig) IsAuthRequired() bool {
+	return c.AuthEnabled || (c.IsProduction() && c.CheckToken)
+}
+
+// IsTLSEnabled returns true if the server itself serves HTTPS
+func (c *EnvConfig) IsTLSEnabled() bool {
+	return c.TLSCertFile != "" || c.TLSKeyFile != ""
+}
+
+// RequiresClientCertificate returns true if API routes need a verified client certificate
+func (c *EnvConfig) RequiresClientCertificate() bool {
+	return c.IsTLSEnabled() && c.TLSClientCAFile != "" && c.TLSClientAuth != "optional"
+}
+
+// IsOIDCEnabled returns true if bearer tokens issued by the OIDC provider are accepted
+func (c *EnvConfig) IsOIDCEnabled() bool {
+	return c.OIDCIssuer != ""
+}
+
+// IsOIDCLoginEnabled returns true if the dashboard offers single sign-on through the OIDC
+// provider
+func (c *EnvConfig) IsOIDCLoginEnabled() bool {
+	return c.OIDCIssuer != "" && c.OIDCClientID != "" && c.OIDCRedirectURL != ""
+}
+
+// GetOIDCAudience returns the audience API bearer tokens must carry
+func (c *EnvConfig) GetOIDCAudience() string {
+	if c.OIDCAudience != "" {
+		return c.OIDCAudience
+	}
+	return c.OIDCClientID
+}
+
 // IsDashboardEnabled returns true if dashboard is enabled
 func (c *EnvConfig) IsDashboardEnabled() bool {
 	return c.HasDashboard
@@ -314,6 +467,8 @@ func (c *EnvConfig) GetHistoricalQueryStorage() string {
         return "sqlite"
     case "postgres", "postgresql":
         return "postgres" // normalize postgresql to postgres
+    case "tsdb":
+        return "tsdb"
     default:
         return "postgres" // default
     }
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-log/internal/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RegisteredAgent is a push agent accepted through auto-registration. Only the SHA-256 of
+// its token is stored.
+type RegisteredAgent struct {
+	Name         string    `json:"name"`
+	TableName    string    `json:"table_name"`
+	TokenSHA256  string    `json:"token_sha256"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+type agentRegistryFile struct {
+	Agents []RegisteredAgent `json:"agents"`
+}
+
+// AgentRegistryPath returns the registry location: AGENT_REGISTRY_PATH, or agents.json in
+// BASE_LOG_FOLDER.
+func AgentRegistryPath() string {
+	envConfig := config.GetEnvConfig()
+	if path := strings.TrimSpace(envConfig.AgentRegistryPath); path != "" {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(envConfig.BaseLogFolder, "agents.json")
+}
+
+// LoadRegisteredAgents reads the agent registry. A missing file holds no agents.
+func LoadRegisteredAgents(path string) ([]RegisteredAgent, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent registry %s: %w", path, err)
+	}
+	var file agentRegistryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: agent registry %s: %v", ErrInvalidConfig, path, err)
+	}
+	return file.Agents, nil
+}
+
+// SaveRegisteredAgents replaces the agent registry atomically, readable by the owner only.
+func SaveRegisteredAgents(path string, agents []RegisteredAgent) error {
+	if agents == nil {
+		agents = []RegisteredAgent{}
+	}
+	data, err := json.MarshalIndent(agentRegistryFile{Agents: agents}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDataMarshalFailed, err)
+	}
+	return writePrivateFile(path, append(data, '\n'))
+}
@@ -0,0 +1,247 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go-log/internal/config"
+)
+
+// APIKeyPrefix starts every API key, so keys can be told apart from JWTs and spotted by
+// secret scanners. A key reads glk_<id>_<secret>.
+const APIKeyPrefix = "glk_"
+
+// APIKey is one entry of the key file. Only a SHA-256 hash of the key is stored.
+type APIKey struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	Scopes    []string   `json:"scopes,omitempty"` // narrows the role's scopes when set
+	Hash      string     `json:"hash"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Status reports whether the key is "active", "expired" or "revoked" at now.
+func (k APIKey) Status(now time.Time) string {
+	switch {
+	case k.RevokedAt != nil:
+		return "revoked"
+	case k.ExpiresAt != nil && !now.Before(*k.ExpiresAt):
+		return "expired"
+	}
+	return "active"
+}
+
+// GrantedScopes returns the scopes the key grants.
+func (k APIKey) GrantedScopes() []string {
+	return GrantedScopes(k.Role, k.Scopes)
+}
+
+type apiKeyFile struct {
+	Keys []APIKey `json:"keys"`
+}
+
+var apiKeyCache = fileCache[map[string]APIKey]{parse: func(path string) (map[string]APIKey, error) {
+	list, err := LoadAPIKeys(path)
+	if err != nil {
+		LogWarnWithContext("apikeys", "failed to load API keys", err)
+		return nil, err
+	}
+	keys := make(map[string]APIKey, len(list))
+	for _, key := range list {
+		keys[key.ID] = key
+	}
+	return keys, nil
+}}
+
+// APIKeysPath returns the key file location: API_KEYS_PATH, or api-keys.json in the
+// working directory.
+func APIKeysPath() string {
+	if path := strings.TrimSpace(config.GetEnvConfig().APIKeysPath); path != "" {
+		return filepath.Clean(path)
+	}
+	return "api-keys.json"
+}
+
+// HasAPIKeys reports whether the key file (API_KEYS_PATH) holds any keys.
+func HasAPIKeys() bool {
+	keys, err := apiKeyCache.get(APIKeysPath())
+	return err == nil && len(keys) > 0
+}
+
+// IsAPIKey reports whether credential looks like an API key rather than a JWT.
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
+
+// LoadAPIKeys reads the key file. A missing file holds no keys.
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API key file %s: %w", path, err)
+	}
+	var file apiKeyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: API key file %s: %v", ErrInvalidConfig, path, err)
+	}
+	return file.Keys, nil
+}
+
+// SaveAPIKeys replaces the key file atomically, readable by the owner only.
+func SaveAPIKeys(path string, keys []APIKey) error {
+	if keys == nil {
+		keys = []APIKey{}
+	}
+	data, err := json.MarshalIndent(apiKeyFile{Keys: keys}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDataMarshalFailed, err)
+	}
+	return writePrivateFile(path, append(data, '\n'))
+}
+
+// CreateAPIKey adds a key to the key file and returns it with the plaintext key, which is
+// not stored anywhere and cannot be recovered later.
+func CreateAPIKey(path, name, role string, scopes []string, expiresAt *time.Time) (APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return APIKey{}, "", fmt.Errorf("%w: an API key needs a name", ErrValidationFailed)
+	}
+	role, err := ParseRole(role)
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	scopes, err = ParseScopes(scopes)
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	for _, scope := range scopes {
+		if len(GrantedScopes(role, []string{scope})) == 0 {
+			return APIKey{}, "", fmt.Errorf("%w: scope %q is not granted by role %s", ErrValidationFailed, scope, role)
+		}
+	}
+
+	keys, err := LoadAPIKeys(path)
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	now := NowUTC()
+	for _, key := range keys {
+		if key.Name == name && key.Status(now) == "active" {
+			return APIKey{}, "", fmt.Errorf("%w: an active API key named %q already exists", ErrConfigEntryExists, name)
+		}
+	}
+
+	id, err := randomAPIKeyPart(6, hex.EncodeToString)
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	secret, err := randomAPIKeyPart(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	plaintext := APIKeyPrefix + id + "_" + secret
+
+	key := APIKey{
+		ID:        id,
+		Name:      name,
+		Role:      role,
+		Scopes:    scopes,
+		Hash:      hashAPIKey(plaintext),
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	if err := SaveAPIKeys(path, append(keys, key)); err != nil {
+		return APIKey{}, "", err
+	}
+	return key, plaintext, nil
+}
+
+// RevokeAPIKey marks the key with the given ID (or the active key with the given name) as
+// revoked. Revoked keys stay in the file so the listing shows what happened to them.
+func RevokeAPIKey(path, idOrName string) (APIKey, error) {
+	keys, err := LoadAPIKeys(path)
+	if err != nil {
+		return APIKey{}, err
+	}
+	now := NowUTC()
+	match := -1
+	for i, key := range keys {
+		if key.ID == idOrName || (key.Name == idOrName && key.Status(now) == "active") {
+			match = i
+			break
+		}
+	}
+	if match < 0 {
+		return APIKey{}, fmt.Errorf("%w: %s", ErrAPIKeyNotFound, idOrName)
+	}
+	if keys[match].RevokedAt == nil {
+		keys[match].RevokedAt = &now
+		if err := SaveAPIKeys(path, keys); err != nil {
+			return APIKey{}, err
+		}
+	}
+	return keys[match], nil
+}
+
+// AuthenticateAPIKey looks up a presented key in the key file (API_KEYS_PATH) and returns
+// it when it is valid, not expired and not revoked.
+func AuthenticateAPIKey(plaintext string) (*APIKey, error) {
+	rest, ok := strings.CutPrefix(plaintext, APIKeyPrefix)
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	id, _, ok := strings.Cut(rest, "_")
+	if !ok || id == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	keys, err := apiKeyCache.get(APIKeysPath())
+	if err != nil {
+		return nil, err
+	}
+	key, ok := keys[id]
+	if !ok {
+		// Hash anyway so unknown IDs take as long as wrong secrets
+		_ = hashAPIKey(plaintext)
+		return nil, ErrInvalidCredentials
+	}
+	presented := hashAPIKey(plaintext)
+	if subtle.ConstantTimeCompare([]byte(presented), []byte(key.Hash)) != 1 {
+		return nil, ErrInvalidCredentials
+	}
+	switch key.Status(NowUTC()) {
+	case "revoked":
+		return nil, fmt.Errorf("%w: API key %s has been revoked", ErrInvalidCredentials, key.ID)
+	case "expired":
+		return nil, fmt.Errorf("%w: API key %s", ErrTokenExpired, key.ID)
+	}
+	return &key, nil
+}
+
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func randomAPIKeyPart(n int, encode func([]byte) string) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return encode(buf), nil
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go-log/internal/config"
+)
+
+// AuditEntry records one administrative change, successful or not.
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	Actor    string    `json:"actor"`
+	Remote   string    `json:"remote,omitempty"`
+	Action   string    `json:"action"`
+	Resource string    `json:"resource"`
+	Name     string    `json:"name,omitempty"`
+	Before   any       `json:"before,omitempty"`
+	After    any       `json:"after,omitempty"`
+	Success  bool      `json:"success"`
+	Error    string    `json:"error,omitempty"`
+}
+
+var auditMu sync.Mutex
+
+// AuditLogPath returns the audit log location: AUDIT_LOG_PATH, or audit.log in BASE_LOG_FOLDER.
+func AuditLogPath() string {
+	envConfig := config.GetEnvConfig()
+	if path := strings.TrimSpace(envConfig.AuditLogPath); path != "" {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(envConfig.BaseLogFolder, "audit.log")
+}
+
+// WriteAuditEntry appends entry to the audit log as one JSON line and mirrors it to the
+// application log.
+func WriteAuditEntry(entry AuditEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = NowUTC()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDataMarshalFailed, err)
+	}
+
+	outcome := "ok"
+	if !entry.Success {
+		outcome = "failed: " + entry.Error
+	}
+	LogInfo("audit: %s %s %s %q by %s (%s)", outcome, entry.Action, entry.Resource, entry.Name, entry.Actor, entry.Remote)
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	path := AuditLogPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
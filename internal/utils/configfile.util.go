@@ -0,0 +1,321 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const maxConfigIncludeDepth = 8
+
+// ConfigFileNames are the configuration files looked up in the project root, in order of
+// preference.
+var ConfigFileNames = []string{"configs.json", "configs.yaml", "configs.yml", "configs.toml"}
+
+// LoadConfigDocument reads a JSON, YAML or TOML configuration file (chosen by extension),
+// resolves its "include" entries, substitutes ${VAR} references in string values and
+// returns the result as JSON.
+//
+// sources maps every file that was read to its modification time, so callers can watch
+// included files too. A missing file is recorded with a zero time. sources is returned
+// even when loading fails.
+func LoadConfigDocument(path string) (data []byte, sources map[string]time.Time, err error) {
+	sources = map[string]time.Time{}
+	tree, err := loadConfigTree(path, sources, nil)
+	if err != nil {
+		return nil, sources, err
+	}
+	expanded, err := interpolateConfigTree(tree, "$")
+	if err != nil {
+		return nil, sources, err
+	}
+	data, err = json.Marshal(expanded)
+	if err != nil {
+		return nil, sources, fmt.Errorf("%s: %w", path, err)
+	}
+	return data, sources, nil
+}
+
+// loadConfigTree parses one file and merges its includes underneath it. stack holds the
+// files currently being loaded, to detect include cycles.
+func loadConfigTree(path string, sources map[string]time.Time, stack []string) (map[string]any, error) {
+	path = filepath.Clean(path)
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	for _, parent := range stack {
+		if parent == path {
+			return nil, fmt.Errorf("%w: include cycle: %s -> %s", ErrInvalidConfig, strings.Join(stack, " -> "), path)
+		}
+	}
+	if len(stack) >= maxConfigIncludeDepth {
+		return nil, fmt.Errorf("%w: includes nested deeper than %d levels at %s", ErrInvalidConfig, maxConfigIncludeDepth, path)
+	}
+
+	// Stat before reading, so a write racing with the read is picked up by the next poll
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime()
+	}
+	sources[path] = modTime
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file %s: %w", path, err)
+	}
+
+	tree, err := parseConfigFile(path, raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse configuration file %s: %w", path, err)
+	}
+
+	includes, err := configIncludes(path, tree["include"])
+	if err != nil {
+		return nil, err
+	}
+	delete(tree, "include")
+
+	merged := map[string]any{}
+	for _, include := range includes {
+		child, err := loadConfigTree(include, sources, append(stack, path))
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeConfigTrees(merged, child)
+	}
+	return mergeConfigTrees(merged, tree), nil
+}
+
+// parseConfigFile decodes raw according to the file extension. Anything that is not
+// YAML or TOML is read as JSON.
+func parseConfigFile(path string, raw []byte) (map[string]any, error) {
+	var tree any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		parsed, err := ParseYAML(raw)
+		if err != nil {
+			return nil, err
+		}
+		tree = parsed
+	case ".toml":
+		parsed, err := ParseTOML(raw)
+		if err != nil {
+			return nil, err
+		}
+		tree = parsed
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&tree); err != nil {
+			return nil, describeConfigJSONError(raw, err)
+		}
+		if decoder.More() {
+			return nil, fmt.Errorf("unexpected content after the JSON document")
+		}
+	}
+	if tree == nil {
+		return map[string]any{}, nil
+	}
+	obj, ok := tree.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("the top level must be an object")
+	}
+	return obj, nil
+}
+
+func describeConfigJSONError(raw []byte, err error) error {
+	syntaxErr, ok := err.(*json.SyntaxError)
+	if !ok {
+		return err
+	}
+	before := raw[:min(int(syntaxErr.Offset), len(raw))]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return fmt.Errorf("json: line %d, column %d: %w", line, column, err)
+}
+
+// configIncludes resolves an "include" value (a path or a list of paths, glob patterns
+// allowed) relative to the including file. A pattern that matches nothing is skipped; a
+// plain path must exist.
+func configIncludes(path string, value any) ([]string, error) {
+	var patterns []string
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		patterns = []string{v}
+	case []any:
+		for _, item := range v {
+			pattern, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s: include entries must be strings", ErrInvalidConfig, path)
+			}
+			patterns = append(patterns, pattern)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s: include must be a path or a list of paths", ErrInvalidConfig, path)
+	}
+
+	var files []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(path), pattern)
+		}
+		if !strings.ContainsAny(pattern, "*?[") {
+			files = append(files, pattern)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: invalid include pattern %q", ErrInvalidConfig, path, pattern)
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// mergeConfigTrees merges overlay into base: objects are merged key by key, lists are
+// concatenated and any other value in overlay replaces the one in base.
+func mergeConfigTrees(base, overlay map[string]any) map[string]any {
+	for key, value := range overlay {
+		switch v := value.(type) {
+		case map[string]any:
+			if existing, ok := base[key].(map[string]any); ok {
+				base[key] = mergeConfigTrees(existing, v)
+				continue
+			}
+		case []any:
+			if existing, ok := base[key].([]any); ok {
+				base[key] = append(existing, v...)
+				continue
+			}
+		}
+		base[key] = value
+	}
+	return base
+}
+
+// interpolateConfigTree substitutes environment variables in every string value.
+func interpolateConfigTree(value any, path string) (any, error) {
+	switch v := value.(type) {
+	case string:
+		expanded, err := ExpandEnv(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, path, err)
+		}
+		return expanded, nil
+	case map[string]any:
+		for key, item := range v {
+			expanded, err := interpolateConfigTree(item, path+"."+key)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = expanded
+		}
+	case []any:
+		for i, item := range v {
+			expanded, err := interpolateConfigTree(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+	}
+	return value, nil
+}
+
+// ExpandEnv substitutes environment variable references in s:
+//
+//	${VAR}           value of VAR; an error if VAR is not set
+//	${VAR:-default}  default if VAR is unset or empty (${VAR-default}: only if unset)
+//	${VAR:?message}  an error with message if VAR is unset or empty (${VAR?message}: only if unset)
+//	$$               a literal $
+//
+// A $ that does not start one of these is kept as is.
+func ExpandEnv(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+			continue
+		case '{':
+		default:
+			b.WriteByte('$')
+			continue
+		}
+
+		end := strings.IndexByte(s[i+2:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable reference %q", s[i:])
+		}
+		value, err := expandEnvReference(s[i+2 : i+2+end])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+		i += end + 2
+	}
+	return b.String(), nil
+}
+
+func expandEnvReference(ref string) (string, error) {
+	name, op, arg := ref, "", ""
+	if idx := strings.IndexAny(ref, ":-?"); idx >= 0 {
+		name = ref[:idx]
+		op = ref[idx : idx+1]
+		if op == ":" {
+			if idx+1 >= len(ref) || (ref[idx+1] != '-' && ref[idx+1] != '?') {
+				return "", fmt.Errorf("invalid variable reference ${%s}", ref)
+			}
+			op = ref[idx : idx+2]
+		}
+		arg = ref[idx+len(op):]
+	}
+	if name == "" || strings.IndexFunc(name, func(r rune) bool {
+		return !(r == '_' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) >= 0 {
+		return "", fmt.Errorf("invalid variable name in ${%s}", ref)
+	}
+
+	value, set := os.LookupEnv(name)
+	switch op {
+	case "":
+		if !set {
+			return "", fmt.Errorf("environment variable %s is not set (use ${%s:-} to allow an empty value)", name, name)
+		}
+	case ":-":
+		if value == "" {
+			value = arg
+		}
+	case "-":
+		if !set {
+			value = arg
+		}
+	case ":?", "?":
+		if !set || (op == ":?" && value == "") {
+			if arg == "" {
+				arg = "required"
+			}
+			return "", fmt.Errorf("environment variable %s: %s", name, arg)
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		want    any
+		wantErr string
+	}{
+		{"empty", "", nil, ""},
+		{"leading zero is decimal", "n: 010", map[string]any{"n": 10}, ""},
+		{"octal prefix", "n: 0o10", map[string]any{"n": 8}, ""},
+		{"quoted number", `n: "010"`, map[string]any{"n": "010"}, ""},
+		{"yes is a string", "flag: yes", map[string]any{"flag": "yes"}, ""},
+		{"booleans", "on: true\noff: false", map[string]any{"on": true, "off": false}, ""},
+		{"float and null", "f: 1.5\nz: ~", map[string]any{"f": 1.5, "z": nil}, ""},
+		{"timestamp stays a string", "at: 2024-03-01T10:00:00Z\nday: 2024-03-01",
+			map[string]any{"at": "2024-03-01T10:00:00Z", "day": "2024-03-01"}, ""},
+		{"list", "storage: [sqlite, file]", map[string]any{"storage": []any{"sqlite", "file"}}, ""},
+		{"anchor and alias", "a: &x {url: http://a}\nb: *x",
+			map[string]any{"a": map[string]any{"url": "http://a"}, "b": map[string]any{"url": "http://a"}}, ""},
+		{"merge key", "base: &b {timeout: 5s, url: http://a}\nsrv:\n  <<: *b\n  url: http://b",
+			map[string]any{
+				"base": map[string]any{"timeout": "5s", "url": "http://a"},
+				"srv":  map[string]any{"timeout": "5s", "url": "http://b"},
+			}, ""},
+		{"duplicate key", "a: 1\na: 2", nil, `key "a" is already defined`},
+		{"syntax error", "a: [1, 2", nil, "yaml:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseYAML([]byte(tt.doc))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		want    map[string]any
+		wantErr bool
+	}{
+		{"empty", "", map[string]any{}, false},
+		{"scalars", "path = './logs'\nport = 3500\nratio = 0.5\non = true",
+			map[string]any{"path": "./logs", "port": int64(3500), "ratio": 0.5, "on": true}, false},
+		{"dates", "at = 2024-03-01T10:00:00Z\nlocal = 2024-03-01T10:00:00\nday = 2024-03-01\nclock = 07:30:00",
+			map[string]any{"at": "2024-03-01T10:00:00Z", "local": "2024-03-01T10:00:00", "day": "2024-03-01", "clock": "07:30:00"}, false},
+		{"inline table", `headers = { Authorization = "Bearer x" }`,
+			map[string]any{"headers": map[string]any{"Authorization": "Bearer x"}}, false},
+		{"array of tables", "[[heartbeat]]\nname = 'a'\n[[heartbeat]]\nname = 'b'",
+			map[string]any{"heartbeat": []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}}}, false},
+		{"leading zero", "port = 010", nil, true},
+		{"duplicate key", "a = 1\na = 2", nil, true},
+		{"syntax error", "a = ", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTOML([]byte(tt.doc))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parsed %#v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigDocumentIncludesAndInterpolation(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"configs.yaml":     "path: ${TEST_LOG_PATH:-./logs}\nstorage: [sqlite]\ninclude: servers.d/*.toml\n",
+		"servers.d/a.toml": "storage = ['file']\n[[servers]]\nname = 'edge'\nadded = 2024-03-01\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("TEST_LOG_PATH", "/var/log/go-log")
+
+	data, sources, err := LoadConfigDocument(filepath.Join(dir, "configs.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 2 {
+		t.Errorf("tracked %d source files, want 2", len(sources))
+	}
+	var got struct {
+		Path    string   `json:"path"`
+		Storage []string `json:"storage"`
+		Servers []struct {
+			Name  string `json:"name"`
+			Added string `json:"added"`
+		} `json:"servers"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Path != "/var/log/go-log" {
+		t.Errorf("path %q, want the interpolated value", got.Path)
+	}
+	if !reflect.DeepEqual(got.Storage, []string{"file", "sqlite"}) {
+		t.Errorf("storage %v, want the included list first", got.Storage)
+	}
+	if len(got.Servers) != 1 || got.Servers[0].Name != "edge" || got.Servers[0].Added != "2024-03-01" {
+		t.Errorf("servers %+v", got.Servers)
+	}
+}
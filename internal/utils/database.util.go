@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -131,7 +132,13 @@ func InitDatabase() error {
 	return nil
 }
 
-// ensureTable creates a table with the given name if it doesn't exist
+// sqliteChildTable returns the quoted name of a child table belonging to tableName.
+func sqliteChildTable(tableName, suffix string) string {
+	return "`" + SanitizeTableName(tableName) + suffix + "`"
+}
+
+// ensureTable creates a metrics table (plus its disk and heartbeat child tables)
+// with the given name if it doesn't exist, migrating a legacy JSON blob table in place.
 func ensureTable(tableName string) error {
 	// Validate table name for security
 	if err := validateTableName(tableName); err != nil {
@@ -140,49 +147,436 @@ func ensureTable(tableName string) error {
 
 	// Get clean name for index naming (remove brackets, quotes etc.)
 	cleanName := SanitizeTableName(tableName)
+	legacyName := "`" + cleanName + legacyTableSuffix + "`"
+
+	isLegacy, err := sqliteHasColumn(tableName, "data")
+	if err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", tableName, err)
+	}
+	if isLegacy {
+		LogInfo("table %s uses the legacy JSON schema, moving it to %s for migration", cleanName, displayTableName(legacyName))
+		stmts := []string{
+			fmt.Sprintf(`DROP INDEX IF EXISTS idx_%s_timestamp;`, cleanName),
+			fmt.Sprintf(`ALTER TABLE %s RENAME TO %s;`, tableName, legacyName),
+		}
+		for _, stmt := range stmts {
+			if _, err := db.Exec(stmt); err != nil {
+				return fmt.Errorf("failed to move legacy table %s: %w", tableName, err)
+			}
+		}
+	}
 
-    statements := []string{
-        fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	disksTable := sqliteChildTable(tableName, DisksTableSuffix)
+	heartbeatsTable := sqliteChildTable(tableName, HeartbeatsTableSuffix)
+
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
             id INTEGER PRIMARY KEY AUTOINCREMENT,
             timestamp TEXT NOT NULL,
-            data TEXT NOT NULL
-        );`, tableName),
-        fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_timestamp ON %s(timestamp);`, cleanName, tableName),
-    }
+            %s,
+            server_metrics TEXT,
+            cpu_architecture TEXT
+        );`, tableName, metricColumnDefs("REAL", "INTEGER")),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+            snapshot_id INTEGER NOT NULL REFERENCES %s(id) ON DELETE CASCADE,
+            timestamp TEXT NOT NULL,
+            path TEXT NOT NULL,
+            device TEXT NOT NULL DEFAULT '',
+            filesystem TEXT NOT NULL DEFAULT '',
+            total_bytes INTEGER NOT NULL DEFAULT 0,
+            used_bytes INTEGER NOT NULL DEFAULT 0,
+            available_bytes INTEGER NOT NULL DEFAULT 0,
+            used_pct REAL NOT NULL DEFAULT 0
+        );`, disksTable, tableName),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_disks_snapshot ON %s(snapshot_id);`, cleanName, disksTable),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+            snapshot_id INTEGER NOT NULL REFERENCES %s(id) ON DELETE CASCADE,
+            timestamp TEXT NOT NULL,
+            name TEXT NOT NULL DEFAULT '',
+            url TEXT NOT NULL DEFAULT '',
+            status TEXT NOT NULL DEFAULT '',
+            response_ms INTEGER NOT NULL DEFAULT 0,
+            response_time TEXT NOT NULL DEFAULT '',
+            last_checked TEXT NOT NULL DEFAULT '',
+            error TEXT NOT NULL DEFAULT ''
+        );`, heartbeatsTable, tableName),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_heartbeats_snapshot ON %s(snapshot_id);`, cleanName, heartbeatsTable),
+	}
 
 	for _, stmt := range statements {
 		if _, err := db.Exec(stmt); err != nil {
 			return fmt.Errorf("failed to ensure table %s: %w", tableName, err)
 		}
 	}
+	if err := addMissingSQLiteColumns(tableName); err != nil {
+		return err
+	}
+	if err := ensureSQLiteUniqueTimestamp(tableName); err != nil {
+		return err
+	}
+
+	// Resume (or start) migrating rows left in a legacy table
+	if exists, err := sqliteTableExists(cleanName + legacyTableSuffix); err == nil && exists {
+		if err := migrateLegacySQLiteTable(legacyName, tableName); err != nil {
+			return fmt.Errorf("failed to migrate legacy table %s: %w", cleanName, err)
+		}
+	}
+
+	return nil
+}
+
+// sqliteTableExists reports whether a table with the given bare name exists.
+func sqliteTableExists(name string) (bool, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(1) FROM sqlite_master WHERE type='table' AND name = ?", name).Scan(&count)
+	return count > 0, err
+}
+
+// sqliteHasColumn reports whether an existing table has the given column.
+func sqliteHasColumn(tableName, column string) (bool, error) {
+	columns, err := sqliteColumns(tableName)
+	return columns[strings.ToLower(column)], err
+}
+
+// sqliteColumns returns the lower-cased column names of an existing table.
+func sqliteColumns(tableName string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", tableName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		columns[strings.ToLower(name)] = true
+	}
+	return columns, rows.Err()
+}
 
-    return nil
+// ensureSQLiteUniqueTimestamp makes timestamps unique within a metrics table, so writing a
+// snapshot again (a resumed transfer, an agent retrying a push) does not store it twice.
+// Tables from before the unique index lose their duplicate rows, keeping the first copy.
+func ensureSQLiteUniqueTimestamp(tableName string) error {
+	cleanName := SanitizeTableName(tableName)
+	uniqueIndex := fmt.Sprintf("idx_%s_timestamp_unique", cleanName)
+	var count int
+	if err := db.QueryRow("SELECT COUNT(1) FROM sqlite_master WHERE type='index' AND name = ?", uniqueIndex).Scan(&count); err != nil {
+		return fmt.Errorf("failed to inspect indexes of %s: %w", cleanName, err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	result, err := db.Exec(fmt.Sprintf(`DELETE FROM %[1]s WHERE id NOT IN (SELECT MIN(id) FROM %[1]s GROUP BY timestamp)`, tableName))
+	if err != nil {
+		return fmt.Errorf("failed to remove duplicate snapshots from %s: %w", cleanName, err)
+	}
+	if removed, _ := result.RowsAffected(); removed > 0 {
+		LogInfo("removed %d duplicate snapshots from %s", removed, cleanName)
+	}
+	stmts := []string{
+		fmt.Sprintf(`CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s(timestamp);`, uniqueIndex, tableName),
+		fmt.Sprintf(`DROP INDEX IF EXISTS idx_%s_timestamp;`, cleanName),
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to index timestamps of %s: %w", cleanName, err)
+		}
+	}
+	return nil
+}
+
+// addMissingSQLiteColumns brings a metrics table created by an older schema up to date.
+// The added columns are NULL in existing rows.
+func addMissingSQLiteColumns(tableName string) error {
+	columns, err := sqliteColumns(tableName)
+	if err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", tableName, err)
+	}
+	for _, def := range missingMetricColumnDefs(columns, "REAL", "INTEGER", "TEXT") {
+		if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s`, tableName, def)); err != nil {
+			return fmt.Errorf("failed to add column to %s: %w", tableName, err)
+		}
+	}
+	return nil
 }
 
-// writeToTableInternal is the internal implementation for writing to any table
-func writeToTableInternal(tableName string, entry models.MonitoringLogEntry) error {
+// migrateLegacySQLiteTable copies rows from a legacy JSON blob table into the normalized
+// schema in batches. The legacy rows are kept: each one is marked in its migrated column in
+// the same transaction, so an interrupted migration resumes where it stopped and the table
+// can still be inspected afterwards. Rows that cannot be converted are marked -1. The table
+// is only dropped by DropLegacySQLiteTables.
+func migrateLegacySQLiteTable(legacyName, tableName string) error {
+	hasMarker, err := sqliteHasColumn(legacyName, legacyMigratedColumn)
+	if err != nil {
+		return fmt.Errorf("failed to inspect legacy table: %w", err)
+	}
+	if !hasMarker {
+		if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s INTEGER NOT NULL DEFAULT 0`, legacyName, legacyMigratedColumn)); err != nil {
+			return fmt.Errorf("failed to prepare legacy table: %w", err)
+		}
+	}
+
+	const batchSize = 500
+	var migrated int
+	var skipped int
+	var lastID int64
+
+	for {
+		rows, err := db.Query(fmt.Sprintf(`SELECT id, timestamp, data FROM %s WHERE id > ? AND %s = 0 ORDER BY id LIMIT %d`,
+			legacyName, legacyMigratedColumn, batchSize), lastID)
+		if err != nil {
+			return fmt.Errorf("failed to read legacy rows: %w", err)
+		}
+
+		type legacyRow struct {
+			id        int64
+			timestamp string
+			data      string
+		}
+		var batch []legacyRow
+		for rows.Next() {
+			var row legacyRow
+			if err := rows.Scan(&row.id, &row.timestamp, &row.data); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan legacy row: %w", err)
+			}
+			batch = append(batch, row)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("legacy row iteration error: %w", err)
+		}
+
+		if len(batch) == 0 {
+			break
+		}
+
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin migration transaction: %w", err)
+		}
+		mark := fmt.Sprintf(`UPDATE %s SET %s = ? WHERE id = ?`, legacyName, legacyMigratedColumn)
+		for _, row := range batch {
+			state := legacyRowMigrated
+			var entry models.MonitoringLogEntry
+			if err := json.Unmarshal([]byte(row.data), &entry); err != nil {
+				LogWarnWithContext("sqlite-migration", fmt.Sprintf("keeping unreadable legacy row %d", row.id), err)
+				state = legacyRowUnconvertible
+			} else if snapshot, err := SnapshotFromLogEntry(entry); err != nil {
+				LogWarnWithContext("sqlite-migration", fmt.Sprintf("keeping legacy row %d", row.id), err)
+				state = legacyRowUnconvertible
+			} else if err := insertSQLiteSnapshotTx(tx, tableName, row.timestamp, snapshot); err != nil {
+				tx.Rollback()
+				return err
+			}
+			if _, err := tx.Exec(mark, state, row.id); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to mark migrated legacy row: %w", err)
+			}
+			if state == legacyRowMigrated {
+				migrated++
+			} else {
+				skipped++
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration batch: %w", err)
+		}
+
+		lastID = batch[len(batch)-1].id
+		LogInfo("migrated %d legacy rows into %s", migrated, displayTableName(tableName))
+	}
+
+	if migrated == 0 && skipped == 0 {
+		return nil
+	}
+	if skipped > 0 {
+		LogWarn("legacy table migration for %s could not convert %d rows, kept in %s (%d rows migrated)", displayTableName(tableName), skipped, legacyName, migrated)
+	}
+	LogInfo("legacy table migration completed for %s (%d rows); %s is kept until \"go-log data drop-legacy\"", displayTableName(tableName), migrated, legacyName)
+	return nil
+}
+
+// DropLegacySQLiteTables drops the <table>_legacy tables whose rows have all been migrated
+// and returns their names. A table with rows that could not be converted is kept unless
+// force is set; one whose migration has not finished is always kept.
+func DropLegacySQLiteTables(force bool) ([]string, error) {
+	if db == nil {
+		return nil, ErrDatabaseNotInit
+	}
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list database tables: %w", err)
+	}
+	listed := make(map[string]struct{})
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		listed[name] = struct{}{}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate table names: %w", err)
+	}
+
+	var dropped []string
+	for name := range listed {
+		parent, ok := strings.CutSuffix(name, legacyTableSuffix)
+		if !ok {
+			continue
+		}
+		if _, exists := listed[parent]; !exists {
+			continue
+		}
+		legacyName := "`" + name + "`"
+		hasMarker, err := sqliteHasColumn(legacyName, legacyMigratedColumn)
+		if err != nil {
+			return dropped, fmt.Errorf("failed to inspect %s: %w", name, err)
+		}
+		if !hasMarker {
+			LogWarn("keeping %s: its migration has not started", name)
+			continue
+		}
+		var pending, unconvertible int64
+		err = db.QueryRow(fmt.Sprintf(`SELECT COALESCE(SUM(%[1]s = 0), 0), COALESCE(SUM(%[1]s = %[2]d), 0) FROM %[3]s`,
+			legacyMigratedColumn, legacyRowUnconvertible, legacyName)).Scan(&pending, &unconvertible)
+		if err != nil {
+			return dropped, fmt.Errorf("failed to inspect %s: %w", name, err)
+		}
+		if pending > 0 {
+			LogWarn("keeping %s: %d rows are not migrated yet", name, pending)
+			continue
+		}
+		if unconvertible > 0 && !force {
+			LogWarn("keeping %s: %d rows could not be converted (drop anyway with --force)", name, unconvertible)
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS %s`, legacyName)); err != nil {
+			return dropped, fmt.Errorf("failed to drop %s: %w", name, err)
+		}
+		dropped = append(dropped, name)
+	}
+	sort.Strings(dropped)
+	return dropped, nil
+}
+
+// insertSQLiteSnapshotTx writes a snapshot and its child rows within an existing transaction.
+// A snapshot whose timestamp is already stored is skipped.
+func insertSQLiteSnapshotTx(tx *sql.Tx, tableName, timestamp string, snapshot *models.SystemMonitoring) error {
+	values := snapshotInsertValues(timestamp, snapshot)
+	query := fmt.Sprintf(`INSERT OR IGNORE INTO %s (timestamp, %s, %s) VALUES (%s)`,
+		tableName, metricColumnNames(), snapshotTextColumns, placeholderList(sqlitePlaceholder, 1, len(values)))
+	result, err := tx.Exec(query, values...)
+	if err != nil {
+		return fmt.Errorf("failed to write to database: %w", err)
+	}
+	if inserted, err := result.RowsAffected(); err == nil && inserted == 0 {
+		return nil
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to read inserted snapshot id: %w", err)
+	}
+
+	return insertSnapshotChildren(tx,
+		sqliteChildTable(tableName, DisksTableSuffix),
+		sqliteChildTable(tableName, HeartbeatsTableSuffix),
+		sqlitePlaceholder, id, timestamp, snapshot)
+}
+
+// insertSQLiteSnapshot writes a snapshot and its child rows in a single transaction.
+func insertSQLiteSnapshot(tableName, timestamp string, snapshot *models.SystemMonitoring) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := insertSQLiteSnapshotTx(tx, tableName, timestamp, snapshot); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit snapshot: %w", err)
+	}
+	return nil
+}
+
+func nullableText(data []byte) any {
+	if data == nil {
+		return nil
+	}
+	return string(data)
+}
+
+// resolveSQLiteTable maps a raw table name ("default" or a server table) to its SQLite
+// table, creating server tables on first use.
+func resolveSQLiteTable(tableName string) (string, error) {
+	if displayTableName(tableName) == displayTableName(DefaultTableName) {
+		return DefaultTableName, nil
+	}
+	return ensureServerLogTable(tableName)
+}
+
+// writeToTableInternal is the internal implementation for writing a snapshot to any table
+func writeToTableInternal(tableName string, snapshot *models.SystemMonitoring) error {
 	if db == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
+	if snapshot == nil {
+		return fmt.Errorf("empty monitoring snapshot")
+	}
+
+	name, err := resolveSQLiteTable(tableName)
+	if err != nil {
+		return err
+	}
+
 	// Validate table name for security
-	if err := validateTableName(tableName); err != nil {
+	if err := validateTableName(name); err != nil {
 		return fmt.Errorf("invalid table name: %w", err)
 	}
 
-	// Convert entry to JSON
-	jsonData, err := json.Marshal(entry)
-	if err != nil {
-		return fmt.Errorf("failed to marshal log entry for database: %w", err)
+	return insertSQLiteSnapshot(name, FormatTimestampUTC(snapshot.Timestamp), snapshot)
+}
+
+// writeSQLiteBatch stores snapshots with their own timestamps in a single transaction.
+func writeSQLiteBatch(tableName string, batch []models.SystemMonitoring) error {
+	if db == nil {
+		return fmt.Errorf("database not initialized")
 	}
 
-	// Insert into table
-	query := fmt.Sprintf(`INSERT INTO %s (timestamp, data) VALUES (?, ?)`, tableName)
-	_, err = db.Exec(query, entry.Time, string(jsonData))
+	name, err := resolveSQLiteTable(tableName)
 	if err != nil {
-		return fmt.Errorf("failed to write to database: %w", err)
+		return err
+	}
+	if err := validateTableName(name); err != nil {
+		return fmt.Errorf("invalid table name: %w", err)
 	}
 
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	for i := range batch {
+		if err := insertSQLiteSnapshotTx(tx, name, FormatTimestampUTC(batch[i].Timestamp), &batch[i]); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit snapshots: %w", err)
+	}
 	return nil
 }
 
@@ -197,18 +591,12 @@ func WriteServerLogToDatabase(tableName string, payload []byte) error {
 		return err
 	}
 
-	entry := models.ServerLogEntry{
-		Time:    FormatTimestampUTC(NowUTC()),
-		Payload: json.RawMessage(payload),
-	}
-
-	jsonData, err := json.Marshal(entry)
+	snapshot, err := SnapshotFromServerPayload(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal server log entry: %w", err)
+		return fmt.Errorf("failed to decode server payload: %w", err)
 	}
 
-	query := fmt.Sprintf("INSERT INTO %s (timestamp, data) VALUES (?, ?)", sanitized)
-	if _, err := db.Exec(query, entry.Time, string(jsonData)); err != nil {
+	if err := insertSQLiteSnapshot(sanitized, FormatTimestampUTC(NowUTC()), snapshot); err != nil {
 		return fmt.Errorf("failed to write server log to database: %w", err)
 	}
 
@@ -218,11 +606,28 @@ func WriteServerLogToDatabase(tableName string, payload []byte) error {
 // CloseDatabase closes the database connection if open
 func CloseDatabase() error {
 	if db != nil {
-		return db.Close()
+		err := db.Close()
+		db = nil
+		return err
 	}
 	return nil
 }
 
+// GetSQLiteTables lists the metrics tables in the SQLite database
+func GetSQLiteTables() ([]string, error) {
+	if db == nil {
+		return nil, ErrDatabaseNotInit
+	}
+	tables, err := collectCleanupTables()
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range tables {
+		tables[i] = displayTableName(name)
+	}
+	return tables, nil
+}
+
 // CleanOldDatabaseEntries removes database entries older than specified date from all tables
 func CleanOldDatabaseEntries(cutoffDate time.Time) error {
 	if db == nil {
@@ -287,7 +692,6 @@ func collectCleanupTables() ([]string, error) {
 		}
 
 		tables = append(tables, name)
-		serverLogTables.Store(name, struct{}{})
 		existing[name] = struct{}{}
 	}
 
@@ -295,6 +699,10 @@ func collectCleanupTables() ([]string, error) {
 		return nil, fmt.Errorf("failed to iterate table names: %w", err)
 	}
 
+	tables = MetricsTables(tables)
+	for _, name := range tables[1:] {
+		serverLogTables.Store(name, struct{}{})
+	}
 	return tables, nil
 }
 
@@ -344,8 +752,8 @@ func IsDatabaseInitialized() bool {
 	return err == nil
 }
 
-// QueryFilteredTableData retrieves data from a specific table within a date range
-func QueryFilteredTableData(tableName, from, to string) ([]models.MonitoringLogEntry, error) {
+// QueryFilteredTableData retrieves snapshots from a specific table within a date range
+func QueryFilteredTableData(tableName, from, to string) ([]models.SystemMonitoring, error) {
 	if db == nil {
 		return nil, fmt.Errorf("database not initialized")
 	}
@@ -355,9 +763,6 @@ func QueryFilteredTableData(tableName, from, to string) ([]models.MonitoringLogE
 		return nil, fmt.Errorf("invalid table name: %w", err)
 	}
 
-	var query string
-	var args []any
-
 	fromNormalized, err := normalizeTimestampInput(from)
 	if err != nil {
 		return nil, fmt.Errorf("invalid from timestamp: %w", err)
@@ -368,56 +773,200 @@ func QueryFilteredTableData(tableName, from, to string) ([]models.MonitoringLogE
 		return nil, fmt.Errorf("invalid to timestamp: %w", err)
 	}
 
-	// Build query based on provided filters
-    if fromNormalized != "" && toNormalized != "" {
-        query = fmt.Sprintf(`SELECT timestamp, data FROM %s 
-                WHERE timestamp >= ? AND timestamp <= ? 
-                ORDER BY timestamp DESC`, tableName)
-        args = []any{fromNormalized, toNormalized}
-    } else if fromNormalized != "" {
-        query = fmt.Sprintf(`SELECT timestamp, data FROM %s 
-                WHERE timestamp >= ? 
-                ORDER BY timestamp DESC`, tableName)
-        args = []any{fromNormalized}
-    } else if toNormalized != "" {
-        query = fmt.Sprintf(`SELECT timestamp, data FROM %s 
-                WHERE timestamp <= ? 
-                ORDER BY timestamp DESC`, tableName)
-        args = []any{toNormalized}
-    } else {
-        // No date filters, get all entries from the table
-        query = fmt.Sprintf(`SELECT timestamp, data FROM %s ORDER BY timestamp DESC`, tableName)
-        args = []any{}
-    }
+	envCfg := config.GetEnvConfig()
+	if envCfg.EnableDownsampling && envCfg.DownsampleMaxPoints > 0 {
+		snapshots, downsampled, err := querySQLiteDownsampled(tableName, fromNormalized, toNormalized, envCfg.DownsampleMaxPoints)
+		if err != nil {
+			LogWarnWithContext("sqlite-query", "downsampled query failed, using raw rows", err)
+		} else if downsampled {
+			return snapshots, nil
+		}
+	}
+
+	columns := snapshotColumns()
+	where, args := sqliteRangeFilter(fromNormalized, toNormalized)
+
+	// Without a date filter only the most recent rows are returned; use QueryTableDataPage to read further back
+	limit := ""
+	if where == "" {
+		limit = "LIMIT 1000"
+	}
+	query := fmt.Sprintf(`SELECT %s FROM %s %s ORDER BY timestamp DESC %s`, columns, tableName, where, limit)
+
+	snapshots, _, err := querySQLiteSnapshots(tableName, query, args)
+	return snapshots, err
+}
+
+// sqliteRangeFilter builds the WHERE clause and arguments for an optional timestamp range
+func sqliteRangeFilter(fromNormalized, toNormalized string) (string, []any) {
+	switch {
+	case fromNormalized != "" && toNormalized != "":
+		return "WHERE timestamp >= ? AND timestamp <= ?", []any{fromNormalized, toNormalized}
+	case fromNormalized != "":
+		return "WHERE timestamp >= ?", []any{fromNormalized}
+	case toNormalized != "":
+		return "WHERE timestamp <= ?", []any{toNormalized}
+	default:
+		return "", []any{}
+	}
+}
+
+// querySQLiteDownsampled aggregates the range into at most maxPoints time buckets with
+// avg/min/max/p95 per metric. It reports false when the range holds few enough rows to return raw.
+func querySQLiteDownsampled(tableName, fromNormalized, toNormalized string, maxPoints int) ([]models.SystemMonitoring, bool, error) {
+	where, args := sqliteRangeFilter(fromNormalized, toNormalized)
+
+	var count int64
+	var first, last sql.NullString
+	stats := fmt.Sprintf(`SELECT COUNT(1), MIN(timestamp), MAX(timestamp) FROM %s %s`, tableName, where)
+	if err := db.QueryRow(stats, args...).Scan(&count, &first, &last); err != nil {
+		return nil, false, fmt.Errorf("failed to inspect range: %w", err)
+	}
+	if count <= int64(maxPoints) || !first.Valid || !last.Valid {
+		return nil, false, nil
+	}
+
+	firstTS, err1 := ParseTimestampUTC(first.String)
+	lastTS, err2 := ParseTimestampUTC(last.String)
+	if err1 != nil || err2 != nil {
+		return nil, false, fmt.Errorf("unparseable timestamps in %s", tableName)
+	}
+	width := int64(lastTS.Sub(firstTS).Seconds())/int64(maxPoints) + 1
+
+	// Nearest-rank p95 per bucket: ceil(0.95 * cnt) using integer arithmetic
+	ranks := make([]string, 0, len(MetricColumns))
+	exprs := make([]string, 0, len(MetricColumns)*4)
+	for i, col := range MetricColumns {
+		ranks = append(ranks, fmt.Sprintf("ROW_NUMBER() OVER (PARTITION BY bucket_id ORDER BY %s) AS rn_%d", col.Name, i))
+		exprs = append(exprs,
+			fmt.Sprintf("avg(%s)", col.Name),
+			fmt.Sprintf("min(%s)", col.Name),
+			fmt.Sprintf("max(%s)", col.Name),
+			fmt.Sprintf("max(CASE WHEN rn_%d = (95 * cnt + 99) / 100 THEN %s END)", i, col.Name))
+	}
+
+	query := fmt.Sprintf(`
+WITH q AS (
+  SELECT CAST(strftime('%%s', timestamp) AS INTEGER) / %d AS bucket_id, %s
+  FROM %s %s
+), r AS (
+  SELECT q.*, COUNT(*) OVER (PARTITION BY bucket_id) AS cnt,
+    %s
+  FROM q
+)
+SELECT bucket_id * %d, COUNT(*),
+    %s
+FROM r
+GROUP BY bucket_id
+ORDER BY bucket_id DESC`, width, metricColumnNames(), tableName, where,
+		strings.Join(ranks, ",\n    "), width, strings.Join(exprs, ",\n    "))
 
 	rows, err := db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query filtered data: %w", err)
+		return nil, false, fmt.Errorf("failed to query downsampled data: %w", err)
 	}
 	defer rows.Close()
 
-	var entries []models.MonitoringLogEntry
+	var snapshots []models.SystemMonitoring
 	for rows.Next() {
-		var timestamp, jsonData string
-		err := rows.Scan(&timestamp, &jsonData)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+		var bucket int64
+		row := newAggregatedRow()
+		if err := rows.Scan(row.scanTargets(&bucket)...); err != nil {
+			return nil, false, fmt.Errorf("failed to scan aggregate row: %w", err)
 		}
+		snapshot := row.snapshot()
+		snapshot.Timestamp = time.Unix(bucket, 0).UTC()
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("row iteration error: %w", err)
+	}
+	return snapshots, true, nil
+}
 
-		var entry models.MonitoringLogEntry
-		err = json.Unmarshal([]byte(jsonData), &entry)
-		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+// querySQLiteSnapshots runs a parent-table query selecting "id, timestamp, <metrics>, server_metrics"
+// and attaches the child rows of every returned snapshot. It also returns the pagination cursor
+// of the last row.
+func querySQLiteSnapshots(tableName, query string, args []any) ([]models.SystemMonitoring, string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query filtered data: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []models.SystemMonitoring
+	var ids []int64
+	var lastCursor string
+	for rows.Next() {
+		row := newSnapshotRow()
+		var timestamp string
+		if err := rows.Scan(row.scanTargets(&timestamp)...); err != nil {
+			return nil, "", fmt.Errorf("failed to scan row: %w", err)
 		}
+		lastCursor = encodeCursor(timestamp, row.id)
 
-		entries = append(entries, entry)
+		snapshot := row.snapshot()
+		if ts, err := ParseTimestampUTC(timestamp); err == nil {
+			snapshot.Timestamp = ts
+		}
+		snapshots = append(snapshots, snapshot)
+		ids = append(ids, row.id)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("row iteration error: %w", err)
+		return nil, "", fmt.Errorf("row iteration error: %w", err)
+	}
+
+	byID := make(map[int64]*models.SystemMonitoring, len(snapshots))
+	for i := range snapshots {
+		byID[ids[i]] = &snapshots[i]
+	}
+	if err := loadSnapshotChildren(db,
+		sqliteChildTable(tableName, DisksTableSuffix),
+		sqliteChildTable(tableName, HeartbeatsTableSuffix),
+		sqlitePlaceholder, byID); err != nil {
+		return nil, "", err
+	}
+
+	return snapshots, lastCursor, nil
+}
+
+// QueryTableDataPage returns one cursor-paginated page of raw snapshots from a table, newest first.
+// The returned cursor is empty once the last page has been read.
+func QueryTableDataPage(tableName, from, to string, page PageRequest) ([]models.SystemMonitoring, string, error) {
+	if db == nil {
+		return nil, "", fmt.Errorf("database not initialized")
+	}
+
+	// Validate table name for security
+	if err := validateTableName(tableName); err != nil {
+		return nil, "", fmt.Errorf("invalid table name: %w", err)
+	}
+
+	fromNormalized, err := normalizeTimestampInput(from)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid from timestamp: %w", err)
+	}
+	toNormalized, err := normalizeTimestampInput(to)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid to timestamp: %w", err)
+	}
+
+	where, args := sqliteRangeFilter(fromNormalized, toNormalized)
+	columns := snapshotColumns()
+	query, args, err := pageQuery(columns, tableName, where, args, page, sqlitePlaceholder)
+	if err != nil {
+		return nil, "", err
 	}
 
-	return entries, nil
+	snapshots, lastCursor, err := querySQLiteSnapshots(tableName, query, args)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(snapshots) < page.EffectiveLimit() {
+		lastCursor = ""
+	}
+	return snapshots, lastCursor, nil
 }
 
 // GetAvailableTables returns a list of available table names for querying
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-log/internal/api/models"
+)
+
+// useTestSQLite opens a fresh SQLite database in a temporary directory for one test.
+func useTestSQLite(t *testing.T) {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db")+"?_journal_mode=WAL&_timeout=5000&_fk=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db = conn
+	t.Cleanup(func() {
+		if err := CloseDatabase(); err != nil {
+			t.Errorf("close sqlite: %v", err)
+		}
+	})
+	if err := ensureTable(DefaultTableName); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLegacySQLiteMigrationKeepsEveryField(t *testing.T) {
+	useTestSQLite(t)
+	legacy := []string{
+		`{"time":"2024-03-01T10:00:00Z","cpu_usage_percent":12.5,"cpu_goroutines":42,"cpu_architecture":"arm64",` +
+			`"ram_used_percent":40,"ram_buffer_bytes":2048,"network_packets_sent":7,"network_errors_in":1,"network_drops_out":2,` +
+			`"diskio_read_count":9,"diskio_io_time":30,"process_sleeping":100,"process_zombie":3,"process_stopped":1}`,
+		`{"time":"2024-03-01T10:00:10Z","cpu_usage_percent":13}`,
+		`not json`,
+	}
+	if _, err := db.Exec(`CREATE TABLE edge (id INTEGER PRIMARY KEY AUTOINCREMENT, timestamp TEXT NOT NULL, data TEXT NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	for i, data := range legacy {
+		if _, err := db.Exec(`INSERT INTO edge (timestamp, data) VALUES (?, ?)`, fmt.Sprintf("2024-03-01T10:00:%02dZ", i*10), data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A second start must not copy the rows again
+	for range 2 {
+		if err := ensureTable("edge"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshots, _, err := QueryTableDataPage("edge", "", "", PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("got %d migrated snapshots, want 2", len(snapshots))
+	}
+	full := snapshots[1]
+	if full.CPU.Goroutines != 42 || full.CPU.Architecture != "arm64" || full.RAM.BufferBytes != 2048 {
+		t.Errorf("cpu/ram fields lost: %+v %+v", full.CPU, full.RAM)
+	}
+	if full.NetworkIO.PacketsSent != 7 || full.NetworkIO.ErrorsIn != 1 || full.NetworkIO.DropsOut != 2 {
+		t.Errorf("network fields lost: %+v", full.NetworkIO)
+	}
+	if full.DiskIO.ReadCount != 9 || full.DiskIO.IOTime != 30 {
+		t.Errorf("disk io fields lost: %+v", full.DiskIO)
+	}
+	if full.Process.SleepingProcs != 100 || full.Process.ZombieProcs != 3 || full.Process.StoppedProcs != 1 {
+		t.Errorf("process fields lost: %+v", full.Process)
+	}
+
+	var kept int
+	if err := db.QueryRow("SELECT COUNT(*) FROM `edge_legacy`").Scan(&kept); err != nil {
+		t.Fatal(err)
+	}
+	if kept != len(legacy) {
+		t.Fatalf("legacy table holds %d rows after migration, want %d", kept, len(legacy))
+	}
+
+	dropped, err := DropLegacySQLiteTables(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dropped) != 0 {
+		t.Fatalf("dropped %v although a row could not be converted", dropped)
+	}
+	dropped, err = DropLegacySQLiteTables(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dropped) != 1 || dropped[0] != "edge_legacy" {
+		t.Fatalf("forced drop removed %v, want [edge_legacy]", dropped)
+	}
+}
+
+func TestAddMissingSQLiteColumns(t *testing.T) {
+	useTestSQLite(t)
+	// A table from before the later metric columns were appended
+	if _, err := db.Exec(`CREATE TABLE old (id INTEGER PRIMARY KEY AUTOINCREMENT, timestamp TEXT NOT NULL,
+		cpu_usage_percent REAL, server_metrics TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO old (timestamp, cpu_usage_percent) VALUES ('2024-03-01T10:00:00Z', 5)`); err != nil {
+		t.Fatal(err)
+	}
+	if err := ensureTable("old"); err != nil {
+		t.Fatal(err)
+	}
+	snapshots, _, err := QueryTableDataPage("old", "", "", PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || snapshots[0].CPU.UsagePercent != 5 {
+		t.Fatalf("unexpected snapshots after upgrade: %+v", snapshots)
+	}
+}
+
+func TestSQLiteRewrittenBatchIsStoredOnce(t *testing.T) {
+	useTestSQLite(t)
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	batch := make([]models.SystemMonitoring, 3)
+	for i := range batch {
+		batch[i].Timestamp = start.Add(time.Duration(i) * 10 * time.Second)
+		batch[i].DiskSpace = []models.DiskSpace{{Path: "/", UsedPct: float64(i)}}
+	}
+
+	// A resumed transfer writes the interrupted page again
+	for range 2 {
+		if err := writeSQLiteBatch("edge", batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for table, want := range map[string]int{"`edge`": 3, "`edge_disks`": 3} {
+		var got int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s holds %d rows, want %d", table, got, want)
+		}
+	}
+}
+
+func TestSQLiteUniqueTimestampRemovesDuplicates(t *testing.T) {
+	useTestSQLite(t)
+	if err := ensureTable("old"); err != nil {
+		t.Fatal(err)
+	}
+	// A table from before the unique index, holding a page written twice
+	if _, err := db.Exec("DROP INDEX idx_old_timestamp_unique"); err != nil {
+		t.Fatal(err)
+	}
+	for _, ts := range []string{"2024-03-01T10:00:00Z", "2024-03-01T10:00:00Z", "2024-03-01T10:00:10Z"} {
+		if _, err := db.Exec("INSERT INTO `old` (timestamp) VALUES (?)", ts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ensureTable("old"); err != nil {
+		t.Fatal(err)
+	}
+	var rows int
+	if err := db.QueryRow("SELECT COUNT(*) FROM `old`").Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 2 {
+		t.Fatalf("%d rows after upgrade, want 2", rows)
+	}
+	if _, err := db.Exec("INSERT INTO `old` (timestamp) VALUES ('2024-03-01T10:00:10Z')"); err == nil {
+		t.Fatal("duplicate timestamp accepted after upgrade")
+	}
+}
@@ -15,6 +15,9 @@ var (
 	ErrUnauthorized        = errors.New("unauthorized access")
 	ErrInvalidClaims       = errors.New("invalid token claims")
 	ErrTokenParsingFailed  = errors.New("failed to parse token")
+	ErrUnknownAgent        = errors.New("unknown agent")
+	ErrForbidden           = errors.New("insufficient permissions")
+	ErrAPIKeyNotFound      = errors.New("API key not found")
 )
 
 // Data Processing errors
@@ -31,6 +34,10 @@ var (
 	ErrConfigNotFound      = errors.New("configuration not found")
 	ErrInvalidConfig       = errors.New("invalid configuration")
 	ErrConfigurationError  = errors.New("configuration error")
+	ErrIngestDisabled      = errors.New("ingest is not enabled")
+	ErrConfigEntryNotFound = errors.New("configuration entry not found")
+	ErrConfigEntryExists   = errors.New("configuration entry already exists")
+	ErrConfigNotWritable   = errors.New("configuration cannot be changed at runtime")
 )
 
 // Network and HTTP errors
@@ -55,6 +62,9 @@ var (
 	ErrDatabaseConnection  = errors.New("database connection error")
 	ErrQueryFailed         = errors.New("database query failed")
 	ErrTransactionFailed   = errors.New("database transaction failed")
+	ErrInvalidCursor       = errors.New("invalid pagination cursor")
+	ErrQueryNotSupported   = errors.New("storage backend does not support queries")
+	ErrStorageUnavailable  = errors.New("no storage backend accepted the write")
 )
 
 // ErrorType represents different categories of errors
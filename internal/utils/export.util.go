@@ -0,0 +1,294 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-log/internal/api/models"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export formats accepted by NewExportWriter.
+const (
+	ExportFormatCSV     = "csv"
+	ExportFormatNDJSON  = "ndjson"
+	ExportFormatParquet = "parquet"
+)
+
+// ErrExportFormatUnsupported is returned for formats this build cannot produce.
+var ErrExportFormatUnsupported = errors.New("export format not supported")
+
+// ExportWriter streams snapshots to an output in one export format.
+type ExportWriter interface {
+	// ContentType is the MIME type of the output.
+	ContentType() string
+	// Extension is the file extension used for downloads.
+	Extension() string
+	// WriteBatch appends snapshots and flushes them to the underlying writer.
+	WriteBatch(batch []models.SystemMonitoring) error
+	// Close finishes the output. It does not close the underlying writer.
+	Close() error
+}
+
+// ExportNeedsColumns reports whether a format has a fixed set of columns, which must be
+// collected with ExportColumns before its writer is created.
+func ExportNeedsColumns(format string) bool {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", ExportFormatCSV, ExportFormatParquet:
+		return true
+	}
+	return false
+}
+
+// NewExportWriter returns a writer for the given format. Formats for which ExportNeedsColumns
+// is true write exactly the given columns.
+func NewExportWriter(format string, w io.Writer, columns *ExportColumns) (ExportWriter, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", ExportFormatCSV:
+		if columns == nil {
+			columns = NewExportColumns()
+		}
+		return &csvExportWriter{w: csv.NewWriter(w), columns: columns}, nil
+	case ExportFormatNDJSON, "jsonl":
+		return &ndjsonExportWriter{w: bufio.NewWriter(w)}, nil
+	case ExportFormatParquet:
+		if columns == nil {
+			columns = NewExportColumns()
+		}
+		return newParquetExportWriter(w, columns), nil
+	default:
+		return nil, fmt.Errorf("%w: %q (want csv, ndjson or parquet)", ErrExportFormatUnsupported, format)
+	}
+}
+
+// exportKey turns a mount path or check name into a column-name fragment ("/" -> "root", "/var/lib" -> "var_lib").
+func exportKey(raw string) string {
+	if strings.Trim(raw, "/ ") == "" {
+		return "root"
+	}
+	var b strings.Builder
+	lastUnderscore := true
+	for _, r := range strings.ToLower(raw) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			lastUnderscore = false
+		} else if !lastUnderscore {
+			b.WriteByte('_')
+			lastUnderscore = true
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
+
+// exportField is one flattened column of a snapshot.
+type exportField struct {
+	Key   string
+	Value any
+}
+
+// flattenSnapshot expands a snapshot into columns: timestamp, the metric columns,
+// cpu_architecture, then disk_<path>_* per mount and heartbeat_<name>_* per check.
+func flattenSnapshot(s *models.SystemMonitoring) []exportField {
+	fields := make([]exportField, 0, 1+len(MetricColumns)+len(s.DiskSpace)*4+len(s.Heartbeat)*2)
+	fields = append(fields, exportField{"timestamp", s.Timestamp})
+	for _, col := range MetricColumns {
+		if col.Integer {
+			fields = append(fields, exportField{col.Name, int64(col.Value(s))})
+		} else {
+			fields = append(fields, exportField{col.Name, col.Value(s)})
+		}
+	}
+	fields = append(fields, exportField{cpuArchitectureColumn, s.CPU.Architecture})
+	for _, disk := range s.DiskSpace {
+		prefix := "disk_" + exportKey(disk.Path) + "_"
+		fields = append(fields,
+			exportField{prefix + "used_pct", disk.UsedPct},
+			exportField{prefix + "total_bytes", disk.TotalBytes},
+			exportField{prefix + "used_bytes", disk.UsedBytes},
+			exportField{prefix + "available_bytes", disk.AvailableBytes})
+	}
+	for _, hb := range s.Heartbeat {
+		prefix := "heartbeat_" + exportKey(hb.Name) + "_"
+		fields = append(fields,
+			exportField{prefix + "status", string(hb.Status)},
+			exportField{prefix + "response_ms", hb.ResponseMs})
+	}
+	return fields
+}
+
+// exportKind is the value type of a flattened column.
+type exportKind int
+
+const (
+	exportKindString exportKind = iota
+	exportKindDouble
+	exportKindInt
+	exportKindTime
+)
+
+func exportKindOf(v any) exportKind {
+	switch v.(type) {
+	case float64:
+		return exportKindDouble
+	case int, int64, uint64:
+		return exportKindInt
+	case time.Time:
+		return exportKindTime
+	}
+	return exportKindString
+}
+
+// ExportColumns is the set of flattened columns found in an export range, in first-seen
+// order. The timestamp and metric columns are always present.
+type ExportColumns struct {
+	names []string
+	kinds []exportKind
+	index map[string]int
+}
+
+// NewExportColumns returns a column set holding the columns every snapshot has.
+func NewExportColumns() *ExportColumns {
+	c := &ExportColumns{index: make(map[string]int)}
+	c.add("timestamp", exportKindTime)
+	for _, col := range MetricColumns {
+		if col.Integer {
+			c.add(col.Name, exportKindInt)
+		} else {
+			c.add(col.Name, exportKindDouble)
+		}
+	}
+	c.add(cpuArchitectureColumn, exportKindString)
+	return c
+}
+
+// Add records the columns of every snapshot in the batch.
+func (c *ExportColumns) Add(batch []models.SystemMonitoring) {
+	for i := range batch {
+		for _, f := range flattenSnapshot(&batch[i]) {
+			c.add(f.Key, exportKindOf(f.Value))
+		}
+	}
+}
+
+func (c *ExportColumns) add(name string, kind exportKind) {
+	if _, ok := c.index[name]; !ok {
+		c.index[name] = len(c.names)
+		c.names = append(c.names, name)
+		c.kinds = append(c.kinds, kind)
+	}
+}
+
+// Names returns the columns in output order.
+func (c *ExportColumns) Names() []string { return c.names }
+
+func formatExportValue(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case time.Time:
+		return FormatTimestampUTC(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// csvExportWriter writes one row per snapshot under a header of all collected columns.
+// Fields outside the column set (a disk that first appeared after the columns were
+// collected) are counted and reported when the export closes.
+type csvExportWriter struct {
+	w       *csv.Writer
+	columns *ExportColumns
+	header  bool
+	dropped map[string]struct{}
+}
+
+func (c *csvExportWriter) ContentType() string { return "text/csv; charset=utf-8" }
+func (c *csvExportWriter) Extension() string   { return "csv" }
+
+func (c *csvExportWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+	return c.w.Write(c.columns.Names())
+}
+
+func (c *csvExportWriter) WriteBatch(batch []models.SystemMonitoring) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	row := make([]string, len(c.columns.names))
+	for i := range batch {
+		clear(row)
+		for _, f := range flattenSnapshot(&batch[i]) {
+			idx, ok := c.columns.index[f.Key]
+			if !ok {
+				if c.dropped == nil {
+					c.dropped = make(map[string]struct{})
+				}
+				c.dropped[f.Key] = struct{}{}
+				continue
+			}
+			row[idx] = formatExportValue(f.Value)
+		}
+		if err := c.w.Write(row); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// Close writes the header of an empty export and reports dropped columns.
+func (c *csvExportWriter) Close() error {
+	if len(c.dropped) > 0 {
+		LogWarn("export: %d columns appeared after the header was written and were left out", len(c.dropped))
+	}
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// ndjsonExportWriter writes one flattened JSON object per line.
+type ndjsonExportWriter struct {
+	w *bufio.Writer
+}
+
+func (n *ndjsonExportWriter) ContentType() string { return "application/x-ndjson" }
+func (n *ndjsonExportWriter) Extension() string   { return "ndjson" }
+
+func (n *ndjsonExportWriter) WriteBatch(batch []models.SystemMonitoring) error {
+	for i := range batch {
+		fields := flattenSnapshot(&batch[i])
+		record := make(map[string]any, len(fields))
+		for _, f := range fields {
+			if ts, ok := f.Value.(time.Time); ok {
+				record[f.Key] = FormatTimestampUTC(ts)
+			} else {
+				record[f.Key] = f.Value
+			}
+		}
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrDataMarshalFailed, err)
+		}
+		n.w.Write(line)
+		n.w.WriteByte('\n')
+	}
+	return n.w.Flush()
+}
+
+func (n *ndjsonExportWriter) Close() error { return n.w.Flush() }
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"go-log/internal/api/models"
+)
+
+// parquetRowGroupRows is how many rows are buffered before a row group is written out. It
+// bounds the memory one Parquet export holds.
+const parquetRowGroupRows = 50000
+
+// parquetExportWriter writes one optional column per collected export column. Parquet
+// orders the columns of a group by name, so they appear sorted rather than in CSV order.
+// The footer is written by Close; a file without it does not open, so truncation is evident.
+type parquetExportWriter struct {
+	w        *parquet.Writer
+	columns  *ExportColumns
+	leaves   []int // Parquet column index of each export column
+	buffered int
+	dropped  map[string]struct{}
+}
+
+func newParquetExportWriter(w io.Writer, columns *ExportColumns) *parquetExportWriter {
+	group := make(parquet.Group, len(columns.names))
+	for i, name := range columns.names {
+		group[name] = parquet.Optional(parquetNode(columns.kinds[i]))
+	}
+	schema := parquet.NewSchema("monitoring", group)
+
+	leaves := make([]int, len(columns.names))
+	for i, name := range columns.names {
+		leaf, _ := schema.Lookup(name)
+		leaves[i] = leaf.ColumnIndex
+	}
+	return &parquetExportWriter{
+		w:       parquet.NewWriter(w, schema, parquet.Compression(&parquet.Snappy)),
+		columns: columns,
+		leaves:  leaves,
+	}
+}
+
+// parquetNode is the Parquet type a column of the given kind is stored as.
+func parquetNode(kind exportKind) parquet.Node {
+	switch kind {
+	case exportKindDouble:
+		return parquet.Leaf(parquet.DoubleType)
+	case exportKindInt:
+		return parquet.Int(64)
+	case exportKindTime:
+		return parquet.Timestamp(parquet.Millisecond)
+	}
+	return parquet.String()
+}
+
+func (p *parquetExportWriter) ContentType() string { return "application/vnd.apache.parquet" }
+func (p *parquetExportWriter) Extension() string   { return "parquet" }
+
+func (p *parquetExportWriter) WriteBatch(batch []models.SystemMonitoring) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	rows := make([]parquet.Row, len(batch))
+	for i := range batch {
+		row := make(parquet.Row, len(p.leaves))
+		for _, leaf := range p.leaves {
+			row[leaf] = parquet.NullValue().Level(0, 0, leaf)
+		}
+		for _, f := range flattenSnapshot(&batch[i]) {
+			idx, ok := p.columns.index[f.Key]
+			if !ok {
+				if p.dropped == nil {
+					p.dropped = make(map[string]struct{})
+				}
+				p.dropped[f.Key] = struct{}{}
+				continue
+			}
+			leaf := p.leaves[idx]
+			row[leaf] = parquetValue(f.Value, p.columns.kinds[idx]).Level(0, 1, leaf)
+		}
+		rows[i] = row
+	}
+	if _, err := p.w.WriteRows(rows); err != nil {
+		return err
+	}
+
+	p.buffered += len(rows)
+	if p.buffered >= parquetRowGroupRows {
+		p.buffered = 0
+		return p.w.Flush()
+	}
+	return nil
+}
+
+// parquetValue converts a flattened field to the Parquet value of its column's kind.
+func parquetValue(v any, kind exportKind) parquet.Value {
+	switch kind {
+	case exportKindTime:
+		if ts, ok := v.(time.Time); ok {
+			return parquet.Int64Value(ts.UnixMilli())
+		}
+	case exportKindDouble:
+		if f, ok := v.(float64); ok {
+			return parquet.DoubleValue(f)
+		}
+	case exportKindInt:
+		switch n := v.(type) {
+		case int:
+			return parquet.Int64Value(int64(n))
+		case int64:
+			return parquet.Int64Value(n)
+		case uint64:
+			return parquet.Int64Value(int64(n))
+		}
+	case exportKindString:
+		return parquet.ByteArrayValue([]byte(formatExportValue(v)))
+	}
+	return parquet.NullValue()
+}
+
+// Close writes the remaining row group and the file footer.
+func (p *parquetExportWriter) Close() error {
+	if len(p.dropped) > 0 {
+		LogWarn("export: %d columns appeared after the schema was written and were left out", len(p.dropped))
+	}
+	return p.w.Close()
+}
@@ -0,0 +1,435 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go-log/internal/api/models"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultExporterBatchSize     = 50
+	defaultExporterFlushInterval = 10 * time.Second
+	defaultExporterMaxRetries    = 3
+	defaultExporterTimeout       = 10 * time.Second
+	exporterQueueBatches         = 20 // queued snapshots are capped at batch_size * exporterQueueBatches
+	exporterRetryBase            = 500 * time.Millisecond
+)
+
+// exporterStopTimeout bounds the final flush of a stopping exporter: requests still in
+// flight are cancelled and snapshots not yet sent are dropped once it passes.
+var exporterStopTimeout = 10 * time.Second
+
+// exportFormat encodes a batch of snapshots for one remote-write protocol.
+type exportFormat struct {
+	contentType string
+	authScheme  string // prefix of the Authorization header built from the token
+	encode      func(batch []models.SystemMonitoring, host string) ([]byte, error)
+}
+
+// exportFormats maps exporter types (and aliases) to their encoders.
+var exportFormats = map[string]exportFormat{
+	"influxdb": {contentType: "text/plain; charset=utf-8", authScheme: "Token", encode: encodeInfluxLines},
+	"influx":   {contentType: "text/plain; charset=utf-8", authScheme: "Token", encode: encodeInfluxLines},
+	"otlp":     {contentType: "application/json", authScheme: "Bearer", encode: encodeOTLPMetrics},
+}
+
+// exportPermanentError marks a rejected request that retrying cannot fix (4xx other than 429).
+type exportPermanentError struct {
+	status int
+	body   string
+}
+
+func (e *exportPermanentError) Error() string {
+	if e.status == 0 {
+		return "export failed: " + e.body
+	}
+	return fmt.Sprintf("export rejected with status %d: %s", e.status, e.body)
+}
+
+// exporter batches snapshots in memory and ships them to one remote-write endpoint.
+type exporter struct {
+	cfg           models.ExporterConfig // the entry it was built from, to detect changes on reload
+	name          string
+	format        exportFormat
+	url           string
+	headers       map[string]string
+	batchSize     int
+	maxQueued     int
+	maxRetries    int
+	flushInterval time.Duration
+	client        *http.Client
+
+	mu      sync.Mutex
+	queue   []models.SystemMonitoring
+	dropped int
+
+	// ctx is cancelled when the final flush runs out of time, aborting requests and backoff
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+var (
+	exportersMu     sync.Mutex
+	activeExporters []*exporter
+
+	exportHostOnce sync.Once
+	exportHost     string
+)
+
+// exporterHostname returns the host tag attached to exported series.
+func exporterHostname() string {
+	exportHostOnce.Do(func() {
+		exportHost = "localhost"
+		if name, err := os.Hostname(); err == nil && strings.TrimSpace(name) != "" {
+			exportHost = name
+		}
+	})
+	return exportHost
+}
+
+func parseExporterDuration(value string, fallback time.Duration) (time.Duration, error) {
+	if strings.TrimSpace(value) == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+	return d, nil
+}
+
+// ValidateExporterConfig reports whether an exporter entry would be accepted by ConfigureExporters.
+func ValidateExporterConfig(cfg models.ExporterConfig) error {
+	_, err := newExporter(cfg)
+	return err
+}
+
+// newExporter validates an exporter entry and fills in defaults.
+func newExporter(cfg models.ExporterConfig) (*exporter, error) {
+	kind := strings.ToLower(strings.TrimSpace(cfg.Type))
+	format, ok := exportFormats[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown exporter type %q (want influxdb or otlp)", cfg.Type)
+	}
+
+	name := strings.TrimSpace(cfg.Name)
+	if name == "" {
+		name = kind
+	}
+
+	parsed, err := url.Parse(strings.TrimSpace(cfg.URL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("exporter %s: url must be an absolute http(s) URL", name)
+	}
+
+	flushInterval, err := parseExporterDuration(cfg.FlushInterval, defaultExporterFlushInterval)
+	if err != nil {
+		return nil, fmt.Errorf("exporter %s: invalid flush_interval: %w", name, err)
+	}
+	timeout, err := parseExporterDuration(cfg.Timeout, defaultExporterTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("exporter %s: invalid timeout: %w", name, err)
+	}
+
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultExporterBatchSize
+	}
+	maxRetries := cfg.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultExporterMaxRetries
+	}
+
+	headers := make(map[string]string, len(cfg.Headers)+2)
+	headers["Content-Type"] = format.contentType
+	if token := strings.TrimSpace(cfg.Token); token != "" {
+		headers["Authorization"] = format.authScheme + " " + token
+	}
+	for k, v := range cfg.Headers {
+		headers[k] = v
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &exporter{
+		cfg:           cfg,
+		name:          name,
+		format:        format,
+		url:           parsed.String(),
+		headers:       headers,
+		batchSize:     batchSize,
+		maxQueued:     batchSize * exporterQueueBatches,
+		maxRetries:    maxRetries,
+		flushInterval: flushInterval,
+		client:        GetHTTPClientWithTimeout(timeout),
+		ctx:           ctx,
+		cancel:        cancel,
+		wake:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}, nil
+}
+
+// enqueue adds a snapshot to the batch, dropping the oldest ones once the queue is full.
+func (e *exporter) enqueue(snapshot models.SystemMonitoring) {
+	e.mu.Lock()
+	e.queue = append(e.queue, snapshot)
+	if over := len(e.queue) - e.maxQueued; over > 0 {
+		e.queue = e.queue[over:]
+		e.dropped += over
+	}
+	full := len(e.queue) >= e.batchSize
+	e.mu.Unlock()
+
+	if full {
+		select {
+		case e.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (e *exporter) run() {
+	defer close(e.done)
+	defer func() {
+		if r := recover(); r != nil {
+			LogErrorWithContext("exporter", fmt.Sprintf("exporter %s panic recovered", e.name), fmt.Errorf("%v", r))
+		}
+	}()
+
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.flush(false)
+		case <-e.wake:
+			e.flush(false)
+		case <-e.stop:
+			e.flush(true)
+			return
+		}
+	}
+}
+
+// flush sends queued snapshots in batches. A batch that still fails after retries is put back
+// at the head of the queue for the next flush, unless this is the final flush on shutdown.
+func (e *exporter) flush(final bool) {
+	for {
+		e.mu.Lock()
+		if e.dropped > 0 {
+			LogWarn("exporter %s: queue full, dropped %d oldest snapshots", e.name, e.dropped)
+			e.dropped = 0
+		}
+		if len(e.queue) == 0 {
+			e.mu.Unlock()
+			return
+		}
+		n := min(e.batchSize, len(e.queue))
+		batch := append([]models.SystemMonitoring(nil), e.queue[:n]...)
+		e.queue = e.queue[n:]
+		e.mu.Unlock()
+
+		retries := e.maxRetries
+		if final {
+			retries = 0 // do not hold up shutdown
+		}
+		err := e.send(batch, retries)
+		if err == nil {
+			continue
+		}
+
+		var permanent *exportPermanentError
+		if final {
+			e.mu.Lock()
+			remaining := len(e.queue)
+			e.queue = nil
+			e.mu.Unlock()
+			LogWarnWithContext("exporter", fmt.Sprintf("exporter %s: dropping %d snapshots on stop", e.name, len(batch)+remaining), err)
+			return
+		}
+		if errors.As(err, &permanent) {
+			LogWarnWithContext("exporter", fmt.Sprintf("exporter %s: dropping %d snapshots", e.name, len(batch)), err)
+			continue
+		}
+
+		LogWarnWithContext("exporter", fmt.Sprintf("exporter %s: send failed, will retry on next flush", e.name), err)
+		e.mu.Lock()
+		e.queue = append(batch, e.queue...)
+		if over := len(e.queue) - e.maxQueued; over > 0 {
+			e.queue = e.queue[over:]
+			e.dropped += over
+		}
+		e.mu.Unlock()
+		return
+	}
+}
+
+// send encodes and posts one batch, retrying 429/5xx/network failures with exponential backoff.
+func (e *exporter) send(batch []models.SystemMonitoring, retries int) error {
+	body, err := e.format.encode(batch, exporterHostname())
+	if err != nil {
+		return &exportPermanentError{body: err.Error()}
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = e.post(body)
+		var permanent *exportPermanentError
+		if err == nil || errors.As(err, &permanent) || attempt >= retries {
+			return err
+		}
+		select {
+		case <-time.After(exporterRetryBase << attempt):
+		case <-e.ctx.Done():
+			return err
+		}
+	}
+}
+
+func (e *exporter) post(body []byte) error {
+	if err := e.ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %v", ErrHTTPRequestFailed, err)
+	}
+	ctx, cancel := context.WithTimeout(e.ctx, e.client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return &exportPermanentError{body: err.Error()}
+	}
+	req.Header.Set("User-Agent", "go-monitoring/1.0")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrHTTPRequestFailed, err)
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("%w: status %d: %s", ErrHTTPRequestFailed, resp.StatusCode, strings.TrimSpace(string(snippet)))
+	default:
+		return &exportPermanentError{status: resp.StatusCode, body: strings.TrimSpace(string(snippet))}
+	}
+}
+
+// close runs the final flush and stops the exporter, giving up on pending snapshots after timeout.
+func (e *exporter) close(timeout time.Duration) {
+	timer := time.AfterFunc(timeout, e.cancel)
+	defer timer.Stop()
+	close(e.stop)
+	<-e.done
+	e.cancel()
+}
+
+// stopExporters closes exporters in parallel, so each gets the full final-flush deadline.
+func stopExporters(exporters []*exporter) {
+	var wg sync.WaitGroup
+	for _, e := range exporters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.close(exporterStopTimeout)
+		}()
+	}
+	wg.Wait()
+}
+
+// ConfigureExporters replaces the running exporters with the given entries. Invalid entries
+// are skipped and reported in the returned error; valid ones start regardless.
+// Unchanged entries keep the running exporters and their queued snapshots; only added or
+// edited entries start, and only removed or edited ones are stopped.
+func ConfigureExporters(cfgs []models.ExporterConfig) error {
+	exportersMu.Lock()
+	running := activeExporters
+	exportersMu.Unlock()
+
+	kept := make([]bool, len(running))
+	var errs []error
+	next := make([]*exporter, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		reused := false
+		for i, e := range running {
+			if !kept[i] && reflect.DeepEqual(e.cfg, cfg) {
+				kept[i], reused = true, true
+				next = append(next, e)
+				break
+			}
+		}
+		if reused {
+			continue
+		}
+		e, err := newExporter(cfg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		go e.run()
+		next = append(next, e)
+		LogInfo("exporter %s started (%s)", e.name, e.url)
+	}
+
+	exportersMu.Lock()
+	activeExporters = next
+	exportersMu.Unlock()
+
+	var removed []*exporter
+	for i, e := range running {
+		if !kept[i] {
+			removed = append(removed, e)
+		}
+	}
+	stopExporters(removed)
+	for _, e := range removed {
+		LogInfo("exporter %s stopped", e.name)
+	}
+	return errors.Join(errs...)
+}
+
+// ExportSnapshot queues a snapshot, including its server_metrics, on every running exporter.
+func ExportSnapshot(snapshot *models.SystemMonitoring) {
+	if snapshot == nil {
+		return
+	}
+	exportersMu.Lock()
+	current := activeExporters
+	exportersMu.Unlock()
+
+	for _, e := range current {
+		e.enqueue(*snapshot)
+	}
+}
+
+// StopExporters flushes pending batches and stops every exporter. Each final flush is cut
+// off after exporterStopTimeout, so an unreachable endpoint cannot hold up shutdown.
+func StopExporters() {
+	exportersMu.Lock()
+	current := activeExporters
+	activeExporters = nil
+	exportersMu.Unlock()
+
+	stopExporters(current)
+}
@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-log/internal/api/models"
+)
+
+// exportReceiver records the requests sent to a stand-in remote-write endpoint.
+type exportReceiver struct {
+	mu       sync.Mutex
+	bodies   []string
+	headers  []http.Header
+	received chan struct{}
+}
+
+func newExportReceiver(t *testing.T, status int) (*exportReceiver, *httptest.Server) {
+	t.Helper()
+	r := &exportReceiver{received: make(chan struct{}, 100)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.bodies = append(r.bodies, string(body))
+		r.headers = append(r.headers, req.Header.Clone())
+		r.mu.Unlock()
+		w.WriteHeader(status)
+		r.received <- struct{}{}
+	}))
+	t.Cleanup(srv.Close)
+	return r, srv
+}
+
+func (r *exportReceiver) wait(t *testing.T) {
+	t.Helper()
+	select {
+	case <-r.received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no export request received")
+	}
+}
+
+func (r *exportReceiver) last() (string, http.Header) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.bodies[len(r.bodies)-1], r.headers[len(r.headers)-1]
+}
+
+func exportTestSnapshot() *models.SystemMonitoring {
+	s := &models.SystemMonitoring{Timestamp: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	s.CPU.UsagePercent = 12.5
+	s.DiskSpace = []models.DiskSpace{{Path: "/data", Device: "sda1", UsedPct: 40}}
+	s.Heartbeat = []models.ServerCheck{{Name: "api", URL: "http://api", Status: models.ServerStatusUp, ResponseMs: 8}}
+	return s
+}
+
+func TestInfluxExporterWritesLineProtocol(t *testing.T) {
+	t.Cleanup(StopExporters)
+	receiver, srv := newExportReceiver(t, http.StatusNoContent)
+	if err := ConfigureExporters([]models.ExporterConfig{{Type: "influxdb", URL: srv.URL + "/api/v2/write?bucket=b", Token: "tok", BatchSize: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	ExportSnapshot(exportTestSnapshot())
+	receiver.wait(t)
+
+	body, header := receiver.last()
+	if got := header.Get("Authorization"); got != "Token tok" {
+		t.Errorf("Authorization %q, want %q", got, "Token tok")
+	}
+	if got := header.Get("Content-Type"); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("Content-Type %q", got)
+	}
+	ts := " 1709287200000000000"
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	want := []struct{ prefix, field string }{
+		{"system,host=", "cpu_usage_percent=12.5"},
+		{"disk,host=", "used_pct=40"},
+		{"heartbeat,host=", "up=true"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), body)
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(lines[i], w.prefix) || !strings.Contains(lines[i], w.field) || !strings.HasSuffix(lines[i], ts) {
+			t.Errorf("line %d = %q, want prefix %q, field %q and timestamp%s", i, lines[i], w.prefix, w.field, ts)
+		}
+	}
+	if !strings.Contains(lines[1], `path=/data`) || !strings.Contains(lines[2], "response_ms=8i") {
+		t.Errorf("tags or integer fields missing:\n%s", body)
+	}
+}
+
+func TestOTLPExporterPostsMetrics(t *testing.T) {
+	t.Cleanup(StopExporters)
+	receiver, srv := newExportReceiver(t, http.StatusOK)
+	if err := ConfigureExporters([]models.ExporterConfig{{Type: "otlp", URL: srv.URL + "/v1/metrics", Token: "tok", BatchSize: 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	ExportSnapshot(exportTestSnapshot())
+	receiver.wait(t)
+
+	body, header := receiver.last()
+	if got := header.Get("Authorization"); got != "Bearer tok" {
+		t.Errorf("Authorization %q, want %q", got, "Bearer tok")
+	}
+	var req otlpExportRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("invalid OTLP JSON: %v", err)
+	}
+	if len(req.ResourceMetrics) != 1 || len(req.ResourceMetrics[0].ScopeMetrics) != 1 {
+		t.Fatalf("unexpected payload shape: %s", body)
+	}
+	metrics := map[string]*otlpMetric{}
+	for _, m := range req.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+	cpu := metrics["system.cpu_usage_percent"]
+	if cpu == nil || cpu.Gauge == nil || len(cpu.Gauge.DataPoints) != 1 || *cpu.Gauge.DataPoints[0].AsDouble != 12.5 {
+		t.Errorf("system.cpu_usage_percent = %+v", cpu)
+	} else if cpu.Gauge.DataPoints[0].TimeUnixNano != "1709287200000000000" {
+		t.Errorf("timeUnixNano %s", cpu.Gauge.DataPoints[0].TimeUnixNano)
+	}
+	if sent := metrics["system.network_bytes_sent"]; sent == nil || sent.Sum == nil || !sent.Sum.IsMonotonic {
+		t.Errorf("system.network_bytes_sent is not a monotonic sum: %+v", sent)
+	}
+	if hb := metrics["heartbeat.up"]; hb == nil || len(hb.Gauge.DataPoints) != 1 || *hb.Gauge.DataPoints[0].AsInt != "1" {
+		t.Errorf("heartbeat.up = %+v", hb)
+	}
+}
+
+func TestConfigureExportersRestartsOnlyChangedEntries(t *testing.T) {
+	t.Cleanup(StopExporters)
+	_, srv := newExportReceiver(t, http.StatusNoContent)
+	influx := models.ExporterConfig{Type: "influxdb", Name: "influx", URL: srv.URL + "/write"}
+	otlp := models.ExporterConfig{Type: "otlp", Name: "otlp", URL: srv.URL + "/v1/metrics"}
+	if err := ConfigureExporters([]models.ExporterConfig{influx, otlp}); err != nil {
+		t.Fatal(err)
+	}
+	running := func() map[string]*exporter {
+		exportersMu.Lock()
+		defer exportersMu.Unlock()
+		byName := map[string]*exporter{}
+		for _, e := range activeExporters {
+			byName[e.name] = e
+		}
+		return byName
+	}
+	before := running()
+
+	otlp.BatchSize = 10
+	if err := ConfigureExporters([]models.ExporterConfig{influx, otlp}); err != nil {
+		t.Fatal(err)
+	}
+	after := running()
+	if after["influx"] != before["influx"] {
+		t.Error("unchanged influx exporter was restarted")
+	}
+	if after["otlp"] == before["otlp"] {
+		t.Error("edited otlp exporter kept running with its old settings")
+	}
+	select {
+	case <-before["otlp"].done:
+	default:
+		t.Error("replaced otlp exporter is still running")
+	}
+
+	if err := ConfigureExporters([]models.ExporterConfig{otlp}); err != nil {
+		t.Fatal(err)
+	}
+	if final := running(); len(final) != 1 || final["otlp"] != after["otlp"] {
+		t.Errorf("after removing influx: %v", final)
+	}
+}
+
+func TestStopExportersBoundsFinalFlush(t *testing.T) {
+	t.Cleanup(StopExporters)
+	previous := exporterStopTimeout
+	exporterStopTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { exporterStopTimeout = previous })
+
+	// An endpoint that accepts the connection but never answers
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	cfg := models.ExporterConfig{Type: "influxdb", URL: srv.URL, Timeout: "1m", FlushInterval: "1h"}
+	if err := ConfigureExporters([]models.ExporterConfig{cfg}); err != nil {
+		t.Fatal(err)
+	}
+	ExportSnapshot(exportTestSnapshot())
+
+	start := time.Now()
+	StopExporters()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("StopExporters took %s, want about %s", elapsed, exporterStopTimeout)
+	}
+}
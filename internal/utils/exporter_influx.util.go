@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"bytes"
+	"go-log/internal/api/models"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InfluxDB line protocol escaping (https://docs.influxdata.com/influxdb/v2/reference/syntax/line-protocol/).
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxTagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+	influxStringEscaper      = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+)
+
+// influxLine builds one line protocol record.
+type influxLine struct {
+	buf    *bytes.Buffer
+	fields int
+}
+
+func newInfluxLine(buf *bytes.Buffer, measurement string, tags ...string) *influxLine {
+	buf.WriteString(influxMeasurementEscaper.Replace(measurement))
+	for i := 0; i+1 < len(tags); i += 2 {
+		if tags[i+1] == "" {
+			continue // empty tag values are rejected by InfluxDB
+		}
+		buf.WriteByte(',')
+		buf.WriteString(influxTagEscaper.Replace(tags[i]))
+		buf.WriteByte('=')
+		buf.WriteString(influxTagEscaper.Replace(tags[i+1]))
+	}
+	return &influxLine{buf: buf}
+}
+
+func (l *influxLine) sep() {
+	if l.fields == 0 {
+		l.buf.WriteByte(' ')
+	} else {
+		l.buf.WriteByte(',')
+	}
+	l.fields++
+}
+
+func (l *influxLine) float(key string, v float64) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+	l.sep()
+	l.buf.WriteString(influxTagEscaper.Replace(key))
+	l.buf.WriteByte('=')
+	l.buf.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+}
+
+func (l *influxLine) integer(key string, v int64) {
+	l.sep()
+	l.buf.WriteString(influxTagEscaper.Replace(key))
+	l.buf.WriteByte('=')
+	l.buf.WriteString(strconv.FormatInt(v, 10))
+	l.buf.WriteByte('i')
+}
+
+func (l *influxLine) boolean(key string, v bool) {
+	l.sep()
+	l.buf.WriteString(influxTagEscaper.Replace(key))
+	l.buf.WriteByte('=')
+	l.buf.WriteString(strconv.FormatBool(v))
+}
+
+func (l *influxLine) str(key, v string) {
+	l.sep()
+	l.buf.WriteString(influxTagEscaper.Replace(key))
+	l.buf.WriteString(`="`)
+	l.buf.WriteString(influxStringEscaper.Replace(v))
+	l.buf.WriteByte('"')
+}
+
+// end terminates the record with a nanosecond timestamp. Records without fields are discarded.
+func (l *influxLine) end(start int, ts int64) {
+	if l.fields == 0 {
+		l.buf.Truncate(start)
+		return
+	}
+	l.buf.WriteByte(' ')
+	l.buf.WriteString(strconv.FormatInt(ts, 10))
+	l.buf.WriteByte('\n')
+}
+
+// exportTimestamp returns the snapshot time in nanoseconds, falling back to now for unset times.
+func exportTimestamp(s *models.SystemMonitoring) int64 {
+	if s.Timestamp.IsZero() {
+		return time.Now().UnixNano()
+	}
+	return s.Timestamp.UnixNano()
+}
+
+// encodeInfluxLines renders snapshots as InfluxDB line protocol:
+// system (host metrics), disk (per mount), heartbeat (per check) and server (per remote server).
+func encodeInfluxLines(batch []models.SystemMonitoring, host string) ([]byte, error) {
+	var buf bytes.Buffer
+	for i := range batch {
+		s := &batch[i]
+		ts := exportTimestamp(s)
+
+		start := buf.Len()
+		line := newInfluxLine(&buf, "system", "host", host)
+		for _, col := range MetricColumns {
+			if col.Integer {
+				line.integer(col.Name, int64(col.Value(s)))
+			} else {
+				line.float(col.Name, col.Value(s))
+			}
+		}
+		line.end(start, ts)
+
+		for _, disk := range s.DiskSpace {
+			start = buf.Len()
+			line = newInfluxLine(&buf, "disk", "host", host, "path", disk.Path, "device", disk.Device, "fstype", disk.FileSystem)
+			line.float("used_pct", disk.UsedPct)
+			line.integer("total_bytes", int64(disk.TotalBytes))
+			line.integer("used_bytes", int64(disk.UsedBytes))
+			line.integer("available_bytes", int64(disk.AvailableBytes))
+			line.end(start, ts)
+		}
+
+		for _, hb := range s.Heartbeat {
+			start = buf.Len()
+			line = newInfluxLine(&buf, "heartbeat", "host", host, "name", hb.Name, "url", hb.URL)
+			line.boolean("up", hb.Status == models.ServerStatusUp)
+			line.integer("response_ms", hb.ResponseMs)
+			line.end(start, ts)
+		}
+
+		for _, srv := range s.ServerMetrics {
+			start = buf.Len()
+			line = newInfluxLine(&buf, "server", "host", host, "server", srv.Name, "address", srv.Address)
+			line.float("cpu_usage", srv.CPUUsage)
+			line.float("memory_used_percent", srv.MemoryUsedPercent)
+			line.float("disk_used_percent", srv.DiskUsedPercent)
+			line.integer("network_in_bytes", int64(srv.NetworkInBytes))
+			line.integer("network_out_bytes", int64(srv.NetworkOutBytes))
+			if srv.Status != "" {
+				line.str("status", srv.Status)
+			}
+			if srv.CircuitState != "" {
+				line.boolean("circuit_open", srv.CircuitState != models.CircuitClosed)
+				line.integer("consecutive_failures", int64(srv.ConsecutiveFailures))
+			}
+			line.end(start, ts)
+		}
+	}
+	return buf.Bytes(), nil
+}
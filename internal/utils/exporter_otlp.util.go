@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"encoding/json"
+	"go-log/internal/api/models"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// OTLP/HTTP JSON payload (opentelemetry-proto metrics/v1), limited to the parts used here.
+type otlpExportRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope     `json:"scope"`
+	Metrics []*otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name  string     `json:"name"`
+	Unit  string     `json:"unit,omitempty"`
+	Gauge *otlpGauge `json:"gauge,omitempty"`
+	Sum   *otlpSum   `json:"sum,omitempty"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality"` // 2 = cumulative
+	IsMonotonic            bool            `json:"isMonotonic"`
+}
+
+type otlpDataPoint struct {
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	TimeUnixNano string         `json:"timeUnixNano"`
+	AsDouble     *float64       `json:"asDouble,omitempty"`
+	AsInt        *string        `json:"asInt,omitempty"` // int64 is string-encoded in OTLP JSON
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// otlpCumulativeMetrics are running counters exported as monotonic sums rather than gauges.
+var otlpCumulativeMetrics = map[string]bool{
+	"system.network_bytes_sent": true,
+	"system.network_bytes_recv": true,
+	"system.diskio_read_bytes":  true,
+	"system.diskio_write_bytes": true,
+	"server.network_in_bytes":   true,
+	"server.network_out_bytes":  true,
+}
+
+func otlpAttrs(pairs ...string) []otlpKeyValue {
+	attrs := make([]otlpKeyValue, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+		attrs = append(attrs, otlpKeyValue{Key: pairs[i], Value: otlpAnyValue{StringValue: pairs[i+1]}})
+	}
+	return attrs
+}
+
+// otlpUnit derives a UCUM unit from a metric name.
+func otlpUnit(name string) string {
+	switch {
+	case strings.HasSuffix(name, "percent") || strings.HasSuffix(name, "pct") || strings.HasSuffix(name, "_usage"):
+		return "%"
+	case strings.Contains(name, "bytes"):
+		return "By"
+	case strings.HasSuffix(name, "_ms"):
+		return "ms"
+	default:
+		return "1"
+	}
+}
+
+// otlpBuilder groups data points by metric name, preserving first-seen order.
+type otlpBuilder struct {
+	metrics []*otlpMetric
+	index   map[string]*otlpMetric
+}
+
+func (b *otlpBuilder) metric(name string) *otlpMetric {
+	if m, ok := b.index[name]; ok {
+		return m
+	}
+	m := &otlpMetric{Name: name, Unit: otlpUnit(name)}
+	if otlpCumulativeMetrics[name] {
+		m.Sum = &otlpSum{AggregationTemporality: 2, IsMonotonic: true}
+	} else {
+		m.Gauge = &otlpGauge{}
+	}
+	b.index[name] = m
+	b.metrics = append(b.metrics, m)
+	return m
+}
+
+func (b *otlpBuilder) add(name string, point otlpDataPoint) {
+	m := b.metric(name)
+	if m.Sum != nil {
+		m.Sum.DataPoints = append(m.Sum.DataPoints, point)
+	} else {
+		m.Gauge.DataPoints = append(m.Gauge.DataPoints, point)
+	}
+}
+
+func (b *otlpBuilder) double(name string, ts string, v float64, attrs []otlpKeyValue) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+	b.add(name, otlpDataPoint{Attributes: attrs, TimeUnixNano: ts, AsDouble: &v})
+}
+
+func (b *otlpBuilder) integer(name string, ts string, v int64, attrs []otlpKeyValue) {
+	s := strconv.FormatInt(v, 10)
+	b.add(name, otlpDataPoint{Attributes: attrs, TimeUnixNano: ts, AsInt: &s})
+}
+
+// encodeOTLPMetrics renders snapshots as an OTLP/HTTP JSON ExportMetricsServiceRequest.
+// Host metrics are named system.<column>; disks, heartbeats and remote servers carry identifying attributes.
+func encodeOTLPMetrics(batch []models.SystemMonitoring, host string) ([]byte, error) {
+	b := &otlpBuilder{index: make(map[string]*otlpMetric)}
+
+	for i := range batch {
+		s := &batch[i]
+		ts := strconv.FormatInt(exportTimestamp(s), 10)
+
+		for _, col := range MetricColumns {
+			name := "system." + col.Name
+			if col.Integer {
+				b.integer(name, ts, int64(col.Value(s)), nil)
+			} else {
+				b.double(name, ts, col.Value(s), nil)
+			}
+		}
+
+		for _, disk := range s.DiskSpace {
+			attrs := otlpAttrs("path", disk.Path, "device", disk.Device, "fstype", disk.FileSystem)
+			b.double("system.disk.used_pct", ts, disk.UsedPct, attrs)
+			b.integer("system.disk.total_bytes", ts, int64(disk.TotalBytes), attrs)
+			b.integer("system.disk.used_bytes", ts, int64(disk.UsedBytes), attrs)
+			b.integer("system.disk.available_bytes", ts, int64(disk.AvailableBytes), attrs)
+		}
+
+		for _, hb := range s.Heartbeat {
+			attrs := otlpAttrs("name", hb.Name, "url", hb.URL)
+			up := int64(0)
+			if hb.Status == models.ServerStatusUp {
+				up = 1
+			}
+			b.integer("heartbeat.up", ts, up, attrs)
+			b.integer("heartbeat.response_ms", ts, hb.ResponseMs, attrs)
+		}
+
+		for _, srv := range s.ServerMetrics {
+			attrs := otlpAttrs("server.name", srv.Name, "server.address", srv.Address)
+			b.double("server.cpu_usage", ts, srv.CPUUsage, attrs)
+			b.double("server.memory_used_percent", ts, srv.MemoryUsedPercent, attrs)
+			b.double("server.disk_used_percent", ts, srv.DiskUsedPercent, attrs)
+			b.integer("server.network_in_bytes", ts, int64(srv.NetworkInBytes), attrs)
+			b.integer("server.network_out_bytes", ts, int64(srv.NetworkOutBytes), attrs)
+			if srv.CircuitState != "" {
+				circuitOpen := int64(0)
+				if srv.CircuitState != models.CircuitClosed {
+					circuitOpen = 1
+				}
+				b.integer("server.circuit_open", ts, circuitOpen, attrs)
+				b.integer("server.consecutive_failures", ts, int64(srv.ConsecutiveFailures), attrs)
+			}
+		}
+	}
+
+	req := otlpExportRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: otlpResource{Attributes: otlpAttrs("service.name", "go-log", "host.name", host)},
+		ScopeMetrics: []otlpScopeMetrics{{
+			Scope:   otlpScope{Name: "go-log"},
+			Metrics: b.metrics,
+		}},
+	}}}
+	return json.Marshal(req)
+}
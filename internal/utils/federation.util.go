@@ -0,0 +1,294 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-log/internal/api/models"
+	"go-log/internal/config"
+)
+
+// Headers of a signed federation request. The signature covers the method, path and
+// query, timestamp, nonce and a SHA-256 of the body.
+const (
+	FederationKeyIDHeader     = "X-GoLog-Key-Id"
+	FederationTimestampHeader = "X-GoLog-Timestamp"
+	FederationNonceHeader     = "X-GoLog-Nonce"
+	FederationSignatureHeader = "X-GoLog-Signature"
+
+	federationSignatureVersion = "v1="
+	defaultFederationKeyID     = "default"
+	maxSignedBodyBytes         = 1 << 20
+)
+
+var (
+	federationNonces   = map[string]time.Time{}
+	federationNoncesMu sync.Mutex
+
+	serverClients   = map[string]versionedClient{}
+	serverClientsMu sync.Mutex
+)
+
+// versionedClient is a client built from TLS files as they were at version.
+type versionedClient struct {
+	version string
+	client  *http.Client
+}
+
+// ServerAuth returns the credentials to present to server. The top-level token is kept as
+// a shorthand for auth.token.
+func ServerAuth(server models.ServerEndpoint) models.ServerAuth {
+	var auth models.ServerAuth
+	if server.Auth != nil {
+		auth = *server.Auth
+	}
+	if auth.Token == "" {
+		auth.Token = server.Token
+	}
+	return auth
+}
+
+// NewServerRequest builds a request to a monitored server carrying its credentials, and
+// returns the client to send it with, which presents the server's client certificate when
+// one is configured.
+func NewServerRequest(ctx context.Context, server models.ServerEndpoint, method, url string, body []byte) (*http.Request, *http.Client, error) {
+	auth := ServerAuth(server)
+	client, err := serverHTTPClient(auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "go-monitoring/1.0")
+	req.Header.Set("Accept", "application/json")
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case auth.Token != "":
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+	case auth.Username != "":
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+	if auth.HMACSecret != "" {
+		if err := SignFederationRequest(req, body, auth.HMACKeyID, auth.HMACSecret); err != nil {
+			return nil, nil, err
+		}
+	}
+	return req, client, nil
+}
+
+// serverHTTPClient returns the shared client, or for mTLS and custom CAs a client with its
+// own TLS settings. Clients are reused until one of their files changes, so rotated
+// certificates are picked up on the next poll.
+func serverHTTPClient(auth models.ServerAuth) (*http.Client, error) {
+	if auth.ClientCert == "" && auth.CACert == "" && auth.ServerName == "" {
+		return GetHTTPClient(), nil
+	}
+	key := strings.Join([]string{auth.ClientCert, auth.ClientKey, auth.CACert, auth.ServerName}, "|")
+	version := strings.Join([]string{fileVersion(auth.ClientCert), fileVersion(auth.ClientKey), fileVersion(auth.CACert)}, "|")
+
+	serverClientsMu.Lock()
+	defer serverClientsMu.Unlock()
+	previous, cached := serverClients[key]
+	if cached && previous.version == version {
+		return previous.client, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: auth.ServerName}
+	if auth.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(auth.ClientCert, auth.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: client certificate: %v", ErrInvalidConfig, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if auth.CACert != "" {
+		pool, err := LoadCertPool(auth.CACert)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	base := GetHTTPClient()
+	transport, ok := base.Transport.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("%w: shared HTTP transport cannot be customized", ErrConfigurationError)
+	}
+	transport = transport.Clone()
+	transport.TLSClientConfig = tlsConfig
+	client := &http.Client{Transport: transport, Timeout: base.Timeout}
+	if cached {
+		previous.client.CloseIdleConnections()
+	}
+	serverClients[key] = versionedClient{version: version, client: client}
+	return client, nil
+}
+
+// fileVersion identifies the current contents of path by modification time and size.
+func fileVersion(path string) string {
+	if path == "" {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "missing"
+	}
+	return fmt.Sprintf("%d.%d", info.ModTime().UnixNano(), info.Size())
+}
+
+// LoadCertPool reads a PEM bundle of CA certificates.
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: CA bundle: %v", ErrInvalidConfig, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%w: CA bundle %s holds no PEM certificates", ErrInvalidConfig, path)
+	}
+	return pool, nil
+}
+
+// DoHTTPRequestWithLimits sends req with client and reads the response with the same
+// status and size checks as MakeHTTPRequestWithLimits.
+func DoHTTPRequestWithLimits(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	return readLimitedResponse(resp)
+}
+
+// SignFederationRequest adds an HMAC-SHA256 signature to req. body must be the request
+// body, which is hashed into the signature.
+func SignFederationRequest(req *http.Request, body []byte, keyID, secret string) error {
+	if keyID == "" {
+		keyID = defaultFederationKeyID
+	}
+	nonce, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(FederationKeyIDHeader, keyID)
+	req.Header.Set(FederationTimestampHeader, timestamp)
+	req.Header.Set(FederationNonceHeader, nonce)
+	req.Header.Set(FederationSignatureHeader, federationSignatureVersion+federationSignature(secret, req.Method, req.URL.RequestURI(), timestamp, nonce, body))
+	return nil
+}
+
+func federationSignature(secret, method, requestURI, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%s", strings.ToUpper(method), requestURI, timestamp, nonce, hex.EncodeToString(bodyHash[:]))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyFederationRequest checks the signature of a signed request against
+// FEDERATION_HMAC_KEYS and returns the key ID that signed it. Requests outside
+// FEDERATION_MAX_CLOCK_SKEW, and nonces seen before, are rejected. The body is read and
+// put back for the handler.
+func VerifyFederationRequest(r *http.Request) (string, error) {
+	envConfig := config.GetEnvConfig()
+	keys := parseFederationKeys(envConfig.FederationHMACKeys)
+	if len(keys) == 0 {
+		return "", fmt.Errorf("%w: request signing is not configured on this node", ErrInvalidCredentials)
+	}
+	keyID := r.Header.Get(FederationKeyIDHeader)
+	if keyID == "" {
+		keyID = defaultFederationKeyID
+	}
+	secret, ok := keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown signing key %q", ErrInvalidCredentials, keyID)
+	}
+
+	timestamp := r.Header.Get(FederationTimestampHeader)
+	nonce := r.Header.Get(FederationNonceHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || nonce == "" || len(nonce) > 128 {
+		return "", fmt.Errorf("%w: signed request needs %s and %s", ErrInvalidCredentials, FederationTimestampHeader, FederationNonceHeader)
+	}
+	signedAt := time.Unix(seconds, 0)
+	if skew := time.Since(signedAt); skew > envConfig.FederationMaxClockSkew || skew < -envConfig.FederationMaxClockSkew {
+		return "", fmt.Errorf("%w: signature timestamp is %s off", ErrTokenExpired, skew.Round(time.Second))
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, err = io.ReadAll(io.LimitReader(r.Body, maxSignedBodyBytes+1))
+		r.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read request body: %w", err)
+		}
+		if len(body) > maxSignedBodyBytes {
+			return "", fmt.Errorf("%w: signed request body is too large", ErrValidationFailed)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	expected := federationSignatureVersion + federationSignature(secret, r.Method, r.URL.RequestURI(), timestamp, nonce, body)
+	if !hmac.Equal([]byte(r.Header.Get(FederationSignatureHeader)), []byte(expected)) {
+		return "", fmt.Errorf("%w: request signature does not match", ErrInvalidCredentials)
+	}
+
+	// Only requests with a valid signature reach the nonce store, so it cannot be flooded
+	federationNoncesMu.Lock()
+	defer federationNoncesMu.Unlock()
+	now := time.Now()
+	for seen, expires := range federationNonces {
+		if now.After(expires) {
+			delete(federationNonces, seen)
+		}
+	}
+	nonceKey := keyID + ":" + nonce
+	if _, replayed := federationNonces[nonceKey]; replayed {
+		return "", fmt.Errorf("%w: request nonce was already used", ErrInvalidCredentials)
+	}
+	federationNonces[nonceKey] = signedAt.Add(envConfig.FederationMaxClockSkew)
+	return keyID, nil
+}
+
+// parseFederationKeys parses FEDERATION_HMAC_KEYS: keyID:secret pairs separated by commas.
+// A secret without a key ID belongs to the key ID "default".
+func parseFederationKeys(value string) map[string]string {
+	keys := map[string]string{}
+	for entry := range strings.SplitSeq(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		keyID, secret, found := strings.Cut(entry, ":")
+		if !found {
+			keyID, secret = defaultFederationKeyID, entry
+		}
+		keys[strings.TrimSpace(keyID)] = strings.TrimSpace(secret)
+	}
+	return keys
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
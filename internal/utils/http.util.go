@@ -136,6 +136,12 @@ func MakeHTTPRequestWithLimits(ctx context.Context, method, url string, body io.
 	if err != nil {
 		return nil, err
 	}
+	return readLimitedResponse(resp)
+}
+
+// readLimitedResponse checks the status of resp and reads its body up to the configured
+// response size limit.
+func readLimitedResponse(resp *http.Response) ([]byte, error) {
 	defer func() {
 		if resp.Body != nil {
 			resp.Body.Close()
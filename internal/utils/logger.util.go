@@ -6,6 +6,7 @@ import (
 	"go-log/internal/api/models"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
@@ -66,45 +67,50 @@ func BuildMonitoringLogEntry(data *models.SystemMonitoring) models.MonitoringLog
 	}
 }
 
-// LogMonitoringData writes monitoring data to daily log file
+// LogMonitoringData writes monitoring data to every configured storage backend
 func LogMonitoringData(data *models.SystemMonitoring) error {
 	if logConfig == nil {
 		return fmt.Errorf("logger not initialized")
 	}
 
-	// Create log entry
-	logEntry := BuildMonitoringLogEntry(data)
-
-	// Write to selected storage backends (empty list = none)
-	if len(logConfig.Storage) == 0 {
-		return nil
-	}
-
+	// Write to selected storage backends (empty list = none, unknown names are ignored)
 	var firstErr error
-	for _, backend := range logConfig.Storage {
-		switch strings.ToLower(strings.TrimSpace(backend)) {
-		case "file":
-			if err := writeLogEntry(logEntry); err != nil && firstErr == nil {
-				firstErr = err
-			}
-		case "sqlite":
-			if err := writeToTableInternal(DefaultTableName, logEntry); err != nil && firstErr == nil {
-				firstErr = err
-			}
-        case "postgres":
-            if IsPostgresInitialized() {
-                if err := WriteToPostgres(DefaultTableName, logEntry); err != nil && firstErr == nil {
-                    firstErr = err
-                }
-            }
-		default:
-			// ignore unknown values
+	for _, backend := range EnabledStorageBackends(logConfig.Storage) {
+		if err := backend.Write(DefaultTableName, data); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", backend.Name(), err)
 		}
 	}
 
 	return firstErr
 }
 
+// writeMonitoringLogFile appends a snapshot to the daily log file
+func writeMonitoringLogFile(data *models.SystemMonitoring) error {
+	return writeLogEntry(BuildMonitoringLogEntry(data))
+}
+
+// GetFileTables lists "default" plus every per-server log directory
+func GetFileTables() ([]string, error) {
+	if logConfig == nil || logConfig.Path == "" {
+		return nil, fmt.Errorf("logger not initialized")
+	}
+
+	tables := []string{displayTableName(DefaultTableName)}
+	entries, err := os.ReadDir(filepath.Join(logConfig.Path, "servers"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return tables, nil
+		}
+		return nil, fmt.Errorf("failed to read server log directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			tables = append(tables, entry.Name())
+		}
+	}
+	return tables, nil
+}
+
 // formatHeartbeatForLog converts heartbeat data to log-friendly format
 func formatHeartbeatForLog(heartbeat []models.ServerCheck) []map[string]any {
 	var result []map[string]any
@@ -250,6 +256,181 @@ func WriteServerLogToFile(basePath string, server models.ServerEndpoint, payload
 	return nil
 }
 
+// fileTableDir returns the directory holding a table's daily log files: the log path itself
+// for the default table, servers/<name> for server tables.
+func fileTableDir(table string) (dir string, isDefault bool, err error) {
+	if logConfig == nil || logConfig.Path == "" {
+		return "", false, fmt.Errorf("logger not initialized")
+	}
+	base, err := ValidateLogPath(logConfig.Path)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid log directory: %w", err)
+	}
+
+	name := displayTableName(strings.TrimSpace(table))
+	if name == "" || name == displayTableName(DefaultTableName) {
+		return base, true, nil
+	}
+	dirName, err := ValidateServerDirName(name)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid server name: %w", err)
+	}
+	return filepath.Join(base, "servers", dirName), false, nil
+}
+
+// readLogArray decodes a daily log file into v. A missing file leaves v untouched.
+func readLogArray(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read log file: %w", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse log file %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// appendLogSnapshots writes snapshots into the daily files matching their own timestamps,
+// so restored history lands on the day it was recorded rather than today.
+func appendLogSnapshots(table string, batch []models.SystemMonitoring) error {
+	dir, isDefault, err := fileTableDir(table)
+	if err != nil {
+		return err
+	}
+	if err := CreateSecureDirectory(dir); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	// Group by UTC day so every file is rewritten once per batch
+	byDay := make(map[string][]*models.SystemMonitoring)
+	var days []string
+	for i := range batch {
+		if batch[i].Timestamp.IsZero() {
+			batch[i].Timestamp = NowUTC()
+		}
+		day := batch[i].Timestamp.UTC().Format("2006-01-02")
+		if _, ok := byDay[day]; !ok {
+			days = append(days, day)
+		}
+		byDay[day] = append(byDay[day], &batch[i])
+	}
+
+	for _, day := range days {
+		logPath := filepath.Join(dir, day+".log")
+		var jsonData []byte
+		if isDefault {
+			var entries []models.MonitoringLogEntry
+			if err := readLogArray(logPath, &entries); err != nil {
+				return err
+			}
+			for _, snapshot := range byDay[day] {
+				entries = append(entries, BuildMonitoringLogEntry(snapshot))
+			}
+			jsonData, err = json.Marshal(entries)
+		} else {
+			var entries []models.ServerLogEntry
+			if err := readLogArray(logPath, &entries); err != nil {
+				return err
+			}
+			for _, snapshot := range byDay[day] {
+				// Same shape as a fetched /api/v1/monitoring payload
+				payload, err := json.Marshal([]*models.SystemMonitoring{snapshot})
+				if err != nil {
+					return fmt.Errorf("failed to marshal server payload: %w", err)
+				}
+				if trimmed, terr := FilterMonitoringPayload(payload); terr == nil && len(trimmed) > 0 {
+					payload = trimmed
+				}
+				entries = append(entries, models.ServerLogEntry{
+					Time:    FormatTimestampUTC(snapshot.Timestamp),
+					Payload: payload,
+				})
+			}
+			jsonData, err = json.Marshal(entries)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to marshal log entries: %w", err)
+		}
+		if err := os.WriteFile(logPath, jsonData, 0640); err != nil {
+			return fmt.Errorf("failed to write log file: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReadLogSnapshots replays a table's daily log files oldest first, calling fn once per file
+// dated after afterDay ("2006-01-02"; empty reads everything). Entries that cannot be decoded
+// are skipped. The default table only keeps the dashboard fields, so its snapshots are partial.
+func ReadLogSnapshots(table, afterDay string, fn func(day string, batch []models.SystemMonitoring) error) error {
+	dir, isDefault, err := fileTableDir(table)
+	if err != nil {
+		return err
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read log directory: %w", err)
+	}
+
+	var days []string
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".log" {
+			continue
+		}
+		day := strings.TrimSuffix(file.Name(), ".log")
+		if _, err := time.Parse("2006-01-02", day); err != nil || day <= afterDay {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	for _, day := range days {
+		logPath := filepath.Join(dir, day+".log")
+		var batch []models.SystemMonitoring
+		if isDefault {
+			var entries []models.MonitoringLogEntry
+			if err := readLogArray(logPath, &entries); err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				if snapshot, err := SnapshotFromLogEntry(entry); err == nil {
+					batch = append(batch, *snapshot)
+				}
+			}
+		} else {
+			var entries []models.ServerLogEntry
+			if err := readLogArray(logPath, &entries); err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				snapshot, err := SnapshotFromServerPayload(entry.Payload)
+				if err != nil {
+					continue
+				}
+				// Server rows are keyed by the time they were stored, as in the databases
+				if ts, err := ParseTimestampUTC(entry.Time); err == nil {
+					snapshot.Timestamp = ts
+				}
+				batch = append(batch, *snapshot)
+			}
+		}
+		if err := fn(day, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetLogFilePath returns the current log file path
 func GetLogFilePath() string {
 	if logConfig == nil {
@@ -263,6 +444,11 @@ func GetLogFilePath() string {
 
 // CleanOldLogs removes log files older than specified days
 func CleanOldLogs(daysToKeep int) error {
+	return cleanLogsBefore(time.Now().AddDate(0, 0, -daysToKeep))
+}
+
+// cleanLogsBefore removes daily log files (main and per-server) dated before cutoffDate
+func cleanLogsBefore(cutoffDate time.Time) error {
 	if logConfig == nil {
 		return fmt.Errorf("logger not initialized")
 	}
@@ -284,8 +470,6 @@ func CleanOldLogs(daysToKeep int) error {
 		return fmt.Errorf("failed to read log directory: %w", err)
 	}
 
-	cutoffDate := time.Now().AddDate(0, 0, -daysToKeep)
-
 	// Clean main log files
 	for _, file := range files {
 		if !file.IsDir() && filepath.Ext(file.Name()) == ".log" {
@@ -0,0 +1,497 @@
+package utils
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-log/internal/api/models"
+	"strings"
+)
+
+// Table suffixes for the child tables that hold per-snapshot collections.
+const (
+	DisksTableSuffix      = "_disks"
+	HeartbeatsTableSuffix = "_heartbeats"
+	legacyTableSuffix     = "_legacy"
+)
+
+// Legacy rows are kept after migration and marked in this column instead: 0 while waiting,
+// 1 once copied into the normalized table, -1 when the row could not be converted.
+const (
+	legacyMigratedColumn   = "migrated"
+	legacyRowMigrated      = 1
+	legacyRowUnconvertible = -1
+)
+
+// MetricColumn describes a numeric column of the normalized metrics schema and
+// how it maps onto a SystemMonitoring snapshot.
+type MetricColumn struct {
+	Name    string
+	Integer bool // stored as INTEGER/BIGINT instead of REAL/DOUBLE PRECISION
+	Value   func(s *models.SystemMonitoring) float64
+	Assign  func(s *models.SystemMonitoring, v float64)
+}
+
+// MetricColumns lists the numeric snapshot columns in storage order.
+var MetricColumns = []MetricColumn{
+	{Name: "cpu_usage_percent",
+		Value:  func(s *models.SystemMonitoring) float64 { return s.CPU.UsagePercent },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.CPU.UsagePercent = v }},
+	{Name: "cpu_cores", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.CPU.CoreCount) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.CPU.CoreCount = int(v) }},
+	{Name: "ram_total_bytes", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.RAM.TotalBytes) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.RAM.TotalBytes = uint64(v) }},
+	{Name: "ram_used_bytes", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.RAM.UsedBytes) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.RAM.UsedBytes = uint64(v) }},
+	{Name: "ram_available_bytes", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.RAM.AvailableBytes) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.RAM.AvailableBytes = uint64(v) }},
+	{Name: "ram_used_percent",
+		Value:  func(s *models.SystemMonitoring) float64 { return s.RAM.UsedPct },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.RAM.UsedPct = v }},
+	{Name: "disk_used_percent",
+		Value: func(s *models.SystemMonitoring) float64 {
+			if pct, ok := getRootDiskMetric(s.DiskSpace, "used_percent").(float64); ok {
+				return pct
+			}
+			return 0
+		},
+		// Derived from the disks child table; nothing to assign back.
+		Assign: func(s *models.SystemMonitoring, v float64) {}},
+	{Name: "network_bytes_sent", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.NetworkIO.BytesSent) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.NetworkIO.BytesSent = uint64(v) }},
+	{Name: "network_bytes_recv", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.NetworkIO.BytesRecv) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.NetworkIO.BytesRecv = uint64(v) }},
+	{Name: "diskio_read_bytes", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.DiskIO.ReadBytes) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.DiskIO.ReadBytes = uint64(v) }},
+	{Name: "diskio_write_bytes", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.DiskIO.WriteBytes) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.DiskIO.WriteBytes = uint64(v) }},
+	{Name: "process_total", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.Process.TotalProcesses) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.Process.TotalProcesses = int(v) }},
+	{Name: "process_running", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.Process.RunningProcs) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.Process.RunningProcs = int(v) }},
+	{Name: "load_avg_1",
+		Value:  func(s *models.SystemMonitoring) float64 { return s.Process.LoadAvg1 },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.Process.LoadAvg1 = v }},
+	{Name: "load_avg_5",
+		Value:  func(s *models.SystemMonitoring) float64 { return s.Process.LoadAvg5 },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.Process.LoadAvg5 = v }},
+	{Name: "load_avg_15",
+		Value:  func(s *models.SystemMonitoring) float64 { return s.Process.LoadAvg15 },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.Process.LoadAvg15 = v }},
+	// Columns below were added after the first release of this schema. New columns are only
+	// ever appended: TSDB blocks store columns by position, and existing SQL tables get them
+	// through ALTER TABLE.
+	{Name: "ram_buffer_bytes", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.RAM.BufferBytes) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.RAM.BufferBytes = uint64(v) }},
+	{Name: "cpu_goroutines", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.CPU.Goroutines) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.CPU.Goroutines = int(v) }},
+	{Name: "network_packets_sent", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.NetworkIO.PacketsSent) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.NetworkIO.PacketsSent = uint64(v) }},
+	{Name: "network_packets_recv", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.NetworkIO.PacketsRecv) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.NetworkIO.PacketsRecv = uint64(v) }},
+	{Name: "network_errors_in", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.NetworkIO.ErrorsIn) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.NetworkIO.ErrorsIn = uint64(v) }},
+	{Name: "network_errors_out", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.NetworkIO.ErrorsOut) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.NetworkIO.ErrorsOut = uint64(v) }},
+	{Name: "network_drops_in", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.NetworkIO.DropsIn) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.NetworkIO.DropsIn = uint64(v) }},
+	{Name: "network_drops_out", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.NetworkIO.DropsOut) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.NetworkIO.DropsOut = uint64(v) }},
+	{Name: "diskio_read_count", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.DiskIO.ReadCount) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.DiskIO.ReadCount = uint64(v) }},
+	{Name: "diskio_write_count", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.DiskIO.WriteCount) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.DiskIO.WriteCount = uint64(v) }},
+	{Name: "diskio_read_time", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.DiskIO.ReadTime) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.DiskIO.ReadTime = uint64(v) }},
+	{Name: "diskio_write_time", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.DiskIO.WriteTime) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.DiskIO.WriteTime = uint64(v) }},
+	{Name: "diskio_io_time", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.DiskIO.IOTime) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.DiskIO.IOTime = uint64(v) }},
+	{Name: "process_sleeping", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.Process.SleepingProcs) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.Process.SleepingProcs = int(v) }},
+	{Name: "process_zombie", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.Process.ZombieProcs) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.Process.ZombieProcs = int(v) }},
+	{Name: "process_stopped", Integer: true,
+		Value:  func(s *models.SystemMonitoring) float64 { return float64(s.Process.StoppedProcs) },
+		Assign: func(s *models.SystemMonitoring, v float64) { s.Process.StoppedProcs = int(v) }},
+}
+
+// Text columns of the parent table that follow the numeric ones. They are read back after
+// the metric columns, in this order.
+const (
+	serverMetricsColumn   = "server_metrics"
+	cpuArchitectureColumn = "cpu_architecture"
+	snapshotTextColumns   = serverMetricsColumn + ", " + cpuArchitectureColumn
+)
+
+// metricColumnNames returns the numeric column names joined for use in SELECT/INSERT lists.
+func metricColumnNames() string {
+	names := make([]string, len(MetricColumns))
+	for i, col := range MetricColumns {
+		names[i] = col.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// snapshotColumns lists the parent-table columns read back into a snapshot, in scan order.
+func snapshotColumns() string {
+	return "id, timestamp, " + metricColumnNames() + ", " + snapshotTextColumns
+}
+
+// snapshotInsertValues returns the values for "timestamp, <metric columns>, <text columns>".
+func snapshotInsertValues(ts any, s *models.SystemMonitoring) []any {
+	values := append([]any{ts}, metricColumnValues(s)...)
+	return append(values, nullableText(marshalServerMetrics(s.ServerMetrics)), nullableText([]byte(s.CPU.Architecture)))
+}
+
+// metricColumnValues extracts the numeric column values of a snapshot in storage order.
+func metricColumnValues(s *models.SystemMonitoring) []any {
+	values := make([]any, len(MetricColumns))
+	for i, col := range MetricColumns {
+		v := col.Value(s)
+		if col.Integer {
+			values[i] = int64(v)
+		} else {
+			values[i] = v
+		}
+	}
+	return values
+}
+
+// metricColumnDefs renders column definitions using the provided SQL types for REAL and INTEGER columns.
+func metricColumnDefs(realType, intType string) string {
+	defs := make([]string, len(MetricColumns))
+	for i, col := range MetricColumns {
+		sqlType := realType
+		if col.Integer {
+			sqlType = intType
+		}
+		defs[i] = fmt.Sprintf("%s %s", col.Name, sqlType)
+	}
+	return strings.Join(defs, ",\n            ")
+}
+
+// missingMetricColumnDefs renders definitions for the metric and text columns a table
+// created by an older schema lacks, given the columns it has (lower-cased).
+func missingMetricColumnDefs(existing map[string]bool, realType, intType, textType string) []string {
+	var defs []string
+	for _, col := range MetricColumns {
+		if existing[col.Name] {
+			continue
+		}
+		sqlType := realType
+		if col.Integer {
+			sqlType = intType
+		}
+		defs = append(defs, fmt.Sprintf("%s %s", col.Name, sqlType))
+	}
+	if !existing[cpuArchitectureColumn] {
+		defs = append(defs, fmt.Sprintf("%s %s", cpuArchitectureColumn, textType))
+	}
+	return defs
+}
+
+// snapshotFromColumns rebuilds a snapshot from scanned numeric columns and the server_metrics document.
+func snapshotFromColumns(values []*float64, serverMetrics []byte) models.SystemMonitoring {
+	var snapshot models.SystemMonitoring
+	for i, col := range MetricColumns {
+		if values[i] != nil {
+			col.Assign(&snapshot, *values[i])
+		}
+	}
+	snapshot.CPU.LoadAverage = fmt.Sprintf("%.2f, %.2f, %.2f",
+		snapshot.Process.LoadAvg1, snapshot.Process.LoadAvg5, snapshot.Process.LoadAvg15)
+
+	if len(serverMetrics) > 0 {
+		var metrics []models.ServerMetrics
+		if err := json.Unmarshal(serverMetrics, &metrics); err == nil {
+			snapshot.ServerMetrics = metrics
+		}
+	}
+	return snapshot
+}
+
+// marshalServerMetrics encodes nested server metrics for the server_metrics column (nil when empty).
+func marshalServerMetrics(metrics []models.ServerMetrics) []byte {
+	if len(metrics) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(metrics)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// MetricsTables filters a table listing down to the queryable metrics tables. A name ending
+// in a child or legacy suffix is only dropped when the table it belongs to is listed too, so
+// a server whose own table is called e.g. "storage_disks" is kept.
+func MetricsTables(names []string) []string {
+	listed := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		listed[strings.Trim(name, "`\"")] = struct{}{}
+	}
+	tables := make([]string, 0, len(names))
+	for _, name := range names {
+		if !isMetricsChildTable(strings.Trim(name, "`\""), listed) {
+			tables = append(tables, name)
+		}
+	}
+	return tables
+}
+
+// isMetricsChildTable reports whether name is the child or legacy table of a listed table.
+func isMetricsChildTable(name string, listed map[string]struct{}) bool {
+	for _, suffix := range []string{DisksTableSuffix, HeartbeatsTableSuffix, legacyTableSuffix} {
+		parent, ok := strings.CutSuffix(name, suffix)
+		if !ok || parent == "" {
+			continue
+		}
+		if _, exists := listed[parent]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// MetricsChildTableNames lists the child and legacy tables a metrics table owns.
+func MetricsChildTableNames(table string) []string {
+	return []string{table + DisksTableSuffix, table + HeartbeatsTableSuffix, table + legacyTableSuffix}
+}
+
+// placeholderFunc renders the positional parameter for the n-th (1-based) argument.
+type placeholderFunc func(n int) string
+
+func sqlitePlaceholder(int) string { return "?" }
+func pgPlaceholder(n int) string   { return fmt.Sprintf("$%d", n) }
+func placeholderList(ph placeholderFunc, start, count int) string {
+	parts := make([]string, count)
+	for i := range parts {
+		parts[i] = ph(start + i)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// snapshotRow holds scan destinations for a parent metrics row.
+type snapshotRow struct {
+	id            int64
+	values        []sql.NullFloat64
+	serverMetrics sql.NullString
+	architecture  sql.NullString
+}
+
+func newSnapshotRow() *snapshotRow {
+	return &snapshotRow{values: make([]sql.NullFloat64, len(MetricColumns))}
+}
+
+// scanTargets returns destinations for "id, <timestamp>, <metric columns>, <text columns>".
+func (r *snapshotRow) scanTargets(timestamp any) []any {
+	targets := make([]any, 0, len(r.values)+4)
+	targets = append(targets, &r.id, timestamp)
+	for i := range r.values {
+		targets = append(targets, &r.values[i])
+	}
+	return append(targets, &r.serverMetrics, &r.architecture)
+}
+
+func (r *snapshotRow) snapshot() models.SystemMonitoring {
+	values := make([]*float64, len(r.values))
+	for i := range r.values {
+		if r.values[i].Valid {
+			values[i] = &r.values[i].Float64
+		}
+	}
+	var serverMetrics []byte
+	if r.serverMetrics.Valid {
+		serverMetrics = []byte(r.serverMetrics.String)
+	}
+	snapshot := snapshotFromColumns(values, serverMetrics)
+	snapshot.CPU.Architecture = r.architecture.String
+	return snapshot
+}
+
+// aggregatedRow holds scan destinations for a downsampled bucket row:
+// "<bucket>, samples" followed by avg, min, max and p95 for every metric column.
+type aggregatedRow struct {
+	samples sql.NullInt64
+	stats   []sql.NullFloat64
+}
+
+func newAggregatedRow() *aggregatedRow {
+	return &aggregatedRow{stats: make([]sql.NullFloat64, len(MetricColumns)*4)}
+}
+
+func (r *aggregatedRow) scanTargets(bucket any) []any {
+	targets := make([]any, 0, len(r.stats)+2)
+	targets = append(targets, bucket, &r.samples)
+	for i := range r.stats {
+		targets = append(targets, &r.stats[i])
+	}
+	return targets
+}
+
+// snapshot builds a point whose metric fields hold the bucket averages. Buckets carry no
+// disk rows, so the root disk usage is restored from its averaged column.
+func (r *aggregatedRow) snapshot() models.SystemMonitoring {
+	avgs := make([]*float64, len(MetricColumns))
+	aggregates := make(map[string]models.MetricStats, len(MetricColumns))
+	for i, col := range MetricColumns {
+		avg, min, max, p95 := r.stats[i*4], r.stats[i*4+1], r.stats[i*4+2], r.stats[i*4+3]
+		if !avg.Valid {
+			continue
+		}
+		avgs[i] = &r.stats[i*4].Float64
+		stats := models.MetricStats{Avg: avg.Float64, Min: min.Float64, Max: max.Float64}
+		if p95.Valid {
+			v := p95.Float64
+			stats.P95 = &v
+		}
+		aggregates[col.Name] = stats
+	}
+
+	snapshot := snapshotFromColumns(avgs, nil)
+	snapshot.Samples = int(r.samples.Int64)
+	snapshot.Aggregates = aggregates
+	if disk, ok := aggregates["disk_used_percent"]; ok {
+		snapshot.DiskSpace = []models.DiskSpace{{Path: "/", UsedPct: disk.Avg}}
+	}
+	return snapshot
+}
+
+// pgAggregateExprs renders avg/min/max/p95 expressions for every metric column (Postgres).
+func pgAggregateExprs() string {
+	parts := make([]string, 0, len(MetricColumns)*4)
+	for _, col := range MetricColumns {
+		parts = append(parts,
+			fmt.Sprintf("avg(%s)::double precision", col.Name),
+			fmt.Sprintf("min(%s)::double precision", col.Name),
+			fmt.Sprintf("max(%s)::double precision", col.Name),
+			fmt.Sprintf("percentile_cont(0.95) WITHIN GROUP (ORDER BY %s)", col.Name))
+	}
+	return strings.Join(parts, ",\n    ")
+}
+
+// insertSnapshotChildren writes disk and heartbeat rows for a stored snapshot.
+func insertSnapshotChildren(tx *sql.Tx, disksTable, heartbeatsTable string, ph placeholderFunc, id int64, ts any, s *models.SystemMonitoring) error {
+	if len(s.DiskSpace) > 0 {
+		q := fmt.Sprintf(`INSERT INTO %s (snapshot_id, timestamp, path, device, filesystem, total_bytes, used_bytes, available_bytes, used_pct) VALUES (%s)`,
+			disksTable, placeholderList(ph, 1, 9))
+		for _, d := range s.DiskSpace {
+			if _, err := tx.Exec(q, id, ts, d.Path, d.Device, d.FileSystem,
+				int64(d.TotalBytes), int64(d.UsedBytes), int64(d.AvailableBytes), d.UsedPct); err != nil {
+				return fmt.Errorf("failed to insert disk row: %w", err)
+			}
+		}
+	}
+
+	if len(s.Heartbeat) > 0 {
+		q := fmt.Sprintf(`INSERT INTO %s (snapshot_id, timestamp, name, url, status, response_ms, response_time, last_checked, error) VALUES (%s)`,
+			heartbeatsTable, placeholderList(ph, 1, 9))
+		for _, hb := range s.Heartbeat {
+			lastChecked := ""
+			if !hb.LastChecked.IsZero() {
+				lastChecked = FormatTimestampUTC(hb.LastChecked)
+			}
+			if _, err := tx.Exec(q, id, ts, hb.Name, hb.URL, string(hb.Status),
+				hb.ResponseMs, hb.ResponseTime, lastChecked, hb.Error); err != nil {
+				return fmt.Errorf("failed to insert heartbeat row: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// loadSnapshotChildren attaches disk and heartbeat rows to the snapshots keyed by id.
+func loadSnapshotChildren(db *sql.DB, disksTable, heartbeatsTable string, ph placeholderFunc, byID map[int64]*models.SystemMonitoring) error {
+	if len(byID) == 0 {
+		return nil
+	}
+
+	ids := make([]any, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+
+	const chunkSize = 500
+	for start := 0; start < len(ids); start += chunkSize {
+		end := min(start+chunkSize, len(ids))
+		chunk := ids[start:end]
+		in := placeholderList(ph, 1, len(chunk))
+
+		diskRows, err := db.Query(fmt.Sprintf(`SELECT snapshot_id, path, device, filesystem, total_bytes, used_bytes, available_bytes, used_pct
+            FROM %s WHERE snapshot_id IN (%s) ORDER BY snapshot_id, path`, disksTable, in), chunk...)
+		if err != nil {
+			return fmt.Errorf("failed to query disk rows: %w", err)
+		}
+		for diskRows.Next() {
+			var id, total, used, available int64
+			var d models.DiskSpace
+			if err := diskRows.Scan(&id, &d.Path, &d.Device, &d.FileSystem, &total, &used, &available, &d.UsedPct); err != nil {
+				diskRows.Close()
+				return fmt.Errorf("failed to scan disk row: %w", err)
+			}
+			d.TotalBytes, d.UsedBytes, d.AvailableBytes = uint64(total), uint64(used), uint64(available)
+			if s, ok := byID[id]; ok {
+				s.DiskSpace = append(s.DiskSpace, d)
+			}
+		}
+		diskRows.Close()
+		if err := diskRows.Err(); err != nil {
+			return fmt.Errorf("disk row iteration error: %w", err)
+		}
+
+		hbRows, err := db.Query(fmt.Sprintf(`SELECT snapshot_id, name, url, status, response_ms, response_time, last_checked, error
+            FROM %s WHERE snapshot_id IN (%s) ORDER BY snapshot_id, name`, heartbeatsTable, in), chunk...)
+		if err != nil {
+			return fmt.Errorf("failed to query heartbeat rows: %w", err)
+		}
+		for hbRows.Next() {
+			var id int64
+			var status, lastChecked string
+			var hb models.ServerCheck
+			if err := hbRows.Scan(&id, &hb.Name, &hb.URL, &status, &hb.ResponseMs, &hb.ResponseTime, &lastChecked, &hb.Error); err != nil {
+				hbRows.Close()
+				return fmt.Errorf("failed to scan heartbeat row: %w", err)
+			}
+			hb.Status = models.ServerStatus(status)
+			if lastChecked != "" {
+				if parsed, err := ParseTimestampUTC(lastChecked); err == nil {
+					hb.LastChecked = parsed
+				}
+			}
+			if s, ok := byID[id]; ok {
+				s.Heartbeat = append(s.Heartbeat, hb)
+			}
+		}
+		hbRows.Close()
+		if err := hbRows.Err(); err != nil {
+			return fmt.Errorf("heartbeat row iteration error: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,534 @@
+package utils
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"go-log/internal/config"
+)
+
+// OIDCProviderMetadata is the part of the provider's discovery document go-log uses.
+type OIDCProviderMetadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	JWKSURI                       string   `json:"jwks_uri"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported,omitempty"`
+}
+
+const (
+	oidcMetadataTTL     = time.Hour
+	oidcKeysTTL         = time.Hour
+	oidcKeysMinRefresh  = 30 * time.Second // an unknown key ID refetches the JWKS at most this often
+	oidcMaxResponseSize = 1 << 20
+	oidcClockSkew       = time.Minute
+)
+
+// oidcSigningMethods are the asymmetric algorithms accepted on provider tokens. HMAC and
+// "none" are never accepted.
+var oidcSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// oidcProviderCache holds the discovery document and signing keys of OIDC_ISSUER.
+type oidcProviderCache struct {
+	mu              sync.Mutex
+	issuer          string
+	metadata        *OIDCProviderMetadata
+	metadataFetched time.Time
+	keys            map[string]crypto.PublicKey
+	keysFetched     time.Time
+}
+
+var oidcProvider oidcProviderCache
+
+// OIDCDiscover returns the discovery document of OIDC_ISSUER, fetched at most once an hour.
+// When a refresh fails the previous document is kept.
+func OIDCDiscover(ctx context.Context) (*OIDCProviderMetadata, error) {
+	issuer := config.GetEnvConfig().OIDCIssuer
+	if issuer == "" {
+		return nil, fmt.Errorf("%w: OIDC_ISSUER is not set", ErrConfigNotFound)
+	}
+	oidcProvider.mu.Lock()
+	defer oidcProvider.mu.Unlock()
+	return oidcProvider.metadataLocked(ctx, issuer)
+}
+
+func (c *oidcProviderCache) metadataLocked(ctx context.Context, issuer string) (*OIDCProviderMetadata, error) {
+	if c.issuer != issuer {
+		c.issuer, c.metadata, c.keys = issuer, nil, nil
+	}
+	if c.metadata != nil && time.Since(c.metadataFetched) < oidcMetadataTTL {
+		return c.metadata, nil
+	}
+
+	metadata, err := fetchOIDCMetadata(ctx, issuer)
+	if err != nil {
+		if c.metadata != nil {
+			LogWarnWithContext("oidc", "failed to refresh the provider metadata, keeping the previous one", err)
+			c.metadataFetched = time.Now()
+			return c.metadata, nil
+		}
+		return nil, err
+	}
+	c.metadata, c.metadataFetched = metadata, time.Now()
+	return metadata, nil
+}
+
+func fetchOIDCMetadata(ctx context.Context, issuer string) (*OIDCProviderMetadata, error) {
+	if err := checkOIDCURL(issuer); err != nil {
+		return nil, err
+	}
+	var metadata OIDCProviderMetadata
+	if err := fetchOIDCJSON(ctx, issuer+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(metadata.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("%w: provider reports issuer %q, expected %q", ErrInvalidConfig, metadata.Issuer, issuer)
+	}
+	for name, endpoint := range map[string]string{
+		"authorization_endpoint": metadata.AuthorizationEndpoint,
+		"token_endpoint":         metadata.TokenEndpoint,
+		"jwks_uri":               metadata.JWKSURI,
+	} {
+		if endpoint == "" {
+			return nil, fmt.Errorf("%w: provider metadata has no %s", ErrInvalidConfig, name)
+		}
+		if err := checkOIDCURL(endpoint); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	metadata.Issuer = issuer
+	return &metadata, nil
+}
+
+// checkOIDCURL requires HTTPS, except on loopback hosts so a local mock provider can be
+// used for testing.
+func checkOIDCURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("%w: invalid OIDC URL %q", ErrInvalidConfig, raw)
+	}
+	if parsed.Scheme == "https" {
+		return nil
+	}
+	host := parsed.Hostname()
+	if ip := net.ParseIP(host); parsed.Scheme == "http" && (host == "localhost" || (ip != nil && ip.IsLoopback())) {
+		return nil
+	}
+	return fmt.Errorf("%w: OIDC URL %q must use https", ErrInvalidConfig, raw)
+}
+
+func fetchOIDCJSON(ctx context.Context, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := GetHTTPClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrHTTPRequestFailed, endpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s returned %s", ErrHTTPRequestFailed, endpoint, resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, oidcMaxResponseSize)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrDataUnmarshalFailed, endpoint, err)
+	}
+	return nil
+}
+
+// jsonWebKey is one entry of the provider's JWKS.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if n.BitLen() < 2048 || e.BitLen() > 31 {
+			return nil, fmt.Errorf("%w: RSA key %q is too weak or malformed", ErrInvalidConfig, k.Kid)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		var ecdhCurve ecdh.Curve
+		switch k.Crv {
+		case "P-256":
+			curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+		case "P-384":
+			curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+		case "P-521":
+			curve, ecdhCurve = elliptic.P521(), ecdh.P521()
+		default:
+			return nil, fmt.Errorf("%w: unsupported curve %q", ErrInvalidConfig, k.Crv)
+		}
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		// Parsing the uncompressed point checks that it lies on the curve
+		size := (curve.Params().BitSize + 7) / 8
+		if x.BitLen() > size*8 || y.BitLen() > size*8 {
+			return nil, fmt.Errorf("%w: EC key %q is malformed", ErrInvalidConfig, k.Kid)
+		}
+		point := append([]byte{4}, append(x.FillBytes(make([]byte, size)), y.FillBytes(make([]byte, size))...)...)
+		if _, err := ecdhCurve.NewPublicKey(point); err != nil {
+			return nil, fmt.Errorf("%w: EC key %q: %v", ErrInvalidConfig, k.Kid, err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported key type %q", ErrInvalidConfig, k.Kty)
+	}
+}
+
+func decodeJWKInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil || len(raw) == 0 {
+		return nil, fmt.Errorf("%w: malformed JWK number", ErrInvalidConfig)
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+func fetchOIDCKeys(ctx context.Context, jwksURI string) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := fetchOIDCJSON(ctx, jwksURI, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use == "enc" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			LogWarnWithContext("oidc", fmt.Sprintf("skipping JWKS key %q", jwk.Kid), err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: %s holds no usable signing keys", ErrInvalidConfig, jwksURI)
+	}
+	return keys, nil
+}
+
+// keyLocked returns the signing key kid. An unknown kid refetches the JWKS so rotated keys
+// are picked up; a token without kid is accepted when the JWKS holds a single key.
+func (c *oidcProviderCache) keyLocked(ctx context.Context, metadata *OIDCProviderMetadata, kid string) (crypto.PublicKey, error) {
+	lookup := func() (crypto.PublicKey, bool) {
+		if key, ok := c.keys[kid]; ok {
+			return key, true
+		}
+		if kid == "" && len(c.keys) == 1 {
+			for _, key := range c.keys {
+				return key, true
+			}
+		}
+		return nil, false
+	}
+
+	key, found := lookup()
+	age := time.Since(c.keysFetched)
+	if (found && age < oidcKeysTTL) || (!found && c.keys != nil && age < oidcKeysMinRefresh) {
+		if !found {
+			return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+		}
+		return key, nil
+	}
+
+	keys, err := fetchOIDCKeys(ctx, metadata.JWKSURI)
+	if err != nil {
+		if found {
+			LogWarnWithContext("oidc", "failed to refresh the provider keys, keeping the previous ones", err)
+			c.keysFetched = time.Now()
+			return key, nil
+		}
+		return nil, err
+	}
+	c.keys, c.keysFetched = keys, time.Now()
+	if key, found = lookup(); !found {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, kid)
+	}
+	return key, nil
+}
+
+// OIDCSigningKeyIDs fetches the provider's JWKS and returns the IDs of its usable keys.
+func OIDCSigningKeyIDs(ctx context.Context) ([]string, error) {
+	metadata, err := OIDCDiscover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := fetchOIDCKeys(ctx, metadata.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(keys))
+	for id := range keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// VerifyOIDCToken checks the signature of a token issued by OIDC_ISSUER against the
+// provider's JWKS, and its issuer, audience and lifetime.
+func VerifyOIDCToken(ctx context.Context, raw, audience string) (jwt.MapClaims, error) {
+	if audience == "" {
+		return nil, fmt.Errorf("%w: set OIDC_CLIENT_ID or OIDC_AUDIENCE to accept OIDC tokens", ErrInvalidConfig)
+	}
+	metadata, err := OIDCDiscover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(oidcSigningMethods),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(oidcClockSkew),
+	)
+	_, err = parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		oidcProvider.mu.Lock()
+		defer oidcProvider.mu.Unlock()
+		return oidcProvider.keyLocked(ctx, metadata, kid)
+	})
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, fmt.Errorf("%w: %v", ErrTokenExpired, err)
+		}
+		return nil, NewAuthError("INVALID_OIDC_TOKEN", "OIDC token is invalid", err)
+	}
+	if subject, _ := claims["sub"].(string); subject == "" {
+		return nil, NewAuthError("INVALID_OIDC_TOKEN", "OIDC token has no subject", ErrInvalidClaims)
+	}
+	return claims, nil
+}
+
+// OIDCAuthorizationURL returns the provider URL that starts an authorization-code login
+// with PKCE (S256).
+func OIDCAuthorizationURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	metadata, err := OIDCDiscover(ctx)
+	if err != nil {
+		return "", err
+	}
+	envConfig := config.GetEnvConfig()
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {envConfig.OIDCClientID},
+		"redirect_uri":          {envConfig.OIDCRedirectURL},
+		"scope":                 {envConfig.OIDCScopes},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// ExchangeOIDCCode redeems an authorization code at the token endpoint and returns the
+// ID token. The client authenticates with OIDC_CLIENT_SECRET when one is set.
+func ExchangeOIDCCode(ctx context.Context, code, codeVerifier string) (string, error) {
+	if code == "" {
+		return "", fmt.Errorf("%w: the provider sent no authorization code", ErrInvalidCredentials)
+	}
+	metadata, err := OIDCDiscover(ctx)
+	if err != nil {
+		return "", err
+	}
+	envConfig := config.GetEnvConfig()
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {envConfig.OIDCRedirectURL},
+		"client_id":     {envConfig.OIDCClientID},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if envConfig.OIDCClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(envConfig.OIDCClientID), url.QueryEscape(envConfig.OIDCClientSecret))
+	}
+
+	resp, err := GetHTTPClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: token endpoint: %v", ErrHTTPRequestFailed, err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, oidcMaxResponseSize)).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("%w: token endpoint: %v", ErrDataUnmarshalFailed, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: token endpoint returned %s: %s %s", ErrInvalidCredentials, resp.Status, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("%w: token endpoint returned no id_token", ErrInvalidCredentials)
+	}
+	return body.IDToken, nil
+}
+
+// OIDCUsername names the user of verified claims: OIDC_USERNAME_CLAIM, then email, then sub.
+func OIDCUsername(claims jwt.MapClaims) string {
+	for _, name := range []string{config.GetEnvConfig().OIDCUsernameClaim, "email", "sub"} {
+		if value, ok := claims[name].(string); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// OIDCGroups returns the groups in OIDC_GROUPS_CLAIM. A dotted name selects a nested claim,
+// e.g. "realm_access.roles".
+func OIDCGroups(claims jwt.MapClaims) []string {
+	var value any = map[string]any(claims)
+	for part := range strings.SplitSeq(config.GetEnvConfig().OIDCGroupsClaim, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[part]
+	}
+	switch groups := value.(type) {
+	case string:
+		return []string{groups}
+	case []any:
+		names := make([]string, 0, len(groups))
+		for _, group := range groups {
+			if name, ok := group.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// ParseOIDCRoleMapping parses OIDC_ROLE_MAPPING: group=role pairs separated by commas, or
+// by semicolons when group names contain commas (LDAP DNs).
+func ParseOIDCRoleMapping(value string) (map[string]string, error) {
+	separator := ","
+	if strings.Contains(value, ";") {
+		separator = ";"
+	}
+	mapping := map[string]string{}
+	for pair := range strings.SplitSeq(value, separator) {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		index := strings.LastIndex(pair, "=")
+		if index <= 0 {
+			return nil, fmt.Errorf("%w: OIDC_ROLE_MAPPING entry %q is not group=role", ErrInvalidConfig, strings.TrimSpace(pair))
+		}
+		role, err := ParseRole(pair[index+1:])
+		if err != nil {
+			return nil, err
+		}
+		mapping[strings.TrimSpace(pair[:index])] = role
+	}
+	return mapping, nil
+}
+
+// OIDCRole maps the groups of verified claims to a role. The most privileged mapped role
+// wins; users in no mapped group get OIDC_DEFAULT_ROLE, or ErrForbidden when it is empty.
+func OIDCRole(claims jwt.MapClaims) (string, error) {
+	envConfig := config.GetEnvConfig()
+	mapping, err := ParseOIDCRoleMapping(envConfig.OIDCRoleMapping)
+	if err != nil {
+		return "", err
+	}
+	best := -1
+	for _, group := range OIDCGroups(claims) {
+		if role, ok := mapping[group]; ok {
+			best = max(best, slices.Index(Roles, role))
+		}
+	}
+	if best >= 0 {
+		return Roles[best], nil
+	}
+	if envConfig.OIDCDefaultRole == "" {
+		return "", fmt.Errorf("%w: none of the user's groups maps to a role", ErrForbidden)
+	}
+	return ParseRole(envConfig.OIDCDefaultRole)
+}
+
+// OIDCTokenScopes returns the go-log scopes named in a token's "scope" or "scp" claim;
+// other scopes, such as "openid", are ignored.
+func OIDCTokenScopes(claims jwt.MapClaims) []string {
+	var names []string
+	if scope, ok := claims["scope"].(string); ok {
+		names = strings.Fields(scope)
+	}
+	if scp, ok := claims["scp"].([]any); ok {
+		for _, name := range scp {
+			if name, ok := name.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	var scopes []string
+	for _, name := range names {
+		if slices.Contains(AllScopes, name) && !slices.Contains(scopes, name) {
+			scopes = append(scopes, name)
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,254 @@
+package utils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"go-log/internal/config"
+)
+
+// mockOIDCProvider serves a discovery document and a JWKS, and signs tokens with the keys
+// it publishes.
+type mockOIDCProvider struct {
+	server *httptest.Server
+
+	mu          sync.Mutex
+	keys        map[string]*ecdsa.PrivateKey
+	jwksFetches int
+}
+
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	t.Helper()
+	p := &mockOIDCProvider{keys: map[string]*ecdsa.PrivateKey{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(OIDCProviderMetadata{
+			Issuer:                p.server.URL,
+			AuthorizationEndpoint: p.server.URL + "/authorize",
+			TokenEndpoint:         p.server.URL + "/token",
+			JWKSURI:               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.jwksFetches++
+		keys := []map[string]string{}
+		for kid, key := range p.keys {
+			keys = append(keys, map[string]string{
+				"kty": "EC", "crv": "P-256", "kid": kid, "use": "sig",
+				"x": base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+				"y": base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	p.rotate(t, "key-1")
+	return p
+}
+
+// rotate replaces the published keys with a new key kid.
+func (p *mockOIDCProvider) rotate(t *testing.T, kid string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.mu.Lock()
+	p.keys = map[string]*ecdsa.PrivateKey{kid: key}
+	p.mu.Unlock()
+}
+
+func (p *mockOIDCProvider) fetches() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.jwksFetches
+}
+
+// claims returns valid ID token claims for the client "go-log".
+func (p *mockOIDCProvider) claims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss": p.server.URL, "aud": "go-log", "sub": "user-1",
+		"iat": now.Unix(), "exp": now.Add(5 * time.Minute).Unix(),
+	}
+}
+
+// sign signs claims with the published key kid, or with an unpublished key when kid is unknown.
+func (p *mockOIDCProvider) sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	p.mu.Unlock()
+	if !ok {
+		var err error
+		if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// useMockOIDC points OIDC_ISSUER at the provider.
+func useMockOIDC(t *testing.T, p *mockOIDCProvider, env map[string]string) {
+	t.Helper()
+	t.Setenv("OIDC_ISSUER", p.server.URL)
+	t.Setenv("OIDC_CLIENT_ID", "go-log")
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	config.InitEnvConfig()
+	t.Cleanup(config.InitEnvConfig)
+}
+
+func TestVerifyOIDCToken(t *testing.T) {
+	p := newMockOIDCProvider(t)
+	useMockOIDC(t, p, nil)
+
+	with := func(key string, value any) jwt.MapClaims {
+		claims := p.claims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+	hmac, err := jwt.NewWithClaims(jwt.SigningMethodHS256, p.claims()).SignedString([]byte("shared"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every rejection other than expiry is an auth error with this code
+	invalid := NewAuthError("INVALID_OIDC_TOKEN", "", nil)
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", p.sign(t, "key-1", p.claims()), nil},
+		{"audience list", p.sign(t, "key-1", with("aud", []string{"other", "go-log"})), nil},
+		{"wrong audience", p.sign(t, "key-1", with("aud", "other-client")), invalid},
+		{"wrong issuer", p.sign(t, "key-1", with("iss", "https://evil.example.com")), invalid},
+		{"expired", p.sign(t, "key-1", with("exp", time.Now().Add(-time.Hour).Unix())), ErrTokenExpired},
+		{"no expiry", p.sign(t, "key-1", with("exp", nil)), invalid},
+		{"no subject", p.sign(t, "key-1", with("sub", nil)), invalid},
+		{"unpublished key", p.sign(t, "key-x", p.claims()), invalid},
+		{"hmac", hmac, invalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := VerifyOIDCToken(context.Background(), tt.token, "go-log")
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if claims["sub"] != "user-1" {
+					t.Errorf("sub = %v", claims["sub"])
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyOIDCTokenKeyRotation(t *testing.T) {
+	p := newMockOIDCProvider(t)
+	useMockOIDC(t, p, nil)
+	ctx := context.Background()
+
+	if _, err := VerifyOIDCToken(ctx, p.sign(t, "key-1", p.claims()), "go-log"); err != nil {
+		t.Fatal(err)
+	}
+	p.rotate(t, "key-2")
+	rotated := p.sign(t, "key-2", p.claims())
+
+	// Right after a fetch an unknown kid does not refetch, so forged kids cannot flood the provider
+	before := p.fetches()
+	if _, err := VerifyOIDCToken(ctx, rotated, "go-log"); err == nil {
+		t.Fatal("unknown kid accepted without a refetch")
+	}
+	if p.fetches() != before {
+		t.Fatalf("JWKS fetched %d times for an unknown kid within %s", p.fetches()-before, oidcKeysMinRefresh)
+	}
+
+	oidcProvider.mu.Lock()
+	oidcProvider.keysFetched = oidcProvider.keysFetched.Add(-oidcKeysMinRefresh)
+	oidcProvider.mu.Unlock()
+
+	if _, err := VerifyOIDCToken(ctx, rotated, "go-log"); err != nil {
+		t.Fatalf("token signed with the rotated key: %v", err)
+	}
+	if p.fetches() != before+1 {
+		t.Errorf("JWKS fetched %d times, want 1", p.fetches()-before)
+	}
+	if _, err := VerifyOIDCToken(ctx, p.sign(t, "key-1", p.claims()), "go-log"); err == nil {
+		t.Error("token signed with the retired key accepted")
+	}
+}
+
+func TestOIDCRole(t *testing.T) {
+	tests := []struct {
+		name        string
+		groupsClaim string
+		mapping     string
+		defaultRole string
+		claims      jwt.MapClaims
+		want        string
+		wantErr     error
+	}{
+		{"mapped group", "groups", "ops=operator,admins=admin", "", jwt.MapClaims{"groups": []any{"ops"}}, RoleOperator, nil},
+		{"most privileged wins", "groups", "ops=operator,admins=admin", "", jwt.MapClaims{"groups": []any{"admins", "ops"}}, RoleAdmin, nil},
+		{"single string claim", "groups", "ops=operator", "", jwt.MapClaims{"groups": "ops"}, RoleOperator, nil},
+		{"nested claim", "realm_access.roles", "monitoring-admin=admin", "", jwt.MapClaims{"realm_access": map[string]any{"roles": []any{"monitoring-admin"}}}, RoleAdmin, nil},
+		{"ldap dn", "groups", "cn=ops,ou=groups,dc=example=operator;cn=admins,ou=groups,dc=example=admin", "",
+			jwt.MapClaims{"groups": []any{"cn=ops,ou=groups,dc=example"}}, RoleOperator, nil},
+		{"unmapped with default", "groups", "ops=operator", "viewer", jwt.MapClaims{"groups": []any{"sales"}}, RoleViewer, nil},
+		{"unmapped without default", "groups", "ops=operator", "", jwt.MapClaims{"groups": []any{"sales"}}, "", ErrForbidden},
+		{"no groups claim", "groups", "ops=operator", "", jwt.MapClaims{}, "", ErrForbidden},
+		{"invalid mapping", "groups", "ops", "", jwt.MapClaims{"groups": []any{"ops"}}, "", ErrInvalidConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OIDC_GROUPS_CLAIM", tt.groupsClaim)
+			t.Setenv("OIDC_ROLE_MAPPING", tt.mapping)
+			t.Setenv("OIDC_DEFAULT_ROLE", tt.defaultRole)
+			config.InitEnvConfig()
+			t.Cleanup(config.InitEnvConfig)
+
+			role, err := OIDCRole(tt.claims)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || role != tt.want {
+				t.Fatalf("role %q (err %v), want %q", role, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-log/internal/api/models"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	outboxDirName     = "agent-outbox"
+	outboxSegmentExt  = ".json"
+	OutboxSegmentSize = 100 // snapshots per segment file, also the size of one backfill request
+)
+
+// AgentOutbox is an on-disk FIFO of snapshots an agent could not deliver yet. It lives in
+// <path>/agent-outbox as small JSON segment files named after their first snapshot, so
+// delivered segments are deleted without rewriting the rest of the backlog.
+type AgentOutbox struct {
+	mu          sync.Mutex
+	dir         string
+	maxSegments int
+	inflight    string // segment handed out by Oldest; appends never touch it
+}
+
+// OpenAgentOutbox opens (creating if needed) the outbox under the log path. maxSnapshots caps
+// the backlog; the oldest segments are dropped beyond it.
+func OpenAgentOutbox(basePath string, maxSnapshots int) (*AgentOutbox, error) {
+	base, err := ValidateLogPath(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid outbox path: %w", err)
+	}
+	dir := filepath.Join(base, outboxDirName)
+	if err := CreateSecureDirectory(dir); err != nil {
+		return nil, fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+	maxSegments := (maxSnapshots + OutboxSegmentSize - 1) / OutboxSegmentSize
+	return &AgentOutbox{dir: dir, maxSegments: max(maxSegments, 1)}, nil
+}
+
+// segments lists segment names oldest first. Names are zero-padded nanosecond timestamps.
+func (o *AgentOutbox) segments() ([]string, error) {
+	entries, err := os.ReadDir(o.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), outboxSegmentExt) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (o *AgentOutbox) readSegment(name string) ([]models.SystemMonitoring, error) {
+	var batch []models.SystemMonitoring
+	if err := readLogArray(filepath.Join(o.dir, name), &batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// writeSegment replaces a segment atomically so a crash never leaves half a file behind.
+func (o *AgentOutbox) writeSegment(name string, batch []models.SystemMonitoring) error {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDataMarshalFailed, err)
+	}
+	path := filepath.Join(o.dir, name)
+	if err := os.WriteFile(path+".tmp", data, 0640); err != nil {
+		return fmt.Errorf("failed to write outbox segment: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write outbox segment: %w", err)
+	}
+	return nil
+}
+
+func segmentName(snapshot *models.SystemMonitoring) string {
+	return fmt.Sprintf("%020d%s", snapshot.Timestamp.UnixNano(), outboxSegmentExt)
+}
+
+// Append adds snapshots (oldest first) to the end of the backlog and returns how many
+// snapshots were dropped to stay within the cap.
+func (o *AgentOutbox) Append(snapshots []models.SystemMonitoring) (int, error) {
+	if len(snapshots) == 0 {
+		return 0, nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	names, err := o.segments()
+	if err != nil {
+		return 0, err
+	}
+
+	// Top up the newest segment unless it is full or currently being delivered
+	if n := len(names); n > 0 && names[n-1] != o.inflight {
+		last := names[n-1]
+		batch, err := o.readSegment(last)
+		if err == nil && len(batch) < OutboxSegmentSize {
+			take := min(OutboxSegmentSize-len(batch), len(snapshots))
+			if err := o.writeSegment(last, append(batch, snapshots[:take]...)); err != nil {
+				return 0, err
+			}
+			snapshots = snapshots[take:]
+		}
+	}
+
+	for len(snapshots) > 0 {
+		take := min(OutboxSegmentSize, len(snapshots))
+		name := segmentName(&snapshots[0])
+		// Keep names unique and ordered even when timestamps repeat or go backwards
+		if n := len(names); n > 0 && name <= names[n-1] {
+			last, _ := strconv.ParseInt(strings.TrimSuffix(names[n-1], outboxSegmentExt), 10, 64)
+			name = fmt.Sprintf("%020d%s", last+1, outboxSegmentExt)
+		}
+		if err := o.writeSegment(name, snapshots[:take]); err != nil {
+			return 0, err
+		}
+		names = append(names, name)
+		snapshots = snapshots[take:]
+	}
+
+	dropped := 0
+	for len(names) > o.maxSegments {
+		if names[0] == o.inflight {
+			break
+		}
+		if batch, err := o.readSegment(names[0]); err == nil {
+			dropped += len(batch)
+		}
+		if err := os.Remove(filepath.Join(o.dir, names[0])); err != nil && !os.IsNotExist(err) {
+			return dropped, fmt.Errorf("failed to trim outbox: %w", err)
+		}
+		names = names[1:]
+	}
+	return dropped, nil
+}
+
+// Oldest returns the oldest segment and its snapshots, or an empty name when the outbox is
+// empty. Unreadable segments are renamed to *.corrupt and skipped.
+func (o *AgentOutbox) Oldest() (string, []models.SystemMonitoring, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	names, err := o.segments()
+	if err != nil {
+		return "", nil, err
+	}
+	for _, name := range names {
+		batch, err := o.readSegment(name)
+		if err != nil {
+			LogWarnWithContext("agent-outbox", fmt.Sprintf("skipping unreadable segment %s", name), err)
+			os.Rename(filepath.Join(o.dir, name), filepath.Join(o.dir, name+".corrupt"))
+			continue
+		}
+		if len(batch) == 0 {
+			os.Remove(filepath.Join(o.dir, name))
+			continue
+		}
+		o.inflight = name
+		return name, batch, nil
+	}
+	return "", nil, nil
+}
+
+// Release ends delivery of a segment handed out by Oldest, deleting it when delivered.
+func (o *AgentOutbox) Release(name string, delivered bool) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.inflight == name {
+		o.inflight = ""
+	}
+	if !delivered {
+		return nil
+	}
+	if err := os.Remove(filepath.Join(o.dir, name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove delivered segment: %w", err)
+	}
+	return nil
+}
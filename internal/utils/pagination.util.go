@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Page size bounds for cursor-paginated historical queries.
+const (
+	DefaultPageLimit = 500
+	MaxPageLimit     = 5000
+)
+
+// PageRequest asks for one page of raw snapshots ordered newest first.
+// Cursor is the opaque value returned with the previous page; empty starts from the newest row.
+type PageRequest struct {
+	Limit  int
+	Cursor string
+}
+
+// Active reports whether the caller asked for pagination at all.
+func (p PageRequest) Active() bool {
+	return p.Limit > 0 || p.Cursor != ""
+}
+
+// EffectiveLimit clamps the requested page size to [1, MaxPageLimit].
+func (p PageRequest) EffectiveLimit() int {
+	switch {
+	case p.Limit <= 0:
+		return DefaultPageLimit
+	case p.Limit > MaxPageLimit:
+		return MaxPageLimit
+	default:
+		return p.Limit
+	}
+}
+
+// encodeCursor builds an opaque cursor from the (timestamp, id) key of the last row of a page.
+func encodeCursor(timestamp string, id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(timestamp + "|" + strconv.FormatInt(id, 10)))
+}
+
+// decodeCursor reverses encodeCursor.
+func decodeCursor(cursor string) (string, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+	sep := strings.LastIndex(string(raw), "|")
+	if sep <= 0 {
+		return "", 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(string(raw[sep+1:]), 10, 64)
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+	return string(raw[:sep]), id, nil
+}
+
+// pageQuery renders a keyset-paginated parent-table query. rangeWhere is the optional
+// timestamp filter (possibly empty) whose arguments come first; ph numbers the placeholders.
+func pageQuery(columns, table, rangeWhere string, rangeArgs []any, page PageRequest, ph placeholderFunc) (string, []any, error) {
+	args := append([]any{}, rangeArgs...)
+	conditions := []string{}
+	if rangeWhere != "" {
+		conditions = append(conditions, strings.TrimPrefix(rangeWhere, "WHERE "))
+	}
+
+	if page.Cursor != "" {
+		ts, id, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		n := len(args)
+		conditions = append(conditions, fmt.Sprintf("(timestamp < %s OR (timestamp = %s AND id < %s))",
+			ph(n+1), ph(n+2), ph(n+3)))
+		args = append(args, ts, ts, id)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	query := fmt.Sprintf(`SELECT %s FROM %s %s ORDER BY timestamp DESC, id DESC LIMIT %d`,
+		columns, table, where, page.EffectiveLimit())
+	return query, args, nil
+}
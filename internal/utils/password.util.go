@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hash algorithms accepted by HashPassword.
+const (
+	PasswordArgon2id = "argon2id"
+	PasswordBcrypt   = "bcrypt"
+)
+
+// argon2id parameters for new hashes (OWASP minimum: 19 MiB, 2 passes, 1 lane). Stored
+// hashes carry their own parameters, so these can be raised without invalidating them.
+const (
+	argon2Memory  = 19 * 1024
+	argon2Time    = 2
+	argon2Threads = 1
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+	bcryptCost    = 12
+)
+
+// HashPassword hashes password with algorithm ("argon2id" or "bcrypt"). argon2id hashes
+// use the PHC string format: $argon2id$v=19$m=...,t=...,p=...$<salt>$<hash>.
+func HashPassword(password, algorithm string) (string, error) {
+	if password == "" {
+		return "", fmt.Errorf("%w: the password is empty", ErrValidationFailed)
+	}
+	switch strings.ToLower(strings.TrimSpace(algorithm)) {
+	case PasswordArgon2id, "":
+		salt := make([]byte, argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("failed to generate salt: %w", err)
+		}
+		key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argon2Memory, argon2Time, argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	case PasswordBcrypt:
+		if len(password) > 72 {
+			return "", fmt.Errorf("%w: bcrypt passwords are limited to 72 bytes", ErrValidationFailed)
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash password: %w", err)
+		}
+		return string(hash), nil
+	}
+	return "", fmt.Errorf("%w: unknown password algorithm %q (expected %s or %s)", ErrValidationFailed, algorithm, PasswordArgon2id, PasswordBcrypt)
+}
+
+// VerifyPassword reports whether password matches an argon2id or bcrypt hash.
+func VerifyPassword(hash, password string) (bool, error) {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		var version int
+		var memory, passes uint32
+		var threads uint8
+		parts := strings.Split(hash, "$")
+		if len(parts) != 6 {
+			return false, fmt.Errorf("%w: malformed argon2id hash", ErrInvalidConfig)
+		}
+		if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+			return false, fmt.Errorf("%w: unsupported argon2id version", ErrInvalidConfig)
+		}
+		if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &passes, &threads); err != nil {
+			return false, fmt.Errorf("%w: malformed argon2id parameters", ErrInvalidConfig)
+		}
+		salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+		if err != nil {
+			return false, fmt.Errorf("%w: malformed argon2id salt", ErrInvalidConfig)
+		}
+		want, err := base64.RawStdEncoding.DecodeString(parts[5])
+		if err != nil || len(want) == 0 {
+			return false, fmt.Errorf("%w: malformed argon2id hash", ErrInvalidConfig)
+		}
+		got := argon2.IDKey([]byte(password), salt, passes, memory, threads, uint32(len(want)))
+		return subtle.ConstantTimeCompare(got, want) == 1, nil
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+		}
+		return true, nil
+	}
+	return false, fmt.Errorf("%w: unrecognized password hash format", ErrInvalidConfig)
+}
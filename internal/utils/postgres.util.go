@@ -4,9 +4,11 @@ import (
     "context"
     "database/sql"
     "encoding/json"
+    "errors"
     "fmt"
     "go-log/internal/api/models"
     "go-log/internal/config"
+    "sort"
     "strings"
     "sync"
     "time"
@@ -289,14 +291,46 @@ func ensurePGTable(tableName string) (string, error) {
         return "", fmt.Errorf("postgres not initialized")
     }
 
+    if err := movePGLegacyTable(db, name); err != nil {
+        return "", err
+    }
+
     nameQuoted := pqQuoteIdent(name)
+    disksQuoted := pqQuoteIdent(name + DisksTableSuffix)
+    heartbeatsQuoted := pqQuoteIdent(name + HeartbeatsTableSuffix)
     stmts := []string{
         fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
-            id SERIAL PRIMARY KEY,
+            id BIGSERIAL,
+            timestamp timestamptz NOT NULL,
+            %s,
+            server_metrics jsonb,
+            cpu_architecture text,
+            PRIMARY KEY (id, timestamp)
+        );`, nameQuoted, metricColumnDefs("double precision", "bigint")),
+        fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+            snapshot_id bigint NOT NULL,
             timestamp timestamptz NOT NULL,
-            data jsonb NOT NULL
-        );`, nameQuoted),
-        fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_timestamp ON %s (timestamp);`, name, nameQuoted),
+            path text NOT NULL,
+            device text NOT NULL DEFAULT '',
+            filesystem text NOT NULL DEFAULT '',
+            total_bytes bigint NOT NULL DEFAULT 0,
+            used_bytes bigint NOT NULL DEFAULT 0,
+            available_bytes bigint NOT NULL DEFAULT 0,
+            used_pct double precision NOT NULL DEFAULT 0
+        );`, disksQuoted),
+        fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_disks_snapshot ON %s (snapshot_id);`, name, disksQuoted),
+        fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+            snapshot_id bigint NOT NULL,
+            timestamp timestamptz NOT NULL,
+            name text NOT NULL DEFAULT '',
+            url text NOT NULL DEFAULT '',
+            status text NOT NULL DEFAULT '',
+            response_ms bigint NOT NULL DEFAULT 0,
+            response_time text NOT NULL DEFAULT '',
+            last_checked text NOT NULL DEFAULT '',
+            error text NOT NULL DEFAULT ''
+        );`, heartbeatsQuoted),
+        fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_heartbeats_snapshot ON %s (snapshot_id);`, name, heartbeatsQuoted),
     }
 
     for _, s := range stmts {
@@ -304,11 +338,22 @@ func ensurePGTable(tableName string) (string, error) {
             return "", fmt.Errorf("failed to ensure table %s: %w", name, err)
         }
     }
+    if err := addMissingPGColumns(db, name); err != nil {
+        return "", err
+    }
+    if err := ensurePGUniqueTimestamp(db, name); err != nil {
+        return "", err
+    }
+
+    if err := migrateLegacyPGTable(db, name); err != nil {
+        return "", fmt.Errorf("failed to migrate legacy table %s: %w", name, err)
+    }
     
     // Handle hypertable conversion if TimescaleDB is available
     if IsTimescaleDBAvailable() {
         // Check if table is already a hypertable
         if isHypertable(db, name) {
+            setupHypertableExtras(db, name)
             // Table is already a hypertable, mark as complete
             tableStatusCacheMu.Lock()
             if tableStatusCache != nil {
@@ -319,7 +364,7 @@ func ensurePGTable(tableName string) (string, error) {
         }
         
         // Try to convert to hypertable
-        hypertableQuery := fmt.Sprintf("SELECT create_hypertable('%s', 'timestamp', if_not_exists => TRUE)", name)
+        hypertableQuery := fmt.Sprintf("SELECT create_hypertable('%s', 'timestamp', if_not_exists => TRUE, migrate_data => TRUE)", name)
         if _, err := db.Exec(hypertableQuery); err != nil {
             // Check if error is due to existing data
             if strings.Contains(err.Error(), "not empty") {
@@ -348,6 +393,7 @@ func ensurePGTable(tableName string) (string, error) {
                 hypertableCache[name] = true
             }
             hypertableCacheMu.Unlock()
+            setupHypertableExtras(db, name)
             
             tableStatusCacheMu.Lock()
             if tableStatusCache != nil {
@@ -376,40 +422,384 @@ func PreparePostgresServerTable(tableName string) error {
     return err
 }
 
-// WriteToPostgres writes a MonitoringLogEntry into the specified Postgres table.
-func WriteToPostgres(tableName string, entry models.MonitoringLogEntry) error {
+// pgTableExists reports whether a table exists in the public schema.
+func pgTableExists(db *sql.DB, name string) (bool, error) {
+    var exists bool
+    err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_schema='public' AND table_name=$1)`, name).Scan(&exists)
+    return exists, err
+}
+
+// pgColumns returns the column names of a table or view in the public schema.
+func pgColumns(db *sql.DB, name string) (map[string]bool, error) {
+    rows, err := db.Query(`SELECT column_name FROM information_schema.columns
+        WHERE table_schema='public' AND table_name=$1`, name)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    columns := make(map[string]bool)
+    for rows.Next() {
+        var column string
+        if err := rows.Scan(&column); err != nil {
+            return nil, err
+        }
+        columns[column] = true
+    }
+    return columns, rows.Err()
+}
+
+// ensurePGUniqueTimestamp makes timestamps unique within a metrics table, so writing a
+// snapshot again (a resumed transfer, an agent retrying a push) does not store it twice.
+// Tables from before the unique index lose their duplicate rows, keeping the first copy.
+// Where the index cannot be built (e.g. compressed hypertable chunks) a plain timestamp
+// index is kept instead and duplicates are possible.
+func ensurePGUniqueTimestamp(db *sql.DB, name string) error {
+    uniqueIndex := "idx_" + name + "_timestamp_unique"
+    var exists bool
+    if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_indexes WHERE schemaname = current_schema() AND indexname = $1)`, uniqueIndex).Scan(&exists); err != nil {
+        return fmt.Errorf("failed to inspect indexes of %s: %w", name, err)
+    }
+    if exists {
+        return nil
+    }
+
+    nameQuoted := pqQuoteIdent(name)
+    dedupe := fmt.Sprintf(`WITH dup AS (
+            DELETE FROM %[1]s a USING %[1]s b
+            WHERE a.timestamp = b.timestamp AND a.id > b.id
+            RETURNING a.id
+        ), disks AS (
+            DELETE FROM %[2]s WHERE snapshot_id IN (SELECT id FROM dup)
+        ), heartbeats AS (
+            DELETE FROM %[3]s WHERE snapshot_id IN (SELECT id FROM dup)
+        )
+        SELECT count(*) FROM dup`,
+        nameQuoted, pqQuoteIdent(name+DisksTableSuffix), pqQuoteIdent(name+HeartbeatsTableSuffix))
+    var removed int64
+    err := db.QueryRow(dedupe).Scan(&removed)
+    if err == nil {
+        if removed > 0 {
+            LogInfo("removed %d duplicate snapshots from %s", removed, name)
+        }
+        _, err = db.Exec(fmt.Sprintf(`CREATE UNIQUE INDEX IF NOT EXISTS %s ON %s (timestamp)`, pqQuoteIdent(uniqueIndex), nameQuoted))
+    }
+    if err != nil {
+        LogWarnWithContext("postgres", fmt.Sprintf("failed to make timestamps of %s unique; rewritten snapshots may be stored twice", name), err)
+        if _, err := db.Exec(fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (timestamp)`, pqQuoteIdent("idx_"+name+"_timestamp"), nameQuoted)); err != nil {
+            return fmt.Errorf("failed to index timestamps of %s: %w", name, err)
+        }
+        return nil
+    }
+    if _, err := db.Exec(fmt.Sprintf(`DROP INDEX IF EXISTS %s`, pqQuoteIdent("idx_"+name+"_timestamp"))); err != nil {
+        LogWarnWithContext("postgres", fmt.Sprintf("failed to drop the old timestamp index of %s", name), err)
+    }
+    return nil
+}
+
+// addMissingPGColumns brings a metrics table created by an older schema up to date.
+// The added columns are NULL in existing rows.
+func addMissingPGColumns(db *sql.DB, name string) error {
+    columns, err := pgColumns(db, name)
+    if err != nil {
+        return fmt.Errorf("failed to inspect table %s: %w", name, err)
+    }
+    for _, def := range missingMetricColumnDefs(columns, "double precision", "bigint", "text") {
+        if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s`, pqQuoteIdent(name), def)); err != nil {
+            return fmt.Errorf("failed to add column to %s: %w", name, err)
+        }
+    }
+    return nil
+}
+
+// movePGLegacyTable renames a table still using the legacy (timestamp, data jsonb) layout
+// to <name>_legacy, along with the objects whose names would clash with the new schema.
+func movePGLegacyTable(db *sql.DB, name string) error {
+    var isLegacy bool
+    err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM information_schema.columns
+        WHERE table_schema='public' AND table_name=$1 AND column_name='data')`, name).Scan(&isLegacy)
+    if err != nil {
+        return fmt.Errorf("failed to inspect table %s: %w", name, err)
+    }
+    if !isLegacy {
+        return nil
+    }
+
+    legacy := name + legacyTableSuffix
+    LogInfo("table %s uses the legacy JSON schema, moving it to %s for migration", name, legacy)
+    stmts := []string{
+        fmt.Sprintf(`DROP INDEX IF EXISTS %s`, pqQuoteIdent("idx_"+name+"_timestamp")),
+        fmt.Sprintf(`ALTER TABLE %s RENAME TO %s`, pqQuoteIdent(name), pqQuoteIdent(legacy)),
+        fmt.Sprintf(`ALTER INDEX IF EXISTS %s RENAME TO %s`, pqQuoteIdent(name+"_pkey"), pqQuoteIdent(legacy+"_pkey")),
+        fmt.Sprintf(`ALTER SEQUENCE IF EXISTS %s RENAME TO %s`, pqQuoteIdent(name+"_id_seq"), pqQuoteIdent(legacy+"_id_seq")),
+    }
+    for _, stmt := range stmts {
+        if _, err := db.Exec(stmt); err != nil {
+            return fmt.Errorf("failed to move legacy table %s: %w", name, err)
+        }
+    }
+
+    hypertableCacheMu.Lock()
+    if hypertableCache != nil {
+        delete(hypertableCache, name)
+    }
+    hypertableCacheMu.Unlock()
+    return nil
+}
+
+// migrateLegacyPGTable copies rows from <name>_legacy into the normalized schema in batches.
+// The legacy rows are kept and marked in their migrated column in the same transaction, so an
+// interrupted migration resumes on the next start. Rows that cannot be converted are marked
+// -1. The legacy table is only dropped by DropLegacyPostgresTables.
+func migrateLegacyPGTable(db *sql.DB, name string) error {
+    legacy := name + legacyTableSuffix
+    exists, err := pgTableExists(db, legacy)
+    if err != nil || !exists {
+        return err
+    }
+
+    legacyQuoted := pqQuoteIdent(legacy)
+    if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s smallint NOT NULL DEFAULT 0`, legacyQuoted, legacyMigratedColumn)); err != nil {
+        return fmt.Errorf("failed to prepare legacy table: %w", err)
+    }
+
+    const batchSize = 500
+    var migrated int
+    var skipped int
+    var lastID int64
+
+    for {
+        rows, err := db.Query(fmt.Sprintf(`SELECT id, timestamp, data FROM %s WHERE id > $1 AND %s = 0 ORDER BY id LIMIT %d`,
+            legacyQuoted, legacyMigratedColumn, batchSize), lastID)
+        if err != nil {
+            return fmt.Errorf("failed to read legacy rows: %w", err)
+        }
+
+        type legacyRow struct {
+            id        int64
+            timestamp time.Time
+            data      []byte
+        }
+        var batch []legacyRow
+        for rows.Next() {
+            var row legacyRow
+            if err := rows.Scan(&row.id, &row.timestamp, &row.data); err != nil {
+                rows.Close()
+                return fmt.Errorf("failed to scan legacy row: %w", err)
+            }
+            batch = append(batch, row)
+        }
+        rows.Close()
+        if err := rows.Err(); err != nil {
+            return fmt.Errorf("legacy row iteration error: %w", err)
+        }
+
+        if len(batch) == 0 {
+            break
+        }
+
+        tx, err := db.Begin()
+        if err != nil {
+            return fmt.Errorf("failed to begin migration transaction: %w", err)
+        }
+        mark := fmt.Sprintf(`UPDATE %s SET %s = $1 WHERE id = $2`, legacyQuoted, legacyMigratedColumn)
+        for _, row := range batch {
+            state := legacyRowMigrated
+            var entry models.MonitoringLogEntry
+            if err := json.Unmarshal(row.data, &entry); err != nil {
+                LogWarnWithContext("pg-migration", fmt.Sprintf("keeping unreadable legacy row %d", row.id), err)
+                state = legacyRowUnconvertible
+            } else if snapshot, err := SnapshotFromLogEntry(entry); err != nil {
+                LogWarnWithContext("pg-migration", fmt.Sprintf("keeping legacy row %d", row.id), err)
+                state = legacyRowUnconvertible
+            } else if err := insertPGSnapshotTx(tx, name, row.timestamp, snapshot); err != nil {
+                tx.Rollback()
+                return err
+            }
+            if _, err := tx.Exec(mark, state, row.id); err != nil {
+                tx.Rollback()
+                return fmt.Errorf("failed to mark migrated legacy row: %w", err)
+            }
+            if state == legacyRowMigrated {
+                migrated++
+            } else {
+                skipped++
+            }
+        }
+        if err := tx.Commit(); err != nil {
+            return fmt.Errorf("failed to commit migration batch: %w", err)
+        }
+
+        lastID = batch[len(batch)-1].id
+        LogInfo("migrated %d legacy rows into %s", migrated, name)
+    }
+
+    if migrated == 0 && skipped == 0 {
+        return nil
+    }
+    if skipped > 0 {
+        LogWarn("legacy table migration for %s could not convert %d rows, kept in %s (%d rows migrated)", name, skipped, legacy, migrated)
+    }
+    LogInfo("legacy table migration completed for %s (%d rows); %s is kept until \"go-log data drop-legacy\"", name, migrated, legacy)
+    return nil
+}
+
+// DropLegacyPostgresTables drops the <table>_legacy tables whose rows have all been migrated
+// and returns their names. A table with rows that could not be converted is kept unless
+// force is set; one whose migration has not finished is always kept.
+func DropLegacyPostgresTables(force bool) ([]string, error) {
+    pgMu.RLock()
+    db := pgdb
+    pgMu.RUnlock()
+    if db == nil {
+        return nil, fmt.Errorf("postgres not initialized")
+    }
+
+    all, err := collectPGTables(db)
+    if err != nil {
+        return nil, err
+    }
+    listed := make(map[string]struct{}, len(all))
+    for _, name := range all {
+        listed[name] = struct{}{}
+    }
+
+    var dropped []string
+    for _, name := range all {
+        parent, ok := strings.CutSuffix(name, legacyTableSuffix)
+        if !ok {
+            continue
+        }
+        if _, exists := listed[parent]; !exists {
+            continue
+        }
+        columns, err := pgColumns(db, name)
+        if err != nil {
+            return dropped, fmt.Errorf("failed to inspect %s: %w", name, err)
+        }
+        if !columns[legacyMigratedColumn] {
+            LogWarn("keeping %s: its migration has not started", name)
+            continue
+        }
+        var pending, unconvertible int64
+        err = db.QueryRow(fmt.Sprintf(`SELECT count(*) FILTER (WHERE %[1]s = 0), count(*) FILTER (WHERE %[1]s = %[2]d) FROM %[3]s`,
+            legacyMigratedColumn, legacyRowUnconvertible, pqQuoteIdent(name))).Scan(&pending, &unconvertible)
+        if err != nil {
+            return dropped, fmt.Errorf("failed to inspect %s: %w", name, err)
+        }
+        if pending > 0 {
+            LogWarn("keeping %s: %d rows are not migrated yet", name, pending)
+            continue
+        }
+        if unconvertible > 0 && !force {
+            LogWarn("keeping %s: %d rows could not be converted (drop anyway with --force)", name, unconvertible)
+            continue
+        }
+        if _, err := db.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS %s`, pqQuoteIdent(name))); err != nil {
+            return dropped, fmt.Errorf("failed to drop %s: %w", name, err)
+        }
+        dropped = append(dropped, name)
+    }
+    sort.Strings(dropped)
+    return dropped, nil
+}
+
+// insertPGSnapshotTx writes a snapshot and its child rows within an existing transaction.
+// A snapshot whose timestamp is already stored is skipped.
+func insertPGSnapshotTx(tx *sql.Tx, name string, ts time.Time, snapshot *models.SystemMonitoring) error {
+    values := snapshotInsertValues(ts, snapshot)
+    q := fmt.Sprintf(`INSERT INTO %s (timestamp, %s, %s) VALUES (%s) ON CONFLICT DO NOTHING RETURNING id`,
+        pqQuoteIdent(name), metricColumnNames(), snapshotTextColumns, placeholderList(pgPlaceholder, 1, len(values)))
+    var id int64
+    if err := tx.QueryRow(q, values...).Scan(&id); err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil
+        }
+        return fmt.Errorf("failed to write to postgres: %w", err)
+    }
+
+    return insertSnapshotChildren(tx,
+        pqQuoteIdent(name+DisksTableSuffix),
+        pqQuoteIdent(name+HeartbeatsTableSuffix),
+        pgPlaceholder, id, ts, snapshot)
+}
+
+// insertPGSnapshot writes a snapshot and its child rows in a single transaction.
+func insertPGSnapshot(db *sql.DB, name string, ts time.Time, snapshot *models.SystemMonitoring) error {
+    tx, err := db.Begin()
+    if err != nil {
+        return fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    if err := insertPGSnapshotTx(tx, name, ts, snapshot); err != nil {
+        tx.Rollback()
+        return err
+    }
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("failed to commit snapshot: %w", err)
+    }
+    return nil
+}
+
+// WriteToPostgres writes a monitoring snapshot into the specified Postgres table.
+func WriteToPostgres(tableName string, snapshot *models.SystemMonitoring) error {
     pgMu.RLock()
     db := pgdb
     pgMu.RUnlock()
     if db == nil {
         return fmt.Errorf("postgres not initialized")
     }
+    if snapshot == nil {
+        return fmt.Errorf("empty monitoring snapshot")
+    }
 
     sanitized, err := ensurePGTable(tableName)
     if err != nil {
         return err
     }
 
-    jsonData, err := json.Marshal(entry)
+    ts := NowUTC()
+    if !snapshot.Timestamp.IsZero() {
+        ts = snapshot.Timestamp.UTC()
+    }
+
+    return insertPGSnapshot(db, sanitized, ts, snapshot)
+}
+
+// writePostgresBatch stores snapshots with their own timestamps in a single transaction.
+func writePostgresBatch(tableName string, batch []models.SystemMonitoring) error {
+    pgMu.RLock()
+    db := pgdb
+    pgMu.RUnlock()
+    if db == nil {
+        return fmt.Errorf("postgres not initialized")
+    }
+
+    sanitized, err := ensurePGTable(tableName)
     if err != nil {
-        return fmt.Errorf("failed to marshal log entry: %w", err)
+        return err
     }
 
-    ts := NowUTC()
-    if entry.Time != "" {
-        if parsed, err := ParseTimestampUTC(entry.Time); err == nil {
-            ts = parsed
+    tx, err := db.Begin()
+    if err != nil {
+        return fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    for i := range batch {
+        ts := NowUTC()
+        if !batch[i].Timestamp.IsZero() {
+            ts = batch[i].Timestamp.UTC()
+        }
+        if err := insertPGSnapshotTx(tx, sanitized, ts, &batch[i]); err != nil {
+            tx.Rollback()
+            return err
         }
     }
-
-    q := fmt.Sprintf(`INSERT INTO %s (timestamp, data) VALUES ($1, $2)`, pqQuoteIdent(sanitized))
-    if _, err := db.Exec(q, ts, jsonData); err != nil {
-        return fmt.Errorf("failed to write to postgres: %w", err)
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("failed to commit snapshots: %w", err)
     }
     return nil
 }
 
-// WriteServerLogToPostgres writes raw server payload into a server-specific Postgres table.
+// WriteServerLogToPostgres decodes a remote server payload into a server-specific Postgres table.
 func WriteServerLogToPostgres(tableName string, payload []byte) error {
     pgMu.RLock()
     db := pgdb
@@ -423,24 +813,19 @@ func WriteServerLogToPostgres(tableName string, payload []byte) error {
         return err
     }
 
-    entry := models.ServerLogEntry{
-        Time:    FormatTimestampUTC(NowUTC()),
-        Payload: json.RawMessage(payload),
-    }
-
-    jsonData, err := json.Marshal(entry)
+    snapshot, err := SnapshotFromServerPayload(payload)
     if err != nil {
-        return fmt.Errorf("failed to marshal server log entry: %w", err)
+        return fmt.Errorf("failed to decode server payload: %w", err)
     }
 
-    q := fmt.Sprintf(`INSERT INTO %s (timestamp, data) VALUES ($1, $2)`, pqQuoteIdent(sanitized))
-    if _, err := db.Exec(q, NowUTC(), jsonData); err != nil {
+    if err := insertPGSnapshot(db, sanitized, NowUTC(), snapshot); err != nil {
         return fmt.Errorf("failed to write server log to postgres: %w", err)
     }
     return nil
 }
 
     // CleanOldPostgresEntries deletes rows older than cutoffDate from all non-system tables.
+    // Hypertables covered by a native TimescaleDB retention policy are left to the policy.
     func CleanOldPostgresEntries(cutoffDate time.Time) error {
     pgMu.RLock()
     db := pgdb
@@ -456,6 +841,9 @@ func WriteServerLogToPostgres(tableName string, payload []byte) error {
 
     var total int64
     for _, t := range tables {
+        if timescaleRetentionManaged(db, t) {
+            continue
+        }
         q := fmt.Sprintf(`DELETE FROM %s WHERE timestamp < $1`, pqQuoteIdent(t))
         res, err := db.Exec(q, cutoffDate)
         if err != nil {
@@ -471,6 +859,35 @@ func WriteServerLogToPostgres(tableName string, payload []byte) error {
     return nil
 }
 
+// GetPostgresTables lists the metrics tables in PostgreSQL, skipping child and legacy tables.
+func GetPostgresTables() ([]string, error) {
+    pgMu.RLock()
+    db := pgdb
+    pgMu.RUnlock()
+    if db == nil {
+        return nil, fmt.Errorf("postgres not initialized")
+    }
+
+    all, err := collectPGTables(db)
+    if err != nil {
+        return nil, err
+    }
+    return MetricsTables(all), nil
+}
+
+// PingPostgres checks that the PostgreSQL connection is alive.
+func PingPostgres() error {
+    pgMu.RLock()
+    db := pgdb
+    pgMu.RUnlock()
+    if db == nil {
+        return fmt.Errorf("postgres not initialized")
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    return db.PingContext(ctx)
+}
+
 func collectPGTables(db *sql.DB) ([]string, error) {
     rows, err := db.Query(`SELECT table_name FROM information_schema.tables WHERE table_schema='public' AND table_type='BASE TABLE'`)
     if err != nil {
@@ -497,7 +914,7 @@ func collectPGTables(db *sql.DB) ([]string, error) {
 }
 
 // QueryFilteredPostgresData retrieves data from Postgres within a date range with smart downsampling.
-func QueryFilteredPostgresData(tableName, from, to string) ([]models.MonitoringLogEntry, error) {
+func QueryFilteredPostgresData(tableName, from, to string) ([]models.SystemMonitoring, error) {
     pgMu.RLock()
     db := pgdb
     pgMu.RUnlock()
@@ -548,6 +965,18 @@ func QueryFilteredPostgresData(tableName, from, to string) ([]models.MonitoringL
         return queryRawData(db, tbl, fromNormalized, toNormalized)
     }
 
+    // Prefer a continuous aggregate whose bucket fits the requested range
+    if IsTimescaleDBAvailable() && fromNormalized != "" && toNormalized != "" {
+        span := rangeSpan(fromNormalized, toNormalized)
+        if agg, width, ok := selectContinuousAggregate(span, maxPointsCfg); ok {
+            entries, err := queryContinuousAggregate(db, name, agg, width, fromNormalized, toNormalized)
+            if err == nil {
+                return entries, nil
+            }
+            LogWarnWithContext("postgres-query", "continuous aggregate query failed, falling back to time_bucket", err)
+        }
+    }
+
     // Try TimescaleDB time_bucket downsampling first, fallback to ntile
     if IsTimescaleDBAvailable() {
         entries, err := queryWithTimeBucket(db, tbl, fromNormalized, toNormalized, maxPointsCfg, totalRows)
@@ -561,178 +990,219 @@ func QueryFilteredPostgresData(tableName, from, to string) ([]models.MonitoringL
     return queryWithNtile(db, tbl, fromNormalized, toNormalized, maxPointsCfg)
 }
 
+// pgSnapshotColumns lists the parent-table columns read back into a snapshot, in scan order.
+func pgSnapshotColumns() string {
+    return snapshotColumns()
+}
+
 // queryRawData retrieves raw data without downsampling
-func queryRawData(db *sql.DB, tbl, fromNormalized, toNormalized string) ([]models.MonitoringLogEntry, error) {
+func queryRawData(db *sql.DB, tbl, fromNormalized, toNormalized string) ([]models.SystemMonitoring, error) {
     var query string
     var args []any
+    cols := pgSnapshotColumns()
 
     switch {
     case fromNormalized != "" && toNormalized != "":
-        query = fmt.Sprintf("SELECT timestamp, data FROM %s WHERE timestamp >= $1 AND timestamp <= $2 ORDER BY timestamp DESC", tbl)
+        query = fmt.Sprintf("SELECT %s FROM %s WHERE timestamp >= $1 AND timestamp <= $2 ORDER BY timestamp DESC", cols, tbl)
         args = []any{fromNormalized, toNormalized}
     case fromNormalized != "":
-        query = fmt.Sprintf("SELECT timestamp, data FROM %s WHERE timestamp >= $1 ORDER BY timestamp DESC", tbl)
+        query = fmt.Sprintf("SELECT %s FROM %s WHERE timestamp >= $1 ORDER BY timestamp DESC", cols, tbl)
         args = []any{fromNormalized}
     case toNormalized != "":
-        query = fmt.Sprintf("SELECT timestamp, data FROM %s WHERE timestamp <= $1 ORDER BY timestamp DESC", tbl)
+        query = fmt.Sprintf("SELECT %s FROM %s WHERE timestamp <= $1 ORDER BY timestamp DESC", cols, tbl)
         args = []any{toNormalized}
     default:
-        query = fmt.Sprintf("SELECT timestamp, data FROM %s ORDER BY timestamp DESC LIMIT 1000", tbl)
+        query = fmt.Sprintf("SELECT %s FROM %s ORDER BY timestamp DESC LIMIT 1000", cols, tbl)
         args = []any{}
     }
 
-    return executeQuery(db, query, args)
+    snapshots, _, err := executeQuery(db, tbl, query, args)
+    return snapshots, err
 }
 
-// queryWithTimeBucket uses TimescaleDB's time_bucket for efficient downsampling
-func queryWithTimeBucket(db *sql.DB, tbl, fromNormalized, toNormalized string, maxPoints int, _ int64) ([]models.MonitoringLogEntry, error) {
-    // Calculate optimal bucket interval
-    bucketInterval := calculateOptimalBucketInterval(fromNormalized, toNormalized, int64(maxPoints))
-    
-    var query string
-    var args []any
+// QueryPostgresDataPage returns one cursor-paginated page of raw snapshots from Postgres, newest first.
+// The returned cursor is empty once the last page has been read.
+func QueryPostgresDataPage(tableName, from, to string, page PageRequest) ([]models.SystemMonitoring, string, error) {
+    pgMu.RLock()
+    db := pgdb
+    pgMu.RUnlock()
+    if db == nil {
+        return nil, "", fmt.Errorf("postgres not initialized")
+    }
 
-    // Build time_bucket query with aggregated data reconstruction
-    switch {
-    case fromNormalized != "" && toNormalized != "":
-        query = fmt.Sprintf(`
-WITH bucketed AS (
-  SELECT 
-    time_bucket('%s', timestamp) as bucket_time,
-    timestamp,
-    data,
-    ROW_NUMBER() OVER (PARTITION BY time_bucket('%s', timestamp) ORDER BY timestamp DESC) as rn
-  FROM %s 
-  WHERE timestamp >= $1 AND timestamp <= $2
-)
-SELECT timestamp, data
-FROM bucketed
-WHERE rn = 1
-ORDER BY bucket_time DESC`, bucketInterval, bucketInterval, tbl)
-        args = []any{fromNormalized, toNormalized}
-    case fromNormalized != "":
-        query = fmt.Sprintf(`
-WITH bucketed AS (
-  SELECT 
-    time_bucket('%s', timestamp) as bucket_time,
-    timestamp,
-    data,
-    ROW_NUMBER() OVER (PARTITION BY time_bucket('%s', timestamp) ORDER BY timestamp DESC) as rn
-  FROM %s 
-  WHERE timestamp >= $1
-)
-SELECT timestamp, data
-FROM bucketed
-WHERE rn = 1
-ORDER BY bucket_time DESC`, bucketInterval, bucketInterval, tbl)
-        args = []any{fromNormalized}
-    case toNormalized != "":
-        query = fmt.Sprintf(`
-WITH bucketed AS (
-  SELECT 
-    time_bucket('%s', timestamp) as bucket_time,
-    timestamp,
-    data,
-    ROW_NUMBER() OVER (PARTITION BY time_bucket('%s', timestamp) ORDER BY timestamp DESC) as rn
-  FROM %s 
-  WHERE timestamp <= $1
-)
-SELECT timestamp, data
-FROM bucketed
-WHERE rn = 1
-ORDER BY bucket_time DESC`, bucketInterval, bucketInterval, tbl)
-        args = []any{toNormalized}
-    default:
-        query = fmt.Sprintf(`
-WITH bucketed AS (
-  SELECT 
-    time_bucket('%s', timestamp) as bucket_time,
-    timestamp,
-    data,
-    ROW_NUMBER() OVER (PARTITION BY time_bucket('%s', timestamp) ORDER BY timestamp DESC) as rn
-  FROM %s
-)
-SELECT timestamp, data
-FROM bucketed
-WHERE rn = 1
-ORDER BY bucket_time DESC
-LIMIT %d`, bucketInterval, bucketInterval, tbl, maxPoints)
-        args = []any{}
+    name, err := ensurePGTable(tableName)
+    if err != nil {
+        return nil, "", err
+    }
+
+    fromNormalized, err := NormalizeTimestampForDB(from)
+    if err != nil {
+        return nil, "", fmt.Errorf("invalid from timestamp: %w", err)
+    }
+    toNormalized, err := NormalizeTimestampForDB(to)
+    if err != nil {
+        return nil, "", fmt.Errorf("invalid to timestamp: %w", err)
     }
 
-    return executeQuery(db, query, args)
+    tbl := pqQuoteIdent(name)
+    where, args := pgRangeFilter(fromNormalized, toNormalized)
+    query, args, err := pageQuery(pgSnapshotColumns(), tbl, where, args, page, pgPlaceholder)
+    if err != nil {
+        return nil, "", err
+    }
+
+    snapshots, lastCursor, err := executeQuery(db, tbl, query, args)
+    if err != nil {
+        return nil, "", err
+    }
+    if len(snapshots) < page.EffectiveLimit() {
+        lastCursor = ""
+    }
+    return snapshots, lastCursor, nil
 }
 
-// queryWithNtile uses ntile-based downsampling as fallback
-func queryWithNtile(db *sql.DB, tbl, fromNormalized, toNormalized string, maxPoints int) ([]models.MonitoringLogEntry, error) {
-    var query string
-    var args []any
-    var where string
+// queryWithTimeBucket uses TimescaleDB's time_bucket to aggregate each bucket
+func queryWithTimeBucket(db *sql.DB, tbl, fromNormalized, toNormalized string, maxPoints int, _ int64) ([]models.SystemMonitoring, error) {
+    // Calculate optimal bucket interval
+    bucketInterval := calculateOptimalBucketInterval(fromNormalized, toNormalized, int64(maxPoints))
 
-    if fromNormalized != "" && toNormalized != "" {
-        where = "WHERE timestamp >= $1 AND timestamp <= $2"
-        args = []any{fromNormalized, toNormalized}
-    } else if fromNormalized != "" {
-        where = "WHERE timestamp >= $1"
-        args = []any{fromNormalized}
-    } else if toNormalized != "" {
-        where = "WHERE timestamp <= $1"
-        args = []any{toNormalized}
-    } else {
-        where = ""
-        args = []any{}
+    where, args := pgRangeFilter(fromNormalized, toNormalized)
+    limit := ""
+    if where == "" {
+        limit = fmt.Sprintf("LIMIT %d", maxPoints)
     }
 
+    query := fmt.Sprintf(`
+SELECT time_bucket('%s', timestamp) AS bucket,
+    count(*),
+    %s
+FROM %s
+%s
+GROUP BY bucket
+ORDER BY bucket DESC
+%s`, bucketInterval, pgAggregateExprs(), tbl, where, limit)
+
+    return executeAggregateQuery(db, query, args)
+}
+
+// queryWithNtile splits the range into maxPoints equally sized groups and aggregates each one
+func queryWithNtile(db *sql.DB, tbl, fromNormalized, toNormalized string, maxPoints int) ([]models.SystemMonitoring, error) {
+    where, args := pgRangeFilter(fromNormalized, toNormalized)
+
     tilesParam := len(args) + 1
-    query = fmt.Sprintf(`
+    query := fmt.Sprintf(`
 WITH q AS (
-  SELECT timestamp, data,
-         ntile($%d) OVER (ORDER BY timestamp DESC) AS bucket,
-         ROW_NUMBER() OVER (ORDER BY timestamp DESC) AS rn
+  SELECT *,
+         ntile($%d) OVER (ORDER BY timestamp DESC) AS bucket_id
   FROM %s %s
-), ranked AS (
-  SELECT timestamp, data, bucket,
-         ROW_NUMBER() OVER (PARTITION BY bucket ORDER BY rn) AS rnk
-  FROM q
 )
-SELECT timestamp, data
-FROM ranked
-WHERE rnk = 1
-ORDER BY bucket DESC`, tilesParam, tbl, where)
+SELECT min(timestamp) AS bucket,
+    count(*),
+    %s
+FROM q
+GROUP BY bucket_id
+ORDER BY bucket DESC`, tilesParam, tbl, where, pgAggregateExprs())
     
     args = append(args, maxPoints)
-    return executeQuery(db, query, args)
+    return executeAggregateQuery(db, query, args)
+}
+
+// pgRangeFilter builds the WHERE clause and arguments for an optional timestamp range
+func pgRangeFilter(fromNormalized, toNormalized string) (string, []any) {
+    switch {
+    case fromNormalized != "" && toNormalized != "":
+        return "WHERE timestamp >= $1 AND timestamp <= $2", []any{fromNormalized, toNormalized}
+    case fromNormalized != "":
+        return "WHERE timestamp >= $1", []any{fromNormalized}
+    case toNormalized != "":
+        return "WHERE timestamp <= $1", []any{toNormalized}
+    default:
+        return "", []any{}
+    }
 }
 
-// executeQuery executes a query and returns MonitoringLogEntry results
-func executeQuery(db *sql.DB, query string, args []any) ([]models.MonitoringLogEntry, error) {
+// executeAggregateQuery scans bucketed rows ("bucket, samples, <avg/min/max/p95 per metric>") into snapshots
+func executeAggregateQuery(db *sql.DB, query string, args []any) ([]models.SystemMonitoring, error) {
     rows, err := db.Query(query, args...)
     if err != nil {
         return nil, fmt.Errorf("failed to execute query: %w", err)
     }
     defer rows.Close()
 
-    var entries []models.MonitoringLogEntry
+    var snapshots []models.SystemMonitoring
     for rows.Next() {
-        var ts time.Time
-        var jsonData []byte
-        if err := rows.Scan(&ts, &jsonData); err != nil {
-            return nil, fmt.Errorf("failed to scan row: %w", err)
+        var bucket time.Time
+        row := newAggregatedRow()
+        if err := rows.Scan(row.scanTargets(&bucket)...); err != nil {
+            return nil, fmt.Errorf("failed to scan aggregate row: %w", err)
         }
+        snapshot := row.snapshot()
+        snapshot.Timestamp = bucket.UTC()
+        snapshots = append(snapshots, snapshot)
+    }
 
-        var entry models.MonitoringLogEntry
-        if err := json.Unmarshal(jsonData, &entry); err != nil {
-            LogWarnWithContext("postgres-query", "failed to unmarshal data, skipping row", err)
-            continue
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("row iteration error: %w", err)
+    }
+    return snapshots, nil
+}
+
+// executeQuery executes a snapshot query against the quoted parent table and attaches child rows.
+// It also returns the pagination cursor of the last row.
+func executeQuery(db *sql.DB, tbl, query string, args []any) ([]models.SystemMonitoring, string, error) {
+    rows, err := db.Query(query, args...)
+    if err != nil {
+        return nil, "", fmt.Errorf("failed to execute query: %w", err)
+    }
+    defer rows.Close()
+
+    var snapshots []models.SystemMonitoring
+    var ids []int64
+    var lastCursor string
+    for rows.Next() {
+        var ts time.Time
+        row := newSnapshotRow()
+        if err := rows.Scan(row.scanTargets(&ts)...); err != nil {
+            return nil, "", fmt.Errorf("failed to scan row: %w", err)
         }
-        entries = append(entries, entry)
+        lastCursor = encodeCursor(FormatTimestampUTC(ts), row.id)
+
+        snapshot := row.snapshot()
+        snapshot.Timestamp = ts.UTC()
+        snapshots = append(snapshots, snapshot)
+        ids = append(ids, row.id)
     }
 
     if err := rows.Err(); err != nil {
-        return nil, fmt.Errorf("row iteration error: %w", err)
+        return nil, "", fmt.Errorf("row iteration error: %w", err)
+    }
+
+    byID := make(map[int64]*models.SystemMonitoring, len(snapshots))
+    for i := range snapshots {
+        byID[ids[i]] = &snapshots[i]
+    }
+    name := strings.Trim(tbl, `"`)
+    if err := loadSnapshotChildren(db,
+        pqQuoteIdent(name+DisksTableSuffix),
+        pqQuoteIdent(name+HeartbeatsTableSuffix),
+        pgPlaceholder, byID); err != nil {
+        return nil, "", err
     }
 
-    return entries, nil
+    return snapshots, lastCursor, nil
+}
+
+// rangeSpan returns the duration between two normalized timestamps, or 0 when it cannot be determined
+func rangeSpan(fromNormalized, toNormalized string) time.Duration {
+    if fromNormalized == "" || toNormalized == "" {
+        return 0
+    }
+    fromTime, err1 := ParseTimestamp(fromNormalized)
+    toTime, err2 := ParseTimestamp(toNormalized)
+    if err1 != nil || err2 != nil || !toTime.After(fromTime) {
+        return 0
+    }
+    return toTime.Sub(fromTime)
 }
 
 // calculateOptimalBucketInterval calculates the optimal time bucket interval
@@ -742,17 +1212,7 @@ func calculateOptimalBucketInterval(fromNormalized, toNormalized string, targetP
     }
 
     // Calculate time span between from and to
-    var span time.Duration
-    if fromNormalized != "" && toNormalized != "" {
-        fromTime, err1 := time.Parse("2006-01-02 15:04:05", fromNormalized)
-        toTime, err2 := time.Parse("2006-01-02 15:04:05", toNormalized)
-        
-        if err1 == nil && err2 == nil {
-            if toTime.After(fromTime) {
-                span = toTime.Sub(fromTime)
-            }
-        }
-    }
+    span := rangeSpan(fromNormalized, toNormalized)
 
     // If we can't determine span, use default
     if span <= 0 {
@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-log/internal/config"
+)
+
+// Rate limit backends selectable with RATE_LIMIT_BACKEND.
+const (
+	RateLimitBackendMemory = "memory"
+	RateLimitBackendRedis  = "redis"
+
+	// rateLimitKeyPrefix namespaces the counters in a shared Redis database.
+	rateLimitKeyPrefix = "golog:ratelimit:"
+)
+
+// RateLimitPolicy is a token bucket refilled at RPS tokens per second and holding at most
+// Burst tokens. An unlimited policy admits every request.
+type RateLimitPolicy struct {
+	Name      string // "default", the route prefix, or "key:<id or name>"
+	RPS       float64
+	Burst     int
+	Unlimited bool
+}
+
+// RateLimitPolicies holds the default policy and the overrides from RATE_LIMIT_POLICIES.
+type RateLimitPolicies struct {
+	Default RateLimitPolicy
+	routes  []RateLimitPolicy          // longest prefix first; Name is the prefix
+	keys    map[string]RateLimitPolicy // by API key ID or name; "*" matches any key
+}
+
+// RateLimitResult is the outcome of counting one request.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // until a rejected request would be admitted
+}
+
+// RateLimitStore counts requests per bucket. Buckets are opaque strings that already
+// include the policy name, so one client gets separate budgets per policy.
+type RateLimitStore interface {
+	Name() string
+	Allow(ctx context.Context, bucket string, policy RateLimitPolicy) (RateLimitResult, error)
+}
+
+// ParseRateLimitPolicies parses RATE_LIMIT_POLICIES: comma-separated entries of
+// "<match>=<rps>:<burst>" or "<match>=off". A match starting with "/" is a route prefix
+// (the longest matching prefix wins); "key:<id or name>" applies to requests made with
+// that API key, and "key:*" to any API key.
+func ParseRateLimitPolicies(value string, defaultPolicy RateLimitPolicy) (RateLimitPolicies, error) {
+	defaultPolicy.Name = "default"
+	policies := RateLimitPolicies{Default: defaultPolicy, keys: map[string]RateLimitPolicy{}}
+	for entry := range strings.SplitSeq(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		match, limit, ok := strings.Cut(entry, "=")
+		match, limit = strings.TrimSpace(match), strings.TrimSpace(limit)
+		if !ok || match == "" {
+			return RateLimitPolicies{}, fmt.Errorf("%w: rate limit policy %q must look like /path=rps:burst or key:name=rps:burst", ErrInvalidConfig, entry)
+		}
+		policy, err := parseRateLimit(match, limit)
+		if err != nil {
+			return RateLimitPolicies{}, err
+		}
+		switch {
+		case strings.HasPrefix(match, "/"):
+			policies.routes = append(policies.routes, policy)
+		case strings.HasPrefix(match, "key:") && len(match) > len("key:"):
+			policies.keys[strings.TrimPrefix(match, "key:")] = policy
+		default:
+			return RateLimitPolicies{}, fmt.Errorf("%w: rate limit policy %q must start with / or key:", ErrInvalidConfig, match)
+		}
+	}
+	sort.SliceStable(policies.routes, func(i, j int) bool {
+		return len(policies.routes[i].Name) > len(policies.routes[j].Name)
+	})
+	return policies, nil
+}
+
+func parseRateLimit(name, limit string) (RateLimitPolicy, error) {
+	if strings.EqualFold(limit, "off") {
+		return RateLimitPolicy{Name: name, Unlimited: true}, nil
+	}
+	rpsText, burstText, ok := strings.Cut(limit, ":")
+	rps, rpsErr := strconv.ParseFloat(strings.TrimSpace(rpsText), 64)
+	burst, burstErr := strconv.Atoi(strings.TrimSpace(burstText))
+	if !ok || rpsErr != nil || burstErr != nil || rps <= 0 || math.IsInf(rps, 0) || burst < 1 {
+		return RateLimitPolicy{}, fmt.Errorf("%w: rate limit %q for %s must be <rps>:<burst> with both above zero, or off", ErrInvalidConfig, limit, name)
+	}
+	return RateLimitPolicy{Name: name, RPS: rps, Burst: burst}, nil
+}
+
+// Match returns the policy for a request to path. key is the API key the request was made
+// with, or nil. A key policy wins over a route policy, which wins over the default.
+func (p RateLimitPolicies) Match(path string, key *APIKey) RateLimitPolicy {
+	if key != nil {
+		for _, name := range []string{key.ID, key.Name, "*"} {
+			if policy, ok := p.keys[name]; ok {
+				return policy
+			}
+		}
+	}
+	for _, policy := range p.routes {
+		if strings.HasPrefix(path, policy.Name) {
+			return policy
+		}
+	}
+	return p.Default
+}
+
+// MemoryRateLimitStore keeps token buckets in this process. The least recently used
+// buckets are evicted beyond maxEntries, and buckets idle for ttl are dropped; a dropped
+// bucket starts full again, as it would have refilled anyway.
+type MemoryRateLimitStore struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List // most recently used first
+	maxEntries int
+	ttl        time.Duration
+}
+
+type rateLimitBucket struct {
+	key      string
+	tokens   float64
+	lastSeen time.Time
+}
+
+// NewMemoryRateLimitStore returns an empty in-process store.
+func NewMemoryRateLimitStore(maxEntries int, ttl time.Duration) *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+		maxEntries: max(maxEntries, 1),
+		ttl:        ttl,
+	}
+}
+
+// Name identifies the backend in logs.
+func (s *MemoryRateLimitStore) Name() string { return RateLimitBackendMemory }
+
+// Allow takes a token from bucket if one is available.
+func (s *MemoryRateLimitStore) Allow(_ context.Context, bucket string, policy RateLimitPolicy) (RateLimitResult, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entry *rateLimitBucket
+	if element, ok := s.entries[bucket]; ok {
+		entry = element.Value.(*rateLimitBucket)
+		entry.tokens = math.Min(float64(policy.Burst), entry.tokens+now.Sub(entry.lastSeen).Seconds()*policy.RPS)
+		s.order.MoveToFront(element)
+	} else {
+		entry = &rateLimitBucket{key: bucket, tokens: float64(policy.Burst)}
+		s.entries[bucket] = s.order.PushFront(entry)
+	}
+	entry.lastSeen = now
+	s.evictLocked(now)
+
+	result := RateLimitResult{Limit: policy.Burst}
+	if entry.tokens < 1 {
+		result.RetryAfter = time.Duration((1 - entry.tokens) / policy.RPS * float64(time.Second))
+		return result, nil
+	}
+	entry.tokens--
+	result.Allowed = true
+	result.Remaining = int(entry.tokens)
+	return result, nil
+}
+
+// evictLocked drops buckets past maxEntries and idle ones from the least recently used end.
+func (s *MemoryRateLimitStore) evictLocked(now time.Time) {
+	for oldest := s.order.Back(); oldest != nil; oldest = s.order.Back() {
+		entry := oldest.Value.(*rateLimitBucket)
+		if len(s.entries) <= s.maxEntries && (s.ttl <= 0 || now.Sub(entry.lastSeen) < s.ttl) {
+			return
+		}
+		s.order.Remove(oldest)
+		delete(s.entries, entry.key)
+	}
+}
+
+// RedisRateLimitStore shares counters between instances through Redis. Each bucket is a
+// fixed window of Burst requests lasting Burst/RPS seconds, which admits the same average
+// rate as the token bucket with a single atomic INCR and no server-side scripts.
+type RedisRateLimitStore struct {
+	client *RedisClient
+}
+
+// NewRedisRateLimitStore returns a store using the server at redisURL.
+func NewRedisRateLimitStore(redisURL string) (*RedisRateLimitStore, error) {
+	client, err := NewRedisClient(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisRateLimitStore{client: client}, nil
+}
+
+// Name identifies the backend in logs.
+func (s *RedisRateLimitStore) Name() string { return RateLimitBackendRedis }
+
+// Allow counts the request in the bucket's current window.
+func (s *RedisRateLimitStore) Allow(ctx context.Context, bucket string, policy RateLimitPolicy) (RateLimitResult, error) {
+	window := time.Duration(float64(policy.Burst) / policy.RPS * float64(time.Second))
+	windowMs := strconv.FormatInt(max(window.Milliseconds(), 1), 10)
+	key := rateLimitKeyPrefix + bucket
+
+	replies, err := s.client.Pipeline(ctx,
+		[]string{"SET", key, "0", "PX", windowMs, "NX"},
+		[]string{"INCR", key},
+		[]string{"PTTL", key},
+	)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	count, ok := replies[1].(int64)
+	if !ok {
+		return RateLimitResult{}, fmt.Errorf("unexpected INCR reply %v", replies[1])
+	}
+	ttl, _ := replies[2].(int64)
+	if ttl < 0 {
+		// The window expired between SET and INCR, leaving a counter without expiry
+		if _, err := s.client.Pipeline(ctx, []string{"PEXPIRE", key, windowMs}); err != nil {
+			return RateLimitResult{}, err
+		}
+		ttl = window.Milliseconds()
+	}
+
+	result := RateLimitResult{Limit: policy.Burst, Remaining: max(policy.Burst-int(count), 0)}
+	if count > int64(policy.Burst) {
+		result.RetryAfter = time.Duration(ttl) * time.Millisecond
+		return result, nil
+	}
+	result.Allowed = true
+	return result, nil
+}
+
+// NewRateLimitStore returns the store selected by RATE_LIMIT_BACKEND.
+func NewRateLimitStore() (RateLimitStore, error) {
+	envConfig := config.GetEnvConfig()
+	switch envConfig.RateLimitBackend {
+	case "", RateLimitBackendMemory:
+		return NewMemoryRateLimitStore(envConfig.RateLimitMaxClients, envConfig.RateLimitClientTTL), nil
+	case RateLimitBackendRedis:
+		if envConfig.RateLimitRedisURL == "" {
+			return nil, fmt.Errorf("%w: RATE_LIMIT_REDIS_URL is required with RATE_LIMIT_BACKEND=redis", ErrInvalidConfig)
+		}
+		return NewRedisRateLimitStore(envConfig.RateLimitRedisURL)
+	}
+	return nil, fmt.Errorf("%w: RATE_LIMIT_BACKEND must be memory or redis, got %q", ErrInvalidConfig, envConfig.RateLimitBackend)
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestRedisRateLimitStore(t *testing.T) (*RedisRateLimitStore, *fakeRedis) {
+	t.Helper()
+	server := newFakeRedis(t)
+	store, err := NewRedisRateLimitStore("redis://" + server.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.client.Close() })
+	return store, server
+}
+
+func TestRedisRateLimitStoreAllow(t *testing.T) {
+	store, server := newTestRedisRateLimitStore(t)
+	ctx := context.Background()
+	// A burst of 3 at 1 request per second is a 3s window
+	policy := RateLimitPolicy{Name: "default", RPS: 1, Burst: 3}
+
+	for i := range 3 {
+		result, err := store.Allow(ctx, "default:10.0.0.1", policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed || result.Limit != 3 || result.Remaining != 2-i {
+			t.Fatalf("request %d: %+v", i+1, result)
+		}
+	}
+	result, err := store.Allow(ctx, "default:10.0.0.1", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed || result.Remaining != 0 || result.RetryAfter != 3*time.Second {
+		t.Fatalf("request over the burst: %+v, want rejected with RetryAfter 3s", result)
+	}
+
+	if result, err := store.Allow(ctx, "default:10.0.0.2", policy); err != nil || !result.Allowed {
+		t.Fatalf("other client: %+v (err %v)", result, err)
+	}
+
+	server.advance(3 * time.Second)
+	if result, err := store.Allow(ctx, "default:10.0.0.1", policy); err != nil || !result.Allowed || result.Remaining != 2 {
+		t.Fatalf("after the window: %+v (err %v)", result, err)
+	}
+	if sent := server.sent(); sent[0] != "SET golog:ratelimit:default:10.0.0.1 0 PX 3000 NX" {
+		t.Errorf("first command %q", sent[0])
+	}
+}
+
+func TestRedisRateLimitStoreRepairsMissingTTL(t *testing.T) {
+	store, server := newTestRedisRateLimitStore(t)
+	policy := RateLimitPolicy{Name: "default", RPS: 2, Burst: 1}
+
+	server.dropTTL = true
+	result, err := store.Allow(context.Background(), "default:10.0.0.1", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allowed {
+		t.Fatalf("first request rejected: %+v", result)
+	}
+	if !slices.Contains(server.sent(), "PEXPIRE golog:ratelimit:default:10.0.0.1 500") {
+		t.Fatalf("counter left without expiry; sent %q", server.sent())
+	}
+
+	// The repaired window still rejects, then ends
+	result, err = store.Allow(context.Background(), "default:10.0.0.1", policy)
+	if err != nil || result.Allowed || result.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("second request: %+v (err %v), want rejected for 500ms", result, err)
+	}
+	server.advance(500 * time.Millisecond)
+	if result, err := store.Allow(context.Background(), "default:10.0.0.1", policy); err != nil || !result.Allowed {
+		t.Fatalf("after the repaired window: %+v (err %v)", result, err)
+	}
+}
+
+func TestRedisRateLimitStoreUnavailable(t *testing.T) {
+	// The address of a closed listener refuses connections
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	store, err := NewRedisRateLimitStore("redis://" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Allow(context.Background(), "default:10.0.0.1", RateLimitPolicy{RPS: 1, Burst: 1}); err == nil {
+		t.Fatal("no error with redis unreachable")
+	}
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Roles, from least to most privileged.
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// Scopes checked by the HTTP routes.
+const (
+	ScopeMetricsRead  = "metrics:read"  // dashboard, monitoring data, tables, server config, export
+	ScopeConfigRead   = "config:read"   // admin listings and reload history
+	ScopeConfigReload = "config:reload" // reload the configuration file
+	ScopeConfigWrite  = "config:write"  // add, change and remove heartbeats and servers
+)
+
+// Roles lists the known roles, from least to most privileged.
+var Roles = []string{RoleViewer, RoleOperator, RoleAdmin}
+
+// AllScopes lists every scope a route can require.
+var AllScopes = []string{ScopeMetricsRead, ScopeConfigRead, ScopeConfigReload, ScopeConfigWrite}
+
+var roleScopes = map[string][]string{
+	RoleViewer:   {ScopeMetricsRead},
+	RoleOperator: {ScopeMetricsRead, ScopeConfigRead, ScopeConfigReload},
+	RoleAdmin:    AllScopes,
+}
+
+// RoleScopes returns the scopes granted by role, or nil for an unknown role.
+func RoleScopes(role string) []string {
+	return slices.Clone(roleScopes[role])
+}
+
+// ParseRole normalizes a role name and rejects unknown roles.
+func ParseRole(name string) (string, error) {
+	role := strings.ToLower(strings.TrimSpace(name))
+	if _, ok := roleScopes[role]; !ok {
+		return "", fmt.Errorf("%w: unknown role %q (expected one of %s)", ErrValidationFailed, name, strings.Join(Roles, ", "))
+	}
+	return role, nil
+}
+
+// ParseScopes normalizes a list of scopes, dropping blanks and duplicates, and rejects
+// unknown scopes.
+func ParseScopes(names []string) ([]string, error) {
+	var scopes []string
+	for _, name := range names {
+		scope := strings.ToLower(strings.TrimSpace(name))
+		if scope == "" || slices.Contains(scopes, scope) {
+			continue
+		}
+		if !slices.Contains(AllScopes, scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q (expected one of %s)", ErrValidationFailed, name, strings.Join(AllScopes, ", "))
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// GrantedScopes returns the scopes of role, narrowed to restrict when restrict is not
+// empty. Scopes outside the role are never granted.
+func GrantedScopes(role string, restrict []string) []string {
+	scopes := RoleScopes(role)
+	if len(restrict) == 0 {
+		return scopes
+	}
+	return slices.DeleteFunc(scopes, func(scope string) bool {
+		return !slices.Contains(restrict, scope)
+	})
+}
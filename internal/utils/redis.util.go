@@ -0,0 +1,233 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	redisPoolSize    = 8
+	redisDialTimeout = 2 * time.Second
+	redisMaxBulkSize = 512 << 20 // the largest string Redis itself allows
+)
+
+// ErrRedisReply is a "-ERR ..." reply from the server.
+var ErrRedisReply = errors.New("redis error reply")
+
+// RedisClient speaks enough of the RESP2 protocol for small pipelined commands. It works
+// with Redis and compatible servers (Valkey, KeyDB, Dragonfly) and keeps a few idle
+// connections for reuse.
+type RedisClient struct {
+	addr     string
+	username string
+	password string
+	db       int
+	tls      *tls.Config
+	idle     chan *redisConn
+}
+
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// NewRedisClient parses redis://[[user]:password@]host[:port][/db]; rediss:// connects
+// over TLS. No connection is made until the first command.
+func NewRedisClient(rawURL string) (*RedisClient, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "redis" && parsed.Scheme != "rediss") {
+		return nil, fmt.Errorf("%w: redis URL must look like redis://[:password@]host:port[/db]", ErrInvalidConfig)
+	}
+	client := &RedisClient{addr: parsed.Host, idle: make(chan *redisConn, redisPoolSize)}
+	if parsed.Port() == "" {
+		client.addr = net.JoinHostPort(parsed.Hostname(), "6379")
+	}
+	if parsed.User != nil {
+		client.username = parsed.User.Username()
+		client.password, _ = parsed.User.Password()
+	}
+	if db := strings.Trim(parsed.Path, "/"); db != "" {
+		if client.db, err = strconv.Atoi(db); err != nil || client.db < 0 {
+			return nil, fmt.Errorf("%w: redis database %q is not a number", ErrInvalidConfig, db)
+		}
+	}
+	if parsed.Scheme == "rediss" {
+		client.tls = &tls.Config{MinVersion: tls.VersionTLS12, ServerName: parsed.Hostname()}
+	}
+	return client, nil
+}
+
+// Pipeline sends the commands in one round trip and returns one reply per command. An
+// error reply to a single command is returned in its slot as an error wrapping
+// ErrRedisReply; a connection failure fails the whole call.
+func (c *RedisClient) Pipeline(ctx context.Context, commands ...[]string) ([]any, error) {
+	conn, err := c.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := conn.pipeline(ctx, commands)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	c.release(conn)
+	return replies, nil
+}
+
+// Close closes the idle connections.
+func (c *RedisClient) Close() error {
+	for {
+		select {
+		case conn := <-c.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *RedisClient) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: redisDialTimeout}
+	var raw net.Conn
+	var err error
+	if c.tls != nil {
+		raw, err = (&tls.Dialer{NetDialer: dialer, Config: c.tls}).DialContext(ctx, "tcp", c.addr)
+	} else {
+		raw, err = dialer.DialContext(ctx, "tcp", c.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", c.addr, err)
+	}
+	conn := &redisConn{Conn: raw, reader: bufio.NewReader(raw)}
+
+	var setup [][]string
+	switch {
+	case c.username != "" && c.password != "":
+		setup = append(setup, []string{"AUTH", c.username, c.password})
+	case c.password != "":
+		setup = append(setup, []string{"AUTH", c.password})
+	}
+	if c.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+	if len(setup) > 0 {
+		replies, err := conn.pipeline(ctx, setup)
+		if err == nil {
+			for _, reply := range replies {
+				if replyErr, ok := reply.(error); ok {
+					err = replyErr
+					break
+				}
+			}
+		}
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis connection setup failed: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+func (c *RedisClient) release(conn *redisConn) {
+	select {
+	case c.idle <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+func (conn *redisConn) pipeline(ctx context.Context, commands [][]string) ([]any, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(redisDialTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var buf strings.Builder
+	for _, command := range commands {
+		fmt.Fprintf(&buf, "*%d\r\n", len(command))
+		for _, arg := range command {
+			fmt.Fprintf(&buf, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	if _, err := io.WriteString(conn, buf.String()); err != nil {
+		return nil, fmt.Errorf("redis write failed: %w", err)
+	}
+
+	replies := make([]any, len(commands))
+	for i := range commands {
+		reply, err := conn.readReply()
+		if err != nil {
+			return nil, fmt.Errorf("redis read failed: %w", err)
+		}
+		replies[i] = reply
+	}
+	return replies, nil
+}
+
+// readReply reads one RESP2 value: a string, int64, nil, []any or an error reply.
+func (conn *redisConn) readReply() (any, error) {
+	line, err := conn.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty redis reply")
+	}
+	payload := line[1:]
+	switch line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return fmt.Errorf("%w: %s", ErrRedisReply, payload), nil
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(payload)
+		if err != nil || size > redisMaxBulkSize {
+			return nil, fmt.Errorf("invalid redis bulk length %q", payload)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(conn.reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis array length %q", payload)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]any, count)
+		for i := range items {
+			if items[i], err = conn.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected redis reply %q", line)
+}
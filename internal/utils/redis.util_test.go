@@ -0,0 +1,276 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a RESP2 stand-in for the few commands the rate limiter sends. Keys expire
+// on a clock the test controls.
+type fakeRedis struct {
+	addr string
+
+	mu          sync.Mutex
+	password    string
+	values      map[string]int64
+	expiry      map[string]time.Time // missing when the key has no expiry
+	now         time.Time
+	commands    [][]string
+	connections int
+	// dropTTL removes the expiry of every key before the next PTTL, as when a window
+	// expires between SET NX and INCR and INCR recreates the key without one
+	dropTTL bool
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &fakeRedis{
+		addr:   listener.Addr().String(),
+		values: map[string]int64{},
+		expiry: map[string]time.Time{},
+		now:    time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+	}
+	var wg sync.WaitGroup
+	t.Cleanup(func() {
+		listener.Close()
+		wg.Wait()
+	})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			r.mu.Lock()
+			r.connections++
+			r.mu.Unlock()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.serve(conn)
+			}()
+		}
+	}()
+	return r
+}
+
+func (r *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		command, err := readRESPCommand(reader)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, r.exec(command)); err != nil {
+			return
+		}
+	}
+}
+
+func readRESPCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+	if line[0] != '*' || err != nil {
+		return nil, fmt.Errorf("not a command: %q", line)
+	}
+	command := make([]string, count)
+	for i := range command {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		command[i] = string(data[:size])
+	}
+	return command, nil
+}
+
+func (r *fakeRedis) exec(command []string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands = append(r.commands, command)
+	for key, at := range r.expiry {
+		if !r.now.Before(at) {
+			delete(r.values, key)
+			delete(r.expiry, key)
+		}
+	}
+
+	switch strings.ToUpper(command[0]) {
+	case "AUTH":
+		if command[len(command)-1] != r.password {
+			return "-WRONGPASS invalid username-password pair\r\n"
+		}
+		return "+OK\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "SET": // SET key value PX ms NX
+		key := command[1]
+		if _, exists := r.values[key]; exists {
+			return "$-1\r\n"
+		}
+		value, _ := strconv.ParseInt(command[2], 10, 64)
+		ms, _ := strconv.Atoi(command[4])
+		r.values[key] = value
+		r.expiry[key] = r.now.Add(time.Duration(ms) * time.Millisecond)
+		return "+OK\r\n"
+	case "INCR":
+		r.values[command[1]]++
+		return fmt.Sprintf(":%d\r\n", r.values[command[1]])
+	case "PTTL":
+		if r.dropTTL {
+			r.dropTTL = false
+			clear(r.expiry)
+		}
+		at, ok := r.expiry[command[1]]
+		if _, exists := r.values[command[1]]; !exists {
+			return ":-2\r\n"
+		} else if !ok {
+			return ":-1\r\n"
+		}
+		return fmt.Sprintf(":%d\r\n", at.Sub(r.now).Milliseconds())
+	case "PEXPIRE":
+		ms, _ := strconv.Atoi(command[2])
+		r.expiry[command[1]] = r.now.Add(time.Duration(ms) * time.Millisecond)
+		return ":1\r\n"
+	case "ECHO":
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(command[1]), command[1])
+	case "MIXED":
+		return "*4\r\n+OK\r\n:-7\r\n$-1\r\n*1\r\n$2\r\nhi\r\n"
+	}
+	return fmt.Sprintf("-ERR unknown command '%s'\r\n", command[0])
+}
+
+// advance moves the server clock forward.
+func (r *fakeRedis) advance(d time.Duration) {
+	r.mu.Lock()
+	r.now = r.now.Add(d)
+	r.mu.Unlock()
+}
+
+// sent returns the commands received so far, each joined with spaces.
+func (r *fakeRedis) sent() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sent []string
+	for _, command := range r.commands {
+		sent = append(sent, strings.Join(command, " "))
+	}
+	return sent
+}
+
+func TestRedisClientPipeline(t *testing.T) {
+	server := newFakeRedis(t)
+	server.password = "secret"
+	client, err := NewRedisClient("redis://:secret@" + server.addr + "/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	ctx := context.Background()
+
+	replies, err := client.Pipeline(ctx,
+		[]string{"ECHO", "a\r\nb"},
+		[]string{"MIXED"},
+		[]string{"NOPE"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replies[0] != "a\r\nb" {
+		t.Errorf("bulk reply %q", replies[0])
+	}
+	mixed, ok := replies[1].([]any)
+	if !ok || len(mixed) != 4 || mixed[0] != "OK" || mixed[1] != int64(-7) || mixed[2] != nil {
+		t.Errorf("array reply %#v", replies[1])
+	} else if nested, ok := mixed[3].([]any); !ok || len(nested) != 1 || nested[0] != "hi" {
+		t.Errorf("nested array %#v", mixed[3])
+	}
+	if replyErr, ok := replies[2].(error); !ok || !errors.Is(replyErr, ErrRedisReply) {
+		t.Errorf("error reply %#v, want an error wrapping ErrRedisReply", replies[2])
+	}
+
+	if _, err := client.Pipeline(ctx, []string{"ECHO", "again"}); err != nil {
+		t.Fatal(err)
+	}
+	sent := server.sent()
+	if len(sent) < 2 || sent[0] != "AUTH secret" || sent[1] != "SELECT 2" {
+		t.Errorf("connection setup sent %q, want AUTH then SELECT", sent)
+	}
+	server.mu.Lock()
+	connections := server.connections
+	server.mu.Unlock()
+	if connections != 1 {
+		t.Errorf("%d connections for two pipelines, want the idle one reused", connections)
+	}
+}
+
+func TestRedisClientSetupFailure(t *testing.T) {
+	server := newFakeRedis(t)
+	server.password = "secret"
+	client, err := NewRedisClient("redis://:wrong@" + server.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Pipeline(context.Background(), []string{"ECHO", "x"}); !errors.Is(err, ErrRedisReply) {
+		t.Fatalf("error %v, want the AUTH error reply", err)
+	}
+}
+
+func TestNewRedisClientURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		wantAddr string
+		wantDB   int
+		wantTLS  bool
+		wantErr  bool
+	}{
+		{url: "redis://cache", wantAddr: "cache:6379"},
+		{url: "redis://user:pw@cache:6380/3", wantAddr: "cache:6380", wantDB: 3},
+		{url: "rediss://cache:6380", wantAddr: "cache:6380", wantTLS: true},
+		{url: "http://cache", wantErr: true},
+		{url: "redis://cache/db", wantErr: true},
+		{url: "redis:///0", wantErr: true},
+	}
+	for _, tt := range tests {
+		client, err := NewRedisClient(tt.url)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("%s: error %v, want %v", tt.url, err, ErrInvalidConfig)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.url, err)
+			continue
+		}
+		if client.addr != tt.wantAddr || client.db != tt.wantDB || (client.tls != nil) != tt.wantTLS {
+			t.Errorf("%s: addr %s db %d tls %v", tt.url, client.addr, client.db, client.tls != nil)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// writePrivateFile replaces path atomically with data, readable by the owner only. It is
+// used for files holding credential hashes (API keys, dashboard users).
+func writePrivateFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// fileCache holds a value parsed from a file and parses it again when the file's
+// modification time or size changes, so edits made with the CLI take effect without a
+// restart.
+type fileCache[T any] struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	size    int64
+	loaded  bool
+	value   T
+	parse   func(path string) (T, error)
+}
+
+func (c *fileCache[T]) get(path string) (T, error) {
+	var modTime time.Time
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		modTime, size = info.ModTime(), info.Size()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loaded && c.path == path && c.modTime.Equal(modTime) && c.size == size {
+		return c.value, nil
+	}
+	value, err := c.parse(path)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	c.path, c.modTime, c.size, c.loaded, c.value = path, modTime, size, true, value
+	return value, nil
+}
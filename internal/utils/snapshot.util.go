@@ -0,0 +1,361 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-log/internal/api/models"
+	"strings"
+	"time"
+)
+
+// SnapshotFromLogEntry converts a legacy JSON log entry (flat local format or nested
+// remote payload format) into a SystemMonitoring snapshot.
+func SnapshotFromLogEntry(entry models.MonitoringLogEntry) (*models.SystemMonitoring, error) {
+	if entry.Body == nil {
+		return nil, fmt.Errorf("empty log entry body")
+	}
+
+	// Check if this is a remote server entry with nested payload structure
+	if payload, hasPayload := entry.Body["payload"]; hasPayload {
+		return snapshotFromServerLogEntry(entry, payload)
+	}
+
+	// Handle local monitoring data with flat structure
+	return snapshotFromFlatLogEntry(entry)
+}
+
+func snapshotFromServerLogEntry(entry models.MonitoringLogEntry, payload any) (*models.SystemMonitoring, error) {
+	// Remote server data is stored as: {"time": "...", "payload": [{"timestamp": "...", "cpu": {...}, ...}]}
+	payloadArray, ok := payload.([]any)
+	if !ok || len(payloadArray) == 0 {
+		return nil, fmt.Errorf("invalid payload format")
+	}
+
+	// Take the first (and typically only) SystemMonitoring entry from the payload
+	firstEntry, ok := payloadArray[0].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid payload entry format")
+	}
+
+	// Convert the nested structure to SystemMonitoring
+	data, err := json.Marshal(firstEntry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload entry: %w", err)
+	}
+
+	var snapshot models.SystemMonitoring
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal to SystemMonitoring: %w", err)
+	}
+
+	// Use the entry time if timestamp is not set
+	if snapshot.Timestamp.IsZero() && entry.Time != "" {
+		if ts, err := ParseTimestampUTC(entry.Time); err == nil {
+			snapshot.Timestamp = ts
+		}
+	}
+
+	return &snapshot, nil
+}
+
+func snapshotFromFlatLogEntry(entry models.MonitoringLogEntry) (*models.SystemMonitoring, error) {
+	snapshot := &models.SystemMonitoring{}
+
+	// Parse timestamp
+	if entry.Time != "" {
+		if ts, err := ParseTimestampUTC(entry.Time); err == nil {
+			snapshot.Timestamp = ts
+		}
+	}
+
+	// Helper function to safely convert to float64
+	toFloat64 := func(v any) float64 {
+		switch val := v.(type) {
+		case float64:
+			return val
+		case float32:
+			return float64(val)
+		case int:
+			return float64(val)
+		case int64:
+			return float64(val)
+		case uint64:
+			return float64(val)
+		default:
+			return 0
+		}
+	}
+
+	// Helper function to safely convert to uint64
+	toUint64 := func(v any) uint64 {
+		switch val := v.(type) {
+		case uint64:
+			return val
+		case int64:
+			if val >= 0 {
+				return uint64(val)
+			}
+			return 0
+		case int:
+			if val >= 0 {
+				return uint64(val)
+			}
+			return 0
+		case float64:
+			if val >= 0 {
+				return uint64(val)
+			}
+			return 0
+		default:
+			return 0
+		}
+	}
+
+	// Helper function to safely convert to int
+	toInt := func(v any) int {
+		switch val := v.(type) {
+		case int:
+			return val
+		case int64:
+			return int(val)
+		case float64:
+			return int(val)
+		default:
+			return 0
+		}
+	}
+
+	// Helper function to safely convert to string
+	toString := func(v any) string {
+		if v == nil {
+			return ""
+		}
+		if s, ok := v.(string); ok {
+			return s
+		}
+		return fmt.Sprintf("%v", v)
+	}
+
+	// Map CPU fields
+	snapshot.CPU = models.CPU{
+		UsagePercent: toFloat64(entry.Body["cpu_usage_percent"]),
+		CoreCount:    toInt(entry.Body["cpu_cores"]),
+		Goroutines:   toInt(entry.Body["cpu_goroutines"]),
+		LoadAverage:  toString(entry.Body["cpu_load_average"]),
+		Architecture: toString(entry.Body["cpu_architecture"]),
+	}
+
+	// Map RAM fields
+	snapshot.RAM = models.RAM{
+		TotalBytes:     toUint64(entry.Body["ram_total_bytes"]),
+		UsedBytes:      toUint64(entry.Body["ram_used_bytes"]),
+		AvailableBytes: toUint64(entry.Body["ram_available_bytes"]),
+		UsedPct:        toFloat64(entry.Body["ram_used_percent"]),
+		BufferBytes:    toUint64(entry.Body["ram_buffer_bytes"]),
+	}
+
+	// Map NetworkIO fields
+	snapshot.NetworkIO = models.NetworkIO{
+		BytesSent:   toUint64(entry.Body["network_bytes_sent"]),
+		BytesRecv:   toUint64(entry.Body["network_bytes_recv"]),
+		PacketsSent: toUint64(entry.Body["network_packets_sent"]),
+		PacketsRecv: toUint64(entry.Body["network_packets_recv"]),
+		ErrorsIn:    toUint64(entry.Body["network_errors_in"]),
+		ErrorsOut:   toUint64(entry.Body["network_errors_out"]),
+		DropsIn:     toUint64(entry.Body["network_drops_in"]),
+		DropsOut:    toUint64(entry.Body["network_drops_out"]),
+	}
+
+	// Map DiskIO fields
+	snapshot.DiskIO = models.DiskIO{
+		ReadBytes:  toUint64(entry.Body["diskio_read_bytes"]),
+		WriteBytes: toUint64(entry.Body["diskio_write_bytes"]),
+		ReadCount:  toUint64(entry.Body["diskio_read_count"]),
+		WriteCount: toUint64(entry.Body["diskio_write_count"]),
+		ReadTime:   toUint64(entry.Body["diskio_read_time"]),
+		WriteTime:  toUint64(entry.Body["diskio_write_time"]),
+		IOTime:     toUint64(entry.Body["diskio_io_time"]),
+	}
+
+	// Map Process fields
+	snapshot.Process = models.Process{
+		TotalProcesses: toInt(entry.Body["process_total"]),
+		RunningProcs:   toInt(entry.Body["process_running"]),
+		SleepingProcs:  toInt(entry.Body["process_sleeping"]),
+		ZombieProcs:    toInt(entry.Body["process_zombie"]),
+		StoppedProcs:   toInt(entry.Body["process_stopped"]),
+		LoadAvg1:       toFloat64(entry.Body["process_load_avg_1"]),
+		LoadAvg5:       toFloat64(entry.Body["process_load_avg_5"]),
+		LoadAvg15:      toFloat64(entry.Body["process_load_avg_15"]),
+	}
+
+	// Map DiskSpace fields - try to get from disk_spaces array first, fallback to flat fields
+	if diskSpaces, ok := entry.Body["disk_spaces"]; ok {
+		if diskArray, ok := diskSpaces.([]any); ok {
+			for _, diskItem := range diskArray {
+				if diskMap, ok := diskItem.(map[string]any); ok {
+					disk := models.DiskSpace{
+						Path:           toString(diskMap["path"]),
+						Device:         toString(diskMap["device"]),
+						FileSystem:     toString(diskMap["filesystem"]),
+						TotalBytes:     toUint64(diskMap["total_bytes"]),
+						UsedBytes:      toUint64(diskMap["used_bytes"]),
+						AvailableBytes: toUint64(diskMap["available_bytes"]),
+						UsedPct:        toFloat64(diskMap["used_pct"]),
+					}
+					snapshot.DiskSpace = append(snapshot.DiskSpace, disk)
+				}
+			}
+		}
+	}
+
+	// If no disk_spaces array, create one from flat fields for backward compatibility
+	if len(snapshot.DiskSpace) == 0 {
+		snapshot.DiskSpace = []models.DiskSpace{
+			{
+				Path:           "/", // Assume root
+				Device:         "unknown",
+				FileSystem:     "unknown",
+				TotalBytes:     toUint64(entry.Body["disk_total_bytes"]),
+				UsedBytes:      toUint64(entry.Body["disk_used_bytes"]),
+				AvailableBytes: toUint64(entry.Body["disk_available_bytes"]),
+				UsedPct:        toFloat64(entry.Body["disk_used_percent"]),
+			},
+		}
+	}
+
+	// Map Heartbeat and ServerMetrics if present
+	if heartbeat, ok := entry.Body["heartbeat"]; ok && heartbeat != nil {
+		// Convert heartbeat data from database storage format
+		if heartbeatArray, ok := heartbeat.([]any); ok {
+			for _, rawCheck := range heartbeatArray {
+				checkMap, ok := rawCheck.(map[string]any)
+				if !ok {
+					continue
+				}
+
+				// Convert timestamps to proper time.Time format
+				var lastChecked time.Time
+				if lastCheckedStr, ok := checkMap["last_checked"].(string); ok {
+					if parsed, err := time.Parse(time.RFC3339, lastCheckedStr); err == nil {
+						lastChecked = parsed
+					}
+				}
+
+				check := models.ServerCheck{
+					Name:         toString(checkMap["name"]),
+					URL:          toString(checkMap["url"]),
+					Status:       models.ServerStatus(toString(checkMap["status"])),
+					ResponseTime: toString(checkMap["response_time"]),
+					ResponseMs:   int64(toFloat64(checkMap["response_ms"])),
+					LastChecked:  lastChecked,
+					Error:        toString(checkMap["error"]),
+				}
+				snapshot.Heartbeat = append(snapshot.Heartbeat, check)
+			}
+		}
+	}
+
+	if serverMetrics, ok := entry.Body["server_metrics"]; ok && serverMetrics != nil {
+		if metricArray, ok := serverMetrics.([]any); ok {
+			for _, rawMetric := range metricArray {
+				metricMap, ok := rawMetric.(map[string]any)
+				if !ok {
+					continue
+				}
+				metric := models.ServerMetrics{
+					Name:              toString(metricMap["name"]),
+					Address:           trimServerAddress(toString(metricMap["address"])),
+					CPUUsage:          toFloat64(metricMap["cpu_usage"]),
+					MemoryUsedPercent: toFloat64(metricMap["memory_used_percent"]),
+					DiskUsedPercent:   toFloat64(metricMap["disk_used_percent"]),
+					NetworkInBytes:    toUint64(metricMap["network_in_bytes"]),
+					NetworkOutBytes:   toUint64(metricMap["network_out_bytes"]),
+					LoadAverage:       toString(metricMap["cpu_load_average"]),
+					Timestamp:         toString(metricMap["timestamp"]),
+					Status:            toString(metricMap["status"]),
+					Message:           toString(metricMap["message"]),
+					DiskSpace:         diskSpacesFromValue(metricMap["disk_space"]),
+
+					CircuitState:        models.CircuitState(toString(metricMap["circuit_state"])),
+					ConsecutiveFailures: int(toFloat64(metricMap["consecutive_failures"])),
+					NextRetry:           toString(metricMap["next_retry"]),
+				}
+				snapshot.ServerMetrics = append(snapshot.ServerMetrics, metric)
+			}
+		}
+	}
+
+	return snapshot, nil
+}
+
+func diskSpacesFromValue(value any) []models.DiskSpace {
+	switch typed := value.(type) {
+	case []models.DiskSpace:
+		return typed
+	case []map[string]any:
+		return diskSpacesFromMaps(typed)
+	case []any:
+		maps := make([]map[string]any, 0, len(typed))
+		for _, item := range typed {
+			if entry, ok := item.(map[string]any); ok {
+				maps = append(maps, entry)
+			}
+		}
+		return diskSpacesFromMaps(maps)
+	default:
+		if value == nil {
+			return nil
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil
+		}
+		var disks []models.DiskSpace
+		if err := json.Unmarshal(data, &disks); err != nil {
+			return nil
+		}
+		return disks
+	}
+}
+
+// SnapshotFromServerPayload decodes a (trimmed) remote /api/v1/monitoring payload into
+// the first SystemMonitoring snapshot it contains.
+func SnapshotFromServerPayload(payload []byte) (*models.SystemMonitoring, error) {
+	if len(payload) == 0 {
+		return nil, fmt.Errorf("empty server payload")
+	}
+
+	var snapshots []models.SystemMonitoring
+	if err := json.Unmarshal(payload, &snapshots); err == nil && len(snapshots) > 0 {
+		return &snapshots[0], nil
+	}
+
+	var wrapper struct {
+		Data []models.SystemMonitoring `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &wrapper); err == nil && len(wrapper.Data) > 0 {
+		return &wrapper.Data[0], nil
+	}
+
+	return nil, fmt.Errorf("failed to parse server payload")
+}
+
+func diskSpacesFromMaps(items []map[string]any) []models.DiskSpace {
+	if len(items) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil
+	}
+	var disks []models.DiskSpace
+	if err := json.Unmarshal(data, &disks); err != nil {
+		return nil
+	}
+	return disks
+}
+
+func trimServerAddress(address string) string {
+	return strings.TrimRight(strings.TrimSpace(address), "/")
+}
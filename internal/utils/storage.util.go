@@ -1,15 +1,191 @@
 package utils
 
-import "strings"
+import (
+	"fmt"
+	"go-log/internal/api/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Canonical storage backend names used in configs.json "storage".
+const (
+	StorageFile     = "file"
+	StorageSQLite   = "sqlite"
+	StoragePostgres = "postgres"
+	StorageTSDB     = "tsdb"
+)
+
+// storageAliases maps accepted spellings to their canonical backend name.
+var storageAliases = map[string]string{
+	"postgresql": StoragePostgres,
+	"pg":         StoragePostgres,
+	"timescale":  StoragePostgres,
+	"sqlite3":    StorageSQLite,
+}
+
+// NormalizeStorageName lower-cases a backend name and resolves aliases such as "postgresql".
+func NormalizeStorageName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if canonical, ok := storageAliases[name]; ok {
+		return canonical
+	}
+	return name
+}
 
 // HasStorage checks if the desired backend exists in the configured list.
 func HasStorage(backends []string, want string) bool {
-    want = strings.ToLower(strings.TrimSpace(want))
-    for _, b := range backends {
-        if strings.ToLower(strings.TrimSpace(b)) == want {
-            return true
-        }
-    }
-    return false
+	want = NormalizeStorageName(want)
+	for _, b := range backends {
+		if NormalizeStorageName(b) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// StorageBackend is implemented by every persistence engine selectable in "storage".
+// Table names are the raw per-server names from configs.json; DefaultTableName is the local host.
+type StorageBackend interface {
+	// Name returns the canonical backend name.
+	Name() string
+	// Init opens the backend; calling it again on an open backend is a no-op.
+	Init() error
+	// Ready reports whether the backend is open and can accept writes.
+	Ready() bool
+	// Write stores one local snapshot.
+	Write(table string, snapshot *models.SystemMonitoring) error
+	// WriteServer stores a raw monitoring payload fetched from a remote server.
+	WriteServer(server models.ServerEndpoint, payload []byte) error
+	// PrepareTable creates the storage for a server table ahead of the first write.
+	PrepareTable(table string) error
+	// Query returns snapshots newest first. An inactive page keeps the downsampled/capped
+	// behaviour; an active page returns one keyset page and the cursor for the next.
+	Query(table, from, to string, page PageRequest) ([]models.SystemMonitoring, string, error)
+	// ListTables lists the tables that hold data, using clean (unquoted) names.
+	ListTables() ([]string, error)
+	// Cleanup removes data older than cutoff.
+	Cleanup(cutoff time.Time) error
+	// Health checks that the backend is reachable.
+	Health() error
+	// Close releases the backend's resources.
+	Close() error
 }
 
+// BatchWriter is implemented by backends that can store many snapshots in one round trip.
+type BatchWriter interface {
+	WriteBatch(table string, batch []models.SystemMonitoring) error
+}
+
+// LegacyTableDropper is implemented by backends that keep the tables of the old JSON
+// schema (<table>_legacy) after copying their rows into the current one.
+type LegacyTableDropper interface {
+	// DropLegacyTables drops every legacy table whose migration has finished and returns
+	// the dropped names. Tables with unconvertible rows are kept unless force is set.
+	DropLegacyTables(force bool) ([]string, error)
+}
+
+// WriteSnapshots stores snapshots under their own timestamps, as one batch when the backend supports it.
+func WriteSnapshots(backend StorageBackend, table string, batch []models.SystemMonitoring) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	if bw, ok := backend.(BatchWriter); ok {
+		return bw.WriteBatch(table, batch)
+	}
+	for i := range batch {
+		if err := backend.Write(table, &batch[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	storageRegistryMu sync.RWMutex
+	storageRegistry   = map[string]StorageBackend{}
+	storageOrder      []string
+)
+
+// RegisterStorageBackend makes a backend selectable by name. Registering a name twice replaces the first.
+func RegisterStorageBackend(backend StorageBackend) {
+	name := NormalizeStorageName(backend.Name())
+	storageRegistryMu.Lock()
+	defer storageRegistryMu.Unlock()
+	if _, exists := storageRegistry[name]; !exists {
+		storageOrder = append(storageOrder, name)
+	}
+	storageRegistry[name] = backend
+}
+
+// GetStorageBackend returns the registered backend for a name or alias.
+func GetStorageBackend(name string) (StorageBackend, bool) {
+	storageRegistryMu.RLock()
+	defer storageRegistryMu.RUnlock()
+	backend, ok := storageRegistry[NormalizeStorageName(name)]
+	return backend, ok
+}
+
+// RegisteredStorageBackends lists registered backend names in registration order.
+func RegisteredStorageBackends() []string {
+	storageRegistryMu.RLock()
+	defer storageRegistryMu.RUnlock()
+	return append([]string(nil), storageOrder...)
+}
+
+// ConfiguredStorageBackends resolves a "storage" list to registered backends in config order,
+// dropping duplicates. Unknown names are skipped.
+func ConfiguredStorageBackends(configured []string) []StorageBackend {
+	seen := make(map[string]struct{}, len(configured))
+	backends := make([]StorageBackend, 0, len(configured))
+	for _, raw := range configured {
+		name := NormalizeStorageName(raw)
+		if _, dup := seen[name]; dup {
+			continue
+		}
+		seen[name] = struct{}{}
+		if backend, ok := GetStorageBackend(name); ok {
+			backends = append(backends, backend)
+		}
+	}
+	return backends
+}
+
+// EnabledStorageBackends returns the configured backends that are ready for use.
+func EnabledStorageBackends(configured []string) []StorageBackend {
+	all := ConfiguredStorageBackends(configured)
+	ready := all[:0]
+	for _, backend := range all {
+		if backend.Ready() {
+			ready = append(ready, backend)
+		}
+	}
+	return ready
+}
+
+// InitStorageBackends opens every configured backend. Failures are logged so the
+// remaining backends still start; unknown names are reported once here.
+func InitStorageBackends(configured []string) {
+	for _, raw := range configured {
+		if _, ok := GetStorageBackend(raw); !ok {
+			LogWarn("unknown storage backend %q ignored (available: %s)", raw, strings.Join(RegisteredStorageBackends(), ", "))
+		}
+	}
+	for _, backend := range ConfiguredStorageBackends(configured) {
+		if err := backend.Init(); err != nil {
+			LogWarnWithContext("storage", fmt.Sprintf("failed to initialize %s storage", backend.Name()), err)
+		}
+	}
+}
+
+// CloseStorageBackends closes every registered backend and returns the first error.
+func CloseStorageBackends() error {
+	var firstErr error
+	for _, name := range RegisteredStorageBackends() {
+		backend, _ := GetStorageBackend(name)
+		if err := backend.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close %s storage: %w", name, err)
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"fmt"
+	"go-log/internal/api/models"
+	"os"
+	"time"
+)
+
+func init() {
+	RegisterStorageBackend(fileStorage{})
+	RegisterStorageBackend(sqliteStorage{})
+	RegisterStorageBackend(postgresStorage{})
+	RegisterStorageBackend(tsdbStorage{})
+}
+
+// fileStorage writes JSON log files under the configured log path. It cannot serve queries.
+type fileStorage struct{}
+
+func (fileStorage) Name() string { return StorageFile }
+
+func (fileStorage) Init() error {
+	if logConfig == nil {
+		return fmt.Errorf("logger not initialized")
+	}
+	return nil
+}
+
+func (fileStorage) Ready() bool { return logConfig != nil && logConfig.Path != "" }
+
+func (fileStorage) Write(_ string, snapshot *models.SystemMonitoring) error {
+	return writeMonitoringLogFile(snapshot)
+}
+
+// WriteBatch files snapshots under the day of their own timestamp.
+func (fileStorage) WriteBatch(table string, batch []models.SystemMonitoring) error {
+	return appendLogSnapshots(table, batch)
+}
+
+func (fileStorage) WriteServer(server models.ServerEndpoint, payload []byte) error {
+	return WriteServerLogToFile(logConfig.Path, server, payload)
+}
+
+func (fileStorage) PrepareTable(string) error { return nil }
+
+func (fileStorage) Query(string, string, string, PageRequest) ([]models.SystemMonitoring, string, error) {
+	return nil, "", ErrQueryNotSupported
+}
+
+func (fileStorage) ListTables() ([]string, error) { return GetFileTables() }
+
+func (fileStorage) Cleanup(cutoff time.Time) error { return cleanLogsBefore(cutoff) }
+
+func (fileStorage) Health() error {
+	if logConfig == nil || logConfig.Path == "" {
+		return fmt.Errorf("logger not initialized")
+	}
+	info, err := os.Stat(logConfig.Path)
+	if err != nil {
+		return fmt.Errorf("log directory unavailable: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("log path %s is not a directory", logConfig.Path)
+	}
+	return nil
+}
+
+func (fileStorage) Close() error { return nil }
+
+// sqliteStorage stores snapshots in the local SQLite database.
+type sqliteStorage struct{}
+
+func (sqliteStorage) Name() string { return StorageSQLite }
+func (sqliteStorage) Init() error  { return InitDatabase() }
+func (sqliteStorage) Ready() bool  { return IsDatabaseInitialized() }
+
+func (sqliteStorage) Write(table string, snapshot *models.SystemMonitoring) error {
+	return writeToTableInternal(table, snapshot)
+}
+
+func (sqliteStorage) WriteBatch(table string, batch []models.SystemMonitoring) error {
+	return writeSQLiteBatch(table, batch)
+}
+
+func (sqliteStorage) WriteServer(server models.ServerEndpoint, payload []byte) error {
+	return WriteServerLogToDatabase(server.TableName, payload)
+}
+
+func (sqliteStorage) PrepareTable(table string) error { return PrepareSQLiteServerTable(table) }
+
+func (sqliteStorage) Query(table, from, to string, page PageRequest) ([]models.SystemMonitoring, string, error) {
+	if page.Active() {
+		return QueryTableDataPage(table, from, to, page)
+	}
+	data, err := QueryFilteredTableData(table, from, to)
+	return data, "", err
+}
+
+func (sqliteStorage) ListTables() ([]string, error)  { return GetSQLiteTables() }
+func (sqliteStorage) Cleanup(cutoff time.Time) error { return CleanOldDatabaseEntries(cutoff) }
+
+func (sqliteStorage) Health() error {
+	if db == nil {
+		return ErrDatabaseNotInit
+	}
+	return db.Ping()
+}
+
+func (sqliteStorage) Close() error { return CloseDatabase() }
+
+func (sqliteStorage) DropLegacyTables(force bool) ([]string, error) {
+	return DropLegacySQLiteTables(force)
+}
+
+// postgresStorage stores snapshots in PostgreSQL, using TimescaleDB features when available.
+type postgresStorage struct{}
+
+func (postgresStorage) Name() string { return StoragePostgres }
+func (postgresStorage) Init() error  { return InitPostgres() }
+func (postgresStorage) Ready() bool  { return IsPostgresInitialized() }
+
+func (postgresStorage) Write(table string, snapshot *models.SystemMonitoring) error {
+	return WriteToPostgres(table, snapshot)
+}
+
+func (postgresStorage) WriteBatch(table string, batch []models.SystemMonitoring) error {
+	return writePostgresBatch(table, batch)
+}
+
+func (postgresStorage) WriteServer(server models.ServerEndpoint, payload []byte) error {
+	return WriteServerLogToPostgres(server.TableName, payload)
+}
+
+func (postgresStorage) PrepareTable(table string) error { return PreparePostgresServerTable(table) }
+
+func (postgresStorage) Query(table, from, to string, page PageRequest) ([]models.SystemMonitoring, string, error) {
+	if page.Active() {
+		return QueryPostgresDataPage(table, from, to, page)
+	}
+	data, err := QueryFilteredPostgresData(table, from, to)
+	return data, "", err
+}
+
+func (postgresStorage) ListTables() ([]string, error)  { return GetPostgresTables() }
+func (postgresStorage) Cleanup(cutoff time.Time) error { return CleanOldPostgresEntries(cutoff) }
+func (postgresStorage) Health() error                  { return PingPostgres() }
+func (postgresStorage) Close() error                   { return ClosePostgres() }
+
+func (postgresStorage) DropLegacyTables(force bool) ([]string, error) {
+	return DropLegacyPostgresTables(force)
+}
+
+// tsdbStorage stores snapshots in the embedded time-series engine.
+type tsdbStorage struct{}
+
+func (tsdbStorage) Name() string { return StorageTSDB }
+func (tsdbStorage) Init() error  { return InitTSDB() }
+func (tsdbStorage) Ready() bool  { return IsTSDBInitialized() }
+
+func (tsdbStorage) Write(table string, snapshot *models.SystemMonitoring) error {
+	return WriteToTSDB(table, snapshot)
+}
+
+func (tsdbStorage) WriteServer(server models.ServerEndpoint, payload []byte) error {
+	return WriteServerLogToTSDB(server.TableName, payload)
+}
+
+func (tsdbStorage) PrepareTable(table string) error { return PrepareTSDBServerTable(table) }
+
+func (tsdbStorage) Query(table, from, to string, page PageRequest) ([]models.SystemMonitoring, string, error) {
+	if page.Active() {
+		return QueryTSDBDataPage(table, from, to, page)
+	}
+	data, err := QueryFilteredTSDBData(table, from, to)
+	return data, "", err
+}
+
+func (tsdbStorage) ListTables() ([]string, error)  { return GetTSDBTables() }
+func (tsdbStorage) Cleanup(cutoff time.Time) error { return CleanOldTSDBEntries(cutoff) }
+
+func (tsdbStorage) Health() error {
+	_, err := GetTSDBTables()
+	return err
+}
+
+func (tsdbStorage) Close() error { return CloseTSDB() }
@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"go-log/internal/api/models"
+	"go-log/internal/config"
+)
+
+// storageContractCase opens one backend for the contract test. read lists a table's
+// snapshots newest first for backends that cannot be queried.
+type storageContractCase struct {
+	name  string
+	setup func(t *testing.T) StorageBackend
+	read  func(t *testing.T, table string) []models.SystemMonitoring
+}
+
+func storageContractCases() []storageContractCase {
+	return []storageContractCase{
+		{
+			name: StorageFile,
+			setup: func(t *testing.T) StorageBackend {
+				// Log paths must lie inside BASE_LOG_FOLDER
+				dir := t.TempDir()
+				t.Setenv("BASE_LOG_FOLDER", dir)
+				config.InitEnvConfig()
+				t.Cleanup(config.InitEnvConfig)
+				InitLogger(&models.MonitoringConfig{Path: dir})
+				t.Cleanup(func() { InitLogger(nil) })
+				return fileStorage{}
+			},
+			read: func(t *testing.T, table string) []models.SystemMonitoring {
+				var snapshots []models.SystemMonitoring
+				err := ReadLogSnapshots(table, "", func(_ string, batch []models.SystemMonitoring) error {
+					snapshots = append(batch, snapshots...)
+					return nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				slices.SortStableFunc(snapshots, func(a, b models.SystemMonitoring) int { return b.Timestamp.Compare(a.Timestamp) })
+				return snapshots
+			},
+		},
+		{
+			name: StorageSQLite,
+			setup: func(t *testing.T) StorageBackend {
+				useTestSQLite(t)
+				return sqliteStorage{}
+			},
+		},
+		{
+			name: StorageTSDB,
+			setup: func(t *testing.T) StorageBackend {
+				useTestTSDB(t)
+				return tsdbStorage{}
+			},
+		},
+		{
+			// Cleanup removes old rows from every table, so point POSTGRES_* at a scratch database
+			name: StoragePostgres,
+			setup: func(t *testing.T) StorageBackend {
+				if os.Getenv("GO_LOG_TEST_POSTGRES") == "" {
+					t.Skip("set GO_LOG_TEST_POSTGRES=1 and POSTGRES_* to run against a scratch database")
+				}
+				if err := InitPostgres(); err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() {
+					pgMu.RLock()
+					conn := pgdb
+					pgMu.RUnlock()
+					for _, suffix := range []string{"", DisksTableSuffix, HeartbeatsTableSuffix} {
+						conn.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS %s CASCADE`, pqQuoteIdent(storageContractTable+suffix)))
+					}
+					ClosePostgres()
+				})
+				return postgresStorage{}
+			},
+		},
+	}
+}
+
+const storageContractTable = "contract_edge"
+
+func TestStorageBackendContract(t *testing.T) {
+	// Two snapshots on each of three days, far enough back that cleaning a shared
+	// Postgres database before the cutoff touches nothing else
+	day := time.Date(2001, 3, 1, 0, 0, 0, 0, time.UTC)
+	var written []models.SystemMonitoring
+	for d := range 3 {
+		for _, offset := range []time.Duration{6 * time.Hour, 18 * time.Hour} {
+			var s models.SystemMonitoring
+			s.Timestamp = day.AddDate(0, 0, d).Add(offset)
+			s.CPU.UsagePercent = float64(len(written) + 1)
+			written = append(written, s)
+		}
+	}
+	from := FormatTimestampUTC(day)
+	to := FormatTimestampUTC(day.AddDate(0, 0, 3))
+
+	for _, tc := range storageContractCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := tc.setup(t)
+			if !backend.Ready() {
+				t.Fatal("backend not ready after setup")
+			}
+			if err := backend.PrepareTable(storageContractTable); err != nil {
+				t.Fatal(err)
+			}
+			if err := WriteSnapshots(backend, storageContractTable, written); err != nil {
+				t.Fatal(err)
+			}
+
+			query := func(from, to string) []models.SystemMonitoring {
+				t.Helper()
+				if tc.read != nil {
+					if _, _, err := backend.Query(storageContractTable, from, to, PageRequest{}); !errors.Is(err, ErrQueryNotSupported) {
+						t.Fatalf("query: error %v, want %v", err, ErrQueryNotSupported)
+					}
+					return tc.read(t, storageContractTable)
+				}
+				snapshots, _, err := backend.Query(storageContractTable, from, to, PageRequest{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return snapshots
+			}
+
+			t.Run("write and query", func(t *testing.T) {
+				got := query(from, to)
+				assertSnapshotTimes(t, got, written)
+				if got[0].CPU.UsagePercent != written[len(written)-1].CPU.UsagePercent {
+					t.Errorf("newest snapshot has cpu %v, want %v", got[0].CPU.UsagePercent, written[len(written)-1].CPU.UsagePercent)
+				}
+			})
+
+			if tc.read == nil {
+				t.Run("range", func(t *testing.T) {
+					second := FormatTimestampUTC(day.AddDate(0, 0, 1))
+					secondEnd := FormatTimestampUTC(day.AddDate(0, 0, 2).Add(-time.Nanosecond))
+					assertSnapshotTimes(t, query(second, secondEnd), written[2:4])
+				})
+
+				t.Run("page", func(t *testing.T) {
+					var got []models.SystemMonitoring
+					page := PageRequest{Limit: 4}
+					for pages := 0; ; pages++ {
+						if pages > len(written) {
+							t.Fatal("pagination did not terminate")
+						}
+						batch, next, err := backend.Query(storageContractTable, "", "", page)
+						if err != nil {
+							t.Fatal(err)
+						}
+						if len(batch) > page.Limit {
+							t.Fatalf("page of %d snapshots, limit %d", len(batch), page.Limit)
+						}
+						got = append(got, batch...)
+						if next == "" {
+							break
+						}
+						page.Cursor = next
+					}
+					assertSnapshotTimes(t, got, written)
+				})
+			}
+
+			t.Run("list", func(t *testing.T) {
+				tables, err := backend.ListTables()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !slices.Contains(tables, storageContractTable) {
+					t.Errorf("tables %v do not include %s", tables, storageContractTable)
+				}
+			})
+
+			t.Run("clean", func(t *testing.T) {
+				if err := backend.Cleanup(day.AddDate(0, 0, 2)); err != nil {
+					t.Fatal(err)
+				}
+				assertSnapshotTimes(t, query(from, to), written[4:])
+			})
+		})
+	}
+}
+
+// assertSnapshotTimes checks that got holds the timestamps of want, newest first.
+func assertSnapshotTimes(t *testing.T, got, want []models.SystemMonitoring) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d snapshots, want %d", len(got), len(want))
+	}
+	for i := range got {
+		expected := want[len(want)-1-i].Timestamp
+		if !got[i].Timestamp.Equal(expected) {
+			t.Errorf("snapshot %d at %s, want %s", i, FormatTimestampUTC(got[i].Timestamp), FormatTimestampUTC(expected))
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package utils
+
+import (
+	"database/sql"
+	"fmt"
+	"go-log/internal/api/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// continuousAggregate describes one TimescaleDB rollup of a metrics hypertable.
+type continuousAggregate struct {
+	Suffix      string        // view name suffix, e.g. "_1m"
+	Bucket      time.Duration // time_bucket width
+	StartOffset string        // refresh window start, relative to now
+	EndOffset   string        // refresh window end, relative to now
+	Schedule    string        // how often the refresh policy runs
+	Retain      bool          // whether the raw retention policy also applies to this rollup
+}
+
+// continuousAggregates lists the rollups from finest to coarsest. Hourly and daily
+// rollups are kept after raw data expires so long ranges stay queryable.
+var continuousAggregates = []continuousAggregate{
+	{Suffix: "_1m", Bucket: time.Minute, StartOffset: "1 hour", EndOffset: "1 minute", Schedule: "1 minute", Retain: true},
+	{Suffix: "_1h", Bucket: time.Hour, StartOffset: "3 days", EndOffset: "1 hour", Schedule: "30 minutes"},
+	{Suffix: "_1d", Bucket: 24 * time.Hour, StartOffset: "30 days", EndOffset: "1 day", Schedule: "6 hours"},
+}
+
+var (
+	timescalePolicyMu  sync.RWMutex
+	timescalePolicyCfg *models.LogRotateConfig
+	timescalePolicySet bool // policies are left untouched until rotation settings are known
+)
+
+// pgInterval renders a duration as a Postgres interval literal.
+func pgInterval(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%d days", int(d/(24*time.Hour)))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%d hours", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%d minutes", int(d/time.Minute))
+	}
+}
+
+// aggregateSelectList renders avg/min/max expressions for every metric column.
+func aggregateSelectList() string {
+	parts := make([]string, 0, len(MetricColumns)*3+1)
+	for _, col := range MetricColumns {
+		parts = append(parts,
+			fmt.Sprintf("avg(%s) AS %s", col.Name, col.Name),
+			fmt.Sprintf("min(%s) AS %s_min", col.Name, col.Name),
+			fmt.Sprintf("max(%s) AS %s_max", col.Name, col.Name))
+	}
+	parts = append(parts, "count(*) AS samples")
+	return strings.Join(parts, ",\n            ")
+}
+
+// continuousAggregateExists reports whether a continuous aggregate view is already defined.
+func continuousAggregateExists(db *sql.DB, view string) (bool, error) {
+	var exists bool
+	err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM timescaledb_information.continuous_aggregates
+        WHERE view_schema='public' AND view_name=$1)`, view).Scan(&exists)
+	return exists, err
+}
+
+// enableRealTimeAggregate makes a rollup answer queries with its materialized buckets plus
+// the raw rows after the last refresh, so the newest window is not missing until the next
+// refresh policy run. TimescaleDB 2.13 and later create materialized-only views by default.
+func enableRealTimeAggregate(db *sql.DB, view string) error {
+	if _, err := db.Exec(fmt.Sprintf(`ALTER MATERIALIZED VIEW %s SET (timescaledb.materialized_only = false)`, pqQuoteIdent(view))); err != nil {
+		return fmt.Errorf("failed to enable real-time aggregation on %s: %w", view, err)
+	}
+	return nil
+}
+
+// ensureContinuousAggregates creates the 1m/1h/1d rollups of a hypertable and their refresh policies.
+// Newly created rollups are materialized once over the full history so migrated data is included.
+func ensureContinuousAggregates(db *sql.DB, name string) error {
+	for _, agg := range continuousAggregates {
+		view := name + agg.Suffix
+		exists, err := continuousAggregateExists(db, view)
+		if err != nil {
+			return fmt.Errorf("failed to inspect continuous aggregate %s: %w", view, err)
+		}
+		if exists {
+			if err := enableRealTimeAggregate(db, view); err != nil {
+				return err
+			}
+			continue
+		}
+
+		create := fmt.Sprintf(`CREATE MATERIALIZED VIEW IF NOT EXISTS %s
+        WITH (timescaledb.continuous) AS
+        SELECT time_bucket(INTERVAL '%s', timestamp) AS bucket,
+            %s
+        FROM %s
+        GROUP BY bucket
+        WITH NO DATA`, pqQuoteIdent(view), pgInterval(agg.Bucket), aggregateSelectList(), pqQuoteIdent(name))
+		if _, err := db.Exec(create); err != nil {
+			return fmt.Errorf("failed to create continuous aggregate %s: %w", view, err)
+		}
+		if err := enableRealTimeAggregate(db, view); err != nil {
+			return err
+		}
+
+		policy := fmt.Sprintf(`SELECT add_continuous_aggregate_policy('%s',
+            start_offset => INTERVAL '%s', end_offset => INTERVAL '%s',
+            schedule_interval => INTERVAL '%s', if_not_exists => TRUE)`,
+			view, agg.StartOffset, agg.EndOffset, agg.Schedule)
+		if _, err := db.Exec(policy); err != nil {
+			return fmt.Errorf("failed to add refresh policy for %s: %w", view, err)
+		}
+
+		if _, err := db.Exec(fmt.Sprintf(`CALL refresh_continuous_aggregate('%s', NULL, NULL)`, view)); err != nil {
+			LogWarnWithContext("timescaledb-aggregate", fmt.Sprintf("initial refresh of %s failed", view), err)
+		}
+		LogInfo("created continuous aggregate %s", view)
+	}
+	return nil
+}
+
+// applyTimescalePolicies installs or removes retention and compression policies on a hypertable
+// according to the current log rotation settings.
+func applyTimescalePolicies(db *sql.DB, name string) error {
+	timescalePolicyMu.RLock()
+	cfg := timescalePolicyCfg
+	timescalePolicyMu.RUnlock()
+
+	retainTargets := []string{name}
+	for _, agg := range continuousAggregates {
+		if agg.Retain {
+			retainTargets = append(retainTargets, name+agg.Suffix)
+		}
+	}
+
+	// Retention: only while log rotation is enabled
+	for _, target := range retainTargets {
+		if _, err := db.Exec(fmt.Sprintf(`SELECT remove_retention_policy('%s', if_exists => TRUE)`, target)); err != nil {
+			return fmt.Errorf("failed to reset retention policy on %s: %w", target, err)
+		}
+		if cfg == nil || !cfg.Enabled || cfg.MaxAgeDays <= 0 {
+			continue
+		}
+		q := fmt.Sprintf(`SELECT add_retention_policy('%s', INTERVAL '%d days', if_not_exists => TRUE)`, target, cfg.MaxAgeDays)
+		if _, err := db.Exec(q); err != nil {
+			return fmt.Errorf("failed to add retention policy on %s: %w", target, err)
+		}
+	}
+
+	// Compression: independent of rotation, disabled when compress_after_days is 0
+	if _, err := db.Exec(fmt.Sprintf(`SELECT remove_compression_policy('%s', if_exists => TRUE)`, name)); err != nil {
+		return fmt.Errorf("failed to reset compression policy on %s: %w", name, err)
+	}
+	if cfg == nil || cfg.CompressAfterDays <= 0 {
+		return nil
+	}
+	enable := fmt.Sprintf(`ALTER TABLE %s SET (timescaledb.compress, timescaledb.compress_orderby = 'timestamp DESC, id')`, pqQuoteIdent(name))
+	if _, err := db.Exec(enable); err != nil {
+		return fmt.Errorf("failed to enable compression on %s: %w", name, err)
+	}
+	q := fmt.Sprintf(`SELECT add_compression_policy('%s', INTERVAL '%d days', if_not_exists => TRUE)`, name, cfg.CompressAfterDays)
+	if _, err := db.Exec(q); err != nil {
+		return fmt.Errorf("failed to add compression policy on %s: %w", name, err)
+	}
+	return nil
+}
+
+// setupHypertableExtras creates rollups and policies for a freshly verified hypertable.
+// Failures are logged rather than returned so writes keep working without them.
+func setupHypertableExtras(db *sql.DB, name string) {
+	if err := ensureContinuousAggregates(db, name); err != nil {
+		LogWarnWithContext("timescaledb-aggregate", fmt.Sprintf("continuous aggregates unavailable for %s", name), err)
+	}
+	timescalePolicyMu.RLock()
+	configured := timescalePolicySet
+	timescalePolicyMu.RUnlock()
+	if !configured {
+		return
+	}
+	if err := applyTimescalePolicies(db, name); err != nil {
+		LogWarnWithContext("timescaledb-policy", fmt.Sprintf("failed to apply policies to %s", name), err)
+	}
+}
+
+// ConfigureTimescalePolicies records the rotation settings used for native TimescaleDB
+// retention/compression and applies them to every existing metrics hypertable.
+func ConfigureTimescalePolicies(cfg *models.LogRotateConfig) error {
+	timescalePolicyMu.Lock()
+	if cfg != nil {
+		copied := *cfg
+		timescalePolicyCfg = &copied
+	} else {
+		timescalePolicyCfg = nil
+	}
+	timescalePolicySet = true
+	timescalePolicyMu.Unlock()
+
+	pgMu.RLock()
+	db := pgdb
+	pgMu.RUnlock()
+	if db == nil || !IsTimescaleDBAvailable() {
+		return nil
+	}
+
+	tables, err := collectPGTables(db)
+	if err != nil {
+		return err
+	}
+	for _, t := range MetricsTables(tables) {
+		if !isHypertable(db, t) {
+			continue
+		}
+		if err := applyTimescalePolicies(db, t); err != nil {
+			LogWarnWithContext("timescaledb-policy", fmt.Sprintf("failed to apply policies to %s", t), err)
+		}
+	}
+	return nil
+}
+
+// timescaleRetentionManaged reports whether raw rows of the table are expired by a native retention policy.
+func timescaleRetentionManaged(db *sql.DB, name string) bool {
+	timescalePolicyMu.RLock()
+	cfg := timescalePolicyCfg
+	timescalePolicyMu.RUnlock()
+	if cfg == nil || !cfg.Enabled || cfg.MaxAgeDays <= 0 {
+		return false
+	}
+	return IsTimescaleDBAvailable() && isHypertable(db, name)
+}
+
+// selectContinuousAggregate picks the coarsest rollup whose bucket is no wider than
+// span/maxPoints, and the width its buckets are merged into so that at most maxPoints
+// remain: span/maxPoints rounded up to a multiple of the rollup's bucket. It returns false
+// when even the finest rollup is too coarse and the raw table should be used.
+func selectContinuousAggregate(span time.Duration, maxPoints int) (continuousAggregate, time.Duration, bool) {
+	if span <= 0 || maxPoints <= 0 {
+		return continuousAggregate{}, 0, false
+	}
+	ideal := span / time.Duration(maxPoints)
+	var chosen continuousAggregate
+	found := false
+	for _, agg := range continuousAggregates {
+		if agg.Bucket <= ideal {
+			chosen = agg
+			found = true
+		}
+	}
+	if !found {
+		return continuousAggregate{}, 0, false
+	}
+	width := (ideal + chosen.Bucket - 1) / chosen.Bucket * chosen.Bucket
+	return chosen, width, true
+}
+
+// queryContinuousAggregate reads bucket statistics from a rollup view, newest first, merging
+// its buckets into buckets of width. Averages are weighted by the samples behind each
+// rollup bucket. Rollups keep avg/min/max only, so P95 is left unset.
+func queryContinuousAggregate(db *sql.DB, name string, agg continuousAggregate, width time.Duration, fromNormalized, toNormalized string) ([]models.SystemMonitoring, error) {
+	view := name + agg.Suffix
+	exists, err := continuousAggregateExists(db, view)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("continuous aggregate %s does not exist", view)
+	}
+
+	// Rollups created before a metric column was added lack it; it reads back as unset
+	present, err := pgColumns(db, view)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect continuous aggregate %s: %w", view, err)
+	}
+	cols := make([]string, 0, len(MetricColumns)*4)
+	for _, col := range MetricColumns {
+		if !present[col.Name] {
+			cols = append(cols, "NULL::double precision", "NULL::double precision", "NULL::double precision", "NULL::double precision")
+			continue
+		}
+		cols = append(cols,
+			fmt.Sprintf("(sum(%[1]s * samples) / nullif(sum(samples) FILTER (WHERE %[1]s IS NOT NULL), 0))::double precision", col.Name),
+			fmt.Sprintf("min(%s_min)::double precision", col.Name),
+			fmt.Sprintf("max(%s_max)::double precision", col.Name),
+			"NULL::double precision")
+	}
+	query := fmt.Sprintf(`SELECT time_bucket(INTERVAL '%s', bucket) AS merged, sum(samples)::bigint, %s
+        FROM %s WHERE bucket >= $1 AND bucket <= $2
+        GROUP BY merged ORDER BY merged DESC`,
+		pgInterval(width), strings.Join(cols, ", "), pqQuoteIdent(view))
+	return executeAggregateQuery(db, query, []any{fromNormalized, toNormalized})
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelectContinuousAggregateBoundsPoints(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		span       time.Duration
+		maxPoints  int
+		wantSuffix string // "" when the raw table should be used
+	}{
+		{time.Hour, 1000, ""},
+		{2 * day, 1000, "_1m"},
+		{41 * day, 1000, "_1m"},
+		{90 * day, 1000, "_1h"},
+		{5 * 365 * day, 1000, "_1d"},
+		{20 * 365 * day, 500, "_1d"},
+	}
+	for _, tt := range tests {
+		agg, width, ok := selectContinuousAggregate(tt.span, tt.maxPoints)
+		if tt.wantSuffix == "" {
+			if ok {
+				t.Errorf("span %s: picked %s, want the raw table", tt.span, agg.Suffix)
+			}
+			continue
+		}
+		if !ok || agg.Suffix != tt.wantSuffix {
+			t.Errorf("span %s: picked %q (ok=%v), want %s", tt.span, agg.Suffix, ok, tt.wantSuffix)
+			continue
+		}
+		if width < agg.Bucket || width%agg.Bucket != 0 {
+			t.Errorf("span %s: width %s is not a multiple of the %s bucket", tt.span, width, agg.Bucket)
+		}
+		if buckets := int(tt.span / width); buckets > tt.maxPoints {
+			t.Errorf("span %s: %d buckets of %s, want at most %d", tt.span, buckets, width, tt.maxPoints)
+		}
+	}
+}
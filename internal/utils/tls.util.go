@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go-log/internal/config"
+)
+
+// tlsReloadInterval is how often the certificate files are checked for changes. Checks
+// happen on handshakes, so an idle server does no work.
+const tlsReloadInterval = 10 * time.Second
+
+// serverCertificates holds the serving certificate and client CA bundle, reloaded from
+// disk when the files change so renewed certificates need no restart.
+type serverCertificates struct {
+	certFile, keyFile, caFile string
+
+	mu        sync.Mutex
+	version   string
+	checkedAt time.Time
+	config    *tls.Config
+}
+
+// NewServerTLSConfig returns the TLS configuration for serving HTTPS from TLS_CERT_FILE and
+// TLS_KEY_FILE. With TLS_CLIENT_CA_FILE, client certificates are requested and verified
+// against that bundle; whether API routes require one is decided per request.
+func NewServerTLSConfig() (*tls.Config, error) {
+	envConfig := config.GetEnvConfig()
+	if envConfig.TLSCertFile == "" || envConfig.TLSKeyFile == "" {
+		return nil, fmt.Errorf("%w: TLS_CERT_FILE and TLS_KEY_FILE must be set together", ErrInvalidConfig)
+	}
+	if mode := envConfig.TLSClientAuth; mode != "require" && mode != "optional" {
+		return nil, fmt.Errorf("%w: TLS_CLIENT_AUTH must be require or optional, got %q", ErrInvalidConfig, mode)
+	}
+	certs := &serverCertificates{
+		certFile: envConfig.TLSCertFile,
+		keyFile:  envConfig.TLSKeyFile,
+		caFile:   envConfig.TLSClientCAFile,
+	}
+	if err := certs.load(); err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return certs.current(), nil
+		},
+	}, nil
+}
+
+// current returns the configuration for a new connection, reloading the files first if
+// they changed. A failed reload keeps serving the previous certificates.
+func (c *serverCertificates) current() *tls.Config {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.checkedAt) < tlsReloadInterval {
+		return c.config
+	}
+	c.checkedAt = time.Now()
+	if c.fileVersion() == c.version {
+		return c.config
+	}
+	if err := c.loadLocked(); err != nil {
+		LogWarnWithContext("tls", "failed to reload certificates, keeping the previous ones", err)
+	} else {
+		LogInfo("reloaded TLS certificates from %s", c.certFile)
+	}
+	return c.config
+}
+
+func (c *serverCertificates) load() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkedAt = time.Now()
+	return c.loadLocked()
+}
+
+func (c *serverCertificates) loadLocked() error {
+	version := c.fileVersion()
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("%w: server certificate: %v", ErrInvalidConfig, err)
+	}
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+		Certificates: []tls.Certificate{cert},
+	}
+	if c.caFile != "" {
+		pool, err := LoadCertPool(c.caFile)
+		if err != nil {
+			return err
+		}
+		// Browsers without a certificate can still reach the dashboard; API routes
+		// check for a verified chain themselves
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		cfg.ClientCAs = pool
+	}
+	c.version = version
+	c.config = cfg
+	return nil
+}
+
+func (c *serverCertificates) fileVersion() string {
+	return strings.Join([]string{fileVersion(c.certFile), fileVersion(c.keyFile), fileVersion(c.caFile)}, "|")
+}
+
+// VerifiedClientCertificate returns the verified client certificate of a TLS connection,
+// or nil when none was presented.
+func VerifiedClientCertificate(state *tls.ConnectionState) *x509.Certificate {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0][0]
+}
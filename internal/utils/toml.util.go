@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DecodeTOML decodes a TOML document into v using the struct's json tags, so YAML, TOML
+// and JSON files share one set of field names.
+func DecodeTOML(data []byte, v any) error {
+	tree, err := ParseTOML(data)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(tree)
+	if err != nil {
+		return fmt.Errorf("toml: %w", err)
+	}
+	if err := json.Unmarshal(encoded, v); err != nil {
+		return fmt.Errorf("toml: %w", err)
+	}
+	return nil
+}
+
+// ParseTOML parses a TOML document into map[string]any, []any and scalar values. Dates and
+// times become strings in the form they were written: RFC 3339 for offset date-times, and
+// 2006-01-02, 2006-01-02T15:04:05 or 15:04:05 for local ones.
+func ParseTOML(data []byte) (map[string]any, error) {
+	var tree map[string]any
+	if _, err := toml.Decode(string(data), &tree); err != nil {
+		return nil, err
+	}
+	if tree == nil {
+		return map[string]any{}, nil
+	}
+	return tomlValue(tree).(map[string]any), nil
+}
+
+// tomlValue converts decoded TOML values to the types JSON and YAML documents produce:
+// arrays of tables become []any and date-times become strings.
+func tomlValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = tomlValue(item)
+		}
+		return v
+	case []map[string]any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = tomlValue(item)
+		}
+		return items
+	case []any:
+		for i, item := range v {
+			v[i] = tomlValue(item)
+		}
+		return v
+	case time.Time:
+		// The decoder marks local values with these zone names
+		switch v.Location().String() {
+		case "date-local":
+			return v.Format("2006-01-02")
+		case "datetime-local":
+			return v.Format("2006-01-02T15:04:05.999999999")
+		case "time-local":
+			return v.Format("15:04:05.999999999")
+		}
+		return v.Format(time.RFC3339Nano)
+	}
+	return value
+}
@@ -0,0 +1,876 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-log/internal/api/models"
+	"go-log/internal/config"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The embedded time-series store keeps one directory per table. Each directory holds
+// append-only partition files, one per UTC day ("20060102.tsb"), made of compressed
+// blocks, plus a write-ahead log for samples not yet sealed into a block.
+const (
+	tsdbBlockMagic      = "TSB1"
+	tsdbBlockHeaderSize = 32
+	tsdbMaxBlockSamples = 120
+	tsdbPartitionLayout = "20060102"
+	tsdbPartitionExt    = ".tsb"
+	tsdbWALName         = "head.wal"
+)
+
+// tsdbSample is one stored snapshot: numeric columns in MetricColumns order plus the
+// nested collections that have no numeric column.
+type tsdbSample struct {
+	T      int64      `json:"t"` // unix milliseconds
+	Values []float64  `json:"v"`
+	Extras tsdbExtras `json:"x"`
+}
+
+type tsdbExtras struct {
+	DiskSpace     []models.DiskSpace     `json:"d,omitempty"`
+	Heartbeat     []models.ServerCheck   `json:"h,omitempty"`
+	ServerMetrics []models.ServerMetrics `json:"s,omitempty"`
+	Architecture  string                 `json:"a,omitempty"`
+}
+
+// tsdbSeries is the open state of one table: the unsealed head and its WAL.
+type tsdbSeries struct {
+	mu      sync.Mutex
+	dir     string
+	head    []tsdbSample
+	headDay string
+	wal     *os.File
+}
+
+var (
+	tsdbMu      sync.RWMutex
+	tsdbRoot    string
+	tsdbHandles map[string]*tsdbSeriesHandle
+)
+
+// tsdbSeriesHandle defers opening a series until first use.
+type tsdbSeriesHandle struct {
+	once   sync.Once
+	series *tsdbSeries
+	err    error
+}
+
+// InitTSDB prepares the embedded time-series store under TSDB_PATH.
+func InitTSDB() error {
+	tsdbMu.Lock()
+	defer tsdbMu.Unlock()
+	if tsdbHandles != nil {
+		return nil // Already initialized
+	}
+
+	root := strings.TrimSpace(config.GetEnvConfig().TSDBPath)
+	if root == "" {
+		return fmt.Errorf("tsdb path is empty")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return fmt.Errorf("failed to create tsdb directory: %w", err)
+	}
+
+	tsdbRoot = root
+	tsdbHandles = make(map[string]*tsdbSeriesHandle)
+	LogInfo("tsdb initialized at %s", root)
+	return nil
+}
+
+// IsTSDBInitialized reports whether the embedded store is ready.
+func IsTSDBInitialized() bool {
+	tsdbMu.RLock()
+	defer tsdbMu.RUnlock()
+	return tsdbHandles != nil
+}
+
+// CloseTSDB seals every open head into blocks and closes the WAL files.
+func CloseTSDB() error {
+	tsdbMu.Lock()
+	defer tsdbMu.Unlock()
+	if tsdbHandles == nil {
+		return nil
+	}
+
+	var firstErr error
+	for name, handle := range tsdbHandles {
+		if handle.series == nil {
+			continue
+		}
+		s := handle.series
+		s.mu.Lock()
+		if err := s.flushLocked(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to flush tsdb table %s: %w", name, err)
+		}
+		if s.wal != nil {
+			_ = s.wal.Close()
+			s.wal = nil
+		}
+		s.mu.Unlock()
+	}
+	tsdbHandles = nil
+	return firstErr
+}
+
+func tsdbSanitizeTable(tableName string) (string, error) {
+	if IsEmptyOrWhitespace(tableName) {
+		return "", fmt.Errorf("table name cannot be empty")
+	}
+	sanitized := SanitizeTableName(strings.Trim(strings.TrimSpace(tableName), "`"))
+	if sanitized == "" {
+		return "", fmt.Errorf("invalid table name")
+	}
+	return sanitized, nil
+}
+
+// getTSDBSeries returns the open series for a table, replaying its WAL on first use.
+func getTSDBSeries(tableName string) (*tsdbSeries, error) {
+	name, err := tsdbSanitizeTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	tsdbMu.Lock()
+	if tsdbHandles == nil {
+		tsdbMu.Unlock()
+		return nil, fmt.Errorf("tsdb not initialized")
+	}
+	handle, ok := tsdbHandles[name]
+	if !ok {
+		handle = &tsdbSeriesHandle{}
+		tsdbHandles[name] = handle
+	}
+	root := tsdbRoot
+	tsdbMu.Unlock()
+
+	handle.once.Do(func() {
+		handle.series, handle.err = openTSDBSeries(filepath.Join(root, name))
+	})
+	return handle.series, handle.err
+}
+
+func openTSDBSeries(dir string) (*tsdbSeries, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create tsdb table directory: %w", err)
+	}
+	s := &tsdbSeries{dir: dir}
+
+	walPath := filepath.Join(dir, tsdbWALName)
+	pending, err := readTSDBWAL(walPath)
+	if err != nil {
+		return nil, err
+	}
+
+	wal, err := os.OpenFile(walPath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tsdb wal: %w", err)
+	}
+	s.wal = wal
+
+	// Seal recovered samples so the WAL starts empty
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sample := range pending {
+		if day := tsdbDay(sample.T); s.headDay != "" && day != s.headDay {
+			if err := s.sealLocked(); err != nil {
+				return nil, err
+			}
+		}
+		s.head = append(s.head, sample)
+		s.headDay = tsdbDay(sample.T)
+	}
+	if err := s.flushLocked(); err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		LogInfo("tsdb recovered %d samples from %s", len(pending), walPath)
+	}
+	return s, nil
+}
+
+// readTSDBWAL loads WAL records, ignoring a torn final line from an interrupted write.
+func readTSDBWAL(path string) ([]tsdbSample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open tsdb wal: %w", err)
+	}
+	defer f.Close()
+
+	var samples []tsdbSample
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var sample tsdbSample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			LogWarnWithContext("tsdb-wal", "skipping unreadable wal record", err)
+			continue
+		}
+		// Records written before a column was added are short; the missing columns read as zero
+		if len(sample.Values) > len(MetricColumns) {
+			continue
+		}
+		if missing := len(MetricColumns) - len(sample.Values); missing > 0 {
+			sample.Values = append(sample.Values, make([]float64, missing)...)
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tsdb wal: %w", err)
+	}
+	return samples, nil
+}
+
+func tsdbDay(ms int64) string {
+	return time.UnixMilli(ms).UTC().Format(tsdbPartitionLayout)
+}
+
+// append adds a sample to the head, sealing a block when it is full or the day rolls over.
+func (s *tsdbSeries) append(sample tsdbSample) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.wal == nil {
+		return fmt.Errorf("tsdb table is closed")
+	}
+
+	day := tsdbDay(sample.T)
+	if len(s.head) > 0 && day != s.headDay {
+		if err := s.flushLocked(); err != nil {
+			return err
+		}
+	}
+
+	record, err := json.Marshal(sample)
+	if err != nil {
+		return fmt.Errorf("failed to encode tsdb sample: %w", err)
+	}
+	if _, err := s.wal.Write(append(record, '\n')); err != nil {
+		return fmt.Errorf("failed to append tsdb wal: %w", err)
+	}
+
+	s.head = append(s.head, sample)
+	s.headDay = day
+	if len(s.head) >= tsdbMaxBlockSamples {
+		return s.flushLocked()
+	}
+	return nil
+}
+
+// sealLocked writes the head as a block without touching the WAL.
+func (s *tsdbSeries) sealLocked() error {
+	if len(s.head) == 0 {
+		return nil
+	}
+	block, err := encodeTSDBBlock(s.head)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(s.dir, s.headDay+tsdbPartitionExt)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open tsdb partition: %w", err)
+	}
+	if _, err := f.Write(block); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write tsdb block: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync tsdb partition: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close tsdb partition: %w", err)
+	}
+
+	s.head = nil
+	s.headDay = ""
+	return nil
+}
+
+// flushLocked seals the head and truncates the WAL it was recovered from.
+func (s *tsdbSeries) flushLocked() error {
+	if err := s.sealLocked(); err != nil {
+		return err
+	}
+	if s.wal != nil {
+		if err := s.wal.Truncate(0); err != nil {
+			return fmt.Errorf("failed to truncate tsdb wal: %w", err)
+		}
+	}
+	return nil
+}
+
+// encodeTSDBBlock serializes samples as:
+// magic | count u16 | cols u16 | minT i64 | maxT i64 | payloadLen u32 | crc32 u32 | payload
+// where payload holds length-prefixed timestamp, per-column value and gzip'd extras streams.
+func encodeTSDBBlock(samples []tsdbSample) ([]byte, error) {
+	timestamps := make([]int64, len(samples))
+	minT, maxT := samples[0].T, samples[0].T
+	for i, sample := range samples {
+		timestamps[i] = sample.T
+		if sample.T < minT {
+			minT = sample.T
+		}
+		if sample.T > maxT {
+			maxT = sample.T
+		}
+	}
+
+	var payload bytes.Buffer
+	writeStream := func(data []byte) {
+		var size [4]byte
+		binary.LittleEndian.PutUint32(size[:], uint32(len(data)))
+		payload.Write(size[:])
+		payload.Write(data)
+	}
+
+	writeStream(encodeTimestamps(timestamps))
+	column := make([]float64, len(samples))
+	for c := range MetricColumns {
+		for i, sample := range samples {
+			column[i] = sample.Values[c]
+		}
+		writeStream(encodeValues(column))
+	}
+
+	extras := make([]tsdbExtras, len(samples))
+	for i, sample := range samples {
+		extras[i] = sample.Extras
+	}
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if err := json.NewEncoder(gz).Encode(extras); err != nil {
+		return nil, fmt.Errorf("failed to encode tsdb extras: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress tsdb extras: %w", err)
+	}
+	writeStream(compressed.Bytes())
+
+	header := make([]byte, tsdbBlockHeaderSize)
+	copy(header, tsdbBlockMagic)
+	binary.LittleEndian.PutUint16(header[4:], uint16(len(samples)))
+	binary.LittleEndian.PutUint16(header[6:], uint16(len(MetricColumns)))
+	binary.LittleEndian.PutUint64(header[8:], uint64(minT))
+	binary.LittleEndian.PutUint64(header[16:], uint64(maxT))
+	binary.LittleEndian.PutUint32(header[24:], uint32(payload.Len()))
+	binary.LittleEndian.PutUint32(header[28:], crc32.ChecksumIEEE(payload.Bytes()))
+	return append(header, payload.Bytes()...), nil
+}
+
+// decodeTSDBBlock reverses encodeTSDBBlock's payload for count samples.
+func decodeTSDBBlock(payload []byte, count, cols int) ([]tsdbSample, error) {
+	readStream := func() ([]byte, error) {
+		if len(payload) < 4 {
+			return nil, errTSDBShortStream
+		}
+		size := binary.LittleEndian.Uint32(payload)
+		if uint32(len(payload)-4) < size {
+			return nil, errTSDBShortStream
+		}
+		data := payload[4 : 4+size]
+		payload = payload[4+size:]
+		return data, nil
+	}
+
+	tsData, err := readStream()
+	if err != nil {
+		return nil, err
+	}
+	timestamps, err := decodeTimestamps(tsData, count)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]tsdbSample, count)
+	for i := range samples {
+		samples[i].T = timestamps[i]
+		samples[i].Values = make([]float64, len(MetricColumns))
+	}
+	for c := 0; c < cols; c++ {
+		data, err := readStream()
+		if err != nil {
+			return nil, err
+		}
+		if c >= len(MetricColumns) {
+			continue // written by a newer schema
+		}
+		values, err := decodeValues(data, count)
+		if err != nil {
+			return nil, err
+		}
+		for i := range samples {
+			samples[i].Values[c] = values[i]
+		}
+	}
+
+	extrasData, err := readStream()
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(extrasData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tsdb extras: %w", err)
+	}
+	defer gz.Close()
+	var extras []tsdbExtras
+	if err := json.NewDecoder(gz).Decode(&extras); err != nil {
+		return nil, fmt.Errorf("failed to decode tsdb extras: %w", err)
+	}
+	for i := range samples {
+		if i < len(extras) {
+			samples[i].Extras = extras[i]
+		}
+	}
+	return samples, nil
+}
+
+// readTSDBPartition decodes the blocks of a partition file that overlap [fromMs, toMs].
+// Reading stops at the first torn or corrupt block, which can only be the tail of the file.
+func readTSDBPartition(path string, fromMs, toMs int64) ([]tsdbSample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tsdb partition: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	header := make([]byte, tsdbBlockHeaderSize)
+	var samples []tsdbSample
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return samples, nil
+			}
+			LogWarnWithContext("tsdb-read", fmt.Sprintf("truncated block header in %s", path), err)
+			return samples, nil
+		}
+		if string(header[:4]) != tsdbBlockMagic {
+			LogWarn("tsdb: invalid block magic in %s, ignoring remainder", path)
+			return samples, nil
+		}
+
+		count := int(binary.LittleEndian.Uint16(header[4:]))
+		cols := int(binary.LittleEndian.Uint16(header[6:]))
+		minT := int64(binary.LittleEndian.Uint64(header[8:]))
+		maxT := int64(binary.LittleEndian.Uint64(header[16:]))
+		size := int(binary.LittleEndian.Uint32(header[24:]))
+		checksum := binary.LittleEndian.Uint32(header[28:])
+
+		if maxT < fromMs || minT > toMs {
+			if _, err := reader.Discard(size); err != nil {
+				return samples, nil
+			}
+			continue
+		}
+
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			LogWarnWithContext("tsdb-read", fmt.Sprintf("truncated block in %s", path), err)
+			return samples, nil
+		}
+		if crc32.ChecksumIEEE(payload) != checksum {
+			LogWarn("tsdb: checksum mismatch in %s, ignoring remainder", path)
+			return samples, nil
+		}
+
+		block, err := decodeTSDBBlock(payload, count, cols)
+		if err != nil {
+			LogWarnWithContext("tsdb-read", fmt.Sprintf("failed to decode block in %s", path), err)
+			return samples, nil
+		}
+		for _, sample := range block {
+			if sample.T >= fromMs && sample.T <= toMs {
+				samples = append(samples, sample)
+			}
+		}
+	}
+}
+
+// rangeSamples returns samples within [fromMs, toMs] from sealed partitions and the head,
+// newest first. With a positive limit, partitions are read newest day first and reading
+// stops once limit samples are known to be the newest; the result may hold more than limit.
+// Samples sharing a millisecond keep their storage order.
+func (s *tsdbSeries) rangeSamples(fromMs, toMs int64, limit int) ([]tsdbSample, error) {
+	s.mu.Lock()
+	head := append([]tsdbSample(nil), s.head...)
+	s.mu.Unlock()
+
+	partitions, err := filepath.Glob(filepath.Join(s.dir, "*"+tsdbPartitionExt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tsdb partitions: %w", err)
+	}
+	// Day names sort chronologically; read the newest first
+	sort.Sort(sort.Reverse(sort.StringSlice(partitions)))
+
+	// The head is not split by day, so its samples are collected up front
+	var headSamples []tsdbSample
+	for _, sample := range head {
+		if sample.T >= fromMs && sample.T <= toMs {
+			headSamples = append(headSamples, sample)
+		}
+	}
+
+	var chunks [][]tsdbSample
+	collected := len(headSamples)
+	for _, path := range partitions {
+		day := strings.TrimSuffix(filepath.Base(path), tsdbPartitionExt)
+		if (fromMs != math.MinInt64 && day < tsdbDay(fromMs)) || (toMs != math.MaxInt64 && day > tsdbDay(toMs)) {
+			continue
+		}
+		partition, err := readTSDBPartition(path, fromMs, toMs)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, partition)
+		collected += len(partition)
+
+		// Older partitions only hold samples before this day, so once limit samples from
+		// this day onwards are known the rest cannot make it into the result
+		if limit > 0 && collected >= limit {
+			if dayStart, err := time.Parse(tsdbPartitionLayout, day); err == nil {
+				known := collected
+				for _, sample := range headSamples {
+					if sample.T < dayStart.UnixMilli() {
+						known--
+					}
+				}
+				if known >= limit {
+					break
+				}
+			}
+		}
+	}
+
+	// Restore storage order (oldest partition first, then the head) before sorting, so
+	// samples sharing a millisecond always come out in the same order across pages
+	samples := make([]tsdbSample, 0, collected)
+	for i := len(chunks) - 1; i >= 0; i-- {
+		samples = append(samples, chunks[i]...)
+	}
+	samples = append(samples, headSamples...)
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].T > samples[j].T })
+	return samples, nil
+}
+
+func tsdbSampleFromSnapshot(ts time.Time, snapshot *models.SystemMonitoring) tsdbSample {
+	values := make([]float64, len(MetricColumns))
+	for i, col := range MetricColumns {
+		values[i] = col.Value(snapshot)
+	}
+	return tsdbSample{
+		T:      ts.UnixMilli(),
+		Values: values,
+		Extras: tsdbExtras{
+			DiskSpace:     snapshot.DiskSpace,
+			Heartbeat:     snapshot.Heartbeat,
+			ServerMetrics: snapshot.ServerMetrics,
+			Architecture:  snapshot.CPU.Architecture,
+		},
+	}
+}
+
+func (sample tsdbSample) snapshot() models.SystemMonitoring {
+	values := make([]*float64, len(sample.Values))
+	for i := range sample.Values {
+		values[i] = &sample.Values[i]
+	}
+	snapshot := snapshotFromColumns(values, nil)
+	snapshot.Timestamp = time.UnixMilli(sample.T).UTC()
+	snapshot.DiskSpace = sample.Extras.DiskSpace
+	snapshot.Heartbeat = sample.Extras.Heartbeat
+	snapshot.ServerMetrics = sample.Extras.ServerMetrics
+	snapshot.CPU.Architecture = sample.Extras.Architecture
+	return snapshot
+}
+
+// WriteToTSDB appends a monitoring snapshot to the specified table of the embedded store.
+func WriteToTSDB(tableName string, snapshot *models.SystemMonitoring) error {
+	if snapshot == nil {
+		return fmt.Errorf("empty monitoring snapshot")
+	}
+	series, err := getTSDBSeries(tableName)
+	if err != nil {
+		return err
+	}
+
+	ts := NowUTC()
+	if !snapshot.Timestamp.IsZero() {
+		ts = snapshot.Timestamp.UTC()
+	}
+	return series.append(tsdbSampleFromSnapshot(ts, snapshot))
+}
+
+// WriteServerLogToTSDB decodes a remote server payload into a server-specific table.
+func WriteServerLogToTSDB(tableName string, payload []byte) error {
+	series, err := getTSDBSeries(tableName)
+	if err != nil {
+		return err
+	}
+	snapshot, err := SnapshotFromServerPayload(payload)
+	if err != nil {
+		return fmt.Errorf("failed to decode server payload: %w", err)
+	}
+	if err := series.append(tsdbSampleFromSnapshot(NowUTC(), snapshot)); err != nil {
+		return fmt.Errorf("failed to write server log to tsdb: %w", err)
+	}
+	return nil
+}
+
+// PrepareTSDBServerTable opens (and creates) a server table in the embedded store
+// and registers it for the tables endpoint.
+func PrepareTSDBServerTable(tableName string) error {
+	if !IsTSDBInitialized() {
+		return nil
+	}
+	if _, err := getTSDBSeries(tableName); err != nil {
+		return err
+	}
+	if name, err := tsdbSanitizeTable(tableName); err == nil && name != DefaultTableName {
+		serverLogTables.Store(name, struct{}{})
+	}
+	return nil
+}
+
+// tsdbRange converts optional from/to inputs into an inclusive millisecond range.
+func tsdbRange(from, to string) (int64, int64, bool, error) {
+	fromMs, toMs := int64(math.MinInt64), int64(math.MaxInt64)
+	bounded := false
+	if !IsEmptyOrWhitespace(from) {
+		t, err := ParseTimestampUTC(from)
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("invalid from timestamp: %w", err)
+		}
+		fromMs, bounded = t.UnixMilli(), true
+	}
+	if !IsEmptyOrWhitespace(to) {
+		t, err := ParseTimestampUTC(to)
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("invalid to timestamp: %w", err)
+		}
+		toMs, bounded = t.UnixMilli(), true
+	}
+	return fromMs, toMs, bounded, nil
+}
+
+// QueryFilteredTSDBData retrieves snapshots from the embedded store within a date range,
+// aggregating into buckets when downsampling is enabled.
+func QueryFilteredTSDBData(tableName, from, to string) ([]models.SystemMonitoring, error) {
+	series, err := getTSDBSeries(tableName)
+	if err != nil {
+		return nil, err
+	}
+	fromMs, toMs, bounded, err := tsdbRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a date filter only the most recent rows are returned, matching the SQL backends
+	limit := 0
+	if !bounded {
+		limit = 1000
+	}
+	samples, err := series.rangeSamples(fromMs, toMs, limit)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(samples) > limit {
+		samples = samples[:limit]
+	}
+
+	envCfg := config.GetEnvConfig()
+	if envCfg.EnableDownsampling && envCfg.DownsampleMaxPoints > 0 && len(samples) > envCfg.DownsampleMaxPoints {
+		return aggregateTSDBSamples(samples, envCfg.DownsampleMaxPoints), nil
+	}
+
+	snapshots := make([]models.SystemMonitoring, len(samples))
+	for i, sample := range samples {
+		snapshots[i] = sample.snapshot()
+	}
+	return snapshots, nil
+}
+
+// QueryTSDBDataPage returns one cursor-paginated page of raw snapshots, newest first.
+// Samples are keyed by millisecond timestamp, and several can share one, so the cursor
+// holds the millisecond of the last sample and how many samples of that millisecond
+// were already returned.
+func QueryTSDBDataPage(tableName, from, to string, page PageRequest) ([]models.SystemMonitoring, string, error) {
+	series, err := getTSDBSeries(tableName)
+	if err != nil {
+		return nil, "", err
+	}
+	fromMs, toMs, _, err := tsdbRange(from, to)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cursorMs, seen := int64(0), 0
+	if page.Cursor != "" {
+		cursorTS, offset, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		t, err := ParseTimestampUTC(cursorTS)
+		if err != nil || offset < 0 {
+			return nil, "", ErrInvalidCursor
+		}
+		cursorMs, seen = t.UnixMilli(), int(offset)
+		if cursorMs < toMs {
+			toMs = cursorMs
+		}
+	}
+
+	limit := page.EffectiveLimit()
+	samples, err := series.rangeSamples(fromMs, toMs, limit+seen)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Skip the samples of the cursor's millisecond that earlier pages returned
+	if page.Cursor != "" {
+		skip := 0
+		for skip < len(samples) && skip < seen && samples[skip].T == cursorMs {
+			skip++
+		}
+		samples = samples[skip:]
+	}
+
+	nextCursor := ""
+	if len(samples) > limit {
+		samples = samples[:limit]
+	}
+	if len(samples) == limit {
+		last := samples[len(samples)-1].T
+		offset := 0
+		for i := len(samples) - 1; i >= 0 && samples[i].T == last; i-- {
+			offset++
+		}
+		if page.Cursor != "" && last == cursorMs {
+			offset += seen
+		}
+		nextCursor = encodeCursor(FormatTimestampUTC(time.UnixMilli(last)), int64(offset))
+	}
+
+	snapshots := make([]models.SystemMonitoring, len(samples))
+	for i, sample := range samples {
+		snapshots[i] = sample.snapshot()
+	}
+	return snapshots, nextCursor, nil
+}
+
+// aggregateTSDBSamples folds newest-first samples into at most maxPoints time buckets
+// with avg/min/max/p95 per metric, mirroring the SQL backends' downsampled shape.
+func aggregateTSDBSamples(samples []tsdbSample, maxPoints int) []models.SystemMonitoring {
+	newest, oldest := samples[0].T, samples[len(samples)-1].T
+	width := (newest-oldest)/int64(maxPoints) + 1
+
+	var result []models.SystemMonitoring
+	for start := 0; start < len(samples); {
+		bucket := (samples[start].T - oldest) / width
+		end := start
+		for end < len(samples) && (samples[end].T-oldest)/width == bucket {
+			end++
+		}
+		group := samples[start:end]
+
+		aggregates := make(map[string]models.MetricStats, len(MetricColumns))
+		avgs := make([]*float64, len(MetricColumns))
+		column := make([]float64, len(group))
+		for c, col := range MetricColumns {
+			sum := 0.0
+			for i, sample := range group {
+				column[i] = sample.Values[c]
+				sum += column[i]
+			}
+			sort.Float64s(column)
+			avg := sum / float64(len(column))
+			p95 := column[(95*len(column)+99)/100-1]
+			avgs[c] = &avg
+			aggregates[col.Name] = models.MetricStats{Avg: avg, Min: column[0], Max: column[len(column)-1], P95: &p95}
+		}
+
+		snapshot := snapshotFromColumns(avgs, nil)
+		snapshot.Timestamp = time.UnixMilli(oldest + bucket*width).UTC()
+		snapshot.Samples = len(group)
+		snapshot.Aggregates = aggregates
+		snapshot.DiskSpace = []models.DiskSpace{{Path: "/", UsedPct: aggregates["disk_used_percent"].Avg}}
+		result = append(result, snapshot)
+		start = end
+	}
+	return result
+}
+
+// GetTSDBTables lists the tables stored in the embedded store.
+func GetTSDBTables() ([]string, error) {
+	tsdbMu.RLock()
+	root := tsdbRoot
+	initialized := tsdbHandles != nil
+	tsdbMu.RUnlock()
+	if !initialized {
+		return nil, fmt.Errorf("tsdb not initialized")
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tsdb tables: %w", err)
+	}
+	var tables []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			tables = append(tables, entry.Name())
+		}
+	}
+	return tables, nil
+}
+
+// CleanOldTSDBEntries removes whole-day partitions that end before cutoffDate.
+func CleanOldTSDBEntries(cutoffDate time.Time) error {
+	tables, err := GetTSDBTables()
+	if err != nil {
+		return err
+	}
+
+	tsdbMu.RLock()
+	root := tsdbRoot
+	tsdbMu.RUnlock()
+
+	cutoffDay := cutoffDate.UTC().Format(tsdbPartitionLayout)
+	removed := 0
+	for _, table := range tables {
+		partitions, err := filepath.Glob(filepath.Join(root, table, "*"+tsdbPartitionExt))
+		if err != nil {
+			continue
+		}
+		for _, path := range partitions {
+			day := strings.TrimSuffix(filepath.Base(path), tsdbPartitionExt)
+			if day >= cutoffDay {
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				LogWarnWithContext("tsdb-cleanup", fmt.Sprintf("failed to remove %s", path), err)
+				continue
+			}
+			removed++
+		}
+	}
+	LogInfo("tsdb cleanup completed: %d partitions removed from %d tables", removed, len(tables))
+	return nil
+}
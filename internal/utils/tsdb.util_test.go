@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// useTestTSDB points the embedded store at a temporary directory for one test.
+func useTestTSDB(t *testing.T) {
+	t.Helper()
+	tsdbMu.Lock()
+	tsdbRoot = t.TempDir()
+	tsdbHandles = make(map[string]*tsdbSeriesHandle)
+	tsdbMu.Unlock()
+	t.Cleanup(func() {
+		if err := CloseTSDB(); err != nil {
+			t.Errorf("close tsdb: %v", err)
+		}
+	})
+}
+
+func TestQueryTSDBDataPageSharedMillisecond(t *testing.T) {
+	useTestTSDB(t)
+	series, err := getTSDBSeries("paging")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two days of samples with several sharing a millisecond, half of them sealed into
+	// partitions and the rest left in the head
+	base := time.Date(2024, 3, 1, 23, 59, 59, 0, time.UTC).UnixMilli()
+	stamps := []int64{base - 5, base, base, base, base + 1000, base + 1000, base + 2000}
+	for i, ms := range stamps {
+		values := make([]float64, len(MetricColumns))
+		values[0] = float64(i)
+		if err := series.append(tsdbSample{T: ms, Values: values}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 3 {
+			series.mu.Lock()
+			err := series.flushLocked()
+			series.mu.Unlock()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	seen := map[float64]int{}
+	page := PageRequest{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > len(stamps) {
+			t.Fatal("pagination did not terminate")
+		}
+		batch, next, err := QueryTSDBDataPage("paging", "", "", page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, snapshot := range batch {
+			seen[snapshot.CPU.UsagePercent]++
+		}
+		if next == "" {
+			break
+		}
+		page.Cursor = next
+	}
+
+	for i := range stamps {
+		if seen[float64(i)] != 1 {
+			t.Fatalf("sample %d returned %d times, want once (all: %v)", i, seen[float64(i)], seen)
+		}
+	}
+}
+
+func TestTSDBRangeSamplesStopsAtLimit(t *testing.T) {
+	useTestTSDB(t)
+	series, err := getTSDBSeries("limit")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := int64(24 * time.Hour / time.Millisecond)
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC).UnixMilli()
+	for d := int64(0); d < 3; d++ {
+		for i := int64(0); i < 3; i++ {
+			sample := tsdbSample{T: base + d*day + i, Values: make([]float64, len(MetricColumns))}
+			if err := series.append(sample); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// The newest day is in the head and the day before in a partition; the oldest
+	// partition is not needed for two samples
+	samples, err := series.rangeSamples(math.MinInt64, math.MaxInt64, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) < 2 || samples[0].T != base+2*day+2 || samples[1].T != base+2*day+1 {
+		t.Fatalf("unexpected newest samples: %+v", samples)
+	}
+	for _, sample := range samples {
+		if sample.T < base+day {
+			t.Fatalf("oldest partition was read: sample at %d", sample.T)
+		}
+	}
+}
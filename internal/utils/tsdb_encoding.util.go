@@ -0,0 +1,258 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+)
+
+// errTSDBShortStream is returned when a compressed stream ends before all samples are decoded.
+var errTSDBShortStream = errors.New("tsdb: compressed stream truncated")
+
+// bitWriter appends individual bits to a byte slice, most significant bit first.
+type bitWriter struct {
+	buf   []byte
+	count uint8 // free bits remaining in the last byte
+}
+
+func (w *bitWriter) writeBit(bit bool) {
+	if w.count == 0 {
+		w.buf = append(w.buf, 0)
+		w.count = 8
+	}
+	if bit {
+		w.buf[len(w.buf)-1] |= 1 << (w.count - 1)
+	}
+	w.count--
+}
+
+func (w *bitWriter) writeBits(value uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.writeBit(value&(1<<uint(i)) != 0)
+	}
+}
+
+func (w *bitWriter) bytes() []byte { return w.buf }
+
+// bitReader reads bits written by bitWriter.
+type bitReader struct {
+	buf []byte
+	pos int // bit position
+}
+
+func (r *bitReader) readBit() (bool, error) {
+	if r.pos >= len(r.buf)*8 {
+		return false, errTSDBShortStream
+	}
+	bit := r.buf[r.pos/8]&(1<<(7-uint(r.pos%8))) != 0
+	r.pos++
+	return bit, nil
+}
+
+func (r *bitReader) readBits(n int) (uint64, error) {
+	var value uint64
+	for i := 0; i < n; i++ {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		value <<= 1
+		if bit {
+			value |= 1
+		}
+	}
+	return value, nil
+}
+
+// encodeTimestamps compresses millisecond timestamps with delta-of-delta encoding
+// (Gorilla, section 4.1.1). Regular scrape intervals collapse to a single bit per sample.
+func encodeTimestamps(ts []int64) []byte {
+	var w bitWriter
+	if len(ts) == 0 {
+		return w.bytes()
+	}
+	w.writeBits(uint64(ts[0]), 64)
+	if len(ts) == 1 {
+		return w.bytes()
+	}
+	prevDelta := ts[1] - ts[0]
+	w.writeBits(uint64(prevDelta), 64)
+
+	for i := 2; i < len(ts); i++ {
+		delta := ts[i] - ts[i-1]
+		dod := delta - prevDelta
+		prevDelta = delta
+		switch {
+		case dod == 0:
+			w.writeBit(false)
+		case dod >= -64 && dod <= 63:
+			w.writeBits(0b10, 2)
+			w.writeBits(uint64(dod), 7)
+		case dod >= -256 && dod <= 255:
+			w.writeBits(0b110, 3)
+			w.writeBits(uint64(dod), 9)
+		case dod >= -2048 && dod <= 2047:
+			w.writeBits(0b1110, 4)
+			w.writeBits(uint64(dod), 12)
+		default:
+			w.writeBits(0b1111, 4)
+			w.writeBits(uint64(dod), 64)
+		}
+	}
+	return w.bytes()
+}
+
+// signExtend interprets the low n bits of v as a two's complement integer.
+func signExtend(v uint64, n int) int64 {
+	shift := 64 - uint(n)
+	return int64(v<<shift) >> shift
+}
+
+// decodeTimestamps reverses encodeTimestamps for count samples.
+func decodeTimestamps(data []byte, count int) ([]int64, error) {
+	out := make([]int64, 0, count)
+	if count == 0 {
+		return out, nil
+	}
+	r := bitReader{buf: data}
+	first, err := r.readBits(64)
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, int64(first))
+	if count == 1 {
+		return out, nil
+	}
+	rawDelta, err := r.readBits(64)
+	if err != nil {
+		return nil, err
+	}
+	delta := int64(rawDelta)
+	out = append(out, out[0]+delta)
+
+	for len(out) < count {
+		// Count leading 1 bits of the control prefix (at most 4)
+		prefix := 0
+		for prefix < 4 {
+			bit, err := r.readBit()
+			if err != nil {
+				return nil, err
+			}
+			if !bit {
+				break
+			}
+			prefix++
+		}
+
+		var dod int64
+		width := [...]int{0, 7, 9, 12, 64}[prefix]
+		if width > 0 {
+			raw, err := r.readBits(width)
+			if err != nil {
+				return nil, err
+			}
+			dod = signExtend(raw, width)
+		}
+		delta += dod
+		out = append(out, out[len(out)-1]+delta)
+	}
+	return out, nil
+}
+
+// encodeValues compresses float64 samples by XOR-ing each value with its predecessor
+// (Gorilla, section 4.1.2). Unchanged gauges cost one bit per sample.
+func encodeValues(values []float64) []byte {
+	var w bitWriter
+	if len(values) == 0 {
+		return w.bytes()
+	}
+	prev := math.Float64bits(values[0])
+	w.writeBits(prev, 64)
+	prevLeading, prevTrailing := -1, 0
+
+	for _, v := range values[1:] {
+		cur := math.Float64bits(v)
+		xor := cur ^ prev
+		prev = cur
+		if xor == 0 {
+			w.writeBit(false)
+			continue
+		}
+		w.writeBit(true)
+
+		leading := bits.LeadingZeros64(xor)
+		trailing := bits.TrailingZeros64(xor)
+		if leading > 31 {
+			leading = 31 // fits the 5-bit field
+		}
+
+		if prevLeading >= 0 && leading >= prevLeading && trailing >= prevTrailing {
+			// Meaningful bits fit inside the previous window
+			w.writeBit(false)
+			w.writeBits(xor>>uint(prevTrailing), 64-prevLeading-prevTrailing)
+			continue
+		}
+
+		w.writeBit(true)
+		significant := 64 - leading - trailing
+		w.writeBits(uint64(leading), 5)
+		w.writeBits(uint64(significant&63), 6) // 64 is stored as 0
+		w.writeBits(xor>>uint(trailing), significant)
+		prevLeading, prevTrailing = leading, trailing
+	}
+	return w.bytes()
+}
+
+// decodeValues reverses encodeValues for count samples.
+func decodeValues(data []byte, count int) ([]float64, error) {
+	out := make([]float64, 0, count)
+	if count == 0 {
+		return out, nil
+	}
+	r := bitReader{buf: data}
+	prev, err := r.readBits(64)
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, math.Float64frombits(prev))
+	leading, trailing := 0, 0
+
+	for len(out) < count {
+		changed, err := r.readBit()
+		if err != nil {
+			return nil, err
+		}
+		if !changed {
+			out = append(out, math.Float64frombits(prev))
+			continue
+		}
+
+		newWindow, err := r.readBit()
+		if err != nil {
+			return nil, err
+		}
+		if newWindow {
+			l, err := r.readBits(5)
+			if err != nil {
+				return nil, err
+			}
+			s, err := r.readBits(6)
+			if err != nil {
+				return nil, err
+			}
+			if s == 0 {
+				s = 64
+			}
+			leading = int(l)
+			trailing = 64 - leading - int(s)
+		}
+
+		meaningful, err := r.readBits(64 - leading - trailing)
+		if err != nil {
+			return nil, err
+		}
+		prev ^= meaningful << uint(trailing)
+		out = append(out, math.Float64frombits(prev))
+	}
+	return out, nil
+}
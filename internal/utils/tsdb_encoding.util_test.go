@@ -0,0 +1,41 @@
+package utils
+
+import "testing"
+
+func TestTimestampEncodingRoundTripAtBoundaries(t *testing.T) {
+	// Each delta-of-delta bucket is exercised at both edges and one step past
+	// them, so a value that would be mis-sized shows up as a decode mismatch.
+	dods := []int64{
+		0, 1, -1,
+		63, 64, -64, -65,
+		255, 256, -256, -257,
+		2047, 2048, -2048, -2049,
+		1 << 40, -(1 << 40),
+	}
+	for _, dod := range dods {
+		ts := []int64{0, 1000, 2000, 3000 + dod, 4000 + dod}
+		encoded := encodeTimestamps(ts)
+		decoded, err := decodeTimestamps(encoded, len(ts))
+		if err != nil {
+			t.Fatalf("dod %d: decode failed: %v", dod, err)
+		}
+		for i := range ts {
+			if decoded[i] != ts[i] {
+				t.Fatalf("dod %d: sample %d decoded as %d, want %d", dod, i, decoded[i], ts[i])
+			}
+		}
+	}
+}
+
+func TestValueEncodingRoundTrip(t *testing.T) {
+	values := []float64{0, 0, 12.5, 12.5, 99.75, -3, 1e12, 0.001}
+	decoded, err := decodeValues(encodeValues(values), len(values))
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	for i := range values {
+		if decoded[i] != values[i] {
+			t.Fatalf("sample %d decoded as %v, want %v", i, decoded[i], values[i])
+		}
+	}
+}
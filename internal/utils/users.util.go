@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go-log/internal/config"
+)
+
+// DashboardUser is a local account that can sign in to the dashboard.
+type DashboardUser struct {
+	Username     string    `json:"username"`
+	Role         string    `json:"role"`
+	PasswordHash string    `json:"password_hash"` // argon2id (PHC string) or bcrypt
+	Disabled     bool      `json:"disabled,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type usersFile struct {
+	Users []DashboardUser `json:"users"`
+}
+
+var usersCache = fileCache[map[string]DashboardUser]{parse: func(path string) (map[string]DashboardUser, error) {
+	list, err := LoadUsers(path)
+	if err != nil {
+		LogWarnWithContext("users", "failed to load dashboard users", err)
+		return nil, err
+	}
+	users := make(map[string]DashboardUser, len(list))
+	for _, user := range list {
+		users[strings.ToLower(user.Username)] = user
+	}
+	return users, nil
+}}
+
+// dummyPasswordHash is verified against when the username is unknown, so a failed login
+// takes as long whether or not the user exists.
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := HashPassword("go-log-dummy-password", PasswordArgon2id)
+	return hash
+})
+
+// UsersPath returns the user file location: USERS_PATH, or users.json in the working
+// directory.
+func UsersPath() string {
+	if path := strings.TrimSpace(config.GetEnvConfig().UsersPath); path != "" {
+		return filepath.Clean(path)
+	}
+	return "users.json"
+}
+
+// LoadUsers reads the user file. A missing file holds no users.
+func LoadUsers(path string) ([]DashboardUser, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user file %s: %w", path, err)
+	}
+	var file usersFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: user file %s: %v", ErrInvalidConfig, path, err)
+	}
+	return file.Users, nil
+}
+
+// SaveUsers replaces the user file atomically, readable by the owner only.
+func SaveUsers(path string, users []DashboardUser) error {
+	if users == nil {
+		users = []DashboardUser{}
+	}
+	data, err := json.MarshalIndent(usersFile{Users: users}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDataMarshalFailed, err)
+	}
+	return writePrivateFile(path, append(data, '\n'))
+}
+
+// UserUpdate describes a change made by UpsertUser. Empty fields keep their current value.
+type UserUpdate struct {
+	Password  string
+	Algorithm string // argon2id (default) or bcrypt
+	Role      string
+	Disabled  *bool
+}
+
+// UpsertUser creates username (create true) or changes an existing user (create false).
+// Usernames are matched case-insensitively.
+func UpsertUser(path, username string, create bool, update UserUpdate) (DashboardUser, error) {
+	username = strings.TrimSpace(username)
+	if username == "" || strings.ContainsAny(username, " \t\r\n:") {
+		return DashboardUser{}, fmt.Errorf("%w: usernames must be non-empty and contain no spaces or colons", ErrValidationFailed)
+	}
+
+	users, err := LoadUsers(path)
+	if err != nil {
+		return DashboardUser{}, err
+	}
+	index := -1
+	for i, user := range users {
+		if strings.EqualFold(user.Username, username) {
+			index = i
+			break
+		}
+	}
+	switch {
+	case create && index >= 0:
+		return DashboardUser{}, fmt.Errorf("%w: user %q already exists", ErrConfigEntryExists, users[index].Username)
+	case !create && index < 0:
+		return DashboardUser{}, fmt.Errorf("%w: user %q", ErrConfigEntryNotFound, username)
+	}
+
+	now := NowUTC()
+	user := DashboardUser{Username: username, Role: RoleViewer, CreatedAt: now}
+	if index >= 0 {
+		user = users[index]
+	}
+	if update.Role != "" {
+		role, err := ParseRole(update.Role)
+		if err != nil {
+			return DashboardUser{}, err
+		}
+		user.Role = role
+	}
+	if update.Password != "" {
+		hash, err := HashPassword(update.Password, update.Algorithm)
+		if err != nil {
+			return DashboardUser{}, err
+		}
+		user.PasswordHash = hash
+	}
+	if update.Disabled != nil {
+		user.Disabled = *update.Disabled
+	}
+	if user.PasswordHash == "" {
+		return DashboardUser{}, fmt.Errorf("%w: a new user needs a password", ErrValidationFailed)
+	}
+	user.UpdatedAt = now
+
+	if index >= 0 {
+		users[index] = user
+	} else {
+		users = append(users, user)
+	}
+	if err := SaveUsers(path, users); err != nil {
+		return DashboardUser{}, err
+	}
+	return user, nil
+}
+
+// RemoveUser deletes username from the user file.
+func RemoveUser(path, username string) error {
+	users, err := LoadUsers(path)
+	if err != nil {
+		return err
+	}
+	for i, user := range users {
+		if strings.EqualFold(user.Username, username) {
+			return SaveUsers(path, append(users[:i], users[i+1:]...))
+		}
+	}
+	return fmt.Errorf("%w: user %q", ErrConfigEntryNotFound, username)
+}
+
+// HasDashboardUsers reports whether the user file (USERS_PATH) holds any users.
+func HasDashboardUsers() bool {
+	users, err := usersCache.get(UsersPath())
+	return err == nil && len(users) > 0
+}
+
+// AuthenticateUser checks a username and password against the user file (USERS_PATH).
+// Unknown users, wrong passwords and disabled accounts all return ErrInvalidCredentials.
+func AuthenticateUser(username, password string) (*DashboardUser, error) {
+	users, err := usersCache.get(UsersPath())
+	if err != nil {
+		return nil, err
+	}
+	user, ok := users[strings.ToLower(strings.TrimSpace(username))]
+	if !ok {
+		_, _ = VerifyPassword(dummyPasswordHash(), password)
+		return nil, ErrInvalidCredentials
+	}
+	match, err := VerifyPassword(user.PasswordHash, password)
+	if err != nil {
+		LogWarnWithContext("users", fmt.Sprintf("cannot verify the password of %q", user.Username), err)
+		return nil, ErrInvalidCredentials
+	}
+	if !match || user.Disabled {
+		return nil, ErrInvalidCredentials
+	}
+	return &user, nil
+}
+
+// LookupUser returns the enabled user named username from the user file (USERS_PATH).
+func LookupUser(username string) (*DashboardUser, error) {
+	users, err := usersCache.get(UsersPath())
+	if err != nil {
+		return nil, err
+	}
+	user, ok := users[strings.ToLower(username)]
+	if !ok || user.Disabled {
+		return nil, fmt.Errorf("%w: user %q no longer exists or is disabled", ErrInvalidCredentials, username)
+	}
+	return &user, nil
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DecodeYAML decodes a YAML document into v using the struct's json tags, so YAML, TOML
+// and JSON files share one set of field names.
+func DecodeYAML(data []byte, v any) error {
+	tree, err := ParseYAML(data)
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(tree)
+	if err != nil {
+		return fmt.Errorf("yaml: %w", err)
+	}
+	if err := json.Unmarshal(encoded, v); err != nil {
+		return fmt.Errorf("yaml: %w", err)
+	}
+	return nil
+}
+
+// ParseYAML parses the first document of a YAML stream into map[string]any, []any and
+// scalar values. Scalars follow the YAML 1.2 core schema: 010 is the integer 10 (octal is
+// written 0o10), yes/no/on/off are strings, and timestamps stay strings. Anchors, aliases
+// and merge keys (<<) are resolved.
+func ParseYAML(data []byte) (any, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		return nil, nil
+	}
+	return yamlNodeValue(&doc, 0)
+}
+
+// maxYAMLAliasDepth bounds alias expansion, so a document of nested aliases cannot expand
+// without limit.
+const maxYAMLAliasDepth = 64
+
+func yamlNodeValue(n *yaml.Node, depth int) (any, error) {
+	if depth > maxYAMLAliasDepth {
+		return nil, fmt.Errorf("yaml: line %d: aliases nested too deeply", n.Line)
+	}
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return yamlNodeValue(n.Content[0], depth)
+	case yaml.AliasNode:
+		return yamlNodeValue(n.Alias, depth+1)
+	case yaml.SequenceNode:
+		items := make([]any, 0, len(n.Content))
+		for _, child := range n.Content {
+			item, err := yamlNodeValue(child, depth)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case yaml.MappingNode:
+		return yamlMapping(n, depth)
+	case yaml.ScalarNode:
+		return yamlScalar(n)
+	}
+	return nil, fmt.Errorf("yaml: line %d: unsupported node", n.Line)
+}
+
+// yamlMapping converts a mapping node. Keys become strings; keys set explicitly win over
+// keys merged in with <<.
+func yamlMapping(n *yaml.Node, depth int) (map[string]any, error) {
+	m := make(map[string]any, len(n.Content)/2)
+	var merged []map[string]any
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		keyNode, valueNode := n.Content[i], n.Content[i+1]
+		if keyNode.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("yaml: line %d: mapping keys must be scalars", keyNode.Line)
+		}
+		if keyNode.ShortTag() == "!!merge" {
+			sources := []*yaml.Node{valueNode}
+			if valueNode.Kind == yaml.SequenceNode {
+				sources = valueNode.Content
+			}
+			for _, source := range sources {
+				value, err := yamlNodeValue(source, depth)
+				if err != nil {
+					return nil, err
+				}
+				mapping, ok := value.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("yaml: line %d: << must merge a mapping", keyNode.Line)
+				}
+				merged = append(merged, mapping)
+			}
+			continue
+		}
+		if _, exists := m[keyNode.Value]; exists {
+			return nil, fmt.Errorf("yaml: line %d: key %q is already defined", keyNode.Line, keyNode.Value)
+		}
+		value, err := yamlNodeValue(valueNode, depth)
+		if err != nil {
+			return nil, err
+		}
+		m[keyNode.Value] = value
+	}
+	for _, mapping := range merged {
+		for key, value := range mapping {
+			if _, exists := m[key]; !exists {
+				m[key] = value
+			}
+		}
+	}
+	return m, nil
+}
+
+// yamlScalar types a scalar. yaml.v3 still reads a leading-zero integer such as 010 as
+// octal (YAML 1.1); the core schema reads it as decimal.
+func yamlScalar(n *yaml.Node) (any, error) {
+	switch n.ShortTag() {
+	case "!!timestamp":
+		return n.Value, nil
+	case "!!int":
+		digits := strings.TrimLeft(n.Value, "+-")
+		if len(digits) > 1 && digits[0] == '0' && strings.Trim(digits, "0123456789") == "" {
+			if v, err := strconv.Atoi(n.Value); err == nil {
+				return v, nil
+			}
+		}
+	}
+	var v any
+	if err := n.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
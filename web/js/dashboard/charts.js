@@ -43,6 +43,28 @@ export function initializeCharts() {
           fill: true,
           tension: 0.35,
           pointRadius: 0
+        },
+        // CPU min/max band, only populated for downsampled historical points
+        {
+          label: 'CPU Max (%)',
+          data: [],
+          borderColor: 'rgba(56, 189, 248, 0.35)',
+          backgroundColor: 'rgba(56, 189, 248, 0.12)',
+          borderWidth: 1,
+          borderDash: [4, 4],
+          fill: '+1',
+          tension: 0.35,
+          pointRadius: 0
+        },
+        {
+          label: 'CPU Min (%)',
+          data: [],
+          borderColor: 'rgba(56, 189, 248, 0.35)',
+          borderWidth: 1,
+          borderDash: [4, 4],
+          fill: false,
+          tension: 0.35,
+          pointRadius: 0
         }
       ]
     },
@@ -248,8 +270,9 @@ export function renderHistoricalCharts(series) {
   // Handle empty series by clearing the charts
   if (!Array.isArray(series) || series.length === 0) {
     state.systemChart.data.labels = [];
-    state.systemChart.data.datasets[0].data = [];
-    state.systemChart.data.datasets[1].data = [];
+    state.systemChart.data.datasets.forEach((dataset) => {
+      dataset.data = [];
+    });
     
     state.networkChart.data.labels = [];
     state.networkChart.data.datasets[0].data = [];
@@ -269,8 +292,9 @@ export function renderHistoricalCharts(series) {
   const chronological = [...series].reverse();
 
   state.systemChart.data.labels = [];
-  state.systemChart.data.datasets[0].data = [];
-  state.systemChart.data.datasets[1].data = [];
+  state.systemChart.data.datasets.forEach((dataset) => {
+    dataset.data = [];
+  });
 
   state.networkChart.data.labels = [];
   state.networkChart.data.datasets[0].data = [];
@@ -285,6 +309,10 @@ export function renderHistoricalCharts(series) {
     state.systemChart.data.datasets[0].data.push(item.cpu_usage || 0);
     state.systemChart.data.datasets[1].data.push(item.memory?.percentage || 0);
 
+    const cpuStats = item.aggregates?.cpu_usage_percent;
+    state.systemChart.data.datasets[2].data.push(cpuStats ? cpuStats.max : null);
+    state.systemChart.data.datasets[3].data.push(cpuStats ? cpuStats.min : null);
+
     const delta = calculateNetworkDelta(item, previous);
     state.networkChart.data.labels.push(label);
     state.networkChart.data.datasets[0].data.push(bytesToMbPerSecond(delta.bytes_received, delta.durationSeconds) || 0);
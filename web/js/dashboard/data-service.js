@@ -14,7 +14,7 @@ import {
   updateRemoteContext,
   updateStatus,
 } from "./ui.js";
-import { sanitizeBaseUrl } from "./utils.js";
+import { redirectOnUnauthorized, sanitizeBaseUrl, withCsrfHeader } from "./utils.js";
 import { updateMetrics, updateTrends } from "./metrics.js";
 import { buildFilterFromRange } from "./ranges.js";
 
@@ -63,10 +63,13 @@ export async function fetchMetrics() {
     const response = await fetch(monitoringUrl, {
       method: "POST",
       cache: "no-store",
-      headers: requestBody ? { "Content-Type": "application/json" } : undefined,
+      headers: withCsrfHeader(requestBody ? { "Content-Type": "application/json" } : {}),
       body: requestBody,
     });
 
+    if (redirectOnUnauthorized(response)) {
+      return;
+    }
     if (!response.ok) {
       throw new Error(`Request failed with status ${response.status}`);
     }
@@ -585,6 +588,8 @@ function normalizeMetrics(raw) {
     server_metrics: container.server_metrics ?? raw.server_metrics ?? [],
     disk_io: container.disk_io ?? {},
     process,
+    samples: container.samples ?? null,
+    aggregates: container.aggregates ?? null,
     raw,
   };
 }
@@ -702,7 +707,7 @@ async function updateHeartbeatFromLatestData() {
     const response = await fetch("/api/v1/monitoring", {
       method: "POST",
       cache: "no-store",
-      headers: { "Content-Type": "application/json" },
+      headers: withCsrfHeader({ "Content-Type": "application/json" }),
       body: JSON.stringify({})
     });
     
@@ -741,7 +746,7 @@ async function updateHeartbeatFromLatestData() {
         const historicalResponse = await fetch("/api/v1/monitoring", {
           method: "POST",
           cache: "no-store", 
-          headers: { "Content-Type": "application/json" },
+          headers: withCsrfHeader({ "Content-Type": "application/json" }),
           body: JSON.stringify({
             from: filterPayload.from,
             to: filterPayload.to,
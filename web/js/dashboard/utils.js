@@ -5,6 +5,23 @@ export function sanitizeBaseUrl(url) {
   return String(url).replace(/\/+$/, '');
 }
 
+// Adds the session's CSRF token (rendered into <meta name="csrf-token"> when signed in)
+// to the headers of a POST request.
+export function withCsrfHeader(headers = {}) {
+  const token = document.querySelector('meta[name="csrf-token"]')?.content;
+  return token ? { ...headers, 'X-CSRF-Token': token } : headers;
+}
+
+// Sends the browser to the login page when the session has expired.
+export function redirectOnUnauthorized(response) {
+  if (response.status === 401 && document.querySelector('meta[name="csrf-token"]')) {
+    const next = window.location.pathname + window.location.search;
+    window.location.assign(`/login?next=${encodeURIComponent(next)}`);
+    return true;
+  }
+  return false;
+}
+
 export function parseTimestamp(value) {
   if (!value) return null;
   const date = new Date(value);
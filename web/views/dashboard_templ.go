@@ -20,11 +20,15 @@ import (
 type DashboardProps struct {
 	Config             *models.MonitoringConfig
 	DefaultRangePreset string
+	User               string // signed-in user, shown with a logout button
+	CSRFToken          string
 }
 
 type HeroProps struct {
 	RefreshLabel       string
 	DefaultRangePreset string
+	User               string
+	CSRFToken          string
 }
 
 func DashboardPage(props DashboardProps) templ.Component {
@@ -51,6 +55,7 @@ func DashboardPage(props DashboardProps) templ.Component {
 		templ_7745c5c3_Err = Layout(LayoutProps{
 			Title:       "System Monitoring Dashboard",
 			Description: "Beautiful, real-time observability for infrastructure metrics.",
+			CSRFToken:   props.CSRFToken,
 			Stylesheets: []string{"/assets/dashboard.css"},
 			Scripts: []ScriptAsset{{
 				Src:    "/js/dashboard/index.js",
@@ -101,6 +106,8 @@ func DashboardShell(props DashboardProps) templ.Component {
 		templ_7745c5c3_Err = HeroSection(HeroProps{
 			RefreshLabel:       computeRefreshLabel(props.Config),
 			DefaultRangePreset: props.DefaultRangePreset,
+			User:               props.User,
+			CSRFToken:          props.CSRFToken,
 		}).Render(ctx, templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
@@ -220,33 +227,69 @@ func HeroSection(props HeroProps) templ.Component {
 			templ_7745c5c3_Var5 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "<header class=\"glass-panel hero hero-collapsed\" data-component=\"hero\" id=\"heroSection\"><div class=\"hero-top\"><div class=\"hero-copy\"><h1>System Monitoring</h1></div><div class=\"hero-actions\"><button id=\"alertsMuteToggle\" class=\"pill alert-mute-toggle\" type=\"button\" role=\"switch\" aria-checked=\"false\" aria-label=\"Mute alerts\"><i class=\"fas fa-bell\"></i> <span class=\"alert-mute-toggle__label\">Alerts</span></button> <select id=\"themeSelect\" class=\"pill theme-select\" aria-label=\"Theme\"><option value=\"compact\">Compact</option> <option value=\"dark\">Dark</option> <option value=\"light\">Light</option></select></div></div><div class=\"hero-details\" id=\"heroDetails\"><div class=\"hero-meta\"><span class=\"status-pill online\" id=\"systemStatus\" role=\"status\"><span class=\"status-dot\"></span> <span class=\"status-text\">System Online</span></span> <span class=\"pill\">Refresh <span id=\"refreshDisplay\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "<header class=\"glass-panel hero hero-collapsed\" data-component=\"hero\" id=\"heroSection\"><div class=\"hero-top\"><div class=\"hero-copy\"><h1>System Monitoring</h1></div><div class=\"hero-actions\"><button id=\"alertsMuteToggle\" class=\"pill alert-mute-toggle\" type=\"button\" role=\"switch\" aria-checked=\"false\" aria-label=\"Mute alerts\"><i class=\"fas fa-bell\"></i> <span class=\"alert-mute-toggle__label\">Alerts</span></button> <select id=\"themeSelect\" class=\"pill theme-select\" aria-label=\"Theme\"><option value=\"compact\">Compact</option> <option value=\"dark\">Dark</option> <option value=\"light\">Light</option></select> ")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var6 string
-		templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(props.RefreshLabel)
+		if props.User != "" {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "<form class=\"logout-form\" method=\"post\" action=\"/logout\"><input type=\"hidden\" name=\"csrf_token\" value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var6 string
+			templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(props.CSRFToken)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/views/dashboard.templ`, Line: 149, Col: 86}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "\"> <button class=\"pill logout-button\" type=\"submit\" aria-label=\"Sign out\"><i class=\"fas fa-right-from-bracket\"></i> <span>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var7 string
+			templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(props.User)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/views/dashboard.templ`, Line: 152, Col: 46}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "</span></button></form>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "</div></div><div class=\"hero-details\" id=\"heroDetails\"><div class=\"hero-meta\"><span class=\"status-pill online\" id=\"systemStatus\" role=\"status\"><span class=\"status-dot\"></span> <span class=\"status-text\">System Online</span></span> <span class=\"pill\">Refresh <span id=\"refreshDisplay\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var8 string
+		templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(props.RefreshLabel)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/views/dashboard.templ`, Line: 148, Col: 89}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/views/dashboard.templ`, Line: 164, Col: 89}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "</span></span> <span class=\"pill\">Last updated <span id=\"lastUpdated\">--</span></span> <button class=\"filter-action\" id=\"exportTrigger\" type=\"button\" aria-label=\"Export data\"><i class=\"fas fa-download\"></i> <span>Export</span></button></div><div class=\"remote-context hidden\" id=\"remoteContext\" role=\"status\" aria-live=\"polite\"><div class=\"remote-context__indicator\"><span class=\"status-dot\"></span> <span class=\"remote-context__label\">Remote Source</span></div><div class=\"remote-context__details\"><span class=\"remote-context__value\" id=\"remoteContextName\">Remote server</span> <span class=\"remote-context__hint\">Live metrics via remote node</span></div><button class=\"remote-context__action\" id=\"remoteContextReset\" type=\"button\" aria-label=\"Return to default server\"><i class=\"fas fa-times\"></i> <span>Disconnect</span></button></div><div class=\"date-filter\"><label><span>From</span> <input type=\"datetime-local\" id=\"filterFrom\" aria-label=\"Start date and time\"></label> <label><span>To</span> <input type=\"datetime-local\" id=\"filterTo\" aria-label=\"End date and time\"></label> <button type=\"button\" class=\"filter-action\" id=\"applyFilter\">Apply</button> <button type=\"button\" class=\"filter-action ghost\" id=\"clearFilter\">Clear</button></div><div class=\"range-presets\" data-default-range=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "</span></span> <span class=\"pill\">Last updated <span id=\"lastUpdated\">--</span></span> <button class=\"filter-action\" id=\"exportTrigger\" type=\"button\" aria-label=\"Export data\"><i class=\"fas fa-download\"></i> <span>Export</span></button></div><div class=\"remote-context hidden\" id=\"remoteContext\" role=\"status\" aria-live=\"polite\"><div class=\"remote-context__indicator\"><span class=\"status-dot\"></span> <span class=\"remote-context__label\">Remote Source</span></div><div class=\"remote-context__details\"><span class=\"remote-context__value\" id=\"remoteContextName\">Remote server</span> <span class=\"remote-context__hint\">Live metrics via remote node</span></div><button class=\"remote-context__action\" id=\"remoteContextReset\" type=\"button\" aria-label=\"Return to default server\"><i class=\"fas fa-times\"></i> <span>Disconnect</span></button></div><div class=\"date-filter\"><label><span>From</span> <input type=\"datetime-local\" id=\"filterFrom\" aria-label=\"Start date and time\"></label> <label><span>To</span> <input type=\"datetime-local\" id=\"filterTo\" aria-label=\"End date and time\"></label> <button type=\"button\" class=\"filter-action\" id=\"applyFilter\">Apply</button> <button type=\"button\" class=\"filter-action ghost\" id=\"clearFilter\">Clear</button></div><div class=\"range-presets\" data-default-range=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var7 string
-		templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(props.DefaultRangePreset)
+		var templ_7745c5c3_Var9 string
+		templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(props.DefaultRangePreset)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/views/dashboard.templ`, Line: 181, Col: 84}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/views/dashboard.templ`, Line: 197, Col: 84}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "\"><button class=\"range-btn\" data-range=\"1h\" type=\"button\">1h</button> <button class=\"range-btn\" data-range=\"6h\" type=\"button\">6h</button> <button class=\"range-btn\" data-range=\"24h\" type=\"button\">24h</button> <button class=\"range-btn\" data-range=\"7d\" type=\"button\">7d</button> <button class=\"range-btn\" data-range=\"30d\" type=\"button\">30d</button></div></div><button class=\"hero-toggle\" id=\"heroToggle\" type=\"button\" aria-expanded=\"false\" aria-controls=\"heroDetails\" aria-label=\"Expand hero panel\"><span class=\"hero-toggle__icon\" aria-hidden=\"true\"><i class=\"fas fa-chevron-down\"></i></span></button></header>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "\"><button class=\"range-btn\" data-range=\"1h\" type=\"button\">1h</button> <button class=\"range-btn\" data-range=\"6h\" type=\"button\">6h</button> <button class=\"range-btn\" data-range=\"24h\" type=\"button\">24h</button> <button class=\"range-btn\" data-range=\"7d\" type=\"button\">7d</button> <button class=\"range-btn\" data-range=\"30d\" type=\"button\">30d</button></div></div><button class=\"hero-toggle\" id=\"heroToggle\" type=\"button\" aria-expanded=\"false\" aria-controls=\"heroDetails\" aria-label=\"Expand hero panel\"><span class=\"hero-toggle__icon\" aria-hidden=\"true\"><i class=\"fas fa-chevron-down\"></i></span></button></header>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -270,12 +313,12 @@ func InitialLoadingOverlay() templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var8 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var8 == nil {
-			templ_7745c5c3_Var8 = templ.NopComponent
+		templ_7745c5c3_Var10 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var10 == nil {
+			templ_7745c5c3_Var10 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "<div id=\"initialLoading\" data-component=\"initial-loading\" style=\"display: flex;\"><div class=\"loading-spinner\"></div><span class=\"loading-text\">Loading dashboard...</span></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "<div id=\"initialLoading\" data-component=\"initial-loading\" style=\"display: flex;\"><div class=\"loading-spinner\"></div><span class=\"loading-text\">Loading dashboard...</span></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -299,12 +342,12 @@ func MetricsSection() templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var9 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var9 == nil {
-			templ_7745c5c3_Var9 = templ.NopComponent
+		templ_7745c5c3_Var11 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var11 == nil {
+			templ_7745c5c3_Var11 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "<section class=\"section-block\" data-component=\"metrics\" role=\"region\" aria-label=\"System metrics\" data-dashboard-section data-section-id=\"metrics\"><button class=\"section-handle\" type=\"button\" data-section-handle aria-label=\"Drag metrics section\" draggable=\"true\"><i class=\"fas fa-grip-vertical\"></i></button><div class=\"section-heading\" data-section-heading><button class=\"section-collapse-toggle\" type=\"button\" aria-expanded=\"true\" aria-controls=\"section-content-metrics\" data-section-collapse data-section-target=\"section-content-metrics\" data-section-label=\"Metrics\"><span class=\"sr-only\" data-section-toggle-label>Collapse metrics section</span> <i class=\"fas fa-chevron-up\" aria-hidden=\"true\"></i></button><div class=\"section-title\"><span class=\"section-badge\">Live Signals</span><h2>Real-time Metrics</h2></div></div><div class=\"section-content\" id=\"section-content-metrics\" data-section-content><div class=\"metrics-grid\"><article class=\"glass-panel metric-card\"><div class=\"metric-header\"><span class=\"metric-label\">CPU Usage</span> <span class=\"metric-trend\" id=\"cpuTrend\" aria-label=\"CPU trend\">-</span></div><div class=\"metric-value-wrap\"><span class=\"metric-value\" id=\"cpu\" aria-label=\"CPU usage percentage\">--</span> <span class=\"metric-unit\">%</span></div><div class=\"metric-progress\" role=\"progressbar\" aria-valuenow=\"0\" aria-valuemin=\"0\" aria-valuemax=\"100\"><span class=\"progress-fill\" id=\"cpuBar\"></span></div></article><article class=\"glass-panel metric-card\"><div class=\"metric-header\"><span class=\"metric-label\">Memory</span> <span class=\"metric-trend\" id=\"memoryTrend\" aria-label=\"Memory trend\">-</span></div><div class=\"metric-value-wrap\"><span class=\"metric-value\" id=\"memory\" aria-label=\"Memory usage percentage\">--</span> <span class=\"metric-unit\">%</span></div><div class=\"metric-progress\" role=\"progressbar\" aria-valuenow=\"0\" aria-valuemin=\"0\" aria-valuemax=\"100\"><span class=\"progress-fill\" id=\"memoryBar\"></span></div></article><article class=\"glass-panel metric-card\"><div class=\"metric-header\"><span class=\"metric-label\">Network In</span> <span class=\"metric-trend\" id=\"networkRxTrend\" aria-label=\"Network in trend\">-</span></div><div class=\"metric-value-wrap\"><span class=\"metric-value\" id=\"networkRx\" aria-label=\"Network incoming rate\">--</span> <span class=\"metric-unit\">MB/s</span></div></article><article class=\"glass-panel metric-card\"><div class=\"metric-header\"><span class=\"metric-label\">Network Out</span> <span class=\"metric-trend\" id=\"networkTxTrend\" aria-label=\"Network out trend\">-</span></div><div class=\"metric-value-wrap\"><span class=\"metric-value\" id=\"networkTx\" aria-label=\"Network outgoing rate\">--</span> <span class=\"metric-unit\">MB/s</span></div></article><article class=\"glass-panel metric-card\"><div class=\"metric-header\"><span class=\"metric-label\">Load Average (1m)</span> <span class=\"metric-trend\" id=\"loadTrend\" aria-label=\"Load average trend\">-</span></div><div class=\"metric-value-wrap\"><span class=\"metric-value\" id=\"loadAvg\" aria-label=\"One minute load average\">--</span></div></article><div id=\"storageGrid\" class=\"storage-grid\" role=\"region\" aria-label=\"Storage drives\"><!-- Storage cards will be dynamically populated here --></div></div></div></section>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "<section class=\"section-block\" data-component=\"metrics\" role=\"region\" aria-label=\"System metrics\" data-dashboard-section data-section-id=\"metrics\"><button class=\"section-handle\" type=\"button\" data-section-handle aria-label=\"Drag metrics section\" draggable=\"true\"><i class=\"fas fa-grip-vertical\"></i></button><div class=\"section-heading\" data-section-heading><button class=\"section-collapse-toggle\" type=\"button\" aria-expanded=\"true\" aria-controls=\"section-content-metrics\" data-section-collapse data-section-target=\"section-content-metrics\" data-section-label=\"Metrics\"><span class=\"sr-only\" data-section-toggle-label>Collapse metrics section</span> <i class=\"fas fa-chevron-up\" aria-hidden=\"true\"></i></button><div class=\"section-title\"><span class=\"section-badge\">Live Signals</span><h2>Real-time Metrics</h2></div></div><div class=\"section-content\" id=\"section-content-metrics\" data-section-content><div class=\"metrics-grid\"><article class=\"glass-panel metric-card\"><div class=\"metric-header\"><span class=\"metric-label\">CPU Usage</span> <span class=\"metric-trend\" id=\"cpuTrend\" aria-label=\"CPU trend\">-</span></div><div class=\"metric-value-wrap\"><span class=\"metric-value\" id=\"cpu\" aria-label=\"CPU usage percentage\">--</span> <span class=\"metric-unit\">%</span></div><div class=\"metric-progress\" role=\"progressbar\" aria-valuenow=\"0\" aria-valuemin=\"0\" aria-valuemax=\"100\"><span class=\"progress-fill\" id=\"cpuBar\"></span></div></article><article class=\"glass-panel metric-card\"><div class=\"metric-header\"><span class=\"metric-label\">Memory</span> <span class=\"metric-trend\" id=\"memoryTrend\" aria-label=\"Memory trend\">-</span></div><div class=\"metric-value-wrap\"><span class=\"metric-value\" id=\"memory\" aria-label=\"Memory usage percentage\">--</span> <span class=\"metric-unit\">%</span></div><div class=\"metric-progress\" role=\"progressbar\" aria-valuenow=\"0\" aria-valuemin=\"0\" aria-valuemax=\"100\"><span class=\"progress-fill\" id=\"memoryBar\"></span></div></article><article class=\"glass-panel metric-card\"><div class=\"metric-header\"><span class=\"metric-label\">Network In</span> <span class=\"metric-trend\" id=\"networkRxTrend\" aria-label=\"Network in trend\">-</span></div><div class=\"metric-value-wrap\"><span class=\"metric-value\" id=\"networkRx\" aria-label=\"Network incoming rate\">--</span> <span class=\"metric-unit\">MB/s</span></div></article><article class=\"glass-panel metric-card\"><div class=\"metric-header\"><span class=\"metric-label\">Network Out</span> <span class=\"metric-trend\" id=\"networkTxTrend\" aria-label=\"Network out trend\">-</span></div><div class=\"metric-value-wrap\"><span class=\"metric-value\" id=\"networkTx\" aria-label=\"Network outgoing rate\">--</span> <span class=\"metric-unit\">MB/s</span></div></article><article class=\"glass-panel metric-card\"><div class=\"metric-header\"><span class=\"metric-label\">Load Average (1m)</span> <span class=\"metric-trend\" id=\"loadTrend\" aria-label=\"Load average trend\">-</span></div><div class=\"metric-value-wrap\"><span class=\"metric-value\" id=\"loadAvg\" aria-label=\"One minute load average\">--</span></div></article><div id=\"storageGrid\" class=\"storage-grid\" role=\"region\" aria-label=\"Storage drives\"><!-- Storage cards will be dynamically populated here --></div></div></div></section>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -328,12 +371,12 @@ func ChartsSection() templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var10 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var10 == nil {
-			templ_7745c5c3_Var10 = templ.NopComponent
+		templ_7745c5c3_Var12 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var12 == nil {
+			templ_7745c5c3_Var12 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "<section class=\"section-block\" data-component=\"charts\" role=\"region\" aria-label=\"Performance charts\" data-dashboard-section data-section-id=\"charts\"><button class=\"section-handle\" type=\"button\" data-section-handle aria-label=\"Drag charts section\" draggable=\"true\"><i class=\"fas fa-grip-vertical\"></i></button><div class=\"section-heading\" data-section-heading><button class=\"section-collapse-toggle\" type=\"button\" aria-expanded=\"true\" aria-controls=\"section-content-charts\" data-section-collapse data-section-target=\"section-content-charts\" data-section-label=\"Charts\"><span class=\"sr-only\" data-section-toggle-label>Collapse charts section</span> <i class=\"fas fa-chevron-up\" aria-hidden=\"true\"></i></button><div class=\"section-title\"><span class=\"section-badge\">Trends</span><h2>Performance Visualisations</h2></div></div><div class=\"section-content\" id=\"section-content-charts\" data-section-content><div class=\"charts-grid\"><article class=\"glass-panel chart-card\"><div class=\"chart-header\"><h3>System Performance</h3><span class=\"chart-subtitle\">CPU and memory usage over time</span></div><div class=\"chart-wrapper\"><canvas id=\"systemChart\" aria-label=\"System performance chart\"></canvas></div></article><article class=\"glass-panel chart-card\"><div class=\"chart-header\"><h3>Network Throughput</h3><span class=\"chart-subtitle\">Inbound vs outbound throughput (MB/s)</span></div><div class=\"chart-wrapper\"><canvas id=\"networkChart\" aria-label=\"Network throughput chart\"></canvas></div></article><article class=\"glass-panel chart-card\"><div class=\"chart-header\"><h3>Resource Distribution</h3><span class=\"chart-subtitle\">CPU, memory, and disk utilisation snapshot</span></div><div class=\"chart-wrapper\"><canvas id=\"usageDonut\" aria-label=\"Resource usage distribution chart\"></canvas></div></article></div></div></section>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "<section class=\"section-block\" data-component=\"charts\" role=\"region\" aria-label=\"Performance charts\" data-dashboard-section data-section-id=\"charts\"><button class=\"section-handle\" type=\"button\" data-section-handle aria-label=\"Drag charts section\" draggable=\"true\"><i class=\"fas fa-grip-vertical\"></i></button><div class=\"section-heading\" data-section-heading><button class=\"section-collapse-toggle\" type=\"button\" aria-expanded=\"true\" aria-controls=\"section-content-charts\" data-section-collapse data-section-target=\"section-content-charts\" data-section-label=\"Charts\"><span class=\"sr-only\" data-section-toggle-label>Collapse charts section</span> <i class=\"fas fa-chevron-up\" aria-hidden=\"true\"></i></button><div class=\"section-title\"><span class=\"section-badge\">Trends</span><h2>Performance Visualisations</h2></div></div><div class=\"section-content\" id=\"section-content-charts\" data-section-content><div class=\"charts-grid\"><article class=\"glass-panel chart-card\"><div class=\"chart-header\"><h3>System Performance</h3><span class=\"chart-subtitle\">CPU and memory usage over time</span></div><div class=\"chart-wrapper\"><canvas id=\"systemChart\" aria-label=\"System performance chart\"></canvas></div></article><article class=\"glass-panel chart-card\"><div class=\"chart-header\"><h3>Network Throughput</h3><span class=\"chart-subtitle\">Inbound vs outbound throughput (MB/s)</span></div><div class=\"chart-wrapper\"><canvas id=\"networkChart\" aria-label=\"Network throughput chart\"></canvas></div></article><article class=\"glass-panel chart-card\"><div class=\"chart-header\"><h3>Resource Distribution</h3><span class=\"chart-subtitle\">CPU, memory, and disk utilisation snapshot</span></div><div class=\"chart-wrapper\"><canvas id=\"usageDonut\" aria-label=\"Resource usage distribution chart\"></canvas></div></article></div></div></section>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -357,43 +400,43 @@ func ServersSection(isInitiallyHidden bool) templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var11 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var11 == nil {
-			templ_7745c5c3_Var11 = templ.NopComponent
+		templ_7745c5c3_Var13 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var13 == nil {
+			templ_7745c5c3_Var13 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		var templ_7745c5c3_Var12 = []any{sectionClasses("section-block servers-section", isInitiallyHidden)}
-		templ_7745c5c3_Err = templ.RenderCSSItems(ctx, templ_7745c5c3_Buffer, templ_7745c5c3_Var12...)
+		var templ_7745c5c3_Var14 = []any{sectionClasses("section-block servers-section", isInitiallyHidden)}
+		templ_7745c5c3_Err = templ.RenderCSSItems(ctx, templ_7745c5c3_Buffer, templ_7745c5c3_Var14...)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "<section class=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "<section class=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var13 string
-		templ_7745c5c3_Var13, templ_7745c5c3_Err = templ.JoinStringErrs(templ.CSSClasses(templ_7745c5c3_Var12).String())
+		var templ_7745c5c3_Var15 string
+		templ_7745c5c3_Var15, templ_7745c5c3_Err = templ.JoinStringErrs(templ.CSSClasses(templ_7745c5c3_Var14).String())
 		if templ_7745c5c3_Err != nil {
 			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/views/dashboard.templ`, Line: 1, Col: 0}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var13))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var15))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "\" data-component=\"servers\" role=\"region\" aria-label=\"Remote server metrics\" data-dashboard-section data-section-id=\"servers\" aria-hidden=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "\" data-component=\"servers\" role=\"region\" aria-label=\"Remote server metrics\" data-dashboard-section data-section-id=\"servers\" aria-hidden=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var14 string
-		templ_7745c5c3_Var14, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%t", isInitiallyHidden))
+		var templ_7745c5c3_Var16 string
+		templ_7745c5c3_Var16, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%t", isInitiallyHidden))
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/views/dashboard.templ`, Line: 339, Col: 263}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/views/dashboard.templ`, Line: 355, Col: 263}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var14))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var16))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "\"><button class=\"section-handle\" type=\"button\" data-section-handle aria-label=\"Drag servers section\" draggable=\"true\"><i class=\"fas fa-grip-vertical\"></i></button><div class=\"section-heading servers-heading\" data-section-heading><button class=\"section-collapse-toggle\" type=\"button\" aria-expanded=\"true\" aria-controls=\"section-content-servers\" data-section-collapse data-section-target=\"section-content-servers\" data-section-label=\"Servers\"><span class=\"sr-only\" data-section-toggle-label>Collapse servers section</span> <i class=\"fas fa-chevron-up\" aria-hidden=\"true\"></i></button><div class=\"section-title\"><span class=\"section-badge\">Remote Servers</span><h2>Distributed Health</h2></div><div class=\"servers-summary\" id=\"serverMetricsSummary\">-- tracked</div></div><div class=\"section-content\" id=\"section-content-servers\" data-section-content><div class=\"servers-grid\" id=\"serverMetricsList\"><div class=\"servers-empty\" id=\"serverMetricsEmpty\">Server metrics will appear here soon</div></div></div></section>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "\"><button class=\"section-handle\" type=\"button\" data-section-handle aria-label=\"Drag servers section\" draggable=\"true\"><i class=\"fas fa-grip-vertical\"></i></button><div class=\"section-heading servers-heading\" data-section-heading><button class=\"section-collapse-toggle\" type=\"button\" aria-expanded=\"true\" aria-controls=\"section-content-servers\" data-section-collapse data-section-target=\"section-content-servers\" data-section-label=\"Servers\"><span class=\"sr-only\" data-section-toggle-label>Collapse servers section</span> <i class=\"fas fa-chevron-up\" aria-hidden=\"true\"></i></button><div class=\"section-title\"><span class=\"section-badge\">Remote Servers</span><h2>Distributed Health</h2></div><div class=\"servers-summary\" id=\"serverMetricsSummary\">-- tracked</div></div><div class=\"section-content\" id=\"section-content-servers\" data-section-content><div class=\"servers-grid\" id=\"serverMetricsList\"><div class=\"servers-empty\" id=\"serverMetricsEmpty\">Server metrics will appear here soon</div></div></div></section>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -417,43 +460,43 @@ func HeartbeatSection(isInitiallyHidden bool) templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var15 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var15 == nil {
-			templ_7745c5c3_Var15 = templ.NopComponent
+		templ_7745c5c3_Var17 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var17 == nil {
+			templ_7745c5c3_Var17 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		var templ_7745c5c3_Var16 = []any{sectionClasses("section-block heartbeat-section", isInitiallyHidden)}
-		templ_7745c5c3_Err = templ.RenderCSSItems(ctx, templ_7745c5c3_Buffer, templ_7745c5c3_Var16...)
+		var templ_7745c5c3_Var18 = []any{sectionClasses("section-block heartbeat-section", isInitiallyHidden)}
+		templ_7745c5c3_Err = templ.RenderCSSItems(ctx, templ_7745c5c3_Buffer, templ_7745c5c3_Var18...)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "<section class=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 20, "<section class=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var17 string
-		templ_7745c5c3_Var17, templ_7745c5c3_Err = templ.JoinStringErrs(templ.CSSClasses(templ_7745c5c3_Var16).String())
+		var templ_7745c5c3_Var19 string
+		templ_7745c5c3_Var19, templ_7745c5c3_Err = templ.JoinStringErrs(templ.CSSClasses(templ_7745c5c3_Var18).String())
 		if templ_7745c5c3_Err != nil {
 			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/views/dashboard.templ`, Line: 1, Col: 0}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var17))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var19))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "\" data-component=\"heartbeats\" role=\"region\" aria-label=\"Server heartbeat status\" data-dashboard-section data-section-id=\"heartbeats\" aria-hidden=\"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 21, "\" data-component=\"heartbeats\" role=\"region\" aria-label=\"Server heartbeat status\" data-dashboard-section data-section-id=\"heartbeats\" aria-hidden=\"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var18 string
-		templ_7745c5c3_Var18, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%t", isInitiallyHidden))
+		var templ_7745c5c3_Var20 string
+		templ_7745c5c3_Var20, templ_7745c5c3_Err = templ.JoinStringErrs(fmt.Sprintf("%t", isInitiallyHidden))
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/views/dashboard.templ`, Line: 363, Col: 273}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/views/dashboard.templ`, Line: 379, Col: 273}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var18))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var20))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, "\"><button class=\"section-handle\" type=\"button\" data-section-handle aria-label=\"Drag heartbeats section\" draggable=\"true\"><i class=\"fas fa-grip-vertical\"></i></button><div class=\"section-heading heartbeat-heading\" data-section-heading><button class=\"section-collapse-toggle\" type=\"button\" aria-expanded=\"true\" aria-controls=\"section-content-heartbeats\" data-section-collapse data-section-target=\"section-content-heartbeats\" data-section-label=\"Heartbeats\"><span class=\"sr-only\" data-section-toggle-label>Collapse heartbeat section</span> <i class=\"fas fa-chevron-up\" aria-hidden=\"true\"></i></button><div class=\"section-title\"><span class=\"section-badge\">Heartbeats</span><h2>Domain Availability</h2></div><div class=\"heartbeat-meta\" id=\"heartbeatSummary\">-- online / -- total</div></div><div class=\"section-content\" id=\"section-content-heartbeats\" data-section-content><div class=\"heartbeat-controls\"><input type=\"text\" class=\"heartbeat-search\" id=\"heartbeatSearch\" placeholder=\"Search servers...\" aria-label=\"Search heartbeat targets\"></div><div class=\"heartbeat-grid\" id=\"heartbeatList\"><div class=\"heartbeat-empty\">No heartbeat data yet</div></div></div></section>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 22, "\"><button class=\"section-handle\" type=\"button\" data-section-handle aria-label=\"Drag heartbeats section\" draggable=\"true\"><i class=\"fas fa-grip-vertical\"></i></button><div class=\"section-heading heartbeat-heading\" data-section-heading><button class=\"section-collapse-toggle\" type=\"button\" aria-expanded=\"true\" aria-controls=\"section-content-heartbeats\" data-section-collapse data-section-target=\"section-content-heartbeats\" data-section-label=\"Heartbeats\"><span class=\"sr-only\" data-section-toggle-label>Collapse heartbeat section</span> <i class=\"fas fa-chevron-up\" aria-hidden=\"true\"></i></button><div class=\"section-title\"><span class=\"section-badge\">Heartbeats</span><h2>Domain Availability</h2></div><div class=\"heartbeat-meta\" id=\"heartbeatSummary\">-- online / -- total</div></div><div class=\"section-content\" id=\"section-content-heartbeats\" data-section-content><div class=\"heartbeat-controls\"><input type=\"text\" class=\"heartbeat-search\" id=\"heartbeatSearch\" placeholder=\"Search servers...\" aria-label=\"Search heartbeat targets\"></div><div class=\"heartbeat-grid\" id=\"heartbeatList\"><div class=\"heartbeat-empty\">No heartbeat data yet</div></div></div></section>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}